@@ -6,16 +6,39 @@ import (
 	"os"
 	"time"
 
+	_ "github.com/JojoWeyn/duo-proj/course-service/docs"
 	"github.com/JojoWeyn/duo-proj/course-service/internal/composite"
 	"github.com/JojoWeyn/duo-proj/course-service/pkg/client/postgresql"
+	"github.com/JojoWeyn/duo-proj/shared/env"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	"github.com/joho/godotenv"
 )
 
+// @title Course Service API
+// @version 1.0
+// @description Сервис курсов: уроки, упражнения, вопросы и попытки их прохождения.
+// @host localhost:8083
+// @BasePath /v1
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found")
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "course-service"),
+		Exporter:    getEnv("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
+		SampleRatio: env.Float("OTEL_TRACES_SAMPLER_ARG", 1),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %s", err.Error())
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := postgresql.NewPostgresDB(postgresql.Config{
 		Host:     getEnv("DB_HOST", "localhost"),
 		User:     getEnv("DB_USER", "postgres"),
@@ -28,11 +51,17 @@ func main() {
 		log.Fatalf("Failed to initialize db: %s", err.Error())
 	}
 
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		log.Fatalf("Failed to register tracing plugin: %s", err.Error())
+	}
+	if err := db.Use(metrics.GormPlugin()); err != nil {
+		log.Fatalf("Failed to register metrics plugin: %s", err.Error())
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	courseComposite, err := composite.NewCourseComposite(ctx, db, composite.Config{
-		GatewayURL:   getEnv("GATEWAY_URL", "176.109.108.209:3211"),
 		RedisURL:     getEnv("REDIS_URL", "redis:6379"),
 		RedisDB:      getEnvAsInt("REDIS_DB", 0),
 		KafkaBrokers: getEnv("KAFKA_BROKERS", "localhost"),
@@ -40,6 +69,12 @@ func main() {
 		S3AccessKey:  getEnv("S3_ACCESS_KEY", "minio"),
 		S3SecretKey:  getEnv("S3_SECRET_KEY", "minio123"),
 		S3Bucket:     getEnv("S3_BUCKET", "duo-bucket"),
+
+		AssertionSecret: getEnv("IDENTITY_ASSERTION_SECRET", ""),
+
+		GatewayCachePurgeChannel: getEnv("GATEWAY_CACHE_PURGE_CHANNEL", "gateway:cache:purge"),
+
+		TrustedNetworks: env.List("TRUSTED_NETWORKS"),
 	})
 	if err != nil {
 		panic(err)
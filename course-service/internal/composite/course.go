@@ -2,6 +2,7 @@ package composite
 
 import (
 	"context"
+	"errors"
 
 	v1 "github.com/JojoWeyn/duo-proj/course-service/internal/controller/http/v1"
 	"github.com/JojoWeyn/duo-proj/course-service/internal/controller/http/v1/admin"
@@ -14,12 +15,15 @@ import (
 	"github.com/JojoWeyn/duo-proj/course-service/internal/service"
 	"github.com/JojoWeyn/duo-proj/course-service/pkg/client/redis"
 	"github.com/JojoWeyn/duo-proj/course-service/pkg/client/s3"
+	"github.com/JojoWeyn/duo-proj/shared/health"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/middleware"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type Config struct {
-	GatewayURL   string
 	RedisURL     string
 	RedisDB      int
 	KafkaBrokers string
@@ -28,6 +32,14 @@ type Config struct {
 	S3AccessKey string
 	S3SecretKey string
 	S3Bucket    string
+
+	AssertionSecret string
+
+	// GatewayCachePurgeChannel - канал Redis, в который публикуются события сброса кэша gateway.
+	GatewayCachePurgeChannel string
+
+	// TrustedNetworks - сети (CIDR или адреса), из которых принимаются запросы; пустой список не ограничивает.
+	TrustedNetworks []string
 }
 
 type CourseComposite struct {
@@ -35,6 +47,9 @@ type CourseComposite struct {
 }
 
 func NewCourseComposite(ctx context.Context, db *gorm.DB, cfg Config) (*CourseComposite, error) {
+	if cfg.AssertionSecret == "" {
+		return nil, errors.New("identity assertion secret is required")
+	}
 
 	if err := db.AutoMigrate(
 		&entity.Course{},
@@ -63,6 +78,7 @@ func NewCourseComposite(ctx context.Context, db *gorm.DB, cfg Config) (*CourseCo
 	completionRepo := postgres.NewCompletionRepo(db)
 	matchingPairRepo := postgres.NewMatchingPairRepository(db)
 	questionOptionsRepo := postgres.NewQuestionOptionRepository(db)
+	titleRepo := postgres.NewTitleRepository(db)
 
 	s3Client, err := s3.NewS3Client(
 		cfg.S3Endpoint,
@@ -92,13 +108,23 @@ func NewCourseComposite(ctx context.Context, db *gorm.DB, cfg Config) (*CourseCo
 	courseCache := cache.NewRedisCache(redisClient)
 	attemptService := service.NewAttemptService(questionRepo, exerciseRepo, attemptRepo, lessonRepo, completionRepo)
 
+	networks, err := middleware.ParseNetworks(cfg.TrustedNetworks)
+	if err != nil {
+		return nil, err
+	}
+
 	handler := gin.Default()
+	handler.Use(middleware.InternalNetwork(networks))
+	handler.Use(tracing.Middleware(), metrics.Middleware())
+	handler.Use(middleware.IdentityMiddleware(cfg.AssertionSecret, "course"))
 
-	courseUseCase := usecase.NewCourseUseCase(courseRepo, courseCache)
+	gatewayPurger := cache.NewGatewayPurger(redisClient, cfg.GatewayCachePurgeChannel)
+	courseUseCase := usecase.NewCourseUseCase(courseRepo, courseCache, gatewayPurger)
 	exerciseUseCase := usecase.NewExerciseUseCase(exerciseRepo)
 	questionUseCase := usecase.NewQuestionUseCase(questionRepo, progressProducer, attemptService)
 	lessonUseCase := usecase.NewLessonUseCase(lessonRepo)
 	attemptUseCase := usecase.NewAttemptUseCase(attemptRepo)
+	titleUseCase := usecase.NewTitleUseCase(titleRepo)
 
 	matchingPairUseCase := usecase.NewMatchingPairUseCase(matchingPairRepo)
 	questionOptionUseCase := usecase.NewQuestionOptionUseCase(questionOptionsRepo)
@@ -114,7 +140,13 @@ func NewCourseComposite(ctx context.Context, db *gorm.DB, cfg Config) (*CourseCo
 	)
 
 	// Инициализируем маршрутизаторы
-	v1.NewRouter(handler, courseUseCase, lessonUseCase, exerciseUseCase, questionUseCase, attemptUseCase, cfg.GatewayURL)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	health.NewRouter(handler, sqlDB)
+	handler.GET(metrics.Path, gin.WrapH(metrics.Handler()))
+	v1.NewRouter(handler, courseUseCase, lessonUseCase, exerciseUseCase, questionUseCase, attemptUseCase, titleUseCase)
 	admin.NewRouter(handler, courseUseCase, lessonUseCase, exerciseUseCase, questionUseCase, matchingPairUseCase, questionOptionUseCase, excelImportUseCase, fileS3UseCase)
 	return &CourseComposite{
 		handler: handler,
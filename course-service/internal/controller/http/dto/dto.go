@@ -181,3 +181,16 @@ type QuestionTypeDTO struct {
 	ID    int    `json:"id"`
 	Title string `json:"title"`
 }
+
+type TitlesRequestDTO struct {
+	Courses   []uuid.UUID `json:"courses"`
+	Lessons   []uuid.UUID `json:"lessons"`
+	Exercises []uuid.UUID `json:"exercises"`
+}
+
+// TitlesResponseDTO - названия по UUID; неизвестные UUID в ответ не попадают.
+type TitlesResponseDTO struct {
+	Courses   map[string]string `json:"courses"`
+	Lessons   map[string]string `json:"lessons"`
+	Exercises map[string]string `json:"exercises"`
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogInvalidator interface {
+	InvalidateCatalog(ctx context.Context) error
+}
+
+// CatalogInvalidation после успешного изменяющего запроса сбрасывает кэш
+// каталога, чтобы gateway и сервис не отдавали устаревшие курсы и уроки.
+func CatalogInvalidation(invalidator CatalogInvalidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		if err := invalidator.InvalidateCatalog(c.Request.Context()); err != nil {
+			log.Printf("Failed to invalidate catalog cache: %v", err)
+		}
+	}
+}
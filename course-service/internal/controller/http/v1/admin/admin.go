@@ -47,6 +47,7 @@ type CourseUseCase interface {
 	CreateCourse(ctx context.Context, title, description string, typeID, difficultyID int) error
 	UpdateCourse(ctx context.Context, course *entity.Course) error
 	DeleteCourse(ctx context.Context, id uuid.UUID) error
+	InvalidateCatalog(ctx context.Context) error
 }
 
 type MatchingPairUseCase interface {
@@ -144,6 +145,16 @@ func newAdminRoutes(handler *gin.RouterGroup, cu CourseUseCase, lu LessonUseCase
 	}
 }
 
+// @Summary Удалить файл из хранилища
+// @Description Удаляет файл из S3 по имени
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file_name query string true "Имя файла"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/file/delete [delete]
 func (r *adminRoutes) deleteFile(c *gin.Context) {
 	fileName := c.Query("file_name")
 	if err := r.fileS3UseCase.DeleteFile(c.Request.Context(), fileName); err != nil {
@@ -152,6 +163,19 @@ func (r *adminRoutes) deleteFile(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, nil)
 }
+
+// @Summary Открепить файл
+// @Description Открепляет файл от упражнения или изображение от вопроса
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param entity query string true "Тип сущности" Enums(exercise, question)
+// @Param uuid query string true "UUID прикрепленного файла"
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/file/unpin [post]
 func (r *adminRoutes) unpinFile(c *gin.Context) {
 	entityType := c.Query("entity")
 	id := uuid.MustParse(c.Query("uuid"))
@@ -175,6 +199,20 @@ func (r *adminRoutes) unpinFile(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported entity type"})
 	}
 }
+
+// @Summary Прикрепить файл
+// @Description Прикрепляет загруженный файл к упражнению или изображение к вопросу
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param entity query string true "Тип сущности" Enums(exercise, question)
+// @Param uuid query string true "UUID упражнения или вопроса"
+// @Param file body object true "Название и ссылка на файл"
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/file/add [post]
 func (r *adminRoutes) addFile(c *gin.Context) {
 	var req struct {
 		Title   string `json:"title"`
@@ -209,6 +247,15 @@ func (r *adminRoutes) addFile(c *gin.Context) {
 
 }
 
+// @Summary Получить список файлов
+// @Description Возвращает список файлов в хранилище
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /admin/file/list [get]
 func (r *adminRoutes) listFile(c *gin.Context) {
 	files, err := r.fileS3UseCase.ListFiles(c.Request.Context())
 	if err != nil {
@@ -219,6 +266,17 @@ func (r *adminRoutes) listFile(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"files": files})
 }
 
+// @Summary Загрузить файл
+// @Description Загружает файл в хранилище и возвращает ссылку на него
+// @Tags Admin
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "Файл"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/file/upload [post]
 func (r *adminRoutes) uploadFile(c *gin.Context) {
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -262,6 +320,17 @@ func (r *adminRoutes) uploadFile(c *gin.Context) {
 	})
 }
 
+// @Summary Получить все курсы
+// @Description Возвращает список всех курсов
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param title query string false "Название курса"
+// @Param difficultyId query int false "ID сложности"
+// @Success 200 {array} entity.Course
+// @Failure 500 {object} map[string]string
+// @Router /admin/course/list [get]
 func (r *adminRoutes) getAllCourses(c *gin.Context) {
 	title := c.Query("title")
 	diffIdStr := c.Query("difficultyId")
@@ -276,6 +345,16 @@ func (r *adminRoutes) getAllCourses(c *gin.Context) {
 	c.JSON(http.StatusOK, courses)
 }
 
+// @Summary Получить уроки курса
+// @Description Возвращает уроки курса
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param course_id path string true "UUID курса"
+// @Success 200 {array} entity.Lesson
+// @Failure 500 {object} map[string]string
+// @Router /admin/course/{course_id}/lesson [get]
 func (r *adminRoutes) getAllLessons(c *gin.Context) {
 	id := c.Param("course_id")
 	lessons, err := r.lessonUseCase.GetLessonsByCourseID(c.Request.Context(), uuid.MustParse(id))
@@ -286,6 +365,16 @@ func (r *adminRoutes) getAllLessons(c *gin.Context) {
 	c.JSON(http.StatusOK, lessons)
 }
 
+// @Summary Получить упражнения урока
+// @Description Возвращает упражнения урока
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param lesson_id path string true "UUID урока"
+// @Success 200 {array} entity.Exercise
+// @Failure 500 {object} map[string]string
+// @Router /admin/lesson/{lesson_id}/exercise [get]
 func (r *adminRoutes) getAllExercises(c *gin.Context) {
 	id := c.Param("lesson_id")
 	exercises, err := r.exerciseUseCase.GetExercisesByLessonID(c.Request.Context(), uuid.MustParse(id))
@@ -296,6 +385,16 @@ func (r *adminRoutes) getAllExercises(c *gin.Context) {
 	c.JSON(http.StatusOK, exercises)
 }
 
+// @Summary Получить вопросы упражнения
+// @Description Возвращает вопросы упражнения
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param exercise_id path string true "UUID упражнения"
+// @Success 200 {array} entity.Question
+// @Failure 500 {object} map[string]string
+// @Router /admin/exercise/{exercise_id}/question [get]
 func (r *adminRoutes) getAllQuestions(c *gin.Context) {
 	id := c.Param("exercise_id")
 	questions, err := r.questionUseCase.GetQuestionsByExerciseID(c.Request.Context(), uuid.MustParse(id))
@@ -306,6 +405,16 @@ func (r *adminRoutes) getAllQuestions(c *gin.Context) {
 	c.JSON(http.StatusOK, questions)
 }
 
+// @Summary Получить пары вопроса
+// @Description Возвращает пары для сопоставления вопроса
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param question_id path string true "UUID вопроса"
+// @Success 200 {array} entity.MatchingPair
+// @Failure 500 {object} map[string]string
+// @Router /admin/question/{question_id}/matching-pair [get]
 func (r *adminRoutes) getAllMatchingPairs(c *gin.Context) {
 	id := c.Param("question_id")
 	matchingPairs, err := r.matchingPairUseCase.GetMatchingPairsByQuestionID(c.Request.Context(), uuid.MustParse(id))
@@ -316,6 +425,16 @@ func (r *adminRoutes) getAllMatchingPairs(c *gin.Context) {
 	c.JSON(http.StatusOK, matchingPairs)
 }
 
+// @Summary Получить варианты ответа
+// @Description Возвращает варианты ответа вопроса
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param question_id path string true "UUID вопроса"
+// @Success 200 {array} entity.QuestionOption
+// @Failure 500 {object} map[string]string
+// @Router /admin/question/{question_id}/question-option [get]
 func (r *adminRoutes) getAllQuestionOptions(c *gin.Context) {
 	id := c.Param("question_id")
 	questionOptions, err := r.questionOptionUseCase.GetOptionsByQuestionID(c.Request.Context(), uuid.MustParse(id))
@@ -326,6 +445,16 @@ func (r *adminRoutes) getAllQuestionOptions(c *gin.Context) {
 	c.JSON(http.StatusOK, questionOptions)
 }
 
+// @Summary Получить курс
+// @Description Возвращает курс
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param course_id path string true "UUID курса"
+// @Success 200 {object} entity.Course
+// @Failure 500 {object} map[string]string
+// @Router /admin/course/{course_id}/info [get]
 func (r *adminRoutes) getCourseByID(c *gin.Context) {
 	id := c.Param("course_id")
 	course, err := r.courseUseCase.GetCourseByID(c.Request.Context(), uuid.MustParse(id))
@@ -337,6 +466,16 @@ func (r *adminRoutes) getCourseByID(c *gin.Context) {
 	c.JSON(http.StatusOK, course)
 }
 
+// @Summary Получить урок
+// @Description Возвращает урок
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param lesson_id path string true "UUID урока"
+// @Success 200 {object} entity.Lesson
+// @Failure 500 {object} map[string]string
+// @Router /admin/lesson/{lesson_id}/info [get]
 func (r *adminRoutes) getLessonByID(c *gin.Context) {
 	id := c.Param("lesson_id")
 	lesson, err := r.lessonUseCase.GetLessonByID(c.Request.Context(), uuid.MustParse(id))
@@ -347,6 +486,16 @@ func (r *adminRoutes) getLessonByID(c *gin.Context) {
 	c.JSON(http.StatusOK, lesson)
 }
 
+// @Summary Получить упражнение
+// @Description Возвращает упражнение
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param exercise_id path string true "UUID упражнения"
+// @Success 200 {object} entity.Exercise
+// @Failure 500 {object} map[string]string
+// @Router /admin/exercise/{exercise_id}/info [get]
 func (r *adminRoutes) getExerciseByID(c *gin.Context) {
 	id := c.Param("exercise_id")
 	exercise, err := r.exerciseUseCase.GetExerciseByID(c.Request.Context(), uuid.MustParse(id))
@@ -357,6 +506,16 @@ func (r *adminRoutes) getExerciseByID(c *gin.Context) {
 	c.JSON(http.StatusOK, exercise)
 }
 
+// @Summary Получить вопрос
+// @Description Возвращает вопрос
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param question_id path string true "UUID вопроса"
+// @Success 200 {object} entity.Question
+// @Failure 500 {object} map[string]string
+// @Router /admin/question/{question_id}/info [get]
 func (r *adminRoutes) getQuestionByID(c *gin.Context) {
 	id := c.Param("question_id")
 	question, err := r.questionUseCase.GetQuestionByID(c.Request.Context(), uuid.MustParse(id))
@@ -367,6 +526,16 @@ func (r *adminRoutes) getQuestionByID(c *gin.Context) {
 	c.JSON(http.StatusOK, question)
 }
 
+// @Summary Получить пару
+// @Description Возвращает пару для сопоставления
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID пары"
+// @Success 200 {object} entity.MatchingPair
+// @Failure 500 {object} map[string]string
+// @Router /admin/matching-pair/{id}/info [get]
 func (r *adminRoutes) getMatchingPairByID(c *gin.Context) {
 	id := c.Param("id")
 	matchingPair, err := r.matchingPairUseCase.GetMatchingPairByID(c.Request.Context(), uuid.MustParse(id))
@@ -377,6 +546,16 @@ func (r *adminRoutes) getMatchingPairByID(c *gin.Context) {
 	c.JSON(http.StatusOK, matchingPair)
 }
 
+// @Summary Получить вариант ответа
+// @Description Возвращает вариант ответа
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID варианта ответа"
+// @Success 200 {object} entity.QuestionOption
+// @Failure 500 {object} map[string]string
+// @Router /admin/question-option/{id}/info [get]
 func (r *adminRoutes) getQuestionOptionByID(c *gin.Context) {
 	id := c.Param("id")
 	questionOption, err := r.questionOptionUseCase.GetOptionByID(c.Request.Context(), uuid.MustParse(id))
@@ -387,6 +566,17 @@ func (r *adminRoutes) getQuestionOptionByID(c *gin.Context) {
 	c.JSON(http.StatusOK, questionOption)
 }
 
+// @Summary Создать курс
+// @Description Создает курс
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.CourseCreateRequestDTO true "Данные"
+// @Success 201 {object} dto.CourseCreateRequestDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/course [post]
 func (r *adminRoutes) createCourse(c *gin.Context) {
 	var course dto.CourseCreateRequestDTO
 	if err := c.ShouldBindJSON(&course); err != nil {
@@ -402,6 +592,17 @@ func (r *adminRoutes) createCourse(c *gin.Context) {
 	c.JSON(http.StatusCreated, course)
 }
 
+// @Summary Создать урок
+// @Description Создает урок
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.LessonCreateRequestDTO true "Данные"
+// @Success 201 {object} dto.LessonCreateRequestDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/lesson [post]
 func (r *adminRoutes) createLesson(c *gin.Context) {
 	var lesson dto.LessonCreateRequestDTO
 	if err := c.ShouldBindJSON(&lesson); err != nil {
@@ -417,6 +618,17 @@ func (r *adminRoutes) createLesson(c *gin.Context) {
 	c.JSON(http.StatusCreated, lesson)
 }
 
+// @Summary Создать упражнение
+// @Description Создает упражнение
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.ExerciseCreateRequestDTO true "Данные"
+// @Success 201 {object} dto.ExerciseCreateRequestDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/exercise [post]
 func (r *adminRoutes) createExercise(c *gin.Context) {
 	var exercise dto.ExerciseCreateRequestDTO
 	if err := c.ShouldBindJSON(&exercise); err != nil {
@@ -432,6 +644,17 @@ func (r *adminRoutes) createExercise(c *gin.Context) {
 	c.JSON(http.StatusCreated, exercise)
 }
 
+// @Summary Создать вопрос
+// @Description Создает вопрос
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.QuestionCreateRequestDTO true "Данные"
+// @Success 201 {object} dto.QuestionCreateRequestDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/question [post]
 func (r *adminRoutes) createQuestion(c *gin.Context) {
 	var question dto.QuestionCreateRequestDTO
 	if err := c.ShouldBindJSON(&question); err != nil {
@@ -447,6 +670,17 @@ func (r *adminRoutes) createQuestion(c *gin.Context) {
 	c.JSON(http.StatusCreated, question)
 }
 
+// @Summary Создать пару для сопоставления
+// @Description Создает пару для сопоставления
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.MatchingPairCreateRequestDTO true "Данные"
+// @Success 201 {object} dto.MatchingPairCreateRequestDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/matching-pair [post]
 func (r *adminRoutes) createMatchingPair(c *gin.Context) {
 	var matchingPair dto.MatchingPairCreateRequestDTO
 	if err := c.ShouldBindJSON(&matchingPair); err != nil {
@@ -462,6 +696,17 @@ func (r *adminRoutes) createMatchingPair(c *gin.Context) {
 	c.JSON(http.StatusCreated, matchingPair)
 }
 
+// @Summary Создать вариант ответа
+// @Description Создает вариант ответа
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.QuestionOptionCreateRequestDTO true "Данные"
+// @Success 201 {object} dto.QuestionOptionCreateRequestDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/question-option [post]
 func (r *adminRoutes) createQuestionOption(c *gin.Context) {
 	var questionOption dto.QuestionOptionCreateRequestDTO
 	if err := c.ShouldBindJSON(&questionOption); err != nil {
@@ -477,6 +722,18 @@ func (r *adminRoutes) createQuestionOption(c *gin.Context) {
 	c.JSON(http.StatusCreated, questionOption)
 }
 
+// @Summary Обновить курс
+// @Description Обновляет курс, UUID передается в теле
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Param data body entity.Course true "Данные"
+// @Success 200 {object} entity.Course
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/course/{id} [patch]
 func (r *adminRoutes) updateCourse(c *gin.Context) {
 	var course entity.Course
 	if err := c.ShouldBindJSON(&course); err != nil {
@@ -492,6 +749,18 @@ func (r *adminRoutes) updateCourse(c *gin.Context) {
 	c.JSON(http.StatusOK, course)
 }
 
+// @Summary Обновить урок
+// @Description Обновляет урок, UUID передается в теле
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Param data body entity.Lesson true "Данные"
+// @Success 200 {object} entity.Lesson
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/lesson/{id} [patch]
 func (r *adminRoutes) updateLesson(c *gin.Context) {
 	var lesson entity.Lesson
 	if err := c.ShouldBindJSON(&lesson); err != nil {
@@ -507,6 +776,18 @@ func (r *adminRoutes) updateLesson(c *gin.Context) {
 	c.JSON(http.StatusOK, lesson)
 }
 
+// @Summary Обновить упражнение
+// @Description Обновляет упражнение, UUID передается в теле
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Param data body entity.Exercise true "Данные"
+// @Success 200 {object} entity.Exercise
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/exercise/{id} [patch]
 func (r *adminRoutes) updateExercise(c *gin.Context) {
 	var exercise entity.Exercise
 	if err := c.ShouldBindJSON(&exercise); err != nil {
@@ -522,6 +803,18 @@ func (r *adminRoutes) updateExercise(c *gin.Context) {
 	c.JSON(http.StatusOK, exercise)
 }
 
+// @Summary Обновить вопрос
+// @Description Обновляет вопрос, UUID передается в теле
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Param data body entity.Question true "Данные"
+// @Success 200 {object} entity.Question
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/question/{id} [patch]
 func (r *adminRoutes) updateQuestion(c *gin.Context) {
 	var question entity.Question
 	if err := c.ShouldBindJSON(&question); err != nil {
@@ -537,6 +830,18 @@ func (r *adminRoutes) updateQuestion(c *gin.Context) {
 	c.JSON(http.StatusOK, question)
 }
 
+// @Summary Обновить пару для сопоставления
+// @Description Обновляет пару для сопоставления, UUID передается в теле
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Param data body entity.MatchingPair true "Данные"
+// @Success 200 {object} entity.MatchingPair
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/matching-pair/{id} [patch]
 func (r *adminRoutes) updateMatchingPair(c *gin.Context) {
 	var matchingPair entity.MatchingPair
 	if err := c.ShouldBindJSON(&matchingPair); err != nil {
@@ -552,6 +857,18 @@ func (r *adminRoutes) updateMatchingPair(c *gin.Context) {
 	c.JSON(http.StatusOK, matchingPair)
 }
 
+// @Summary Обновить вариант ответа
+// @Description Обновляет вариант ответа, UUID передается в теле
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Param data body entity.QuestionOption true "Данные"
+// @Success 200 {object} entity.QuestionOption
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/question-option/{id} [patch]
 func (r *adminRoutes) updateQuestionOption(c *gin.Context) {
 	var questionOption entity.QuestionOption
 	if err := c.ShouldBindJSON(&questionOption); err != nil {
@@ -567,6 +884,16 @@ func (r *adminRoutes) updateQuestionOption(c *gin.Context) {
 	c.JSON(http.StatusOK, questionOption)
 }
 
+// @Summary Удалить курс
+// @Description Удаляет курс
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/course/{id} [delete]
 func (r *adminRoutes) deleteCourse(c *gin.Context) {
 	id := c.Param("id")
 	if err := r.courseUseCase.DeleteCourse(c.Request.Context(), uuid.MustParse(id)); err != nil {
@@ -576,6 +903,16 @@ func (r *adminRoutes) deleteCourse(c *gin.Context) {
 	c.JSON(http.StatusOK, nil)
 }
 
+// @Summary Удалить урок
+// @Description Удаляет урок
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/lesson/{id} [delete]
 func (r *adminRoutes) deleteLesson(c *gin.Context) {
 	id := c.Param("id")
 	if err := r.lessonUseCase.DeleteLesson(c.Request.Context(), uuid.MustParse(id)); err != nil {
@@ -585,6 +922,16 @@ func (r *adminRoutes) deleteLesson(c *gin.Context) {
 	c.JSON(http.StatusOK, nil)
 }
 
+// @Summary Удалить упражнение
+// @Description Удаляет упражнение
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/exercise/{id} [delete]
 func (r *adminRoutes) deleteExercise(c *gin.Context) {
 	id := c.Param("id")
 	if err := r.exerciseUseCase.DeleteExercise(c.Request.Context(), uuid.MustParse(id)); err != nil {
@@ -594,6 +941,16 @@ func (r *adminRoutes) deleteExercise(c *gin.Context) {
 	c.JSON(http.StatusOK, nil)
 }
 
+// @Summary Удалить вопрос
+// @Description Удаляет вопрос
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/question/{id} [delete]
 func (r *adminRoutes) deleteQuestion(c *gin.Context) {
 	id := c.Param("id")
 	if err := r.questionUseCase.DeleteQuestion(c.Request.Context(), uuid.MustParse(id)); err != nil {
@@ -603,6 +960,16 @@ func (r *adminRoutes) deleteQuestion(c *gin.Context) {
 	c.JSON(http.StatusOK, nil)
 }
 
+// @Summary Удалить пару для сопоставления
+// @Description Удаляет пару для сопоставления
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/matching-pair/{id} [delete]
 func (r *adminRoutes) deleteMatchingPair(c *gin.Context) {
 	id := c.Param("id")
 	if err := r.matchingPairUseCase.DeleteMatchingPair(c.Request.Context(), uuid.MustParse(id)); err != nil {
@@ -612,6 +979,16 @@ func (r *adminRoutes) deleteMatchingPair(c *gin.Context) {
 	c.JSON(http.StatusOK, nil)
 }
 
+// @Summary Удалить вариант ответа
+// @Description Удаляет вариант ответа
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/question-option/{id} [delete]
 func (r *adminRoutes) deleteQuestionOption(c *gin.Context) {
 	id := c.Param("id")
 	if err := r.questionOptionUseCase.DeleteQuestionOption(c.Request.Context(), uuid.MustParse(id)); err != nil {
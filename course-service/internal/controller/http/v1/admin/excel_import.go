@@ -14,6 +14,18 @@ type ExcelImportUseCase interface {
 }
 
 // importCourseFromExcel обрабатывает запрос на импорт курса из Excel-файла
+//
+// @Summary Импортировать курс из Excel
+// @Description Создает курс с уроками, упражнениями и вопросами из Excel-файла
+// @Tags Admin
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "Excel-файл"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/course/import-excel [post]
 func (r *adminRoutes) importCourseFromExcel(c *gin.Context) {
 	// Получаем файл из запроса
 	file, _, err := c.Request.FormFile("file")
@@ -7,7 +7,7 @@ import (
 
 func NewRouter(handler *gin.Engine, cu CourseUseCase, lu LessonUseCase, eu ExerciseUseCase, qu QuestionUseCase, mpu MatchingPairUseCase, qou QuestionOptionUseCase, excelImportUseCase ExcelImportUseCase, fileS3UseCase FileS3UseCase) {
 
-	v1 := handler.Group("/v1", middleware.RoleMiddleware("admin"))
+	v1 := handler.Group("/v1", middleware.RoleMiddleware("admin"), middleware.CatalogInvalidation(cu))
 	{
 		newAdminRoutes(v1, cu, lu, eu, qu, mpu, qou, excelImportUseCase, fileS3UseCase)
 	}
@@ -27,6 +27,16 @@ func newAttemptRoutes(handler *gin.RouterGroup, attemptUseCase AttemptUseCase, q
 	}
 }
 
+// @Summary Начать попытку
+// @Description Начинает прохождение упражнения и возвращает идентификатор попытки
+// @Tags Attempts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param exercise_id path string true "UUID упражнения"
+// @Success 200 {object} dto.StartAttemptResponseDTO
+// @Failure 500 {object} map[string]string
+// @Router /attempts/start/{exercise_id} [post]
 func (a *attemptRoutes) startAttempt(c *gin.Context) {
 	userUUID := c.GetHeader("X-User-UUID")
 	exerciseUUID := c.Param("exercise_id")
@@ -40,6 +50,18 @@ func (a *attemptRoutes) startAttempt(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.StartAttemptResponseDTO{SessionID: *sessionID})
 }
 
+// @Summary Ответить на вопрос
+// @Description Проверяет ответ на вопрос в рамках попытки
+// @Tags Attempts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param session_id query string true "UUID попытки"
+// @Param answer body dto.SubmitAnswerRequestDTO true "Ответ"
+// @Success 200 {object} dto.SubmitAnswerResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /attempts/answer [post]
 func (a *attemptRoutes) submitAnswer(c *gin.Context) {
 	sessionID := c.Query("session_id")
 	userUUID := c.GetHeader("X-User-UUID")
@@ -73,6 +95,17 @@ func (a *attemptRoutes) submitAnswer(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.SubmitAnswerResponseDTO{IsCorrect: correct})
 }
 
+// @Summary Завершить попытку
+// @Description Завершает попытку и возвращает количество верных ответов
+// @Tags Attempts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param session_id query string true "UUID попытки"
+// @Success 200 {object} dto.FinishAttemptResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /attempts/finish [post]
 func (a *attemptRoutes) finishAttempt(c *gin.Context) {
 	sessionID := c.Query("session_id")
 
@@ -31,6 +31,16 @@ func newCourseRoutes(handler *gin.RouterGroup, courseUseCase CourseUseCase) {
 	}
 }
 
+// @Summary Получить курс
+// @Description Возвращает информацию о курсе
+// @Tags Courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID курса"
+// @Success 200 {object} dto.CourseInfoDTO
+// @Failure 500 {object} map[string]string
+// @Router /course/{id}/info [get]
 func (r *courseRoutes) getCourseByID(c *gin.Context) {
 	id := c.Param("id")
 	course, err := r.courseUseCase.GetCourseByID(c.Request.Context(), uuid.MustParse(id))
@@ -52,6 +62,17 @@ func (r *courseRoutes) getCourseByID(c *gin.Context) {
 	c.JSON(http.StatusOK, courseDTO)
 }
 
+// @Summary Получить список курсов
+// @Description Возвращает список курсов с фильтрацией по названию и сложности
+// @Tags Courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param title query string false "Название курса"
+// @Param difficultyId query int false "ID сложности"
+// @Success 200 {array} dto.CourseSmallDTO
+// @Failure 500 {object} map[string]string
+// @Router /course/list [get]
 func (r *courseRoutes) getAllCourses(c *gin.Context) {
 	title := c.Query("title")
 	diffIdStr := c.Query("difficultyId")
@@ -31,6 +31,17 @@ func newExerciseRoutes(handler *gin.RouterGroup, exerciseUseCase ExerciseUseCase
 	}
 }
 
+// @Summary Получить упражнения урока
+// @Description Возвращает список упражнений урока
+// @Tags Exercises
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID урока"
+// @Success 200 {array} dto.ExerciseAllDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lesson/{id}/content [get]
 func (h *exerciseRoutes) getAllExercises(c *gin.Context) {
 	id := c.Param("id")
 	lessonID, err := uuid.Parse(id)
@@ -60,6 +71,17 @@ func (h *exerciseRoutes) getAllExercises(c *gin.Context) {
 	c.JSON(http.StatusOK, dtoList)
 }
 
+// @Summary Получить упражнение
+// @Description Возвращает упражнение вместе с прикрепленными файлами
+// @Tags Exercises
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID упражнения"
+// @Success 200 {object} dto.ExerciseOneDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /exercise/{id}/info [get]
 func (h *exerciseRoutes) getExerciseByID(c *gin.Context) {
 	id := c.Param("id")
 	exerciseID, err := uuid.Parse(id)
@@ -30,6 +30,17 @@ func newLessonRoutes(handler *gin.RouterGroup, lessonUseCase LessonUseCase) {
 	}
 }
 
+// @Summary Получить урок
+// @Description Возвращает урок вместе с прикрепленными файлами
+// @Tags Lessons
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID урока"
+// @Success 200 {object} dto.LessonDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lesson/{id}/info [get]
 func (r *lessonRoutes) getLessonByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -71,6 +82,17 @@ func (r *lessonRoutes) getLessonByID(c *gin.Context) {
 	c.JSON(http.StatusOK, dto)
 }
 
+// @Summary Получить уроки курса
+// @Description Возвращает список уроков курса
+// @Tags Lessons
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID курса"
+// @Success 200 {array} dto.LessonsDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /course/{id}/content [get]
 func (r *lessonRoutes) getAllLessons(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -43,6 +43,17 @@ func newQuestionRoutes(handler *gin.RouterGroup, questionUseCase QuestionUseCase
 	}
 }
 
+// @Summary Получить вопрос
+// @Description Возвращает вопрос с вариантами ответа и перемешанными парами для сопоставления
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID вопроса"
+// @Success 200 {object} dto.QuestionDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /question/{id}/info [get]
 func (q *questionRoutes) getQuestionByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -102,6 +113,17 @@ func (q *questionRoutes) getQuestionByID(c *gin.Context) {
 	c.JSON(http.StatusOK, questionDTO)
 }
 
+// @Summary Получить вопросы упражнения
+// @Description Возвращает список вопросов упражнения
+// @Tags Questions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID упражнения"
+// @Success 200 {array} dto.QuestionsDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /exercise/{id}/question [get]
 func (q *questionRoutes) getAllQuestions(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -1,19 +1,13 @@
 package v1
 
 import (
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewRouter(handler *gin.Engine, cu CourseUseCase, lu LessonUseCase, eu ExerciseUseCase, qu QuestionUseCase, au AttemptUseCase, gatewayUrl string) {
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{gatewayUrl}
-	config.AllowMethods = []string{"GET", "POST", "OPTIONS", "PATCH", "DELETE"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length"}
-	config.AllowCredentials = true
-
-	handler.Use(cors.New(config))
+func NewRouter(handler *gin.Engine, cu CourseUseCase, lu LessonUseCase, eu ExerciseUseCase, qu QuestionUseCase, au AttemptUseCase, tu TitleUseCase) {
+	handler.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	v1 := handler.Group("/v1")
 	{
@@ -22,5 +16,6 @@ func NewRouter(handler *gin.Engine, cu CourseUseCase, lu LessonUseCase, eu Exerc
 		newExerciseRoutes(v1, eu)
 		newQuestionRoutes(v1, qu, au)
 		newAttemptRoutes(v1, au, qu)
+		newTitleRoutes(v1, tu)
 	}
 }
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/JojoWeyn/duo-proj/course-service/internal/controller/http/dto"
+	"github.com/JojoWeyn/duo-proj/course-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/course-service/internal/domain/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TitleUseCase interface {
+	GetTitles(ctx context.Context, courses, lessons, exercises []uuid.UUID) (*entity.Titles, error)
+}
+
+type titleRoutes struct {
+	titleUseCase TitleUseCase
+}
+
+func newTitleRoutes(handler *gin.RouterGroup, titleUseCase TitleUseCase) {
+	r := &titleRoutes{
+		titleUseCase: titleUseCase,
+	}
+
+	handler.POST("/course/titles", r.getTitles)
+}
+
+// @Summary Названия курсов, уроков и упражнений
+// @Description Возвращает названия по списку UUID одним запросом, например для прогресса пользователя
+// @Tags Courses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.TitlesRequestDTO true "UUID курсов, уроков и упражнений"
+// @Success 200 {object} dto.TitlesResponseDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /course/titles [post]
+func (r *titleRoutes) getTitles(c *gin.Context) {
+	var req dto.TitlesRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	titles, err := r.titleUseCase.GetTitles(c.Request.Context(), req.Courses, req.Lessons, req.Exercises)
+	if err != nil {
+		if errors.Is(err, usecase.ErrTooManyTitles) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TitlesResponseDTO{
+		Courses:   titleMap(titles.Courses),
+		Lessons:   titleMap(titles.Lessons),
+		Exercises: titleMap(titles.Exercises),
+	})
+}
+
+func titleMap(titles map[uuid.UUID]string) map[string]string {
+	out := make(map[string]string, len(titles))
+	for id, title := range titles {
+		out[id.String()] = title
+	}
+	return out
+}
@@ -1,8 +1,11 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	"github.com/google/uuid"
 	"time"
 )
@@ -50,7 +53,7 @@ func NewProducer(brokers, topic string) (*Producer, error) {
 	}, nil
 }
 
-func (p *Producer) SendUserAttemptEvent(userUUID, questionUUID uuid.UUID, isCorrect bool, sessionUUID uuid.UUID) error {
+func (p *Producer) SendUserAttemptEvent(ctx context.Context, userUUID, questionUUID uuid.UUID, isCorrect bool, sessionUUID uuid.UUID) error {
 	event := UserAttemptEvent{
 		UserUUID:     userUUID,
 		QuestionUUID: questionUUID,
@@ -65,29 +68,34 @@ func (p *Producer) SendUserAttemptEvent(userUUID, questionUUID uuid.UUID, isCorr
 		Value: sarama.ByteEncoder(msgBytes),
 	}
 
-	_, _, err := p.producer.SendMessage(msg)
-	return err
+	return p.send(ctx, msg)
 }
 
-func (p *Producer) SendUserProgressEvent(event UserProgressEvent) error {
+func (p *Producer) SendUserProgressEvent(ctx context.Context, event UserProgressEvent) error {
 	msgBytes, _ := json.Marshal(event)
 	msg := &sarama.ProducerMessage{
 		Topic: p.topic,
 		Value: sarama.ByteEncoder(msgBytes),
 	}
 
-	_, _, err := p.producer.SendMessage(msg)
-	return err
+	return p.send(ctx, msg)
 }
 
-func (p *Producer) SendUserEvent(event UserEvent) error {
+func (p *Producer) SendUserEvent(ctx context.Context, event UserEvent) error {
 	msgBytes, _ := json.Marshal(event)
 	msg := &sarama.ProducerMessage{
 		Topic: "user_create",
 		Value: sarama.ByteEncoder(msgBytes),
 	}
 
+	return p.send(ctx, msg)
+}
+
+func (p *Producer) send(ctx context.Context, msg *sarama.ProducerMessage) error {
+	span := tracing.StartProduce(ctx, msg)
 	_, _, err := p.producer.SendMessage(msg)
+	tracing.End(span, err)
+	metrics.ObserveProduce(msg.Topic, err)
 	return err
 }
 
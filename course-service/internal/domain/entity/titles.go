@@ -0,0 +1,10 @@
+package entity
+
+import "github.com/google/uuid"
+
+// Titles - названия курсов, уроков и упражнений по UUID.
+type Titles struct {
+	Courses   map[uuid.UUID]string
+	Lessons   map[uuid.UUID]string
+	Exercises map[uuid.UUID]string
+}
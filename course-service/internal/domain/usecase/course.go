@@ -20,17 +20,27 @@ type CourseRepository interface {
 type Cache interface {
 	Get(ctx context.Context, key string, dest interface{}) error
 	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
 }
 
+// GatewayPurger сбрасывает кэш ответов gateway для маршрутов сервиса.
+type GatewayPurger interface {
+	Purge(ctx context.Context, services ...string) error
+}
+
+const allCoursesCacheKey = "all_courses"
+
 type CourseUseCase struct {
-	repo  CourseRepository
-	cache Cache
+	repo   CourseRepository
+	cache  Cache
+	purger GatewayPurger
 }
 
-func NewCourseUseCase(repo CourseRepository, cache Cache) *CourseUseCase {
+func NewCourseUseCase(repo CourseRepository, cache Cache, purger GatewayPurger) *CourseUseCase {
 	return &CourseUseCase{
-		repo:  repo,
-		cache: cache,
+		repo:   repo,
+		cache:  cache,
+		purger: purger,
 	}
 }
 
@@ -44,7 +54,7 @@ func (c *CourseUseCase) GetCourseByID(ctx context.Context, id uuid.UUID) (*entit
 }
 
 func (c *CourseUseCase) GetAllCourses(ctx context.Context, title string, diffId int, typeId int) ([]entity.Course, error) {
-	cacheKey := "all_courses"
+	cacheKey := allCoursesCacheKey
 
 	var allCourses []entity.Course
 	if err := c.cache.Get(ctx, cacheKey, &allCourses); err == nil && allCourses != nil {
@@ -91,3 +101,12 @@ func (c *CourseUseCase) UpdateCourse(ctx context.Context, course *entity.Course)
 func (c *CourseUseCase) DeleteCourse(ctx context.Context, id uuid.UUID) error {
 	return c.repo.Delete(ctx, id)
 }
+
+// InvalidateCatalog сбрасывает кэш каталога в сервисе и в gateway после
+// изменения курсов, уроков, упражнений или вопросов.
+func (c *CourseUseCase) InvalidateCatalog(ctx context.Context) error {
+	if err := c.cache.Delete(ctx, allCoursesCacheKey); err != nil {
+		return err
+	}
+	return c.purger.Purge(ctx, "course")
+}
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"github.com/JojoWeyn/duo-proj/course-service/internal/controller/kafka"
 	"github.com/JojoWeyn/duo-proj/course-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/course-service/pkg/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"log"
 )
 
@@ -37,13 +40,22 @@ func NewQuestionUseCase(repo QuestionRepository, producer *kafka.Producer, servi
 	}
 }
 
-func (q *QuestionUseCase) CheckAnswer(ctx context.Context, userUUID, questionID uuid.UUID, userAnswers interface{}, sessionUUID uuid.UUID) (bool, error) {
+func (q *QuestionUseCase) CheckAnswer(ctx context.Context, userUUID, questionID uuid.UUID, userAnswers interface{}, sessionUUID uuid.UUID) (isCorrect bool, err error) {
+	ctx, span := tracing.Start(ctx, "QuestionUseCase.CheckAnswer",
+		attribute.String("question.uuid", questionID.String()),
+		attribute.String("session.uuid", sessionUUID.String()),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Bool("answer.correct", isCorrect))
+		tracing.End(span, err)
+	}()
+
 	question, err := q.repo.GetByID(ctx, questionID)
 	if err != nil {
 		return false, err
 	}
 
-	isCorrect, err := func() (bool, error) {
+	isCorrect, err = func() (bool, error) {
 		switch question.TypeID {
 		case 1: // Single Choice
 			userAnswerUUID, ok := userAnswers.(string)
@@ -123,13 +135,19 @@ func (q *QuestionUseCase) CheckAnswer(ctx context.Context, userUUID, questionID
 	}()
 
 	if err == nil {
+		result := "incorrect"
+		if isCorrect {
+			result = "correct"
+		}
+		metrics.AnswersChecked.WithLabelValues(result).Inc()
+
 		go func() {
-			if err := q.producer.SendUserAttemptEvent(userUUID, questionID, isCorrect, sessionUUID); err != nil {
+			if err := q.producer.SendUserAttemptEvent(ctx, userUUID, questionID, isCorrect, sessionUUID); err != nil {
 				log.Printf("Failed to send progress event: %v", err)
 			}
 
 			if isCorrect == true {
-				if err := q.producer.SendUserEvent(kafka.UserEvent{
+				if err := q.producer.SendUserEvent(ctx, kafka.UserEvent{
 					UUID:   userUUID.String(),
 					Login:  "",
 					Action: "question",
@@ -152,10 +170,10 @@ func (q *QuestionUseCase) CheckAnswer(ctx context.Context, userUUID, questionID
 		if len(userProgressEvents) > 0 {
 			go func() {
 				for _, event := range userProgressEvents {
-					if err := q.producer.SendUserProgressEvent(event); err != nil {
+					if err := q.producer.SendUserProgressEvent(ctx, event); err != nil {
 						log.Printf("Failed to send progress event: %v", err)
 					}
-					if err := q.producer.SendUserEvent(kafka.UserEvent{
+					if err := q.producer.SendUserEvent(ctx, kafka.UserEvent{
 						UUID:   event.UserUUID.String(),
 						Login:  "",
 						Action: event.EntityType,
@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JojoWeyn/duo-proj/course-service/internal/domain/entity"
+	"github.com/google/uuid"
+)
+
+// maxTitles ограничивает число UUID в одном запросе названий.
+const maxTitles = 1000
+
+var ErrTooManyTitles = errors.New("too many uuids requested")
+
+type TitleRepository interface {
+	GetTitles(ctx context.Context, courses, lessons, exercises []uuid.UUID) (*entity.Titles, error)
+}
+
+type TitleUseCase struct {
+	repo TitleRepository
+}
+
+func NewTitleUseCase(repo TitleRepository) *TitleUseCase {
+	return &TitleUseCase{
+		repo: repo,
+	}
+}
+
+func (t *TitleUseCase) GetTitles(ctx context.Context, courses, lessons, exercises []uuid.UUID) (*entity.Titles, error) {
+	if len(courses)+len(lessons)+len(exercises) > maxTitles {
+		return nil, ErrTooManyTitles
+	}
+	return t.repo.GetTitles(ctx, courses, lessons, exercises)
+}
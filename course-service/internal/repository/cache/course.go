@@ -43,3 +43,7 @@ func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl
 
 	return nil
 }
+
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	return c.redisClient.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// GatewayPurger публикует в Redis событие сброса кэша ответов gateway.
+// Gateway подписан на канал и удаляет записи маршрутов указанных сервисов.
+type GatewayPurger struct {
+	redisClient *redis.Client
+	channel     string
+}
+
+func NewGatewayPurger(redisClient *redis.Client, channel string) *GatewayPurger {
+	return &GatewayPurger{
+		redisClient: redisClient,
+		channel:     channel,
+	}
+}
+
+func (p *GatewayPurger) Purge(ctx context.Context, services ...string) error {
+	data, err := json.Marshal(map[string][]string{"services": services})
+	if err != nil {
+		return err
+	}
+	return p.redisClient.Publish(ctx, p.channel, data).Err()
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/JojoWeyn/duo-proj/course-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TitleRepository struct {
+	db *gorm.DB
+}
+
+func NewTitleRepository(db *gorm.DB) *TitleRepository {
+	return &TitleRepository{
+		db: db,
+	}
+}
+
+func (t *TitleRepository) GetTitles(ctx context.Context, courses, lessons, exercises []uuid.UUID) (*entity.Titles, error) {
+	var (
+		titles entity.Titles
+		err    error
+	)
+
+	if titles.Courses, err = t.titles(ctx, &entity.Course{}, courses); err != nil {
+		return nil, err
+	}
+	if titles.Lessons, err = t.titles(ctx, &entity.Lesson{}, lessons); err != nil {
+		return nil, err
+	}
+	if titles.Exercises, err = t.titles(ctx, &entity.Exercise{}, exercises); err != nil {
+		return nil, err
+	}
+	return &titles, nil
+}
+
+func (t *TitleRepository) titles(ctx context.Context, model any, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	titles := make(map[uuid.UUID]string, len(ids))
+	if len(ids) == 0 {
+		return titles, nil
+	}
+
+	var rows []struct {
+		UUID  uuid.UUID
+		Title string
+	}
+	if err := t.db.WithContext(ctx).
+		Model(model).
+		Select("uuid", "title").
+		Where("uuid IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		titles[row.UUID] = row.Title
+	}
+	return titles, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/JojoWeyn/duo-proj/course-service/internal/controller/kafka"
 	"github.com/JojoWeyn/duo-proj/course-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/course-service/pkg/metrics"
 	"github.com/google/uuid"
 	"time"
 )
@@ -66,6 +67,7 @@ func (a *AttemptService) ProcessUserAttempt(ctx context.Context, event kafka.Use
 	}
 
 	if isCompleted, points := a.isExerciseCompleted(ctx, event.UserUUID, exercise.UUID, event.QuestionUUID, event.IsCorrect, event.SessionUUID); isCompleted {
+		metrics.ExercisesCompleted.Inc()
 		progressEvents = append(progressEvents, kafka.UserProgressEvent{
 			UserUUID:   event.UserUUID,
 			EntityType: "exercise",
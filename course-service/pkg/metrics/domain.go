@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// AnswersChecked - количество проверенных ответов, result - correct или incorrect.
+	AnswersChecked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "answers_checked_total",
+		Help: "Количество проверенных ответов на вопросы.",
+	}, []string{"result"})
+
+	// ExercisesCompleted - количество упражнений, завершенных пользователями.
+	ExercisesCompleted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "exercises_completed_total",
+		Help: "Количество упражнений, завершенных пользователями.",
+	})
+)
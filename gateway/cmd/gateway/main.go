@@ -1,179 +1,293 @@
 package main
 
 import (
+	"context"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 
-	"github.com/gin-contrib/cors"
-
+	"github.com/JojoWeyn/duo-proj/gateway/internal/apikey"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/audit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/cache"
 	v1 "github.com/JojoWeyn/duo-proj/gateway/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/controller/kafka"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/maintenance"
 	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
-	"github.com/gin-gonic/gin"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/openapi"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/ratelimit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/router"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/JojoWeyn/duo-proj/gateway/pkg/client/redis"
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
+	"github.com/JojoWeyn/duo-proj/shared/env"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/joho/godotenv"
-	"golang.org/x/time/rate"
 )
 
-type route struct {
-	Method    string
-	Path      string
-	Service   string
-	Protected bool
-}
-
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
 	}
 
-	serviceURLs := map[string]string{
-		"identity": getEnv("IDENTITY_SERVICE_URL", "http://localhost:8081"),
-		"user":     getEnv("USER_SERVICE_URL", "http://176.109.108.209:8082"),
-		"course":   getEnv("COURSE_SERVICE_URL", "http://176.109.108.209:8083"),
+	services := map[string]upstream.ServiceConfig{
+		"identity": getServiceConfig("IDENTITY", "http://localhost:8081"),
+		"user":     getServiceConfig("USER", "http://176.109.108.209:8082"),
+		"course":   getServiceConfig("COURSE", "http://176.109.108.209:8083"),
 	}
 
+	// public.pem нужен только для токенов без kid, выданных до ротации ключей.
 	jwtPublicKey, err := loadPublicKey("/app/public.pem")
+	if errors.Is(err, os.ErrNotExist) {
+		jwtPublicKey, err = nil, nil
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "gateway"),
+		Exporter:    getEnv("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
+		SampleRatio: env.Float("OTEL_TRACES_SAMPLER_ARG", 1),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	upstreams, err := upstream.NewRegistry(services, upstream.Config{
+		MaxRetries:       getEnvAsInt("UPSTREAM_MAX_RETRIES", 2),
+		RetryBackoff:     getEnvAsDuration("UPSTREAM_RETRY_BACKOFF", 100*time.Millisecond),
+		BreakerThreshold: getEnvAsInt("UPSTREAM_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  getEnvAsDuration("UPSTREAM_BREAKER_COOLDOWN", 30*time.Second),
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	upstreams.StartHealthChecks(ctx, getEnvAsDuration("UPSTREAM_HEALTH_INTERVAL", 5*time.Second))
+
+	identity, _ := upstreams.Get("identity")
+	revoked := auth.NewRevocationCache(identity.URL.String(), identity.Client.Transport)
+	go revoked.Start(ctx, getEnvAsDuration("REVOCATION_POLL_INTERVAL", 10*time.Second))
+
+	jwks := auth.NewJWKSCache(identity.URL.String(), identity.Client.Transport)
+	go jwks.Start(ctx, getEnvAsDuration("JWKS_POLL_INTERVAL", 5*time.Minute))
+
+	subjects := auth.NewSubjectCache(identity.URL.String(), identity.Client.Transport, getEnvAsDuration("SUBJECT_EVENTS_TTL", time.Hour))
+	go subjects.Start(ctx, getEnvAsDuration("DISABLED_IDENTITIES_POLL_INTERVAL", 5*time.Minute))
+
+	// Каждый экземпляр шлюза должен получать все события, поэтому группа по
+	// умолчанию своя у каждого хоста; общая группа поделила бы события между ними.
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("Failed to get hostname: %s", err.Error())
+	}
+	identityConsumer := kafka.NewIdentityConsumer(
+		[]string{getEnv("KAFKA_BROKERS", "kafka:29092")},
+		getEnv("KAFKA_IDENTITY_TOPIC", "identity_changed"),
+		getEnv("KAFKA_IDENTITY_GROUP", "gateway-"+hostname),
+		subjects,
+	)
+	go identityConsumer.Start(ctx)
+
+	verifier := auth.NewVerifier(jwks, jwtPublicKey, revoked, subjects)
+	assertionSecret := getEnv("IDENTITY_ASSERTION_SECRET", "")
+	if assertionSecret == "" {
+		log.Fatal("IDENTITY_ASSERTION_SECRET is required")
+	}
+	signer := assertion.NewSigner(assertionSecret, getEnvAsDuration("IDENTITY_ASSERTION_TTL", 30*time.Second))
 
-	proxy := v1.NewProxyHandler(jwtPublicKey)
-
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"}
-	config.AllowMethods = []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.ExposeHeaders = []string{"Authorization"}
-
-	router := gin.Default()
-	router.Use(cors.New(config))
-
-	refreshLimiter := rate.NewLimiter(rate.Every(1*time.Second), 1)
-
-	public := router.Group("/v1", middleware.RateLimitMiddleware(refreshLimiter))
-	protected := router.Group("/v1", middleware.AuthMiddleware(serviceURLs["identity"]))
-
-	publicRoutes := []route{
-		{"POST", "/auth/register", "identity", false},
-		{"POST", "/auth/login", "identity", false},
-		{"POST", "/auth/refresh", "identity", false},
-		{"POST", "/auth/password/reset", "identity", false},
-		{"POST", "/auth/verification/code", "identity", false},
-		{"POST", "/auth/verification/email", "identity", false},
-	}
-
-	protectedRoutes := []route{
-		{"POST", "/auth/logout", "identity", false},
-		{"GET", "/auth/token/status", "identity", false},
-		{"GET", "/auth/me", "identity", false},
-
-		// User
-		{"GET", "/users/:uuid", "user", true},
-		{"GET", "/users/all", "user", true},
-		{"GET", "/users/me", "user", true},
-		{"GET", "/users/achievements/:uuid", "user", true},
-		{"GET", "/achievements/list", "user", true},
-		{"GET", "/users/me/progress", "user", true},
-		{"GET", "/users/leaderboard", "user", true},
-		{"GET", "/users/me/streak", "user", true},
-		{"PATCH", "/users/me", "user", true},
-		{"POST", "/users/me/avatar", "user", true},
-
-		// Course
-		{"GET", "/course/list", "course", true},
-		{"GET", "/course/:uuid/info", "course", true},
-		{"GET", "/course/:uuid/content", "course", true},
-		{"GET", "/lesson/:uuid/info", "course", true},
-		{"GET", "/lesson/:uuid/content", "course", true},
-		{"GET", "/exercise/:uuid/info", "course", true},
-		{"GET", "/question/:uuid/info", "course", true},
-		{"GET", "/exercise/:uuid/question", "course", true},
-		{"POST", "/question/:uuid/check", "course", true},
-		{"POST", "/attempts/start/:exercise_id", "course", true},
-		{"POST", "/attempts/answer", "course", true},
-		{"POST", "/attempts/finish", "course", true},
-
-		// Admin
-		{"GET", "/admin/users", "user", true},
-		{"DELETE", "/admin/users/:uuid", "user", true},
-
-		{"POST", "/admin/achievements/create", "user", true},
-		{"GET", "/admin/achievements/:uuid", "user", true},
-		{"PATCH", "/admin/achievements/:uuid", "user", true},
-		{"DELETE", "/admin/achievements/:uuid", "user", true},
-		{"GET", "/admin/achievements/list", "user", true},
-
-		{"GET", "/admin/course/list", "course", true},
-		{"POST", "/admin/course/import-excel", "course", true},
-		{"GET", "/admin/course/:course_id/lesson", "course", true},
-		{"GET", "/admin/lesson/:lesson_id/exercise", "course", true},
-		{"GET", "/admin/exercise/:exercise_id/question", "course", true},
-		{"GET", "/admin/question/:question_id/question-option", "course", true},
-		{"GET", "/admin/question/:question_id/matching-pair", "course", true},
-
-		{"GET", "/admin/course/:course_id", "course", true},
-		{"GET", "/admin/lesson/:lesson_id", "course", true},
-		{"GET", "/admin/exercise/:exercise_id", "course", true},
-		{"GET", "/admin/question/:question_id", "course", true},
-		{"GET", "/admin/question-option/:uuid", "course", true},
-		{"GET", "/admin/matching-pair/:uuid", "course", true},
-
-		{"POST", "/admin/course", "course", true},
-		{"POST", "/admin/lesson", "course", true},
-		{"POST", "/admin/exercise", "course", true},
-		{"POST", "/admin/question", "course", true},
-		{"POST", "/admin/question-option", "course", true},
-		{"POST", "/admin/matching-pair", "course", true},
-
-		{"PATCH", "/admin/course/:uuid", "course", true},
-		{"PATCH", "/admin/lesson/:uuid", "course", true},
-		{"PATCH", "/admin/exercise/:uuid", "course", true},
-		{"PATCH", "/admin/question/:uuid", "course", true},
-		{"PATCH", "/admin/question-option/:uuid", "course", true},
-		{"PATCH", "/admin/matching-pair/:uuid", "course", true},
-
-		{"DELETE", "/admin/course/:uuid", "course", true},
-		{"DELETE", "/admin/lesson/:uuid", "course", true},
-		{"DELETE", "/admin/exercise/:uuid", "course", true},
-		{"DELETE", "/admin/question/:uuid", "course", true},
-		{"DELETE", "/admin/question-option/:uuid", "course", true},
-		{"DELETE", "/admin/matching-pair/:uuid", "course", true},
-
-		// File Admin
-		{"POST", "/admin/file/upload", "course", true},
-		{"GET", "/admin/file/list", "course", true},
-		{"POST", "/admin/file/add", "course", true},
-		{"POST", "/admin/file/unpin", "course", true},
-		{"DELETE", "/admin/file/delete", "course", true},
-	}
-
-	registerRoutes(public, proxy, publicRoutes, serviceURLs)
-	registerRoutes(protected, proxy, protectedRoutes, serviceURLs)
-
-	router.GET("/swagger/:service/*path", func(c *gin.Context) {
-		service := c.Param("service")
-		path := c.Param("path")
-
-		targetURL, ok := serviceURLs[service]
-		if !ok {
-			c.JSON(404, gin.H{"error": "Service not found"})
-			return
+	proxy := v1.NewProxyHandler(upstreams, verifier, signer)
+
+	routesFile := getEnv("ROUTES_FILE", "/app/routes.yaml")
+	table, err := routes.Load(routesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := table.Validate(upstreams); err != nil {
+		log.Fatalf("Invalid routes file: %s", err.Error())
+	}
+
+	rateLimitBackend := getEnv("RATE_LIMIT_BACKEND", "memory")
+	cacheBackend := getEnv("RESPONSE_CACHE_BACKEND", "redis")
+	auditBackend := getEnv("AUDIT_BACKEND", "redis")
+	apiKeyBackend := getEnv("API_KEY_BACKEND", "redis")
+	usesRedis := rateLimitBackend == "redis" || cacheBackend != "none" || auditBackend == "redis" || apiKeyBackend == "redis"
+	// Режим обслуживания хранится в redis, если он уже нужен другим подсистемам:
+	// иначе переключение увидела бы только одна реплика.
+	defaultMaintenanceBackend := "memory"
+	if usesRedis {
+		defaultMaintenanceBackend = "redis"
+	}
+	maintenanceBackend := getEnv("MAINTENANCE_BACKEND", defaultMaintenanceBackend)
+
+	var redisClient *goredis.Client
+	if usesRedis || maintenanceBackend == "redis" {
+		redisClient, err = redis.NewRedisClient(ctx, redis.Config{
+			Addr: getEnv("REDIS_URL", "redis:6379"),
+			DB:   getEnvAsInt("REDIS_DB", 0),
+		})
+		if err != nil {
+			log.Fatalf("Failed to connect to redis: %s", err.Error())
 		}
+	}
+
+	var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+	if rateLimitBackend == "redis" {
+		limiter = ratelimit.NewRedisLimiter(redisClient)
+	}
+
+	// Кэш ответов: none отключает его, memory хранит ответы в реплике, redis - общий.
+	// События сброса от сервисов приходят через Redis, поэтому он нужен при любом
+	// включенном кэше: иначе реплика отдавала бы устаревшие ответы до конца TTL.
+	var (
+		responseCache  cache.Store
+		cachePublisher cache.Publisher
+	)
+	switch cacheBackend {
+	case "none":
+	case "redis":
+		responseCache = cache.NewRedisStore(redisClient)
+	default:
+		responseCache = cache.NewMemoryStore(getEnvAsInt("RESPONSE_CACHE_MAX_ENTRIES", 10000))
+	}
+	if responseCache != nil {
+		channel := getEnv("RESPONSE_CACHE_PURGE_CHANNEL", cache.PurgeChannel)
+		cachePublisher = cache.NewRedisPublisher(redisClient, channel)
+		go cache.Subscribe(ctx, redisClient, channel, responseCache)
+	}
+
+	security := getSecurityPolicy(getEnv("GATEWAY_ENV", "development"))
+	if err := security.Validate(); err != nil {
+		log.Fatalf("Invalid security policy: %s", err.Error())
+	}
+
+	// Журнал аудита административных запросов: redis пишет в общий для реплик
+	// поток и переживает перезапуск, memory теряет записи при перезапуске и
+	// годится только для разработки, none отключает аудит.
+	var auditLog audit.Store
+	switch auditBackend {
+	case "none":
+	case "redis":
+		// Журнал не обрезается, пока AUDIT_MAX_RECORDS не задан явно.
+		auditLog = audit.NewRedisStore(redisClient, getEnv("AUDIT_STREAM", audit.Stream), int64(getEnvAsInt("AUDIT_MAX_RECORDS", 0)))
+	case "memory":
+		log.Println("AUDIT_BACKEND=memory: audit records are kept in this replica only and lost on restart")
+		auditLog = audit.NewMemoryStore(getEnvAsInt("AUDIT_MAX_RECORDS", 10000))
+	default:
+		log.Fatalf("Unknown AUDIT_BACKEND %q", auditBackend)
+	}
+
+	// Ключи интеграций: redis делит ключи и квоты между репликами, memory - для
+	// разработки, none отключает вход по ключу.
+	var apiKeys apikey.Store
+	switch apiKeyBackend {
+	case "none":
+	case "redis":
+		apiKeys = apikey.NewRedisStore(redisClient)
+	case "memory":
+		log.Println("API_KEY_BACKEND=memory: api keys and quotas are kept in this replica only and lost on restart")
+		apiKeys = apikey.NewMemoryStore()
+	default:
+		log.Fatalf("Unknown API_KEY_BACKEND %q", apiKeyBackend)
+	}
+
+	// Недоступность счетчиков квот: reject отвечает 503, allow пропускает запрос
+	// по ключу без учета квоты, чтобы сбой Redis не останавливал интеграции.
+	var apiKeyQuotaFailOpen bool
+	switch quotaOnError := getEnv("API_KEY_QUOTA_ON_ERROR", "reject"); quotaOnError {
+	case "reject":
+	case "allow":
+		apiKeyQuotaFailOpen = true
+	default:
+		log.Fatalf("Unknown API_KEY_QUOTA_ON_ERROR %q, expected reject or allow", quotaOnError)
+	}
 
-		// Проксирование Swagger-запроса
-		proxy.ProxySwagger(targetURL, path)(c)
+	// Режим обслуживания: с redis переключение видят все реплики, с memory - только эта.
+	var maintenanceStore maintenance.Store
+	switch maintenanceBackend {
+	case "redis":
+		maintenanceStore = maintenance.NewRedisStore(redisClient, getEnv("MAINTENANCE_KEY", maintenance.Key))
+	case "memory":
+		log.Println("MAINTENANCE_BACKEND=memory: maintenance mode is switched in this replica only")
+		maintenanceStore = maintenance.NewMemoryStore()
+	default:
+		log.Fatalf("Unknown MAINTENANCE_BACKEND %q", maintenanceBackend)
+	}
+	maintenanceSwitch := maintenance.NewSwitch(maintenanceStore)
+	go maintenanceSwitch.Start(ctx, getEnvAsDuration("MAINTENANCE_POLL_INTERVAL", 5*time.Second))
+
+	aggregator := openapi.NewAggregator(upstreams, getEnvAsDuration("OPENAPI_CACHE_TTL", time.Minute))
+
+	deps := router.Deps{
+		Proxy:               proxy,
+		Dashboard:           v1.NewDashboardHandler(upstreams, signer),
+		Verifier:            verifier,
+		Limiter:             limiter,
+		Upstreams:           upstreams,
+		OpenAPI:             aggregator,
+		APIKeys:             apiKeys,
+		APIKeyQuotaFailOpen: apiKeyQuotaFailOpen,
+		Audit:               auditLog,
+		Maintenance:         maintenanceSwitch,
+		Cache:               responseCache,
+		CachePublisher:      cachePublisher,
+		Security:            security,
+		TrustedProxies:      env.List("TRUSTED_PROXIES"),
+	}
+
+	engine, err := router.New(table, deps)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handler := router.NewHandler(engine)
+	go checkOpenAPI(ctx, aggregator, table)
+
+	watcher := routes.NewWatcher(routesFile, 5*time.Second, upstreams, func(t *routes.Table) error {
+		engine, err := router.New(t, deps)
+		if err != nil {
+			return err
+		}
+		handler.Swap(engine)
+		go checkOpenAPI(ctx, aggregator, t)
+		return nil
 	})
+	go watcher.Start(ctx)
+
+	metricsAddr := ":" + getEnv("METRICS_PORT", "9091")
+	go func() {
+		if err := metrics.Serve(metricsAddr); err != nil {
+			log.Fatalf("Failed to start metrics server: %s", err.Error())
+		}
+	}()
 
 	port := getEnv("PORT", "3211")
-	if err := router.Run(":" + port); err != nil {
+	log.Printf("Starting gateway on port %s with %d routes", port, len(table.Routes))
+	if err := http.ListenAndServe(":"+port, handler); err != nil {
 		log.Fatalf("Failed to start server: %s", err.Error())
 	}
 }
 
+// checkOpenAPI сверяет таблицу маршрутов со спецификациями сервисов и пишет расхождения в лог.
+func checkOpenAPI(ctx context.Context, aggregator *openapi.Aggregator, table *routes.Table) {
+	_, issues := aggregator.Build(ctx, table)
+	for _, issue := range issues {
+		log.Printf("OpenAPI check: %s", issue)
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -181,6 +295,63 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getSecurityPolicy читает CORS_ALLOW_ORIGINS, CORS_ALLOW_CREDENTIALS,
+// CORS_EXPOSE_HEADERS и HSTS_MAX_AGE. В development по умолчанию разрешен любой
+// Origin без HSTS, в остальных окружениях список Origin обязателен, а HSTS включен.
+func getSecurityPolicy(appEnv string) middleware.SecurityPolicy {
+	development := appEnv == "development"
+
+	origins := env.List("CORS_ALLOW_ORIGINS")
+	if len(origins) == 0 && development {
+		origins = []string{"*"}
+	}
+
+	hstsMaxAge := 365 * 24 * time.Hour
+	if development {
+		hstsMaxAge = 0
+	}
+
+	return middleware.SecurityPolicy{
+		AllowOrigins:     origins,
+		AllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+		ExposeHeaders:    env.List("CORS_EXPOSE_HEADERS"),
+		HSTSMaxAge:       getEnvAsDuration("HSTS_MAX_AGE", hstsMaxAge),
+	}
+}
+
+// getServiceConfig читает <NAME>_SERVICE_URL (список адресов экземпляров через запятую),
+// <NAME>_SERVICE_BALANCER и <NAME>_SERVICE_HEALTH_PATH.
+func getServiceConfig(name, defaultURL string) upstream.ServiceConfig {
+	urls := env.List(name + "_SERVICE_URL")
+	if len(urls) == 0 {
+		urls = []string{defaultURL}
+	}
+
+	return upstream.ServiceConfig{
+		URLs:       urls,
+		Balancer:   getEnv(name+"_SERVICE_BALANCER", upstream.BalancerRoundRobin),
+		HealthPath: getEnv(name+"_SERVICE_HEALTH_PATH", "/health"),
+	}
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
 func loadPublicKey(path string) (*rsa.PublicKey, error) {
 	keyData, err := os.ReadFile(path)
 	if err != nil {
@@ -204,19 +375,3 @@ func loadPublicKey(path string) (*rsa.PublicKey, error) {
 
 	return publicKey, nil
 }
-
-func registerRoutes(group *gin.RouterGroup, proxy *v1.ProxyHandler, routes []route, services map[string]string) {
-	for _, r := range routes {
-		handler := proxy.ProxyService(services[r.Service], r.Protected)
-		switch r.Method {
-		case "GET":
-			group.GET(r.Path, handler)
-		case "POST":
-			group.POST(r.Path, handler)
-		case "PATCH":
-			group.PATCH(r.Path, handler)
-		case "DELETE":
-			group.DELETE(r.Path, handler)
-		}
-	}
-}
@@ -0,0 +1,153 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Header - заголовок, в котором интеграции передают ключ.
+const Header = "X-API-Key"
+
+// secretPrefix отличает ключи gateway от JWT и упрощает поиск утечек в логах и репозиториях.
+const secretPrefix = "duo_"
+
+var ErrInvalidKey = errors.New("invalid api key")
+
+// Key - ключ интеграции. Сам секрет не хранится, только его sha256.
+// ID передается сервисам вместо UUID пользователя.
+type Key struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Scopes       []string   `json:"scopes"`
+	DailyQuota   int64      `json:"daily_quota"`
+	MonthlyQuota int64      `json:"monthly_quota"`
+	SecretHash   string     `json:"secret_hash,omitempty"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// New создает ключ и возвращает секрет вида duo_<id>.<random>: он показывается
+// один раз при выпуске, дальше восстановить его нельзя.
+func New(name string, scopes []string, dailyQuota, monthlyQuota int64, createdBy string) (*Key, string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(random)
+
+	key := &Key{
+		ID:           uuid.NewString(),
+		Name:         name,
+		Scopes:       scopes,
+		DailyQuota:   dailyQuota,
+		MonthlyQuota: monthlyQuota,
+		SecretHash:   hashSecret(secret),
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now().UTC(),
+	}
+	return key, secretPrefix + key.ID + "." + secret, nil
+}
+
+// Parse разбирает ключ из заголовка на идентификатор и секрет.
+func Parse(value string) (id, secret string, err error) {
+	rest, ok := strings.CutPrefix(value, secretPrefix)
+	if !ok {
+		return "", "", ErrInvalidKey
+	}
+	id, secret, ok = strings.Cut(rest, ".")
+	if !ok || secret == "" {
+		return "", "", ErrInvalidKey
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", "", ErrInvalidKey
+	}
+	return id, secret, nil
+}
+
+// Verify сравнивает секрет с сохраненным хэшем за постоянное время.
+func (k *Key) Verify(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(k.SecretHash)) == 1
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Period - за какой период превышена квота.
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodMonth Period = "month"
+)
+
+// Usage - расход ключа за текущие сутки и месяц (UTC) после запроса.
+// Exceeded не пуст, если запрос отклонен по квоте, и тогда счетчики не меняются.
+type Usage struct {
+	Day      int64
+	Month    int64
+	Exceeded Period
+}
+
+// UsageDays - сколько последних суток хранится расход ключа по дням:
+// отчет об использовании можно построить только за этот срок.
+const UsageDays = 366
+
+// DayUsage - число запросов ключа за сутки для отчета.
+type DayUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
+
+// Store хранит ключи и счетчики их использования. Get возвращает nil, nil,
+// если ключа нет. Квота 0 не ограничивает запросы.
+type Store interface {
+	Create(ctx context.Context, key *Key) error
+	Get(ctx context.Context, id string) (*Key, error)
+	List(ctx context.Context) ([]Key, error)
+	Revoke(ctx context.Context, id string, at time.Time) (*Key, error)
+	Consume(ctx context.Context, key *Key, now time.Time) (Usage, error)
+	// DailyUsage отдает расход по дням с from по to включительно и расход за месяц to.
+	DailyUsage(ctx context.Context, id string, from, to time.Time) ([]DayUsage, int64, error)
+}
+
+func dayKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func monthKey(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// ResetAt - когда обнулится квота за период: начало следующих суток или месяца в UTC.
+func ResetAt(period Period, now time.Time) time.Time {
+	now = now.UTC()
+	if period == PeriodMonth {
+		return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,119 @@
+package apikey_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/apikey"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func stores(t *testing.T) (map[string]apikey.Store, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]apikey.Store{
+		"memory": apikey.NewMemoryStore(),
+		"redis":  apikey.NewRedisStore(client),
+	}, server
+}
+
+func TestNewAndParse(t *testing.T) {
+	key, secret, err := apikey.New("reports", []string{"reports"}, 10, 100, "admin-1")
+	require.NoError(t, err)
+
+	id, raw, err := apikey.Parse(secret)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, id)
+	require.True(t, key.Verify(raw))
+	require.False(t, key.Verify(raw+"x"))
+
+	for _, value := range []string{"", "token", "duo_" + key.ID, "duo_not-a-uuid.secret", "duo_" + key.ID + "."} {
+		_, _, err := apikey.Parse(value)
+		require.ErrorIs(t, err, apikey.ErrInvalidKey, value)
+	}
+}
+
+func TestStores(t *testing.T) {
+	all, _ := stores(t)
+	for name, store := range all {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			key, _, err := apikey.New("reports", []string{"reports"}, 2, 3, "admin-1")
+			require.NoError(t, err)
+			require.NoError(t, store.Create(ctx, key))
+
+			got, err := store.Get(ctx, key.ID)
+			require.NoError(t, err)
+			require.Equal(t, key.SecretHash, got.SecretHash)
+
+			missing, err := store.Get(ctx, "00000000-0000-0000-0000-000000000000")
+			require.NoError(t, err)
+			require.Nil(t, missing)
+
+			day := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+			usage, err := store.Consume(ctx, key, day)
+			require.NoError(t, err)
+			require.Equal(t, apikey.Usage{Day: 1, Month: 1}, usage)
+			_, err = store.Consume(ctx, key, day)
+			require.NoError(t, err)
+
+			usage, err = store.Consume(ctx, key, day)
+			require.NoError(t, err)
+			require.Equal(t, apikey.PeriodDay, usage.Exceeded)
+			require.EqualValues(t, 2, usage.Day, "rejected request is not counted")
+
+			nextDay := day.AddDate(0, 0, 1)
+			_, err = store.Consume(ctx, key, nextDay)
+			require.NoError(t, err)
+			usage, err = store.Consume(ctx, key, nextDay)
+			require.NoError(t, err)
+			require.Equal(t, apikey.PeriodMonth, usage.Exceeded)
+
+			days, month, err := store.DailyUsage(ctx, key.ID, day.AddDate(0, 0, -1), nextDay)
+			require.NoError(t, err)
+			require.Equal(t, []apikey.DayUsage{
+				{Date: "2026-03-09", Requests: 0},
+				{Date: "2026-03-10", Requests: 2},
+				{Date: "2026-03-11", Requests: 1},
+			}, days)
+			require.EqualValues(t, 3, month)
+
+			revoked, err := store.Revoke(ctx, key.ID, nextDay)
+			require.NoError(t, err)
+			require.True(t, revoked.Revoked())
+			got, err = store.Get(ctx, key.ID)
+			require.NoError(t, err)
+			require.True(t, got.Revoked())
+		})
+	}
+}
+
+func TestRedisStore_KeepsDailyUsageForReport(t *testing.T) {
+	all, server := stores(t)
+	ctx := context.Background()
+	key, _, err := apikey.New("reports", []string{"reports"}, 0, 0, "admin-1")
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = all["redis"].Consume(ctx, key, now)
+	require.NoError(t, err)
+
+	// Сутки должны дожить до конца окна отчета, даже если запрос был в их начале.
+	server.FastForward(apikey.UsageDays * 24 * time.Hour)
+	days, _, err := all["redis"].DailyUsage(ctx, key.ID, now, now)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, days[0].Requests)
+}
+
+func TestResetAt(t *testing.T) {
+	now := time.Date(2026, 12, 31, 15, 0, 0, 0, time.UTC)
+
+	require.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), apikey.ResetAt(apikey.PeriodDay, now))
+	require.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), apikey.ResetAt(apikey.PeriodMonth, now))
+	require.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), apikey.ResetAt(apikey.PeriodMonth, now.AddDate(0, -11, -30)))
+}
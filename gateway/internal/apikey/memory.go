@@ -0,0 +1,103 @@
+package apikey
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит ключи и счетчики в памяти реплики. Подходит для
+// разработки: после перезапуска ключи нужно выпустить заново.
+type MemoryStore struct {
+	mu     sync.Mutex
+	keys   map[string]Key
+	counts map[string]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys:   make(map[string]Key),
+		counts: make(map[string]int64),
+	}
+}
+
+func (s *MemoryStore) Create(_ context.Context, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[key.ID] = *key
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func (s *MemoryStore) List(_ context.Context) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *MemoryStore) Revoke(_ context.Context, id string, at time.Time) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		s.keys[id] = key
+	}
+	return &key, nil
+}
+
+func (s *MemoryStore) Consume(_ context.Context, key *Key, now time.Time) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := key.ID + ":" + dayKey(now)
+	month := key.ID + ":" + monthKey(now)
+	usage := Usage{Day: s.counts[day], Month: s.counts[month]}
+	switch {
+	case key.DailyQuota > 0 && usage.Day >= key.DailyQuota:
+		usage.Exceeded = PeriodDay
+		return usage, nil
+	case key.MonthlyQuota > 0 && usage.Month >= key.MonthlyQuota:
+		usage.Exceeded = PeriodMonth
+		return usage, nil
+	}
+
+	s.counts[day]++
+	s.counts[month]++
+	usage.Day++
+	usage.Month++
+	return usage, nil
+}
+
+func (s *MemoryStore) DailyUsage(_ context.Context, id string, from, to time.Time) ([]DayUsage, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var days []DayUsage
+	for d := from.UTC(); !d.After(to.UTC()); d = d.AddDate(0, 0, 1) {
+		days = append(days, DayUsage{Date: dayKey(d), Requests: s.counts[id+":"+dayKey(d)]})
+	}
+	return days, s.counts[id+":"+monthKey(to)], nil
+}
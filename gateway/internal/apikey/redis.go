@@ -0,0 +1,177 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// consumeScript атомарно проверяет квоты и увеличивает счетчики суток и месяца,
+// чтобы реплики gateway не превысили квоту при одновременных запросах.
+var consumeScript = redis.NewScript(`
+local daily = tonumber(ARGV[1])
+local monthly = tonumber(ARGV[2])
+
+local day = tonumber(redis.call('GET', KEYS[1]) or '0')
+local month = tonumber(redis.call('GET', KEYS[2]) or '0')
+
+if daily > 0 and day >= daily then
+	return {day, month, 1}
+end
+if monthly > 0 and month >= monthly then
+	return {day, month, 2}
+end
+
+day = redis.call('INCR', KEYS[1])
+month = redis.call('INCR', KEYS[2])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('EXPIRE', KEYS[2], ARGV[4])
+
+return {day, month, 0}
+`)
+
+const (
+	// Счетчики хранятся дольше периода, чтобы по ним строился отчет: суточный -
+	// UsageDays суток после последнего запроса за эти сутки.
+	dayUsageTTL   = (UsageDays + 1) * 24 * time.Hour
+	monthUsageTTL = 400 * 24 * time.Hour
+)
+
+// RedisStore хранит ключи в Redis, поэтому выпуск, отзыв и квоты общие для
+// всех реплик gateway. Ключ - gateway:apikeys:<id>, счетчики -
+// gateway:apikeys:usage:<id>:<сутки или месяц>.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: "gateway:apikeys:",
+	}
+}
+
+func (s *RedisStore) Create(ctx context.Context, key *Key) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.prefix+key.ID, data, 0)
+		pipe.SAdd(ctx, s.prefix+"index", key.ID)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (*Key, error) {
+	data, err := s.client.Get(ctx, s.prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{}
+	if err := json.Unmarshal(data, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *RedisStore) List(ctx context.Context) ([]Key, error) {
+	ids, err := s.client.SMembers(ctx, s.prefix+"index").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(ids))
+	for _, id := range ids {
+		key, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *RedisStore) Revoke(ctx context.Context, id string, at time.Time) (*Key, error) {
+	key, err := s.Get(ctx, id)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	key.RevokedAt = &at
+	data, err := json.Marshal(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.client.Set(ctx, s.prefix+id, data, 0).Err(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *RedisStore) Consume(ctx context.Context, key *Key, now time.Time) (Usage, error) {
+	values, err := consumeScript.Run(ctx, s.client,
+		[]string{s.usageKey(key.ID, dayKey(now)), s.usageKey(key.ID, monthKey(now))},
+		key.DailyQuota, key.MonthlyQuota, int(dayUsageTTL.Seconds()), int(monthUsageTTL.Seconds()),
+	).Int64Slice()
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := Usage{Day: values[0], Month: values[1]}
+	switch values[2] {
+	case 1:
+		usage.Exceeded = PeriodDay
+	case 2:
+		usage.Exceeded = PeriodMonth
+	}
+	return usage, nil
+}
+
+func (s *RedisStore) DailyUsage(ctx context.Context, id string, from, to time.Time) ([]DayUsage, int64, error) {
+	var keys []string
+	var days []DayUsage
+	for d := from.UTC(); !d.After(to.UTC()); d = d.AddDate(0, 0, 1) {
+		keys = append(keys, s.usageKey(id, dayKey(d)))
+		days = append(days, DayUsage{Date: dayKey(d)})
+	}
+	keys = append(keys, s.usageKey(id, monthKey(to)))
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	counts := make([]int64, len(values))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			counts[i], _ = strconv.ParseInt(str, 10, 64)
+		}
+	}
+	for i := range days {
+		days[i].Requests = counts[i]
+	}
+	return days, counts[len(counts)-1], nil
+}
+
+func (s *RedisStore) usageKey(id, period string) string {
+	return s.prefix + "usage:" + id + ":" + period
+}
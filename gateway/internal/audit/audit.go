@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Record - запись аудита административного запроса, прошедшего через gateway.
+type Record struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	RequestID string            `json:"request_id,omitempty"`
+	ActorUUID string            `json:"actor_uuid"`
+	Role      string            `json:"role"`
+	ClientIP  string            `json:"client_ip"`
+	Method    string            `json:"method"`
+	Route     string            `json:"route"`
+	Path      string            `json:"path"`
+	Service   string            `json:"service"`
+	Entity    string            `json:"entity"`
+	Targets   map[string]string `json:"targets,omitempty"`
+	Status    int               `json:"status"`
+	LatencyMs int64             `json:"latency_ms"`
+	// BodyHash - sha256 тела запроса в hex, пустое для запросов без тела.
+	BodyHash string `json:"body_hash,omitempty"`
+	BodySize int64  `json:"body_size"`
+}
+
+// Filter отбирает записи: пустые поля не ограничивают выборку.
+// Target совпадает с любым из идентификаторов в Record.Targets.
+type Filter struct {
+	Actor  string
+	Entity string
+	Target string
+	From   time.Time
+	To     time.Time
+	Limit  int
+}
+
+func (f Filter) match(r *Record) bool {
+	if f.Actor != "" && r.ActorUUID != f.Actor {
+		return false
+	}
+	if f.Entity != "" && r.Entity != f.Entity {
+		return false
+	}
+	if !f.From.IsZero() && r.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && r.Time.After(f.To) {
+		return false
+	}
+	if f.Target != "" {
+		for _, id := range r.Targets {
+			if id == f.Target {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Store - журнал аудита, в который можно только дописывать.
+// Query возвращает записи от новых к старым, не больше Filter.Limit.
+type Store interface {
+	Append(ctx context.Context, record *Record) error
+	Query(ctx context.Context, filter Filter) ([]Record, error)
+}
+
+// Entity - тип сущности маршрута: сегмент после admin, например users для
+// /admin/users/:uuid и identities для /auth/admin/identities/:uuid, а для
+// маршрутов вне admin - первый сегмент пути.
+func Entity(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments[:len(segments)-1] {
+		if segment == "admin" {
+			return segments[i+1]
+		}
+	}
+	return segments[0]
+}
@@ -0,0 +1,110 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/audit"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func stores(t *testing.T) map[string]audit.Store {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]audit.Store{
+		"memory": audit.NewMemoryStore(100),
+		"redis":  audit.NewRedisStore(client, audit.Stream, 0),
+	}
+}
+
+func TestStores(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	records := []audit.Record{
+		{Time: now.Add(-3 * time.Hour), ActorUUID: "admin-1", Entity: "users", Targets: map[string]string{"uuid": "user-1"}},
+		{Time: now.Add(-2 * time.Hour), ActorUUID: "admin-2", Entity: "identities", Targets: map[string]string{"uuid": "user-1"}},
+		{Time: now.Add(-time.Hour), ActorUUID: "admin-1", Entity: "course"},
+	}
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := range records {
+				record := records[i]
+				require.NoError(t, store.Append(ctx, &record))
+				require.NotEmpty(t, record.ID)
+			}
+
+			all, err := store.Query(ctx, audit.Filter{Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, all, 3)
+			require.Equal(t, "course", all[0].Entity, "newest record goes first")
+
+			byActor, err := store.Query(ctx, audit.Filter{Actor: "admin-1", Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, byActor, 2)
+
+			byTarget, err := store.Query(ctx, audit.Filter{Target: "user-1", Entity: "identities", Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, byTarget, 1)
+			require.Equal(t, "admin-2", byTarget[0].ActorUUID)
+
+			limited, err := store.Query(ctx, audit.Filter{Limit: 1})
+			require.NoError(t, err)
+			require.Len(t, limited, 1)
+		})
+	}
+}
+
+func TestStores_FilterByRecordTime(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			// Обе записи дописываются сейчас, но запросы начались раньше.
+			require.NoError(t, store.Append(ctx, &audit.Record{Time: now.Add(-time.Minute), Entity: "users"}))
+			require.NoError(t, store.Append(ctx, &audit.Record{Time: now.Add(-3 * time.Hour), Entity: "course"}))
+
+			records, err := store.Query(ctx, audit.Filter{From: now.Add(-2 * time.Minute), To: now.Add(-30 * time.Second), Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			require.Equal(t, "users", records[0].Entity)
+		})
+	}
+}
+
+func TestMemoryStore_KeepsLatest(t *testing.T) {
+	ctx := context.Background()
+	store := audit.NewMemoryStore(2)
+	for _, entity := range []string{"users", "course", "lesson"} {
+		require.NoError(t, store.Append(ctx, &audit.Record{Entity: entity}))
+	}
+
+	records, err := store.Query(ctx, audit.Filter{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "lesson", records[0].Entity)
+	require.Equal(t, "course", records[1].Entity)
+}
+
+func TestEntity(t *testing.T) {
+	tests := map[string]string{
+		"/admin/users/:uuid":                "users",
+		"/admin/course":                     "course",
+		"/auth/admin/identities/:uuid/role": "identities",
+		"/auth/admin/lockouts/:kind/:key":   "lockouts",
+		"/reports":                          "reports",
+		"/admin":                            "admin",
+	}
+
+	for path, want := range tests {
+		t.Run(path, func(t *testing.T) {
+			require.Equal(t, want, audit.Entity(path))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// MemoryStore хранит последние maxRecords записей в памяти реплики.
+// Подходит для разработки: после перезапуска журнал пуст.
+type MemoryStore struct {
+	mu         sync.Mutex
+	records    []Record
+	next       int
+	seq        uint64
+	maxRecords int
+}
+
+func NewMemoryStore(maxRecords int) *MemoryStore {
+	if maxRecords <= 0 {
+		maxRecords = 1
+	}
+	return &MemoryStore{maxRecords: maxRecords}
+}
+
+func (s *MemoryStore) Append(_ context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	record.ID = strconv.FormatUint(s.seq, 10)
+	if len(s.records) < s.maxRecords {
+		s.records = append(s.records, *record)
+		return nil
+	}
+	s.records[s.next] = *record
+	s.next = (s.next + 1) % s.maxRecords
+	return nil
+}
+
+func (s *MemoryStore) Query(_ context.Context, filter Filter) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Record
+	// Самая новая запись стоит перед s.next (или в конце, пока буфер не заполнен).
+	for i := 0; i < len(s.records) && len(result) < filter.Limit; i++ {
+		idx := (s.next - 1 - i + 2*len(s.records)) % len(s.records)
+		if filter.match(&s.records[idx]) {
+			result = append(result, s.records[idx])
+		}
+	}
+	return result, nil
+}
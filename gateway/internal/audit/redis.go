@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Stream - поток Redis, в который gateway пишет записи аудита.
+const Stream = "gateway:audit"
+
+// queryBatch - сколько записей читается из потока за раз при поиске по фильтру.
+const queryBatch = 500
+
+// appendDelay - насколько позже Record.Time запись может попасть в поток: Time -
+// начало запроса, а дописывается запись после ответа сервиса. Запросы дольше
+// этого срока при выборке с To могут не найтись.
+const appendDelay = 10 * time.Minute
+
+// RedisStore пишет записи в Redis Stream: поток только дополняется, общий для
+// всех реплик gateway, а идентификаторы записей упорядочены по времени
+// добавления, поэтому выборка по интервалу читает только нужный участок потока.
+type RedisStore struct {
+	client     *redis.Client
+	stream     string
+	maxRecords int64
+}
+
+// NewRedisStore создает журнал в потоке stream. maxRecords > 0 включает обрезку
+// потока примерно до maxRecords последних записей, старые записи при этом
+// удаляются без архивации; 0 - журнал хранится целиком.
+func NewRedisStore(client *redis.Client, stream string, maxRecords int64) *RedisStore {
+	return &RedisStore{client: client, stream: stream, maxRecords: maxRecords}
+}
+
+func (s *RedisStore) Append(ctx context.Context, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	id, err := s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: s.maxRecords,
+		Approx: s.maxRecords > 0,
+		Values: map[string]interface{}{"record": data},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	record.ID = id
+	return nil
+}
+
+// Query сужает чтение потока по времени добавления, а окончательно отбирает
+// записи по Record.Time: запись добавляется не раньше начала запроса и не
+// позже чем через appendDelay после него.
+func (s *RedisStore) Query(ctx context.Context, filter Filter) ([]Record, error) {
+	end, start := "+", "-"
+	if !filter.To.IsZero() {
+		end = streamTime(filter.To.Add(appendDelay))
+	}
+	if !filter.From.IsZero() {
+		start = streamTime(filter.From)
+	}
+
+	var result []Record
+	for len(result) < filter.Limit {
+		messages, err := s.client.XRevRangeN(ctx, s.stream, end, start, queryBatch).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, message := range messages {
+			raw, _ := message.Values["record"].(string)
+			var record Record
+			if err := json.Unmarshal([]byte(raw), &record); err != nil {
+				continue
+			}
+			record.ID = message.ID
+			if filter.match(&record) {
+				result = append(result, record)
+				if len(result) == filter.Limit {
+					break
+				}
+			}
+		}
+
+		if len(messages) < queryBatch {
+			break
+		}
+		end = "(" + messages[len(messages)-1].ID
+	}
+	return result, nil
+}
+
+// streamTime переводит время в идентификатор потока: его первая часть - миллисекунды.
+func streamTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
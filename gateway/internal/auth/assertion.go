@@ -0,0 +1,5 @@
+package auth
+
+// ServiceRole - роль, с которой сервисы получают запросы по ключу интеграции.
+// Вместо UUID пользователя в утверждении передается идентификатор ключа.
+const ServiceRole = "service"
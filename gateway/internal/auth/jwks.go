@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksRefetchInterval - не чаще этого ключи перезагружаются из-за неизвестного kid.
+const jwksRefetchInterval = 30 * time.Second
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKSCache хранит открытые ключи проверки access-токенов из
+// /.well-known/jwks.json identity-service. Набор обновляется по расписанию,
+// а при токене с неизвестным kid - сразу, чтобы подхватить ротацию ключей.
+type JWKSCache struct {
+	mu       sync.RWMutex
+	keys     map[string]*rsa.PublicKey
+	endpoint string
+	client   *http.Client
+
+	// fetchMu и refetchedAt ограничивают внеочередные загрузки.
+	fetchMu     sync.Mutex
+	refetchedAt time.Time
+}
+
+// NewJWKSCache принимает транспорт, через который доступен identity-service,
+// чтобы запросы шли через ту же балансировку, что и проксируемые.
+func NewJWKSCache(identityServiceURL string, transport http.RoundTripper) *JWKSCache {
+	return &JWKSCache{
+		keys:     make(map[string]*rsa.PublicKey),
+		endpoint: identityServiceURL + "/.well-known/jwks.json",
+		client:   &http.Client{Transport: transport, Timeout: 5 * time.Second},
+	}
+}
+
+// Start обновляет ключи с заданным интервалом до отмены контекста.
+func (c *JWKSCache) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.sync(ctx); err != nil {
+			log.Printf("Failed to sync JWKS: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Key возвращает ключ с идентификатором kid. Неизвестный kid вызывает
+// внеочередную загрузку набора, но не чаще jwksRefetchInterval.
+func (c *JWKSCache) Key(kid string) (*rsa.PublicKey, bool) {
+	if key, ok := c.lookup(kid); ok {
+		return key, true
+	}
+
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	// Пока ждали блокировку, набор мог обновить другой запрос.
+	if key, ok := c.lookup(kid); ok {
+		return key, true
+	}
+	if time.Since(c.refetchedAt) < jwksRefetchInterval {
+		return nil, false
+	}
+	c.refetchedAt = time.Now()
+
+	if err := c.sync(context.Background()); err != nil {
+		log.Printf("Failed to sync JWKS: %v", err)
+		return nil, false
+	}
+	return c.lookup(kid)
+}
+
+// Keys возвращает все известные ключи.
+func (c *JWKSCache) Keys() []*rsa.PublicKey {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]*rsa.PublicKey, 0, len(c.keys))
+	for _, key := range c.keys {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (c *JWKSCache) lookup(kid string) (*rsa.PublicKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+func (c *JWKSCache) sync(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(body.Keys))
+	for _, jwk := range body.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("Skipping invalid JWK %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys = keys
+	return nil
+}
+
+func (k jsonWebKey) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
@@ -0,0 +1,30 @@
+package auth_test
+
+import (
+	"crypto/rsa"
+	"net/http"
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKSCache_FetchesUnknownKid(t *testing.T) {
+	key := newKey(t)
+	server := jwksServer(t, map[string]*rsa.PrivateKey{"k1": key})
+	cache := auth.NewJWKSCache(server.URL, http.DefaultTransport)
+
+	got, ok := cache.Key("k1")
+	require.True(t, ok)
+	require.True(t, key.PublicKey.Equal(got))
+	require.Len(t, cache.Keys(), 1)
+
+	// Повторная загрузка из-за неизвестного kid ограничена по частоте.
+	server.Close()
+	_, ok = cache.Key("k2")
+	require.False(t, ok)
+
+	got, ok = cache.Key("k1")
+	require.True(t, ok)
+	require.True(t, key.PublicKey.Equal(got))
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type revokedToken struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type revokedTokensResponse struct {
+	Tokens []revokedToken `json:"tokens"`
+	Cursor time.Time      `json:"cursor"`
+}
+
+// RevocationCache хранит jti отозванных токенов до истечения их срока действия.
+// Список инкрементально подтягивается из identity-service по курсору.
+type RevocationCache struct {
+	mu       sync.RWMutex
+	revoked  map[string]time.Time
+	cursor   time.Time
+	synced   bool
+	endpoint string
+	client   *http.Client
+}
+
+// NewRevocationCache принимает транспорт, через который доступен identity-service,
+// чтобы опрос шел через ту же балансировку, что и проксируемые запросы.
+func NewRevocationCache(identityServiceURL string, transport http.RoundTripper) *RevocationCache {
+	return &RevocationCache{
+		revoked:  make(map[string]time.Time),
+		endpoint: identityServiceURL + "/v1/auth/token/revoked",
+		client:   &http.Client{Transport: transport, Timeout: 5 * time.Second},
+	}
+}
+
+func (c *RevocationCache) IsRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	expiresAt, ok := c.revoked[jti]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// Start опрашивает identity-service с заданным интервалом до отмены контекста.
+func (c *RevocationCache) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.sync(ctx); err != nil {
+			log.Printf("Failed to sync revoked tokens: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *RevocationCache) sync(ctx context.Context) error {
+	c.mu.RLock()
+	cursor := c.cursor
+	c.mu.RUnlock()
+
+	endpoint := c.endpoint
+	if !cursor.IsZero() {
+		endpoint += "?since=" + url.QueryEscape(cursor.Format(time.RFC3339Nano))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body revokedTokensResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, t := range body.Tokens {
+		c.revoked[t.JTI] = t.ExpiresAt
+	}
+	for jti, expiresAt := range c.revoked {
+		if now.After(expiresAt) {
+			delete(c.revoked, jti)
+		}
+	}
+	if body.Cursor.After(c.cursor) {
+		c.cursor = body.Cursor
+	}
+	if !c.synced {
+		log.Printf("Revoked tokens synced: %d active", len(c.revoked))
+		c.synced = true
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationCache_IncrementalSync(t *testing.T) {
+	cursor := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var mu sync.Mutex
+	var sinces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sinces = append(sinces, r.URL.Query().Get("since"))
+		first := len(sinces) == 1
+		mu.Unlock()
+
+		tokens := []map[string]any{}
+		if first {
+			tokens = append(tokens,
+				map[string]any{"jti": "active", "expires_at": time.Now().Add(time.Hour)},
+				map[string]any{"jti": "expired", "expires_at": time.Now().Add(-time.Minute)},
+			)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"tokens": tokens, "cursor": cursor})
+	}))
+	defer server.Close()
+
+	cache := auth.NewRevocationCache(server.URL, http.DefaultTransport)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Start(ctx, 20*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sinces) >= 2
+	}, time.Second, 10*time.Millisecond)
+
+	require.True(t, cache.IsRevoked("active"))
+	require.False(t, cache.IsRevoked("expired"))
+	require.False(t, cache.IsRevoked(""))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Empty(t, sinces[0])
+	require.Equal(t, cursor.Format(time.RFC3339Nano), sinces[1])
+}
+
+func TestRevocationCache_KeepsStateOnError(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+
+		if call > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"tokens": []map[string]any{{"jti": "revoked", "expires_at": time.Now().Add(time.Hour)}},
+			"cursor": time.Now(),
+		})
+	}))
+	defer server.Close()
+
+	cache := auth.NewRevocationCache(server.URL, http.DefaultTransport)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Start(ctx, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls >= 3
+	}, time.Second, 10*time.Millisecond)
+	require.True(t, cache.IsRevoked("revoked"))
+}
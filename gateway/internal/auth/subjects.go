@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	domainErrors "github.com/JojoWeyn/duo-proj/gateway/internal/domain/errors"
+)
+
+// Действия из событий identity-service об изменении пользователей.
+const (
+	SubjectRoleChanged     = "role_changed"
+	SubjectDisabled        = "disabled"
+	SubjectEnabled         = "enabled"
+	SubjectSessionsRevoked = "sessions_revoked"
+)
+
+// SubjectEvent - изменение пользователя администратором, см. топик identity_changed.
+type SubjectEvent struct {
+	UUID      string    `json:"uuid"`
+	Action    string    `json:"action"`
+	Disabled  bool      `json:"disabled"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type disabledIdentitiesResponse struct {
+	UUIDs []string `json:"uuids"`
+}
+
+// SubjectCache хранит отключенных пользователей и время, до которого выданные
+// им токены недействительны. Identity-service и сам отзывает токены таких
+// пользователей, но события приходят сразу, а не со следующим опросом
+// RevocationCache. Список отключенных загружается из identity-service при
+// старте и периодически сверяется, поэтому пропущенные события не оставляют
+// отключенного пользователя активным.
+type SubjectCache struct {
+	mu        sync.RWMutex
+	disabled  map[string]struct{}
+	notBefore map[string]time.Time
+	// changed - события disabled/enabled, пришедшие во время загрузки списка;
+	// они новее загруженного списка и применяются поверх него.
+	changed map[string]bool
+	// ttl - сколько помнить notBefore; должно быть не меньше срока жизни access токена.
+	ttl      time.Duration
+	synced   bool
+	endpoint string
+	client   *http.Client
+}
+
+// NewSubjectCache принимает транспорт, через который доступен identity-service,
+// как и NewRevocationCache.
+func NewSubjectCache(identityServiceURL string, transport http.RoundTripper, ttl time.Duration) *SubjectCache {
+	return &SubjectCache{
+		disabled:  make(map[string]struct{}),
+		notBefore: make(map[string]time.Time),
+		ttl:       ttl,
+		endpoint:  identityServiceURL + "/v1/auth/identities/disabled",
+		client:    &http.Client{Transport: transport, Timeout: 5 * time.Second},
+	}
+}
+
+// Start загружает список отключенных пользователей и повторяет загрузку с
+// заданным интервалом до отмены контекста.
+func (c *SubjectCache) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.sync(ctx); err != nil {
+			log.Printf("Failed to sync disabled identities: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *SubjectCache) sync(ctx context.Context) error {
+	c.mu.Lock()
+	c.changed = make(map[string]bool)
+	c.mu.Unlock()
+
+	uuids, err := c.fetchDisabled(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.changed
+	c.changed = nil
+	if err != nil {
+		return err
+	}
+
+	disabled := make(map[string]struct{}, len(uuids))
+	for _, sub := range uuids {
+		disabled[sub] = struct{}{}
+	}
+	for sub, isDisabled := range changed {
+		if isDisabled {
+			disabled[sub] = struct{}{}
+		} else {
+			delete(disabled, sub)
+		}
+	}
+	c.disabled = disabled
+
+	if !c.synced {
+		log.Printf("Disabled identities synced: %d", len(c.disabled))
+		c.synced = true
+	}
+	return nil
+}
+
+func (c *SubjectCache) fetchDisabled(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var body disabledIdentitiesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.UUIDs, nil
+}
+
+// Apply учитывает событие identity-service.
+func (c *SubjectCache) Apply(event SubjectEvent) {
+	if event.UUID == "" {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch event.Action {
+	case SubjectDisabled:
+		c.disabled[event.UUID] = struct{}{}
+		if c.changed != nil {
+			c.changed[event.UUID] = true
+		}
+	case SubjectEnabled:
+		delete(c.disabled, event.UUID)
+		if c.changed != nil {
+			c.changed[event.UUID] = false
+		}
+	case SubjectRoleChanged, SubjectSessionsRevoked:
+		if event.Timestamp.After(c.notBefore[event.UUID]) {
+			c.notBefore[event.UUID] = event.Timestamp
+		}
+	}
+
+	cutoff := time.Now().Add(-c.ttl)
+	for sub, at := range c.notBefore {
+		if at.Before(cutoff) {
+			delete(c.notBefore, sub)
+		}
+	}
+}
+
+// Check отклоняет токены отключенных пользователей и токены, выданные до
+// смены роли или принудительного выхода.
+func (c *SubjectCache) Check(claims *Claims) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.disabled[claims.Sub]; ok {
+		return domainErrors.ErrAccountDisabled
+	}
+
+	// iat хранится с точностью до секунды, поэтому токены, выданные в ту же
+	// секунду, что и изменение, принимаются: старые из них все равно отозваны.
+	notBefore, ok := c.notBefore[claims.Sub]
+	if ok && (claims.IssuedAt == nil || claims.IssuedAt.Before(notBefore.Truncate(time.Second))) {
+		return domainErrors.ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	domainErrors "github.com/JojoWeyn/duo-proj/gateway/internal/domain/errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func subjectClaims(sub string, issuedAt time.Time) *auth.Claims {
+	return &auth.Claims{Sub: sub, RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issuedAt)}}
+}
+
+func TestSubjectCache_Apply(t *testing.T) {
+	changedAt := time.Date(2025, 1, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	tests := []struct {
+		name     string
+		events   []auth.SubjectEvent
+		issuedAt time.Time
+		wantErr  error
+	}{
+		{
+			name:     "no events",
+			issuedAt: changedAt,
+		},
+		{
+			name:     "disabled",
+			events:   []auth.SubjectEvent{{UUID: "user", Action: auth.SubjectDisabled}},
+			issuedAt: time.Now(),
+			wantErr:  domainErrors.ErrAccountDisabled,
+		},
+		{
+			name: "enabled again",
+			events: []auth.SubjectEvent{
+				{UUID: "user", Action: auth.SubjectDisabled},
+				{UUID: "user", Action: auth.SubjectEnabled},
+			},
+			issuedAt: time.Now(),
+		},
+		{
+			name:     "token issued before role change",
+			events:   []auth.SubjectEvent{{UUID: "user", Action: auth.SubjectRoleChanged, Timestamp: changedAt}},
+			issuedAt: changedAt.Add(-time.Second),
+			wantErr:  domainErrors.ErrTokenRevoked,
+		},
+		{
+			name:     "token issued in the same second as sessions revoked",
+			events:   []auth.SubjectEvent{{UUID: "user", Action: auth.SubjectSessionsRevoked, Timestamp: changedAt}},
+			issuedAt: changedAt.Truncate(time.Second),
+		},
+		{
+			name: "older event does not move not before back",
+			events: []auth.SubjectEvent{
+				{UUID: "user", Action: auth.SubjectRoleChanged, Timestamp: changedAt},
+				{UUID: "user", Action: auth.SubjectSessionsRevoked, Timestamp: changedAt.Add(-time.Hour)},
+			},
+			issuedAt: changedAt.Add(-time.Minute),
+			wantErr:  domainErrors.ErrTokenRevoked,
+		},
+		{
+			name:     "event without uuid is ignored",
+			events:   []auth.SubjectEvent{{Action: auth.SubjectDisabled}},
+			issuedAt: time.Now(),
+		},
+		{
+			name:     "other user",
+			events:   []auth.SubjectEvent{{UUID: "other", Action: auth.SubjectDisabled}},
+			issuedAt: time.Now(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := auth.NewSubjectCache("", nil, 24*time.Hour*365*10)
+			for _, event := range tt.events {
+				cache.Apply(event)
+			}
+
+			err := cache.Check(subjectClaims("user", tt.issuedAt))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSubjectCache_RejectsTokenWithoutIssuedAt(t *testing.T) {
+	cache := auth.NewSubjectCache("", nil, time.Hour)
+	cache.Apply(auth.SubjectEvent{UUID: "user", Action: auth.SubjectSessionsRevoked})
+
+	err := cache.Check(&auth.Claims{Sub: "user"})
+	require.ErrorIs(t, err, domainErrors.ErrTokenRevoked)
+}
+
+func TestSubjectCache_ForgetsNotBeforeAfterTTL(t *testing.T) {
+	cache := auth.NewSubjectCache("", nil, time.Hour)
+	cache.Apply(auth.SubjectEvent{UUID: "user", Action: auth.SubjectRoleChanged, Timestamp: time.Now().Add(-2 * time.Hour)})
+
+	require.NoError(t, cache.Check(subjectClaims("user", time.Now().Add(-3*time.Hour))))
+}
+
+func TestSubjectCache_LoadsDisabledIdentities(t *testing.T) {
+	var mu sync.Mutex
+	disabled := []string{"disabled"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/auth/identities/disabled", r.URL.Path)
+
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"uuids": disabled})
+	}))
+	defer server.Close()
+
+	cache := auth.NewSubjectCache(server.URL, http.DefaultTransport, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Start(ctx, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return cache.Check(subjectClaims("disabled", time.Now())) != nil
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, cache.Check(subjectClaims("active", time.Now())))
+
+	// Включенный пользователь пропадает из списка при следующей загрузке.
+	mu.Lock()
+	disabled = []string{}
+	mu.Unlock()
+
+	require.Eventually(t, func() bool {
+		return cache.Check(subjectClaims("disabled", time.Now())) == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSubjectCache_EventDuringLoadWins(t *testing.T) {
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+		// Список прочитан до события и уже устарел.
+		json.NewEncoder(w).Encode(map[string]any{"uuids": []string{"enabled"}})
+	}))
+	defer server.Close()
+
+	cache := auth.NewSubjectCache(server.URL, http.DefaultTransport, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Start(ctx, time.Hour)
+
+	<-requested
+	cache.Apply(auth.SubjectEvent{UUID: "enabled", Action: auth.SubjectEnabled})
+	cache.Apply(auth.SubjectEvent{UUID: "disabled", Action: auth.SubjectDisabled})
+	close(release)
+
+	require.Eventually(t, func() bool {
+		return cache.Check(subjectClaims("disabled", time.Now())) != nil
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, cache.Check(subjectClaims("enabled", time.Now())))
+}
+
+func TestSubjectCache_KeepsStateOnError(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+
+		if call > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"uuids": []string{"disabled"}})
+	}))
+	defer server.Close()
+
+	cache := auth.NewSubjectCache(server.URL, http.DefaultTransport, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Start(ctx, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls >= 3
+	}, time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, cache.Check(subjectClaims("disabled", time.Now())), domainErrors.ErrAccountDisabled)
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"strings"
+
+	domainErrors "github.com/JojoWeyn/duo-proj/gateway/internal/domain/errors"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ClaimsKey - ключ, под которым проверенные claims хранятся в gin.Context.
+const ClaimsKey = "claims"
+
+type Claims struct {
+	Sub  string `json:"sub"`
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Verifier проверяет access-токены локально: подпись RS256 ключом из JWKS
+// identity-service по kid, срок действия, наличие jti в кэше отозванных токенов
+// и состояние пользователя в SubjectCache.
+type Verifier struct {
+	keys *JWKSCache
+	// publicKey - ключ из public.pem для токенов без kid, выданных до
+	// ротации ключей; может быть nil.
+	publicKey *rsa.PublicKey
+	revoked   *RevocationCache
+	subjects  *SubjectCache
+}
+
+func NewVerifier(keys *JWKSCache, publicKey *rsa.PublicKey, revoked *RevocationCache, subjects *SubjectCache) *Verifier {
+	return &Verifier{
+		keys:      keys,
+		publicKey: publicKey,
+		revoked:   revoked,
+		subjects:  subjects,
+	}
+}
+
+func (v *Verifier) Verify(tokenString string) (*Claims, error) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return nil, domainErrors.ErrInvalidToken
+	}
+	kid, _ := unverified.Header["kid"].(string)
+
+	claims, err := v.parse(tokenString, v.verificationKeys(kid))
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ExpiresAt == nil {
+		return nil, domainErrors.ErrInvalidToken
+	}
+
+	if claims.Sub == "" {
+		return nil, domainErrors.ErrUUIDNotFoundInToken
+	}
+
+	if v.revoked != nil && v.revoked.IsRevoked(claims.ID) {
+		return nil, domainErrors.ErrTokenRevoked
+	}
+
+	if v.subjects != nil {
+		if err := v.subjects.Check(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// verificationKeys возвращает ключ из JWKS по kid, а для токенов без kid -
+// все известные ключи.
+func (v *Verifier) verificationKeys(kid string) []*rsa.PublicKey {
+	if kid != "" {
+		if key, ok := v.keys.Key(kid); ok {
+			return []*rsa.PublicKey{key}
+		}
+		return nil
+	}
+
+	keys := v.keys.Keys()
+	if v.publicKey != nil {
+		keys = append(keys, v.publicKey)
+	}
+	return keys
+}
+
+// parse проверяет подпись токена ключами по очереди, до первого подошедшего.
+func (v *Verifier) parse(tokenString string, keys []*rsa.PublicKey) (*Claims, error) {
+	for _, key := range keys {
+		claims := &Claims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			if token.Method != jwt.SigningMethodRS256 {
+				return nil, domainErrors.ErrUnexpectedSigningMethod
+			}
+			return key, nil
+		})
+		if err != nil {
+			var validationErr *jwt.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+				continue
+			}
+			if validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+				return nil, domainErrors.ErrTokenExpired
+			}
+			return nil, domainErrors.ErrInvalidToken
+		}
+
+		if !token.Valid {
+			return nil, domainErrors.ErrInvalidToken
+		}
+		return claims, nil
+	}
+
+	return nil, domainErrors.ErrInvalidToken
+}
+
+// VerifyHeader проверяет токен из заголовка Authorization вида "Bearer <token>".
+func (v *Verifier) VerifyHeader(header string) (*Claims, error) {
+	if header == "" {
+		return nil, domainErrors.ErrNoTokenProvided
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return nil, domainErrors.ErrInvalidToken
+	}
+
+	return v.Verify(token)
+}
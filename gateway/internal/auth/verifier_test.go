@@ -0,0 +1,174 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	domainErrors "github.com/JojoWeyn/duo-proj/gateway/internal/domain/errors"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+// jwksServer отдает открытые ключи так же, как /.well-known/jwks.json identity-service.
+func jwksServer(t *testing.T, keys map[string]*rsa.PrivateKey) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/.well-known/jwks.json", r.URL.Path)
+
+		var body struct {
+			Keys []map[string]string `json:"keys"`
+		}
+		for kid, key := range keys {
+			body.Keys = append(body.Keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type tokenOptions struct {
+	kid      string
+	sub      string
+	noSub    bool
+	jti      string
+	issuedAt time.Time
+	ttl      time.Duration
+	method   jwt.SigningMethod
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, opts tokenOptions) string {
+	if opts.sub == "" && !opts.noSub {
+		opts.sub = "user-1"
+	}
+	if opts.issuedAt.IsZero() {
+		opts.issuedAt = time.Now()
+	}
+	if opts.ttl == 0 {
+		opts.ttl = time.Minute
+	}
+	if opts.method == nil {
+		opts.method = jwt.SigningMethodRS256
+	}
+
+	token := jwt.NewWithClaims(opts.method, auth.Claims{
+		Sub:  opts.sub,
+		Role: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        opts.jti,
+			IssuedAt:  jwt.NewNumericDate(opts.issuedAt),
+			ExpiresAt: jwt.NewNumericDate(opts.issuedAt.Add(opts.ttl)),
+		},
+	})
+	if opts.kid != "" {
+		token.Header["kid"] = opts.kid
+	}
+
+	var signingKey any = key
+	if opts.method == jwt.SigningMethodHS256 {
+		signingKey = []byte("secret")
+	}
+	signed, err := token.SignedString(signingKey)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	current, legacy, other := newKey(t), newKey(t), newKey(t)
+	server := jwksServer(t, map[string]*rsa.PrivateKey{"current": current})
+	verifier := auth.NewVerifier(auth.NewJWKSCache(server.URL, http.DefaultTransport), &legacy.PublicKey, nil, nil)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "key from jwks", token: signToken(t, current, tokenOptions{kid: "current"})},
+		{name: "legacy token without kid", token: signToken(t, legacy, tokenOptions{})},
+		{name: "token without kid signed by jwks key", token: signToken(t, current, tokenOptions{})},
+		{name: "unknown kid", token: signToken(t, other, tokenOptions{kid: "other"}), wantErr: domainErrors.ErrInvalidToken},
+		{name: "kid of another key", token: signToken(t, other, tokenOptions{kid: "current"}), wantErr: domainErrors.ErrInvalidToken},
+		{name: "expired", token: signToken(t, current, tokenOptions{kid: "current", issuedAt: time.Now().Add(-time.Hour)}), wantErr: domainErrors.ErrTokenExpired},
+		{name: "hmac", token: signToken(t, current, tokenOptions{kid: "current", method: jwt.SigningMethodHS256}), wantErr: domainErrors.ErrInvalidToken},
+		{name: "no subject", token: signToken(t, current, tokenOptions{kid: "current", noSub: true}), wantErr: domainErrors.ErrUUIDNotFoundInToken},
+		{name: "malformed", token: "not.a.token", wantErr: domainErrors.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, claims)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "user-1", claims.Sub)
+		})
+	}
+}
+
+func TestVerifyHeader(t *testing.T) {
+	key := newKey(t)
+	verifier := auth.NewVerifier(auth.NewJWKSCache(jwksServer(t, nil).URL, http.DefaultTransport), &key.PublicKey, nil, nil)
+
+	_, err := verifier.VerifyHeader("")
+	require.ErrorIs(t, err, domainErrors.ErrNoTokenProvided)
+
+	_, err = verifier.VerifyHeader("Basic dXNlcjpwYXNz")
+	require.ErrorIs(t, err, domainErrors.ErrInvalidToken)
+
+	claims, err := verifier.VerifyHeader("Bearer " + signToken(t, key, tokenOptions{}))
+	require.NoError(t, err)
+	require.Equal(t, "user-1", claims.Sub)
+}
+
+func TestVerify_Revoked(t *testing.T) {
+	key := newKey(t)
+	identity := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/revoked":
+			json.NewEncoder(w).Encode(map[string]any{
+				"tokens": []map[string]any{{"jti": "revoked-jti", "expires_at": time.Now().Add(time.Hour)}},
+				"cursor": time.Now(),
+			})
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"keys": []any{}})
+		}
+	}))
+	defer identity.Close()
+
+	revoked := auth.NewRevocationCache(identity.URL, http.DefaultTransport)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go revoked.Start(ctx, time.Hour)
+	require.Eventually(t, func() bool { return revoked.IsRevoked("revoked-jti") }, time.Second, 10*time.Millisecond)
+
+	verifier := auth.NewVerifier(auth.NewJWKSCache(identity.URL, http.DefaultTransport), &key.PublicKey, revoked, nil)
+
+	_, err := verifier.Verify(signToken(t, key, tokenOptions{jti: "revoked-jti"}))
+	require.ErrorIs(t, err, domainErrors.ErrTokenRevoked)
+
+	_, err = verifier.Verify(signToken(t, key, tokenOptions{jti: "active-jti"}))
+	require.NoError(t, err)
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Key определяет запись кэша: сервис и политику маршрута, путь запроса и
+// вариант ответа (query и роль пользователя).
+type Key struct {
+	Service string
+	Cache   string
+	Path    string
+	Variant string
+}
+
+// Entry - сохраненный ответ сервиса.
+type Entry struct {
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	ETag     string      `json:"etag"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// Filter выбирает записи для сброса. Пустое поле не ограничивает выборку,
+// непустые условия должны выполняться одновременно.
+type Filter struct {
+	Services []string `json:"services,omitempty"`
+	Caches   []string `json:"caches,omitempty"`
+	Paths    []string `json:"paths,omitempty"`
+}
+
+func (f Filter) Empty() bool {
+	return len(f.Services) == 0 && len(f.Caches) == 0 && len(f.Paths) == 0
+}
+
+func (f Filter) match(key Key) bool {
+	return matchAny(f.Services, key.Service) && matchAny(f.Caches, key.Cache) && matchAny(f.Paths, key.Path)
+}
+
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Store хранит ответы до истечения TTL. Get возвращает nil без ошибки, если записи нет.
+type Store interface {
+	Get(ctx context.Context, key Key) (*Entry, error)
+	Set(ctx context.Context, key Key, entry *Entry, ttl time.Duration) error
+	Purge(ctx context.Context, filter Filter) (int, error)
+}
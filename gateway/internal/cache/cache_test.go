@@ -0,0 +1,113 @@
+package cache_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(body string) *cache.Entry {
+	return &cache.Entry{
+		Status:   http.StatusOK,
+		Header:   http.Header{"Content-Type": {"application/json"}},
+		Body:     []byte(body),
+		ETag:     `"etag"`,
+		StoredAt: time.Now(),
+	}
+}
+
+func stores(t *testing.T) map[string]cache.Store {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]cache.Store{
+		"memory": cache.NewMemoryStore(100),
+		"redis":  cache.NewRedisStore(client),
+	}
+}
+
+func TestStores(t *testing.T) {
+	catalog := cache.Key{Service: "course", Cache: "catalog", Path: "/v1/course/list", Variant: "|user"}
+	lessons := cache.Key{Service: "course", Cache: "catalog", Path: "/v1/lessons/1", Variant: "|user"}
+	profile := cache.Key{Service: "user", Cache: "profile", Path: "/v1/users/me", Variant: "|user:1"}
+
+	tests := []struct {
+		name       string
+		filter     cache.Filter
+		wantPurged int
+		wantLeft   []cache.Key
+	}{
+		{name: "by service", filter: cache.Filter{Services: []string{"course"}}, wantPurged: 2, wantLeft: []cache.Key{profile}},
+		{name: "by path", filter: cache.Filter{Paths: []string{"/v1/lessons/1"}}, wantPurged: 1, wantLeft: []cache.Key{catalog, profile}},
+		{name: "all conditions", filter: cache.Filter{Services: []string{"user"}, Caches: []string{"catalog"}}, wantPurged: 0, wantLeft: []cache.Key{catalog, lessons, profile}},
+	}
+
+	for backend, store := range stores(t) {
+		for _, tt := range tests {
+			t.Run(backend+" "+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				_, err := store.Purge(ctx, cache.Filter{Services: []string{"course", "user"}})
+				require.NoError(t, err)
+
+				for _, key := range []cache.Key{catalog, lessons, profile} {
+					require.NoError(t, store.Set(ctx, key, entry(key.Path), time.Minute))
+				}
+
+				purged, err := store.Purge(ctx, tt.filter)
+				require.NoError(t, err)
+				require.Equal(t, tt.wantPurged, purged)
+
+				for _, key := range tt.wantLeft {
+					got, err := store.Get(ctx, key)
+					require.NoError(t, err)
+					require.NotNil(t, got, key.Path)
+					require.Equal(t, key.Path, string(got.Body))
+					require.Equal(t, `"etag"`, got.ETag)
+				}
+			})
+		}
+	}
+}
+
+func TestStores_Expire(t *testing.T) {
+	key := cache.Key{Service: "course", Cache: "catalog", Path: "/v1/course/list"}
+	store := cache.NewMemoryStore(100)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, key, entry("list"), 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	got, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+func TestSubscribe(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	store := cache.NewMemoryStore(100)
+	key := cache.Key{Service: "course", Cache: "catalog", Path: "/v1/course/list"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, store.Set(ctx, key, entry("list"), time.Minute))
+
+	go cache.Subscribe(ctx, client, cache.PurgeChannel, store)
+	publisher := cache.NewRedisPublisher(client, cache.PurgeChannel)
+
+	// Подписка устанавливается асинхронно, поэтому событие повторяется до сброса.
+	require.Eventually(t, func() bool {
+		require.NoError(t, publisher.Publish(ctx, cache.Filter{Services: []string{"course"}}))
+		got, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		return got == nil
+	}, time.Second, 20*time.Millisecond)
+}
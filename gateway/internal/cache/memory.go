@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	key     Key
+	entry   *Entry
+	expires time.Time
+}
+
+// MemoryStore хранит ответы в памяти процесса. При заполнении новые ответы
+// не сохраняются, пока не истекут старые.
+type MemoryStore struct {
+	mu         sync.Mutex
+	entries    map[Key]memoryEntry
+	maxEntries int
+	lastSweep  time.Time
+}
+
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		entries:    make(map[Key]memoryEntry),
+		maxEntries: maxEntries,
+		lastSweep:  time.Now(),
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key Key) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(e.expires) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+	return e.entry, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key Key, entry *Entry, ttl time.Duration) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, false)
+	if _, ok := s.entries[key]; !ok && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.sweep(now, true)
+		if len(s.entries) >= s.maxEntries {
+			return nil
+		}
+	}
+
+	s.entries[key] = memoryEntry{key: key, entry: entry, expires: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Purge(_ context.Context, filter Filter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for key := range s.entries {
+		if filter.match(key) {
+			delete(s.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// sweep удаляет истекшие записи не чаще раза в sweepInterval, если не force.
+// Вызывается под мьютексом.
+func (s *MemoryStore) sweep(now time.Time, force bool) {
+	if !force && now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// PurgeChannel - канал Redis, в который сервисы и реплики gateway публикуют
+// события сброса кэша в виде JSON Filter.
+const PurgeChannel = "gateway:cache:purge"
+
+// Publisher рассылает события сброса кэша всем репликам gateway.
+type Publisher interface {
+	Publish(ctx context.Context, filter Filter) error
+}
+
+type RedisPublisher struct {
+	client  *redis.Client
+	channel string
+}
+
+func NewRedisPublisher(client *redis.Client, channel string) *RedisPublisher {
+	return &RedisPublisher{client: client, channel: channel}
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, filter Filter) error {
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(ctx, p.channel, data).Err()
+}
+
+// Subscribe сбрасывает записи store по событиям из канала до отмены контекста.
+// Пустой фильтр игнорируется, чтобы случайное событие не очистило весь кэш.
+func Subscribe(ctx context.Context, client *redis.Client, channel string, store Store) {
+	sub := client.Subscribe(ctx, channel)
+	defer sub.Close()
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var filter Filter
+			if err := json.Unmarshal([]byte(msg.Payload), &filter); err != nil || filter.Empty() {
+				log.Printf("Ignoring invalid cache purge event: %q", msg.Payload)
+				continue
+			}
+
+			purged, err := store.Purge(ctx, filter)
+			if err != nil {
+				log.Printf("Failed to purge response cache: %v", err)
+				continue
+			}
+			log.Printf("Purged %d cached responses by event %s", purged, msg.Payload)
+		}
+	}
+}
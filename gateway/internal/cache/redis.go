@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore хранит ответы в Redis, поэтому реплики gateway делят общий кэш.
+// Ключ записи - gateway:cache:<service>:<cache>:<hash пути>:<hash варианта>:
+// сервис и политика берутся из таблицы маршрутов, а путь хэшируется, чтобы
+// сброс по фильтру сводился к SCAN по шаблону без экранирования.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: "gateway:cache:",
+	}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key Key) (*Entry, error) {
+	data, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key Key, entry *Entry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.key(key), data, ttl).Err()
+}
+
+func (s *RedisStore) Purge(ctx context.Context, filter Filter) (int, error) {
+	purged := 0
+	for _, pattern := range s.patterns(filter) {
+		iter := s.client.Scan(ctx, 0, pattern, 500).Iterator()
+		var keys []string
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return purged, err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		n, err := s.client.Del(ctx, keys...).Result()
+		if err != nil {
+			return purged, err
+		}
+		purged += int(n)
+	}
+	return purged, nil
+}
+
+func (s *RedisStore) key(key Key) string {
+	return s.prefix + key.Service + ":" + key.Cache + ":" + hash(key.Path) + ":" + hash(key.Variant)
+}
+
+// patterns раскладывает фильтр в шаблоны SCAN: по одному на каждое сочетание условий.
+func (s *RedisStore) patterns(filter Filter) []string {
+	paths := make([]string, 0, len(filter.Paths))
+	for _, path := range filter.Paths {
+		paths = append(paths, hash(path))
+	}
+
+	var patterns []string
+	for _, service := range orAny(filter.Services) {
+		for _, cache := range orAny(filter.Caches) {
+			for _, path := range orAny(paths) {
+				patterns = append(patterns, s.prefix+service+":"+cache+":"+path+":*")
+			}
+		}
+	}
+	return patterns
+}
+
+func orAny(values []string) []string {
+	if len(values) == 0 {
+		return []string{"*"}
+	}
+	return values
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,179 @@
+package v1
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/apikey"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// defaultUsageDays - интервал отчета об использовании ключа по умолчанию,
+	// наибольший ограничен сроком хранения расхода apikey.UsageDays.
+	defaultUsageDays = 30
+)
+
+type APIKeysHandler struct {
+	keys   apikey.Store
+	scopes []string
+}
+
+// NewAPIKeysHandler создает обработчик выпуска ключей. scopes - наборы
+// маршрутов из таблицы, на которые можно выпустить ключ.
+func NewAPIKeysHandler(keys apikey.Store, scopes []string) *APIKeysHandler {
+	return &APIKeysHandler{keys: keys, scopes: scopes}
+}
+
+type createAPIKeyRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	DailyQuota   int64    `json:"daily_quota" binding:"min=0"`
+	MonthlyQuota int64    `json:"monthly_quota" binding:"min=0"`
+}
+
+// Create выпускает ключ. Секрет возвращается только в этом ответе.
+func (h *APIKeysHandler) Create(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and at least one scope are required, quotas must not be negative"})
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(h.scopes, scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope " + scope, "scopes": h.scopes})
+			return
+		}
+	}
+
+	var createdBy string
+	if value, ok := c.Get(auth.ClaimsKey); ok {
+		if claims, ok := value.(*auth.Claims); ok {
+			createdBy = claims.Sub
+		}
+	}
+
+	key, secret, err := apikey.New(strings.TrimSpace(req.Name), req.Scopes, req.DailyQuota, req.MonthlyQuota, createdBy)
+	if err != nil {
+		log.Printf("Failed to generate api key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+	if err := h.keys.Create(c.Request.Context(), key); err != nil {
+		log.Printf("Failed to save api key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create api key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"api_key": publicKey(*key), "secret": secret})
+}
+
+// List отдает все ключи, включая отозванные, без хэшей секретов.
+func (h *APIKeysHandler) List(c *gin.Context) {
+	keys, err := h.keys.List(c.Request.Context())
+	if err != nil {
+		log.Printf("Failed to list api keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list api keys"})
+		return
+	}
+
+	result := make([]apikey.Key, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, publicKey(key))
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": result, "scopes": h.scopes})
+}
+
+// Revoke отзывает ключ. Запись о ключе и его расход сохраняются для отчетов.
+func (h *APIKeysHandler) Revoke(c *gin.Context) {
+	key, err := h.keys.Revoke(c.Request.Context(), c.Param("id"), time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to revoke api key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke api key"})
+		return
+	}
+	if key == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_key": publicKey(*key)})
+}
+
+// Usage отдает расход ключа по дням (UTC) за интервал from..to в формате
+// YYYY-MM-DD, по умолчанию за последние 30 дней, и расход за месяц to.
+func (h *APIKeysHandler) Usage(c *gin.Context) {
+	key, err := h.keys.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("Failed to get api key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get api key usage"})
+		return
+	}
+	if key == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, err := parseDate(c.Query("to"), today)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	from, err := parseDate(c.Query("from"), to.AddDate(0, 0, -(defaultUsageDays-1)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	if from.After(to) || to.Sub(from) >= apikey.UsageDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to and the range must not exceed 366 days"})
+		return
+	}
+	// Расход за более ранние сутки уже удален, отчет показал бы вместо него нули.
+	if from.Before(today.AddDate(0, 0, -(apikey.UsageDays - 1))) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "usage is kept for the last 366 days only"})
+		return
+	}
+
+	days, month, err := h.keys.DailyUsage(c.Request.Context(), key.ID, from, to)
+	if err != nil {
+		log.Printf("Failed to get api key usage: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get api key usage"})
+		return
+	}
+
+	var total int64
+	for _, day := range days {
+		total += day.Requests
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_key": publicKey(*key),
+		"from":    from.Format(dateLayout),
+		"to":      to.Format(dateLayout),
+		"total":   total,
+		"days":    days,
+		"month": gin.H{
+			"month":    to.Format("2006-01"),
+			"requests": month,
+			"quota":    key.MonthlyQuota,
+		},
+	})
+}
+
+func parseDate(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse(dateLayout, value)
+}
+
+func publicKey(key apikey.Key) apikey.Key {
+	key.SecretHash = ""
+	return key
+}
@@ -0,0 +1,48 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/apikey"
+	v1 "github.com/JojoWeyn/duo-proj/gateway/internal/controller/http/v1"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeysHandler_UsageRange(t *testing.T) {
+	store := apikey.NewMemoryStore()
+	key, _, err := apikey.New("reports", []string{"reports"}, 0, 0, "admin-1")
+	require.NoError(t, err)
+	require.NoError(t, store.Create(context.Background(), key))
+
+	engine := gin.New()
+	engine.GET("/v1/admin/gateway/api-keys/:id/usage", v1.NewAPIKeysHandler(store, []string{"reports"}).Usage)
+
+	today := time.Now().UTC()
+	date := func(days int) string { return today.AddDate(0, 0, days).Format("2006-01-02") }
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{name: "default", query: "", wantCode: http.StatusOK},
+		{name: "whole retention", query: "?from=" + date(-(apikey.UsageDays - 1)), wantCode: http.StatusOK},
+		{name: "before retention", query: "?from=" + date(-apikey.UsageDays) + "&to=" + date(-apikey.UsageDays+10), wantCode: http.StatusBadRequest},
+		{name: "too long", query: "?from=" + date(-10) + "&to=" + date(apikey.UsageDays), wantCode: http.StatusBadRequest},
+		{name: "from after to", query: "?from=" + date(0) + "&to=" + date(-1), wantCode: http.StatusBadRequest},
+		{name: "invalid date", query: "?from=yesterday", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/admin/gateway/api-keys/"+key.ID+"/usage"+tt.query, nil))
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/audit"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditHandler struct {
+	store audit.Store
+}
+
+func NewAuditHandler(store audit.Store) *AuditHandler {
+	return &AuditHandler{store: store}
+}
+
+// Query отдает записи журнала аудита от новых к старым. Фильтры: actor - UUID
+// администратора, entity - тип сущности (users, course...), target - ее
+// идентификатор, from и to - интервал в RFC 3339, limit - до 1000 записей.
+func (h *AuditHandler) Query(c *gin.Context) {
+	filter := audit.Filter{
+		Actor:  c.Query("actor"),
+		Entity: c.Query("entity"),
+		Target: c.Query("target"),
+		Limit:  defaultAuditLimit,
+	}
+
+	var err error
+	if value := c.Query("from"); value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxAuditLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	records, err := h.store.Query(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Failed to query audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query audit log"})
+		return
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"records": records})
+}
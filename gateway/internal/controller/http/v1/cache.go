@@ -0,0 +1,45 @@
+package v1
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/cache"
+	"github.com/gin-gonic/gin"
+)
+
+type CacheHandler struct {
+	store     cache.Store
+	publisher cache.Publisher
+}
+
+// NewCacheHandler создает обработчик сброса кэша. publisher может быть nil,
+// тогда сбрасывается только кэш этой реплики.
+func NewCacheHandler(store cache.Store, publisher cache.Publisher) *CacheHandler {
+	return &CacheHandler{store: store, publisher: publisher}
+}
+
+// Purge сбрасывает записи кэша ответов по сервисам, политикам и путям
+// и рассылает событие остальным репликам gateway.
+func (h *CacheHandler) Purge(c *gin.Context) {
+	var filter cache.Filter
+	if err := c.ShouldBindJSON(&filter); err != nil || filter.Empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one of services, caches or paths is required"})
+		return
+	}
+
+	purged, err := h.store.Purge(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Failed to purge response cache: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to purge cache"})
+		return
+	}
+
+	if h.publisher != nil {
+		if err := h.publisher.Publish(c.Request.Context(), filter); err != nil {
+			log.Printf("Failed to publish cache purge event: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
@@ -0,0 +1,298 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DashboardService - имя, под которым агрегированный ответ хранится в кэше ответов.
+const DashboardService = "dashboard"
+
+// maxDashboardPartBody ограничивает ответ одного сервиса при сборке dashboard.
+const maxDashboardPartBody = 4 << 20
+
+type DashboardHandler struct {
+	upstreams *upstream.Registry
+	signer    *assertion.Signer
+}
+
+func NewDashboardHandler(upstreams *upstream.Registry, signer *assertion.Signer) *DashboardHandler {
+	return &DashboardHandler{upstreams: upstreams, signer: signer}
+}
+
+// dashboard - данные главного экрана. Части, которые не удалось получить,
+// равны null, а причина записывается в Errors под именем части.
+type dashboard struct {
+	User         json.RawMessage    `json:"user"`
+	Streak       json.RawMessage    `json:"streak"`
+	Progress     *dashboardProgress `json:"progress"`
+	Achievements json.RawMessage    `json:"achievements"`
+	Courses      json.RawMessage    `json:"courses"`
+	Errors       map[string]string  `json:"errors,omitempty"`
+}
+
+// dashboardProgress - прогресс пользователя, в котором к курсам, урокам
+// и упражнениям добавлены их названия из course-service.
+type dashboardProgress struct {
+	Exercises []dashboardExerciseProgress `json:"exercises"`
+	Lessons   []dashboardLessonProgress   `json:"lessons"`
+	Courses   []dashboardCourseProgress   `json:"courses"`
+}
+
+type dashboardExerciseProgress struct {
+	UUID         string    `json:"uuid"`
+	ExerciseUUID string    `json:"exercise_uuid"`
+	Title        string    `json:"title,omitempty"`
+	TotalPoints  int       `json:"total_points"`
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
+type dashboardLessonProgress struct {
+	UUID        string    `json:"uuid"`
+	LessonUUID  string    `json:"lesson_uuid"`
+	Title       string    `json:"title,omitempty"`
+	TotalPoints int       `json:"total_points"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type dashboardCourseProgress struct {
+	UUID        string    `json:"uuid"`
+	CourseUUID  string    `json:"course_uuid"`
+	Title       string    `json:"title,omitempty"`
+	TotalPoints int       `json:"total_points"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// dashboardTitles - названия из course-service по UUID для каждого типа сущностей.
+type dashboardTitles struct {
+	Courses   map[string]string `json:"courses"`
+	Lessons   map[string]string `json:"lessons"`
+	Exercises map[string]string `json:"exercises"`
+}
+
+type dashboardPart struct {
+	name    string
+	service string
+	path    string
+	// body отправляется методом POST в JSON, без него запрос идет методом GET.
+	body any
+	into any
+}
+
+// Get собирает dashboard из user-service и course-service: запросы идут
+// параллельно, каждый ограничен timeout, а названия для прогресса
+// запрашиваются после него одним запросом. Если часть сервисов не ответила,
+// клиент получает 200 с остальными частями и списком ошибок, такой ответ
+// помечается no-store и не попадает в кэш. Если не ответил никто - 502.
+func (h *DashboardHandler) Get(prefix string, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(auth.ClaimsKey)
+		claims, ok := value.(*auth.Claims)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var (
+			result       dashboard
+			progress     dashboardProgress
+			achievements struct {
+				Achievements json.RawMessage `json:"achievements"`
+			}
+		)
+		parts := []dashboardPart{
+			{name: "user", service: "user", path: "/users/me", into: &result.User},
+			{name: "streak", service: "user", path: "/users/me/streak", into: &result.Streak},
+			{name: "progress", service: "user", path: "/users/me/progress", into: &progress},
+			{name: "achievements", service: "user", path: "/users/achievements/" + claims.Sub, into: &achievements},
+			{name: "courses", service: "course", path: "/course/list", into: &result.Courses},
+		}
+
+		errs := make([]error, len(parts))
+		var wg sync.WaitGroup
+		for i, part := range parts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = h.fetch(c.Request.Context(), claims, part, prefix, timeout)
+			}()
+		}
+		wg.Wait()
+
+		failed := make(map[string]bool, len(parts))
+		for i, err := range errs {
+			if err == nil {
+				continue
+			}
+			log.Printf("Dashboard: failed to load %s for %s: %v", parts[i].name, claims.Sub, err)
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[parts[i].name] = dashboardError(err)
+			failed[parts[i].name] = true
+		}
+
+		if len(failed) == len(parts) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to load dashboard", "errors": result.Errors})
+			return
+		}
+
+		if failed["user"] {
+			result.User = nil
+		}
+		if failed["streak"] {
+			result.Streak = nil
+		}
+		if failed["courses"] {
+			result.Courses = nil
+		}
+		if !failed["achievements"] {
+			result.Achievements = achievements.Achievements
+		}
+		if !failed["progress"] {
+			if err := h.addTitles(c.Request.Context(), claims, &progress, prefix, timeout); err != nil {
+				log.Printf("Dashboard: failed to load titles for %s: %v", claims.Sub, err)
+				if result.Errors == nil {
+					result.Errors = make(map[string]string)
+				}
+				result.Errors["titles"] = dashboardError(err)
+				failed["titles"] = true
+			}
+			result.Progress = &progress
+		}
+
+		if len(failed) > 0 {
+			c.Header("Cache-Control", "no-store")
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// fetch запрашивает часть dashboard у сервиса от имени пользователя и разбирает
+// ответ 200 в part.into. Заголовки трассировки добавляет транспорт upstream.
+func (h *DashboardHandler) fetch(ctx context.Context, claims *auth.Claims, part dashboardPart, prefix string, timeout time.Duration) (err error) {
+	ctx, span := tracing.Start(ctx, "dashboard "+part.name, attribute.String("dashboard.part", part.name))
+	defer func() { tracing.End(span, err) }()
+
+	target, ok := h.upstreams.Get(part.service)
+	if !ok {
+		return fmt.Errorf("unknown service %q", part.service)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method, body := http.MethodGet, io.Reader(nil)
+	if part.body != nil {
+		data, err := json.Marshal(part.body)
+		if err != nil {
+			return err
+		}
+		method, body = http.MethodPost, bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.URL.String()+prefix+part.path, body)
+	if err != nil {
+		return err
+	}
+	if part.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	token, err := h.signer.Sign(claims.Sub, claims.Role, part.service, req.Method, req.URL.Path)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(assertion.Header, token)
+	req.Header.Set("Accept", "application/json")
+	if requestID := tracing.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set(tracing.RequestIDHeader, requestID)
+	}
+
+	resp, err := target.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxDashboardPartBody))
+		return &dashboardStatusError{status: resp.StatusCode}
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxDashboardPartBody)).Decode(part.into)
+}
+
+type dashboardStatusError struct {
+	status int
+}
+
+func (e *dashboardStatusError) Error() string {
+	return fmt.Sprintf("service responded with status %d", e.status)
+}
+
+// dashboardError - причина ошибки для клиента без подробностей о сервисах.
+func dashboardError(err error) string {
+	var statusErr *dashboardStatusError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("status %d", statusErr.status)
+	default:
+		return "unavailable"
+	}
+}
+
+// addTitles дописывает к прогрессу названия курсов, уроков и упражнений.
+func (h *DashboardHandler) addTitles(ctx context.Context, claims *auth.Claims, progress *dashboardProgress, prefix string, timeout time.Duration) error {
+	request := struct {
+		Courses   []string `json:"courses"`
+		Lessons   []string `json:"lessons"`
+		Exercises []string `json:"exercises"`
+	}{}
+	for _, p := range progress.Courses {
+		request.Courses = append(request.Courses, p.CourseUUID)
+	}
+	for _, p := range progress.Lessons {
+		request.Lessons = append(request.Lessons, p.LessonUUID)
+	}
+	for _, p := range progress.Exercises {
+		request.Exercises = append(request.Exercises, p.ExerciseUUID)
+	}
+	if len(request.Courses)+len(request.Lessons)+len(request.Exercises) == 0 {
+		return nil
+	}
+
+	var titles dashboardTitles
+	part := dashboardPart{name: "titles", service: "course", path: "/course/titles", body: request, into: &titles}
+	if err := h.fetch(ctx, claims, part, prefix, timeout); err != nil {
+		return err
+	}
+
+	for i := range progress.Courses {
+		progress.Courses[i].Title = titles.Courses[progress.Courses[i].CourseUUID]
+	}
+	for i := range progress.Lessons {
+		progress.Lessons[i].Title = titles.Lessons[progress.Lessons[i].LessonUUID]
+	}
+	for i := range progress.Exercises {
+		progress.Exercises[i].Title = titles.Exercises[progress.Exercises[i].ExerciseUUID]
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	v1 "github.com/JojoWeyn/duo-proj/gateway/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+const progressJSON = `{
+  "exercises": [{"uuid": "p-1", "exercise_uuid": "e-1", "total_points": 5}],
+  "lessons": [{"uuid": "p-2", "lesson_uuid": "l-1", "total_points": 10}],
+  "courses": [{"uuid": "p-3", "course_uuid": "c-1", "total_points": 15}]
+}`
+
+const titlesJSON = `{
+  "courses": {"c-1": "Go"},
+  "lessons": {"l-1": "Горутины"},
+  "exercises": {"e-1": "Каналы"}
+}`
+
+// jsonService отвечает на "METHOD path" из responses, только если утверждение
+// gateway выдано этому сервису для этого запроса. Пустой ответ - статус 500.
+func jsonService(t *testing.T, audience string, responses map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := assertion.Verify([]byte("secret"), r.Header.Get(assertion.Header), audience, r.Method, r.URL.Path); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, ok := responses[r.Method+" "+r.URL.Path]
+		switch {
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case body == "":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getDashboard(t *testing.T, course map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	user := jsonService(t, "user", map[string]string{
+		"GET /v1/users/me":                  `{"uuid": "user-1"}`,
+		"GET /v1/users/me/streak":           `{"streak": 3}`,
+		"GET /v1/users/me/progress":         progressJSON,
+		"GET /v1/users/achievements/user-1": `{"achievements": []}`,
+	})
+	registry, err := upstream.NewRegistry(map[string]upstream.ServiceConfig{
+		"user":   {URLs: []string{user.URL}},
+		"course": {URLs: []string{jsonService(t, "course", course).URL}},
+	}, upstream.Config{BreakerThreshold: 5, BreakerCooldown: time.Second})
+	require.NoError(t, err)
+
+	handler := v1.NewDashboardHandler(registry, assertion.NewSigner("secret", time.Minute))
+	engine := gin.New()
+	engine.GET("/v1/dashboard", func(c *gin.Context) {
+		c.Set(auth.ClaimsKey, &auth.Claims{Sub: "user-1", Role: "user"})
+	}, handler.Get("/v1", time.Second))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/dashboard", nil))
+	return w
+}
+
+type dashboardResponse struct {
+	Progress struct {
+		Exercises []map[string]any `json:"exercises"`
+		Lessons   []map[string]any `json:"lessons"`
+		Courses   []map[string]any `json:"courses"`
+	} `json:"progress"`
+	Courses json.RawMessage   `json:"courses"`
+	Errors  map[string]string `json:"errors"`
+}
+
+func TestDashboard_Titles(t *testing.T) {
+	w := getDashboard(t, map[string]string{
+		"GET /v1/course/list":    `[{"uuid": "c-1", "title": "Go"}]`,
+		"POST /v1/course/titles": titlesJSON,
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get("Cache-Control"))
+
+	var resp dashboardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Empty(t, resp.Errors)
+
+	require.Equal(t, "p-1", resp.Progress.Exercises[0]["uuid"])
+	require.Equal(t, "Каналы", resp.Progress.Exercises[0]["title"])
+	require.Equal(t, "p-2", resp.Progress.Lessons[0]["uuid"])
+	require.Equal(t, "Горутины", resp.Progress.Lessons[0]["title"])
+	require.Equal(t, "p-3", resp.Progress.Courses[0]["uuid"])
+	require.Equal(t, "c-1", resp.Progress.Courses[0]["course_uuid"])
+	require.Equal(t, "Go", resp.Progress.Courses[0]["title"])
+}
+
+func TestDashboard_TitlesUnavailable(t *testing.T) {
+	w := getDashboard(t, map[string]string{
+		"GET /v1/course/list":    `[]`,
+		"POST /v1/course/titles": "",
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	var resp dashboardResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, map[string]string{"titles": "status 500"}, resp.Errors)
+	require.Equal(t, "p-3", resp.Progress.Courses[0]["uuid"])
+	require.NotContains(t, resp.Progress.Courses[0], "title")
+}
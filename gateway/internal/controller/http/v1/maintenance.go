@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/maintenance"
+	"github.com/gin-gonic/gin"
+)
+
+type MaintenanceHandler struct {
+	sw *maintenance.Switch
+}
+
+func NewMaintenanceHandler(sw *maintenance.Switch) *MaintenanceHandler {
+	return &MaintenanceHandler{sw: sw}
+}
+
+type setMaintenanceRequest struct {
+	Enabled *bool      `json:"enabled" binding:"required"`
+	Message string     `json:"message"`
+	Until   *time.Time `json:"until"`
+}
+
+// Get отдает текущее состояние режима обслуживания.
+func (h *MaintenanceHandler) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, h.sw.State())
+}
+
+// Set включает или выключает режим обслуживания на всех репликах.
+// until (RFC 3339) - ожидаемое окончание работ для Retry-After.
+func (h *MaintenanceHandler) Set(c *gin.Context) {
+	var req setMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enabled is required"})
+		return
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "until must be in the future"})
+		return
+	}
+
+	state := maintenance.State{
+		Enabled:   *req.Enabled,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if state.Enabled {
+		state.Message = req.Message
+		state.Until = req.Until
+	}
+	if value, ok := c.Get(auth.ClaimsKey); ok {
+		if claims, ok := value.(*auth.Claims); ok {
+			state.UpdatedBy = claims.Sub
+		}
+	}
+
+	if err := h.sw.Set(c.Request.Context(), state); err != nil {
+		log.Printf("Failed to save maintenance state: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update maintenance mode"})
+		return
+	}
+	log.Printf("Maintenance mode set to %t by %s", state.Enabled, state.UpdatedBy)
+
+	c.JSON(http.StatusOK, state)
+}
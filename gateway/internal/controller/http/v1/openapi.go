@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/openapi"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/gin-gonic/gin"
+)
+
+type OpenAPIHandler struct {
+	aggregator *openapi.Aggregator
+	table      *routes.Table
+}
+
+func NewOpenAPIHandler(aggregator *openapi.Aggregator, table *routes.Table) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		aggregator: aggregator,
+		table:      table,
+	}
+}
+
+// Document отдает единую спецификацию всех сервисов с публичными путями gateway.
+func (h *OpenAPIHandler) Document(c *gin.Context) {
+	doc, _ := h.aggregator.Build(c.Request.Context(), h.table)
+	c.JSON(http.StatusOK, doc)
+}
+
+// Check отдает расхождения между таблицей маршрутов и спецификациями сервисов.
+func (h *OpenAPIHandler) Check(c *gin.Context) {
+	_, issues := h.aggregator.Build(c.Request.Context(), h.table)
+	if issues == nil {
+		issues = []openapi.Issue{}
+	}
+	c.JSON(http.StatusOK, gin.H{"issues": issues})
+}
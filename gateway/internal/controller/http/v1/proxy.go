@@ -2,88 +2,94 @@ package v1
 
 import (
 	"context"
-	"crypto/rsa"
 	"fmt"
-	"github.com/golang-jwt/jwt/v4"
 	"io"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
 	"strings"
 	"time"
 
-	"errors"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
 	"github.com/gin-gonic/gin"
 )
 
 type ProxyHandler struct {
-	timeout      time.Duration
-	jwtPublicKey *rsa.PublicKey
+	timeout   time.Duration
+	upstreams *upstream.Registry
+	verifier  *auth.Verifier
+	signer    *assertion.Signer
+	streams   *streamTracker
 }
 
-type Claims struct {
-	Sub  string `json:"sub"`
-	Role string `json:"role"`
-	jwt.RegisteredClaims
-}
-
-func NewProxyHandler(jwtPublicKey *rsa.PublicKey) *ProxyHandler {
+func NewProxyHandler(upstreams *upstream.Registry, verifier *auth.Verifier, signer *assertion.Signer) *ProxyHandler {
 	return &ProxyHandler{
-		timeout:      10 * time.Second,
-		jwtPublicKey: jwtPublicKey,
+		timeout:   10 * time.Second,
+		upstreams: upstreams,
+		verifier:  verifier,
+		signer:    signer,
+		streams:   newStreamTracker(),
 	}
 }
 
-func (h *ProxyHandler) ProxyService(serviceURL string, addUUID bool) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		parsedURL, err := url.Parse(serviceURL)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid service URL"})
-			return
-		}
+func (h *ProxyHandler) ProxyService(service string, addUUID bool, timeout time.Duration) gin.HandlerFunc {
+	if timeout <= 0 {
+		timeout = h.timeout
+	}
 
-		proxy := httputil.NewSingleHostReverseProxy(parsedURL)
-		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-			c.JSON(http.StatusBadGateway, gin.H{"error": "service unavailable"})
+	target, ok := h.upstreams.Get(service)
+	if !ok {
+		return func(c *gin.Context) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid service URL"})
 		}
+	}
 
-		originalDirector := proxy.Director
-		proxy.Director = func(req *http.Request) {
-			originalDirector(req)
-			if auth := c.GetHeader("Authorization"); auth != "" {
-				req.Header.Set("Authorization", auth)
-				if addUUID {
-					if uuid, role, err := h.extractUUIDFromJWT(auth); err == nil {
-						req.Header.Set("X-User-UUID", uuid)
-						req.Header.Set("X-User-Role", role)
-					}
-				}
-			}
-			req.Header.Set("Origin", "http://37.18.102.166:3211")
-		}
+	return func(c *gin.Context) {
+		h.forwardIdentity(c, service, addUUID)
+		// Сервисы считают клиентом первый адрес цепочки, поэтому присланную
+		// клиентом цепочку заменяем адресом, определенным по TrustedProxies.
+		c.Request.Header.Set("X-Forwarded-For", c.ClientIP())
 
-		ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 		defer cancel()
 		c.Request = c.Request.WithContext(ctx)
 
-		proxy.ServeHTTP(c.Writer, c.Request)
+		target.ServeHTTP(c.Writer, c.Request)
 	}
 }
 
-func (p *ProxyHandler) ProxySwagger(targetService, path string) gin.HandlerFunc {
+// forwardIdentity заменяет присланные клиентом заголовки идентичности
+// утверждением, подписанным gateway для сервиса.
+func (h *ProxyHandler) forwardIdentity(c *gin.Context, service string, addUUID bool) {
+	stripIdentityHeaders(c.Request.Header)
+	if addUUID {
+		if uuid, role, err := h.extractUUIDFromJWT(c); err == nil {
+			token, err := h.signer.Sign(uuid, role, service, c.Request.Method, c.Request.URL.Path)
+			if err == nil {
+				c.Request.Header.Set(assertion.Header, token)
+			}
+		}
+	}
+}
+
+func (h *ProxyHandler) ProxySwagger(service, path string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		target := fmt.Sprintf("%s/swagger%s", targetService, path)
+		target, ok := h.upstreams.Get(service)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			return
+		}
 
-		req, err := http.NewRequest(c.Request.Method, target, nil)
+		req, err := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, fmt.Sprintf("%s/swagger%s", target.URL, path), nil)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
 			return
 		}
 
-		req.Header = c.Request.Header
+		req.Header = c.Request.Header.Clone()
+		stripIdentityHeaders(req.Header)
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := target.Client.Do(req)
 		if err != nil {
 			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to forward request"})
 			return
@@ -101,35 +107,30 @@ func (p *ProxyHandler) ProxySwagger(targetService, path string) gin.HandlerFunc
 	}
 }
 
-func (h *ProxyHandler) extractUUIDFromJWT(tokenString string) (string, string, error) {
-	if tokenString == "" {
-		return "", "", errors.New("empty token")
+// stripIdentityHeaders удаляет присланные клиентом заголовки идентичности,
+// чтобы сервисы получали только то, что подписал gateway.
+func stripIdentityHeaders(header http.Header) {
+	for key := range header {
+		if strings.HasPrefix(key, "X-User-") {
+			header.Del(key)
+		}
 	}
+	header.Del(assertion.Header)
+}
 
-	parts := strings.Split(tokenString, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return "", "", errors.New("invalid token format")
+// extractUUIDFromJWT берет claims, проверенные AuthMiddleware, а если их нет -
+// проверяет заголовок Authorization самостоятельно.
+func (h *ProxyHandler) extractUUIDFromJWT(c *gin.Context) (string, string, error) {
+	if value, ok := c.Get(auth.ClaimsKey); ok {
+		if claims, ok := value.(*auth.Claims); ok {
+			return claims.Sub, claims.Role, nil
+		}
 	}
 
-	claims := &Claims{}
-
-	token, err := jwt.ParseWithClaims(parts[1], claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return h.jwtPublicKey, nil
-	})
+	claims, err := h.verifier.VerifyHeader(c.GetHeader("Authorization"))
 	if err != nil {
 		return "", "", err
 	}
 
-	if !token.Valid {
-		return "", "", errors.New("invalid token")
-	}
-
-	if claims.Sub == "" {
-		return "", "", errors.New("uuid is not found")
-	}
-
 	return claims.Sub, claims.Role, nil
 }
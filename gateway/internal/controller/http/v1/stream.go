@@ -0,0 +1,237 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/JojoWeyn/duo-proj/gateway/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// ProxyStream проксирует долгоживущее соединение: WebSocket после upgrade
+// или поток SSE. Таймаут маршрута не действует, вместо него соединение
+// закрывается после policy.IdleTimeout без данных и по истечении токена.
+func (h *ProxyHandler) ProxyStream(service string, addUUID bool, policy routes.StreamPolicy) gin.HandlerFunc {
+	target, ok := h.upstreams.Get(service)
+	if !ok {
+		return func(c *gin.Context) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid service URL"})
+		}
+	}
+
+	return func(c *gin.Context) {
+		claims, _ := c.Get(auth.ClaimsKey)
+		userClaims, _ := claims.(*auth.Claims)
+
+		user := c.ClientIP()
+		if userClaims != nil {
+			user = userClaims.Sub
+		}
+
+		switch h.streams.acquire(user, policy) {
+		case streamLimitTotal:
+			metrics.ObserveStreamRejected(service, "total")
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "too many open connections"})
+			return
+		case streamLimitUser:
+			metrics.ObserveStreamRejected(service, "user")
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many open connections for user"})
+			return
+		}
+		defer h.streams.release(user)
+
+		metrics.StreamOpened(service)
+		defer metrics.StreamClosed(service)
+
+		h.forwardIdentity(c, service, addUUID)
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+
+		watch := newStreamWatch(policy.IdleTimeout, cancel)
+		defer watch.stop()
+		if userClaims != nil && userClaims.ExpiresAt != nil {
+			watch.expireAt(userClaims.ExpiresAt.Time)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		target.ServeHTTP(&streamWriter{ResponseWriter: c.Writer, watch: watch}, c.Request)
+	}
+}
+
+const (
+	streamAllowed = iota
+	streamLimitTotal
+	streamLimitUser
+)
+
+// streamTracker считает открытые долгоживущие соединения. Он общий для всех
+// версий таблицы маршрутов, поэтому перезагрузка не обнуляет счетчики.
+type streamTracker struct {
+	mu      sync.Mutex
+	total   int
+	perUser map[string]int
+}
+
+func newStreamTracker() *streamTracker {
+	return &streamTracker{perUser: make(map[string]int)}
+}
+
+func (t *streamTracker) acquire(user string, policy routes.StreamPolicy) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if policy.MaxConnections > 0 && t.total >= policy.MaxConnections {
+		return streamLimitTotal
+	}
+	if policy.MaxPerUser > 0 && t.perUser[user] >= policy.MaxPerUser {
+		return streamLimitUser
+	}
+	t.total++
+	t.perUser[user]++
+	return streamAllowed
+}
+
+func (t *streamTracker) release(user string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total--
+	if t.perUser[user]--; t.perUser[user] <= 0 {
+		delete(t.perUser, user)
+	}
+}
+
+// streamWatch закрывает соединение, если данных не было дольше idle. Активность
+// отмечается атомарно, а таймер при срабатывании сверяется с ней и при
+// необходимости взводится на остаток, поэтому горячий путь не трогает таймер.
+type streamWatch struct {
+	idle         time.Duration
+	lastActivity atomic.Int64
+	cancel       context.CancelFunc
+
+	mu          sync.Mutex
+	conn        net.Conn
+	idleTimer   *time.Timer
+	expiryTimer *time.Timer
+	stopped     bool
+}
+
+func newStreamWatch(idle time.Duration, cancel context.CancelFunc) *streamWatch {
+	w := &streamWatch{idle: idle, cancel: cancel}
+	w.touch()
+	w.mu.Lock()
+	w.idleTimer = time.AfterFunc(idle, w.checkIdle)
+	w.mu.Unlock()
+	return w
+}
+
+func (w *streamWatch) touch() {
+	w.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (w *streamWatch) checkIdle() {
+	idleFor := time.Since(time.Unix(0, w.lastActivity.Load()))
+	if idleFor >= w.idle {
+		w.close()
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.stopped {
+		w.idleTimer = time.AfterFunc(w.idle-idleFor, w.checkIdle)
+	}
+}
+
+// expireAt закрывает соединение в момент истечения токена, с которым оно открыто.
+func (w *streamWatch) expireAt(t time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expiryTimer = time.AfterFunc(time.Until(t), w.close)
+}
+
+func (w *streamWatch) setConn(conn net.Conn) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.conn = conn
+}
+
+// close отменяет запрос к сервису (SSE) и закрывает соединение клиента после upgrade.
+func (w *streamWatch) close() {
+	w.cancel()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		w.conn.Close()
+	}
+}
+
+func (w *streamWatch) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+	w.idleTimer.Stop()
+	if w.expiryTimer != nil {
+		w.expiryTimer.Stop()
+	}
+}
+
+// streamWriter отмечает активность на каждую запись клиенту, а при upgrade
+// отдает ReverseProxy соединение, которое отмечает чтение и запись.
+type streamWriter struct {
+	gin.ResponseWriter
+	watch *streamWatch
+}
+
+func (w *streamWriter) Write(data []byte) (int, error) {
+	w.watch.touch()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *streamWriter) WriteString(s string) (int, error) {
+	w.watch.touch()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *streamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watched := &watchedConn{Conn: conn, watch: w.watch}
+	w.watch.setConn(watched)
+	return watched, rw, nil
+}
+
+type watchedConn struct {
+	net.Conn
+	watch *streamWatch
+}
+
+func (c *watchedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.watch.touch()
+	}
+	return n, err
+}
+
+func (c *watchedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if n > 0 {
+		c.watch.touch()
+	}
+	return n, err
+}
@@ -0,0 +1,213 @@
+package v1_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	v1 "github.com/JojoWeyn/duo-proj/gateway/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+const eventsPath = "/v1/users/me/events"
+
+// sseService отправляет events событий с интервалом every и держит поток
+// открытым, пока его не закроет gateway.
+func sseService(t *testing.T, events int, every time.Duration) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := assertion.Verify([]byte("secret"), r.Header.Get(assertion.Header), "user", r.Method, r.URL.Path); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		for i := range events {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(every):
+			}
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type streamGateway struct {
+	server    *httptest.Server
+	upstreams *upstream.Registry
+}
+
+func newStreamGateway(t *testing.T, service *httptest.Server, policy routes.StreamPolicy, ttl time.Duration) streamGateway {
+	t.Helper()
+
+	registry, err := upstream.NewRegistry(map[string]upstream.ServiceConfig{
+		"user": {URLs: []string{service.URL}},
+	}, upstream.Config{BreakerThreshold: 5, BreakerCooldown: time.Second})
+	require.NoError(t, err)
+
+	proxy := v1.NewProxyHandler(registry, nil, assertion.NewSigner("secret", time.Minute))
+
+	engine := gin.New()
+	engine.GET(eventsPath, func(c *gin.Context) {
+		claims := &auth.Claims{Sub: c.Query("user"), Role: "user"}
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ttl))
+		c.Set(auth.ClaimsKey, claims)
+		c.Next()
+	}, proxy.ProxyStream("user", true, policy))
+
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return streamGateway{server: server, upstreams: registry}
+}
+
+func (g streamGateway) open(t *testing.T, ctx context.Context, user string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.server.URL+eventsPath+"?user="+user, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func (g streamGateway) active() int64 {
+	return g.upstreams.Status()[0].Instances[0].ActiveRequests
+}
+
+func TestProxyStream_Limits(t *testing.T) {
+	g := newStreamGateway(t, sseService(t, 0, 0), routes.StreamPolicy{
+		IdleTimeout:    time.Minute,
+		MaxConnections: 2,
+		MaxPerUser:     1,
+	}, time.Hour)
+
+	ctx, closeFirst := context.WithCancel(context.Background())
+	defer closeFirst()
+	first := g.open(t, ctx, "alice")
+	require.Equal(t, http.StatusOK, first.StatusCode)
+
+	tests := []struct {
+		name     string
+		user     string
+		wantCode int
+	}{
+		{name: "second stream of the same user", user: "alice", wantCode: http.StatusTooManyRequests},
+		{name: "another user", user: "bob", wantCode: http.StatusOK},
+		{name: "total limit", user: "carol", wantCode: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := g.open(t, context.Background(), tt.user)
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+
+	// Закрытый поток освобождает место.
+	closeFirst()
+	require.Eventually(t, func() bool {
+		resp := g.open(t, context.Background(), "carol")
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 20*time.Millisecond)
+}
+
+func TestProxyStream_ActiveUntilClosed(t *testing.T) {
+	g := newStreamGateway(t, sseService(t, 0, 0), routes.StreamPolicy{IdleTimeout: time.Minute}, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := g.open(t, ctx, "alice")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Заголовки уже получены, но поток открыт - экземпляр занят.
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, int64(1), g.active())
+
+	cancel()
+	require.Eventually(t, func() bool { return g.active() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestProxyStream_Close(t *testing.T) {
+	tests := []struct {
+		name       string
+		events     int
+		every      time.Duration
+		idle       time.Duration
+		ttl        time.Duration
+		wantEvents int
+		minOpen    time.Duration
+	}{
+		{name: "idle stream is closed", idle: 50 * time.Millisecond, ttl: time.Hour},
+		{
+			name:       "activity keeps stream open",
+			events:     5,
+			every:      30 * time.Millisecond,
+			idle:       60 * time.Millisecond,
+			ttl:        time.Hour,
+			wantEvents: 5,
+			minOpen:    150 * time.Millisecond,
+		},
+		// Срок токена хранится с точностью до секунды.
+		{name: "expired token closes stream", events: 300, every: 10 * time.Millisecond, idle: time.Minute, ttl: 1500 * time.Millisecond, minOpen: 400 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newStreamGateway(t, sseService(t, tt.events, tt.every), routes.StreamPolicy{IdleTimeout: tt.idle}, tt.ttl)
+
+			start := time.Now()
+			resp := g.open(t, context.Background(), "alice")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			done := make(chan int, 1)
+			go func() {
+				received := 0
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					if strings.HasPrefix(scanner.Text(), "data: ") {
+						received++
+					}
+				}
+				done <- received
+			}()
+
+			select {
+			case received := <-done:
+				require.GreaterOrEqual(t, time.Since(start), tt.minOpen)
+				if tt.wantEvents > 0 {
+					require.Equal(t, tt.wantEvents, received)
+				}
+				if tt.events > tt.wantEvents && tt.wantEvents == 0 {
+					require.Less(t, received, tt.events)
+				}
+			case <-time.After(3 * time.Second):
+				t.Fatal("stream was not closed")
+			}
+			io.Copy(io.Discard, resp.Body)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/gin-gonic/gin"
+)
+
+type UpstreamsHandler struct {
+	upstreams *upstream.Registry
+}
+
+func NewUpstreamsHandler(upstreams *upstream.Registry) *UpstreamsHandler {
+	return &UpstreamsHandler{upstreams: upstreams}
+}
+
+// Status отдает состояние экземпляров каждого сервиса: здоровье, breaker и нагрузку.
+func (h *UpstreamsHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"upstreams": h.upstreams.Status()})
+}
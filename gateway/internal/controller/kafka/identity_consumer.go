@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+)
+
+// retryInterval - пауза перед повторным подключением к Kafka. Без Kafka шлюз
+// продолжает работать: токены все равно отзываются через RevocationCache.
+const retryInterval = 10 * time.Second
+
+// IdentityConsumer читает события identity-service об изменении пользователей
+// и передает их в SubjectCache. Группа постоянная, поэтому после перезапуска
+// чтение продолжается с сохраненного смещения. События должны получать все
+// экземпляры шлюза, так что каждому экземпляру нужна своя группа; то, что
+// экземпляр пропустил, восстанавливает SubjectCache.Start.
+type IdentityConsumer struct {
+	brokers  []string
+	topic    string
+	groupID  string
+	subjects *auth.SubjectCache
+}
+
+func NewIdentityConsumer(brokers []string, topic, groupID string, subjects *auth.SubjectCache) *IdentityConsumer {
+	return &IdentityConsumer{
+		brokers:  brokers,
+		topic:    topic,
+		groupID:  groupID,
+		subjects: subjects,
+	}
+}
+
+// Start читает топик до отмены контекста.
+func (c *IdentityConsumer) Start(ctx context.Context) {
+	config := sarama.NewConfig()
+	config.Version = sarama.MaxVersion
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	for {
+		consumerGroup, err := sarama.NewConsumerGroup(c.brokers, c.groupID, config)
+		if err != nil {
+			log.Printf("Error creating consumer group client: %v", err)
+		} else {
+			c.consume(ctx, consumerGroup)
+			consumerGroup.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (c *IdentityConsumer) consume(ctx context.Context, consumerGroup sarama.ConsumerGroup) {
+	handler := identityHandler{subjects: c.subjects}
+	for {
+		if err := consumerGroup.Consume(ctx, []string{c.topic}, handler); err != nil {
+			log.Printf("Error from consumer: %v", err)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+type identityHandler struct {
+	subjects *auth.SubjectCache
+}
+
+func (identityHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
+func (identityHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+func (h identityHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for message := range claim.Messages() {
+		var event auth.SubjectEvent
+		if err := json.Unmarshal(message.Value, &event); err != nil {
+			log.Printf("Failed to parse identity event: %v", err)
+		} else {
+			h.subjects.Apply(event)
+		}
+
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
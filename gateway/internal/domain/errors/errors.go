@@ -25,6 +25,9 @@ var (
 	ErrInvalidToken            = errors.New("2002") // Неверный токен
 	ErrUnexpectedSigningMethod = errors.New("2003") // Неожиданный метод подписания токена
 	ErrUUIDNotFoundInToken     = errors.New("2004") // UUID не найден в токене
+	ErrTokenExpired            = errors.New("2005") // Срок действия токена истек
+	ErrTokenRevoked            = errors.New("2006") // Токен отозван
+	ErrAccountDisabled         = errors.New("2007") // Аккаунт отключен
 
 	// Ошибки, связанные с SMTP (3000-3999)
 	ErrConnectionToSMTPServer    = errors.New("3001") // Ошибка подключения к SMTP серверу
@@ -0,0 +1,78 @@
+package maintenance
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// State - режим обслуживания платформы. Пока он включен, gateway отвечает
+// на изменяющие запросы 503, а чтение продолжает работать.
+type State struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+	// Until - ожидаемое окончание работ, по нему считается Retry-After.
+	Until     *time.Time `json:"until,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Store хранит состояние, общее для реплик gateway.
+type Store interface {
+	Load(ctx context.Context) (State, error)
+	Save(ctx context.Context, state State) error
+}
+
+// Switch держит текущее состояние в памяти, чтобы не обращаться к хранилищу на
+// каждый запрос, и периодически перечитывает его, узнавая о переключениях на
+// других репликах.
+type Switch struct {
+	store Store
+	state atomic.Pointer[State]
+}
+
+func NewSwitch(store Store) *Switch {
+	s := &Switch{store: store}
+	s.state.Store(&State{})
+	return s
+}
+
+func (s *Switch) State() State {
+	return *s.state.Load()
+}
+
+// Set сохраняет состояние и сразу применяет его на этой реплике.
+func (s *Switch) Set(ctx context.Context, state State) error {
+	if err := s.store.Save(ctx, state); err != nil {
+		return err
+	}
+	s.state.Store(&state)
+	return nil
+}
+
+// Start перечитывает состояние с заданным интервалом до отмены контекста.
+func (s *Switch) Start(ctx context.Context, interval time.Duration) {
+	s.refresh(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+func (s *Switch) refresh(ctx context.Context) {
+	state, err := s.store.Load(ctx)
+	if err != nil {
+		log.Printf("Failed to load maintenance state: %v", err)
+		return
+	}
+	s.state.Store(&state)
+}
@@ -0,0 +1,50 @@
+package maintenance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/maintenance"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitch_SharedThroughRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	first := maintenance.NewSwitch(maintenance.NewRedisStore(client, maintenance.Key))
+	second := maintenance.NewSwitch(maintenance.NewRedisStore(client, maintenance.Key))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go second.Start(ctx, 10*time.Millisecond)
+
+	require.NoError(t, first.Set(ctx, maintenance.State{Enabled: true, Message: "migrating", UpdatedBy: "admin-1"}))
+	require.True(t, first.State().Enabled, "applied on this replica immediately")
+
+	require.Eventually(t, func() bool { return second.State().Enabled }, time.Second, 10*time.Millisecond)
+	require.Equal(t, "migrating", second.State().Message)
+
+	require.NoError(t, first.Set(ctx, maintenance.State{Enabled: false}))
+	require.Eventually(t, func() bool { return !second.State().Enabled }, time.Second, 10*time.Millisecond)
+}
+
+func TestSwitch_KeepsStateWhenStoreFails(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	sw := maintenance.NewSwitch(maintenance.NewRedisStore(client, maintenance.Key))
+	require.NoError(t, sw.Set(context.Background(), maintenance.State{Enabled: true}))
+
+	server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	sw.Start(ctx, 10*time.Millisecond)
+
+	require.True(t, sw.State().Enabled)
+}
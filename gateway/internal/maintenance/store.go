@@ -0,0 +1,67 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Key - ключ Redis с состоянием режима обслуживания.
+const Key = "gateway:maintenance"
+
+// MemoryStore хранит состояние в памяти: переключение действует только на эту реплику.
+type MemoryStore struct {
+	mu    sync.Mutex
+	state State
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Load(_ context.Context) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, nil
+}
+
+func (s *MemoryStore) Save(_ context.Context, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	return nil
+}
+
+// RedisStore хранит состояние в Redis, поэтому переключение видят все реплики.
+type RedisStore struct {
+	client *redis.Client
+	key    string
+}
+
+func NewRedisStore(client *redis.Client, key string) *RedisStore {
+	return &RedisStore{client: client, key: key}
+}
+
+func (s *RedisStore) Load(ctx context.Context) (State, error) {
+	var state State
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func (s *RedisStore) Save(ctx context.Context, state State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.key, data, 0).Err()
+}
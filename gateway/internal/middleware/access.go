@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessMiddleware пропускает запрос, только если роль из проверенного JWT
+// входит в roles и роли выданы все permissions. Должен идти после AuthMiddleware.
+func AccessMiddleware(table *routes.Table, roles, permissions []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get(auth.ClaimsKey)
+		claims, _ := value.(*auth.Claims)
+		if !ok || claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no token provided"})
+			c.Abort()
+			return
+		}
+
+		if len(roles) > 0 && !slices.Contains(roles, claims.Role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":          "insufficient role",
+				"role":           claims.Role,
+				"required_roles": roles,
+			})
+			c.Abort()
+			return
+		}
+
+		var missing []string
+		for _, permission := range permissions {
+			if !table.HasPermission(claims.Role, permission) {
+				missing = append(missing, permission)
+			}
+		}
+		if len(missing) > 0 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":               "missing permissions",
+				"role":                claims.Role,
+				"missing_permissions": missing,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessMiddleware(t *testing.T) {
+	table := &routes.Table{
+		RolePermissions: map[string][]string{
+			"admin":  {routes.AllPermissions},
+			"editor": {"courses:write"},
+			"user":   {"courses:read"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		claims      *auth.Claims
+		roles       []string
+		permissions []string
+		wantCode    int
+		wantError   string
+	}{
+		{
+			name:     "no claims",
+			roles:    []string{"admin"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:      "missing role",
+			claims:    &auth.Claims{Sub: "user-1"},
+			roles:     []string{"admin", "editor"},
+			wantCode:  http.StatusForbidden,
+			wantError: "insufficient role",
+		},
+		{
+			name:      "role not allowed",
+			claims:    &auth.Claims{Sub: "user-1", Role: "user"},
+			roles:     []string{"admin", "editor"},
+			wantCode:  http.StatusForbidden,
+			wantError: "insufficient role",
+		},
+		{
+			name:        "missing permission",
+			claims:      &auth.Claims{Sub: "user-1", Role: "user"},
+			permissions: []string{"courses:read", "courses:write"},
+			wantCode:    http.StatusForbidden,
+			wantError:   "missing permissions",
+		},
+		{
+			name:        "allowed role and permission",
+			claims:      &auth.Claims{Sub: "user-1", Role: "editor"},
+			roles:       []string{"admin", "editor"},
+			permissions: []string{"courses:write"},
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "wildcard permission",
+			claims:      &auth.Claims{Sub: "admin-1", Role: "admin"},
+			permissions: []string{"courses:write", "users:delete"},
+			wantCode:    http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/resource", func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set(auth.ClaimsKey, tt.claims)
+				}
+			}, middleware.AccessMiddleware(table, tt.roles, tt.permissions), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resource", nil))
+
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantError != "" {
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				require.Equal(t, tt.wantError, body["error"])
+			}
+		})
+	}
+}
+
+func TestAccessMiddleware_ListsMissingPermissions(t *testing.T) {
+	table := &routes.Table{RolePermissions: map[string][]string{"user": {"courses:read"}}}
+
+	engine := gin.New()
+	engine.GET("/resource", func(c *gin.Context) {
+		c.Set(auth.ClaimsKey, &auth.Claims{Sub: "user-1", Role: "user"})
+	}, middleware.AccessMiddleware(table, nil, []string{"courses:read", "courses:write"}))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/resource", nil))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.JSONEq(t, `{"error":"missing permissions","role":"user","missing_permissions":["courses:write"]}`, w.Body.String())
+}
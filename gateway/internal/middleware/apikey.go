@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/apikey"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyContextKey - ключ, под которым ключ интеграции хранится в gin.Context.
+const APIKeyContextKey = "api_key"
+
+// APIKeyMiddleware пускает на маршрут набора scope по ключу из X-API-Key:
+// ключ должен быть действующим, выпущенным на этот набор и не исчерпавшим
+// квоты. Запрос получает claims с ID ключа и ролью service, поэтому сервисы
+// видят идентичность интеграции, а не пользователя. Без заголовка запрос
+// проверяется обычным next (AuthMiddleware). quotaFailOpen задает, что делать,
+// если счетчики квот недоступны: пропустить запрос без учета или ответить 503.
+func APIKeyMiddleware(keys apikey.Store, scope string, quotaFailOpen bool, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.GetHeader(apikey.Header)
+		if value == "" {
+			next(c)
+			return
+		}
+		// Ключ не должен уйти в сервис вместе с остальными заголовками.
+		c.Request.Header.Del(apikey.Header)
+
+		id, secret, err := apikey.Parse(value)
+		if err != nil {
+			abortInvalidKey(c)
+			return
+		}
+
+		key, err := keys.Get(c.Request.Context(), id)
+		if err != nil {
+			log.Printf("API key store error: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to check api key"})
+			c.Abort()
+			return
+		}
+		if key == nil || key.Revoked() || !key.Verify(secret) {
+			abortInvalidKey(c)
+			return
+		}
+		if !key.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "api key is not allowed for this route"})
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		usage, err := keys.Consume(c.Request.Context(), key, now)
+		if err != nil {
+			log.Printf("API key usage error: %v", err)
+			if !quotaFailOpen {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to check api key quota"})
+				c.Abort()
+				return
+			}
+		} else {
+			setQuotaHeaders(c, key, usage)
+			if usage.Exceeded != "" {
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(time.Until(apikey.ResetAt(usage.Exceeded, now)))))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "api key quota exceeded", "period": usage.Exceeded})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set(APIKeyContextKey, key)
+		c.Set(auth.ClaimsKey, &auth.Claims{Sub: key.ID, Role: auth.ServiceRole})
+		c.Next()
+	}
+}
+
+func setQuotaHeaders(c *gin.Context, key *apikey.Key, usage apikey.Usage) {
+	if key.DailyQuota > 0 {
+		c.Header("X-Quota-Day-Remaining", strconv.FormatInt(max(key.DailyQuota-usage.Day, 0), 10))
+	}
+	if key.MonthlyQuota > 0 {
+		c.Header("X-Quota-Month-Remaining", strconv.FormatInt(max(key.MonthlyQuota-usage.Month, 0), 10))
+	}
+}
+
+func abortInvalidKey(c *gin.Context) {
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+	c.Abort()
+}
@@ -0,0 +1,102 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/apikey"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenQuotaStore - ключи читаются, а счетчики квот недоступны.
+type brokenQuotaStore struct {
+	*apikey.MemoryStore
+}
+
+func (brokenQuotaStore) Consume(context.Context, *apikey.Key, time.Time) (apikey.Usage, error) {
+	return apikey.Usage{}, errors.New("redis is down")
+}
+
+func apiKeyEngine(store apikey.Store, quotaFailOpen bool) *gin.Engine {
+	engine := gin.New()
+	engine.GET("/v1/reports", middleware.APIKeyMiddleware(store, "reports", quotaFailOpen, func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}), func(c *gin.Context) {
+		claims := c.MustGet(auth.ClaimsKey).(*auth.Claims)
+		c.String(http.StatusOK, claims.Sub+" "+claims.Role)
+	})
+	return engine
+}
+
+func newAPIKey(t *testing.T, store apikey.Store, scope string, dailyQuota int64) (*apikey.Key, string) {
+	key, secret, err := apikey.New("reports", []string{scope}, dailyQuota, 0, "admin-1")
+	require.NoError(t, err)
+	require.NoError(t, store.Create(context.Background(), key))
+	return key, secret
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	store := apikey.NewMemoryStore()
+	key, secret := newAPIKey(t, store, "reports", 1)
+	_, otherScope := newAPIKey(t, store, "catalog", 0)
+	revokedKey, revoked := newAPIKey(t, store, "reports", 0)
+	_, err := store.Revoke(context.Background(), revokedKey.ID, time.Now())
+	require.NoError(t, err)
+
+	engine := apiKeyEngine(store, false)
+	do := func(value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/reports", nil)
+		if value != "" {
+			req.Header.Set(apikey.Header, value)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(secret)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, key.ID+" "+auth.ServiceRole, w.Body.String())
+	require.Equal(t, "0", w.Header().Get("X-Quota-Day-Remaining"))
+
+	w = do(secret)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	require.Equal(t, http.StatusForbidden, do(otherScope).Code)
+	require.Equal(t, http.StatusUnauthorized, do(revoked).Code)
+	require.Equal(t, http.StatusUnauthorized, do(secret+"x").Code)
+	require.Equal(t, http.StatusUnauthorized, do("").Code, "without a key the request goes to next")
+}
+
+func TestAPIKeyMiddleware_QuotaStoreError(t *testing.T) {
+	tests := []struct {
+		name          string
+		quotaFailOpen bool
+		wantCode      int
+	}{
+		{name: "fail closed", quotaFailOpen: false, wantCode: http.StatusServiceUnavailable},
+		{name: "fail open", quotaFailOpen: true, wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := brokenQuotaStore{apikey.NewMemoryStore()}
+			_, secret := newAPIKey(t, store, "reports", 1)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/reports", nil)
+			req.Header.Set(apikey.Header, secret)
+			w := httptest.NewRecorder()
+			apiKeyEngine(store, tt.quotaFailOpen).ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
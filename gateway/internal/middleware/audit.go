@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/audit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// auditWriteTimeout ограничивает запись в журнал: запрос клиента к этому
+// моменту может быть уже отменен, но запись все равно должна попасть в журнал.
+const auditWriteTimeout = 2 * time.Second
+
+// maxAuditDrain - сколько непрочитанного сервисом тела дочитывается для хэша,
+// например когда запрос отклонен до проксирования.
+const maxAuditDrain = 1 << 20
+
+// AuditMiddleware записывает в журнал административный запрос: кто, что и над
+// какими сущностями сделал и чем это закончилось. Тело запроса не сохраняется,
+// а хэшируется по мере чтения сервисом. Должен идти сразу после AuthMiddleware,
+// чтобы в журнал попадали и отказы по правам.
+func AuditMiddleware(store audit.Store, service, entity string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		var body *hashingReader
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body = &hashingReader{ReadCloser: c.Request.Body, hash: sha256.New()}
+			c.Request.Body = body
+		}
+
+		c.Next()
+
+		if body != nil {
+			io.Copy(io.Discard, io.LimitReader(body, maxAuditDrain))
+		}
+
+		record := &audit.Record{
+			Time:      start.UTC(),
+			RequestID: tracing.RequestIDFromContext(c.Request.Context()),
+			ClientIP:  c.ClientIP(),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			Service:   service,
+			Entity:    entity,
+			Status:    c.Writer.Status(),
+			LatencyMs: time.Since(start).Milliseconds(),
+		}
+		if value, ok := c.Get(auth.ClaimsKey); ok {
+			if claims, ok := value.(*auth.Claims); ok {
+				record.ActorUUID = claims.Sub
+				record.Role = claims.Role
+			}
+		}
+		if len(c.Params) > 0 {
+			record.Targets = make(map[string]string, len(c.Params))
+			for _, param := range c.Params {
+				record.Targets[param.Key] = param.Value
+			}
+		}
+		if body != nil && body.size > 0 {
+			record.BodyHash = hex.EncodeToString(body.hash.Sum(nil))
+			record.BodySize = body.size
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), auditWriteTimeout)
+		defer cancel()
+		if err := store.Append(ctx, record); err != nil {
+			log.Printf("Failed to write audit record for %s %s by %s: %v", record.Method, record.Path, record.ActorUUID, err)
+		}
+	}
+}
+
+// hashingReader считает sha256 и размер тела по мере того, как его читает прокси.
+type hashingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+	size int64
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.hash.Write(p[:n])
+		r.size += int64(n)
+	}
+	return n, err
+}
@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/audit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	const body = `{"role": "admin"}`
+	sum := sha256.Sum256([]byte(body))
+
+	tests := []struct {
+		name     string
+		handler  gin.HandlerFunc
+		wantCode int
+	}{
+		{
+			name: "proxied",
+			handler: func(c *gin.Context) {
+				io.ReadAll(c.Request.Body)
+				c.Status(http.StatusOK)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			// Тело не прочитано сервисом, но хэш все равно считается.
+			name:     "denied",
+			handler:  func(c *gin.Context) { c.AbortWithStatus(http.StatusForbidden) },
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := audit.NewMemoryStore(10)
+			engine := gin.New()
+			engine.PUT("/v1/auth/admin/identities/:uuid/role", func(c *gin.Context) {
+				c.Set(auth.ClaimsKey, &auth.Claims{Sub: "admin-1", Role: "admin"})
+			}, middleware.AuditMiddleware(store, "identity", "identities"), tt.handler)
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/auth/admin/identities/user-1/role", strings.NewReader(body)))
+			require.Equal(t, tt.wantCode, w.Code)
+
+			records, err := store.Query(context.Background(), audit.Filter{Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+
+			record := records[0]
+			require.Equal(t, "admin-1", record.ActorUUID)
+			require.Equal(t, "admin", record.Role)
+			require.Equal(t, "identity", record.Service)
+			require.Equal(t, "identities", record.Entity)
+			require.Equal(t, "/v1/auth/admin/identities/:uuid/role", record.Route)
+			require.Equal(t, map[string]string{"uuid": "user-1"}, record.Targets)
+			require.Equal(t, tt.wantCode, record.Status)
+			require.Equal(t, hex.EncodeToString(sum[:]), record.BodyHash)
+			require.Equal(t, int64(len(body)), record.BodySize)
+		})
+	}
+}
@@ -1,60 +1,97 @@
 package middleware
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"strings"
 
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	domainErrors "github.com/JojoWeyn/duo-proj/gateway/internal/domain/errors"
 	"github.com/gin-gonic/gin"
 )
 
-type TokenStatus struct {
-	IsBlacklisted bool `json:"is_blacklisted"`
+var authErrorMessages = map[error]string{
+	domainErrors.ErrNoTokenProvided:         "no token provided",
+	domainErrors.ErrTokenExpired:            "token expired",
+	domainErrors.ErrTokenRevoked:            "token revoked",
+	domainErrors.ErrAccountDisabled:         "account disabled",
+	domainErrors.ErrUnexpectedSigningMethod: "invalid token",
+	domainErrors.ErrUUIDNotFoundInToken:     "invalid token",
 }
 
-func AuthMiddleware(identityServiceURL string) gin.HandlerFunc {
+// AuthMiddleware проверяет access-токен локально, без обращения к identity-service,
+// и сохраняет claims в контексте запроса.
+func AuthMiddleware(verifier *auth.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "no token provided"})
-			c.Abort()
+		claims, err := verifier.VerifyHeader(c.GetHeader("Authorization"))
+		if err != nil {
+			abortUnauthorized(c, err)
 			return
 		}
 
-		token = strings.TrimPrefix(token, "Bearer ")
+		c.Set(auth.ClaimsKey, claims)
+		c.Next()
+	}
+}
 
-		req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/auth/token/status", identityServiceURL), nil)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
-			c.Abort()
-			return
-		}
+// accessTokenParam - query-параметр с токеном для WebSocket и EventSource:
+// браузер не дает выставить им заголовок Authorization.
+const accessTokenParam = "access_token"
 
-		req.Header.Set("Authorization", "Bearer "+token)
+// accessTokenKey - ключ контекста gin, под которым AccessTokenMiddleware
+// оставляет токен из query для StreamAuthMiddleware.
+const accessTokenKey = "access_token"
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate token"})
-			c.Abort()
-			return
+// AccessTokenMiddleware убирает access_token из query до логгера gin, чтобы
+// токен не попал в журнал запросов. Должен стоять перед gin.Logger.
+func AccessTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := takeAccessToken(c); token != "" {
+			c.Set(accessTokenKey, token)
 		}
-		defer resp.Body.Close()
+		c.Next()
+	}
+}
 
-		if resp.StatusCode != http.StatusOK {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
+// StreamAuthMiddleware проверяет токен так же, как AuthMiddleware, но если
+// заголовка нет, берет токен из access_token. Параметр убирается из запроса,
+// а токен переносится в Authorization, чтобы не попасть в логи сервиса.
+func StreamAuthMiddleware(verifier *auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := takeAccessToken(c); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
 		}
 
-		var tokenStatus TokenStatus
-		if err := json.NewDecoder(resp.Body).Decode(&tokenStatus); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to parse token status"})
-			c.Abort()
+		claims, err := verifier.VerifyHeader(c.GetHeader("Authorization"))
+		if err != nil {
+			abortUnauthorized(c, err)
 			return
 		}
 
+		c.Set(auth.ClaimsKey, claims)
 		c.Next()
 	}
 }
+
+// takeAccessToken возвращает токен из query, убирая параметр из запроса, или
+// токен, который уже забрал AccessTokenMiddleware.
+func takeAccessToken(c *gin.Context) string {
+	if token := c.GetString(accessTokenKey); token != "" {
+		return token
+	}
+
+	query := c.Request.URL.Query()
+	token := query.Get(accessTokenParam)
+	if token != "" {
+		query.Del(accessTokenParam)
+		c.Request.URL.RawQuery = query.Encode()
+	}
+	return token
+}
+
+func abortUnauthorized(c *gin.Context, err error) {
+	message, ok := authErrorMessages[err]
+	if !ok {
+		message = "invalid token"
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+	c.Abort()
+}
@@ -0,0 +1,137 @@
+package middleware_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newVerifier возвращает Verifier, принимающий токены, подписанные ключом без kid.
+func newVerifier(t *testing.T) (*auth.Verifier, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []any{}})
+	}))
+	t.Cleanup(jwks.Close)
+
+	return auth.NewVerifier(auth.NewJWKSCache(jwks.URL, http.DefaultTransport), &key.PublicKey, nil, nil), key
+}
+
+func accessToken(t *testing.T, key *rsa.PrivateKey, sub, role string, ttl time.Duration) string {
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.Claims{
+		Sub:  sub,
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	verifier, key := newVerifier(t)
+
+	router := gin.New()
+	router.GET("/me", middleware.AuthMiddleware(verifier), func(c *gin.Context) {
+		claims := c.MustGet(auth.ClaimsKey).(*auth.Claims)
+		c.String(http.StatusOK, claims.Sub)
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "valid", header: "Bearer " + accessToken(t, key, "user-1", "user", time.Minute), wantCode: http.StatusOK, wantBody: "user-1"},
+		{name: "no token", wantCode: http.StatusUnauthorized, wantBody: `{"error":"no token provided"}`},
+		{name: "expired", header: "Bearer " + accessToken(t, key, "user-1", "user", -time.Minute), wantCode: http.StatusUnauthorized, wantBody: `{"error":"token expired"}`},
+		{name: "garbage", header: "Bearer garbage", wantCode: http.StatusUnauthorized, wantBody: `{"error":"invalid token"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			require.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestStreamAuthMiddleware_QueryToken(t *testing.T) {
+	verifier, key := newVerifier(t)
+	token := accessToken(t, key, "user-1", "user", time.Minute)
+
+	router := gin.New()
+	router.GET("/events", middleware.StreamAuthMiddleware(verifier), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"query": c.Request.URL.RawQuery, "auth": c.GetHeader("Authorization")})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?access_token="+token+"&course=1", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, "course=1", body["query"])
+	require.Equal(t, "Bearer "+token, body["auth"])
+}
+
+func TestAccessTokenMiddleware_KeepsTokenOutOfLogs(t *testing.T) {
+	verifier, key := newVerifier(t)
+	token := accessToken(t, key, "user-1", "user", time.Minute)
+
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(middleware.AccessTokenMiddleware(), gin.LoggerWithWriter(&logs))
+	router.GET("/events", middleware.StreamAuthMiddleware(verifier), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"query": c.Request.URL.RawQuery, "auth": c.GetHeader("Authorization")})
+	})
+	router.GET("/other", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetHeader("Authorization"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?access_token="+token+"&course=1", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, "course=1", body["query"])
+	require.Equal(t, "Bearer "+token, body["auth"])
+
+	// Обычные маршруты токен из query в Authorization не получают.
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other?access_token="+token, nil))
+	require.Empty(t, w.Body.String())
+
+	require.Contains(t, logs.String(), "/events?course=1")
+	require.NotContains(t, logs.String(), token)
+	require.NotContains(t, logs.String(), "access_token")
+}
@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/cache"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/JojoWeyn/duo-proj/gateway/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cacheHeader = "X-Cache"
+
+	// maxCachedBody - ответы больше этого размера отдаются клиенту без кэширования.
+	maxCachedBody = 1 << 20
+)
+
+// cachedHeaders - заголовки ответа сервиса, которые сохраняются вместе с телом.
+// Остальные (CORS, X-Request-ID, RateLimit-*) выставляются заново на каждый запрос.
+var cachedHeaders = []string{"Content-Type", "Content-Encoding", "Content-Language", "Cache-Control", "Last-Modified", "Vary"}
+
+// CacheMiddleware отдает ответы маршрута из кэша по пути, query и роли
+// пользователя (или самому пользователю, если policy.Key = user). Промах проксируется в сервис, ответ 200 сохраняется на TTL
+// политики. Для ответов 200 вычисляется ETag, и по If-None-Match клиент
+// получает 304. Запрос с Cache-Control: no-cache идет мимо кэша, но
+// обновляет его. При недоступности хранилища запрос обслуживается сервисом.
+func CacheMiddleware(store cache.Store, service, name string, policy routes.CachePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := cache.Key{
+			Service: service,
+			Cache:   name,
+			Path:    c.Request.URL.Path,
+			Variant: c.Request.URL.Query().Encode() + "|" + cacheOwner(c, policy.Key),
+		}
+
+		if !strings.Contains(c.GetHeader("Cache-Control"), "no-cache") {
+			entry, err := store.Get(c.Request.Context(), key)
+			if err != nil {
+				log.Printf("Response cache error: %v", err)
+			}
+			if entry != nil {
+				metrics.ObserveCache(name, metrics.CacheHit)
+				writeCached(c, entry)
+				return
+			}
+		}
+
+		w := &cacheWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if w.passthrough {
+			metrics.ObserveCache(name, metrics.CacheBypass)
+			return
+		}
+
+		entry := w.entry()
+		if w.cacheable() {
+			metrics.ObserveCache(name, metrics.CacheMiss)
+			if err := store.Set(c.Request.Context(), key, entry, policy.TTL); err != nil {
+				log.Printf("Response cache error: %v", err)
+			}
+		} else {
+			metrics.ObserveCache(name, metrics.CacheBypass)
+		}
+
+		c.Header(cacheHeader, "MISS")
+		writeEntry(c, entry)
+	}
+}
+
+// cacheOwner - часть ключа кэша: ответы разных ролей или пользователей хранятся отдельно.
+func cacheOwner(c *gin.Context, key string) string {
+	if value, ok := c.Get(auth.ClaimsKey); ok {
+		if claims, ok := value.(*auth.Claims); ok {
+			if key == routes.CacheKeyUser {
+				return "user:" + claims.Sub
+			}
+			return claims.Role
+		}
+	}
+	return "anonymous"
+}
+
+func writeCached(c *gin.Context, entry *cache.Entry) {
+	for _, name := range cachedHeaders {
+		if values := entry.Header.Values(name); len(values) > 0 {
+			c.Writer.Header()[name] = values
+		}
+	}
+	c.Header(cacheHeader, "HIT")
+	c.Header("Age", strconv.Itoa(int(time.Since(entry.StoredAt).Seconds())))
+	writeEntry(c, entry)
+	c.Abort()
+}
+
+// writeEntry отдает ответ с ETag или 304, если клиент прислал тот же ETag.
+func writeEntry(c *gin.Context, entry *cache.Entry) {
+	if entry.ETag != "" {
+		c.Header("ETag", entry.ETag)
+		if c.Writer.Header().Get("Cache-Control") == "" {
+			c.Header("Cache-Control", "private, no-cache")
+		}
+		if etagMatch(c.GetHeader("If-None-Match"), entry.ETag) {
+			c.Writer.Header().Del("Content-Length")
+			c.Status(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+	}
+
+	c.Writer.Header().Set("Content-Length", strconv.Itoa(len(entry.Body)))
+	c.Writer.WriteHeader(entry.Status)
+	c.Writer.Write(entry.Body)
+}
+
+// etagMatch проверяет If-None-Match по слабому сравнению из RFC 9110.
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheWriter придерживает ответ сервиса, чтобы до отправки клиенту выставить
+// ETag. Если ответ больше maxCachedBody, накопленное отправляется и дальше
+// запись идет напрямую без кэширования.
+type cacheWriter struct {
+	gin.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        []byte
+	passthrough bool
+}
+
+func (w *cacheWriter) WriteHeader(status int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *cacheWriter) WriteHeaderNow() {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(data)
+	}
+	w.wroteHeader = true
+
+	if len(w.body)+len(data) > maxCachedBody {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+		if _, err := w.ResponseWriter.Write(w.body); err != nil {
+			return 0, err
+		}
+		w.body = nil
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.body = append(w.body, data...)
+	return len(data), nil
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *cacheWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *cacheWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return len(w.body)
+}
+
+func (w *cacheWriter) Written() bool {
+	return w.passthrough || w.wroteHeader
+}
+
+// Flush ничего не делает, пока ответ придерживается: ReverseProxy сбрасывает
+// буфер после каждого чтения из сервиса.
+func (w *cacheWriter) Flush() {
+	if w.passthrough {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// cacheable разрешает сохранить только успешный ответ, который сервис не
+// пометил как личный или запрещенный к хранению.
+func (w *cacheWriter) cacheable() bool {
+	if w.status != http.StatusOK {
+		return false
+	}
+	header := w.ResponseWriter.Header()
+	cacheControl := header.Get("Cache-Control")
+	return !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private") &&
+		header.Get("Set-Cookie") == ""
+}
+
+func (w *cacheWriter) entry() *cache.Entry {
+	header := make(http.Header, len(cachedHeaders))
+	for _, name := range cachedHeaders {
+		if values := w.ResponseWriter.Header().Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+
+	entry := &cache.Entry{
+		Status:   w.status,
+		Header:   header,
+		Body:     w.body,
+		StoredAt: time.Now(),
+	}
+	if w.status == http.StatusOK {
+		sum := sha256.Sum256(w.body)
+		entry.ETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+	return entry
+}
@@ -0,0 +1,154 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/cache"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheRouter отвечает телом с номером обращения к сервису, чтобы отличать HIT от MISS.
+func cacheRouter(store cache.Store, key string, upstream func(c *gin.Context)) (*gin.Engine, *int) {
+	calls := 0
+	router := gin.New()
+	router.GET("/course/list", func(c *gin.Context) {
+		if sub := c.GetHeader("X-Test-User"); sub != "" {
+			c.Set(auth.ClaimsKey, &auth.Claims{Sub: sub, Role: c.GetHeader("X-Test-Role")})
+		}
+		c.Next()
+	}, middleware.CacheMiddleware(store, "course", "catalog", routes.CachePolicy{TTL: time.Minute, Key: key}), func(c *gin.Context) {
+		calls++
+		upstream(c)
+	})
+	return router, &calls
+}
+
+func okUpstream(c *gin.Context) {
+	c.Header("Content-Type", "application/json")
+	c.String(http.StatusOK, `{"courses":[]}`)
+}
+
+func get(router http.Handler, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/course/list", nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheMiddleware_HitAndETag(t *testing.T) {
+	router, calls := cacheRouter(cache.NewMemoryStore(100), routes.CacheKeyRole, okUpstream)
+
+	miss := get(router, nil)
+	require.Equal(t, http.StatusOK, miss.Code)
+	require.Equal(t, "MISS", miss.Header().Get("X-Cache"))
+	etag := miss.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	hit := get(router, nil)
+	require.Equal(t, http.StatusOK, hit.Code)
+	require.Equal(t, "HIT", hit.Header().Get("X-Cache"))
+	require.Equal(t, etag, hit.Header().Get("ETag"))
+	require.Equal(t, "application/json", hit.Header().Get("Content-Type"))
+	require.Equal(t, `{"courses":[]}`, hit.Body.String())
+
+	notModified := get(router, map[string]string{"If-None-Match": `W/` + etag})
+	require.Equal(t, http.StatusNotModified, notModified.Code)
+	require.Empty(t, notModified.Body.String())
+
+	refreshed := get(router, map[string]string{"Cache-Control": "no-cache"})
+	require.Equal(t, "MISS", refreshed.Header().Get("X-Cache"))
+	require.Equal(t, 2, *calls)
+}
+
+func TestCacheMiddleware_Variants(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		first     map[string]string
+		second    map[string]string
+		wantCalls int
+	}{
+		{
+			name:      "same role shares entry",
+			key:       routes.CacheKeyRole,
+			first:     map[string]string{"X-Test-User": "1", "X-Test-Role": "user"},
+			second:    map[string]string{"X-Test-User": "2", "X-Test-Role": "user"},
+			wantCalls: 1,
+		},
+		{
+			name:      "roles are separated",
+			key:       routes.CacheKeyRole,
+			first:     map[string]string{"X-Test-User": "1", "X-Test-Role": "user"},
+			second:    map[string]string{"X-Test-User": "2", "X-Test-Role": "admin"},
+			wantCalls: 2,
+		},
+		{
+			name:      "users are separated",
+			key:       routes.CacheKeyUser,
+			first:     map[string]string{"X-Test-User": "1", "X-Test-Role": "user"},
+			second:    map[string]string{"X-Test-User": "2", "X-Test-Role": "user"},
+			wantCalls: 2,
+		},
+		{
+			name:      "anonymous is separated from users",
+			key:       routes.CacheKeyRole,
+			first:     nil,
+			second:    map[string]string{"X-Test-User": "1", "X-Test-Role": "user"},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, calls := cacheRouter(cache.NewMemoryStore(100), tt.key, okUpstream)
+
+			get(router, tt.first)
+			get(router, tt.second)
+
+			require.Equal(t, tt.wantCalls, *calls)
+		})
+	}
+}
+
+func TestCacheMiddleware_NotCacheable(t *testing.T) {
+	tests := []struct {
+		name     string
+		upstream func(c *gin.Context)
+	}{
+		{name: "error status", upstream: func(c *gin.Context) { c.String(http.StatusInternalServerError, "boom") }},
+		{name: "private", upstream: func(c *gin.Context) {
+			c.Header("Cache-Control", "private")
+			c.String(http.StatusOK, "mine")
+		}},
+		{name: "no-store", upstream: func(c *gin.Context) {
+			c.Header("Cache-Control", "no-store")
+			c.String(http.StatusOK, "secret")
+		}},
+		{name: "set-cookie", upstream: func(c *gin.Context) {
+			c.SetCookie("session", "1", 60, "/", "", false, true)
+			c.String(http.StatusOK, "cookie")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, calls := cacheRouter(cache.NewMemoryStore(100), routes.CacheKeyRole, tt.upstream)
+
+			get(router, nil)
+			second := get(router, nil)
+
+			require.Equal(t, "MISS", second.Header().Get("X-Cache"))
+			require.Equal(t, 2, *calls)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/maintenance"
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceRetryAfter - Retry-After, если окончание работ не указано.
+const maintenanceRetryAfter = 5 * time.Minute
+
+// MaintenanceMiddleware в режиме обслуживания отвечает 503 на изменяющие
+// запросы, пропуская чтение и пути из exempt (например, сам переключатель).
+func MaintenanceMiddleware(sw *maintenance.Switch, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := sw.State()
+		if !state.Enabled || isReadMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		for _, path := range exempt {
+			if c.Request.URL.Path == path {
+				c.Next()
+				return
+			}
+		}
+
+		retryAfter := maintenanceRetryAfter
+		if state.Until != nil {
+			retryAfter = max(time.Until(*state.Until), time.Second)
+		}
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+
+		message := state.Message
+		if message == "" {
+			message = "the platform is in read-only maintenance mode"
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": message, "maintenance": true})
+		c.Abort()
+	}
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,49 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/maintenance"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	sw := maintenance.NewSwitch(maintenance.NewMemoryStore())
+	engine := gin.New()
+	engine.Use(middleware.MaintenanceMiddleware(sw, "/v1/admin/gateway/maintenance", "/v1/auth/login"))
+	engine.NoRoute(func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/v1/course/answers").Code, "disabled mode lets changes through")
+
+	until := time.Now().Add(90 * time.Second)
+	require.NoError(t, sw.Set(context.Background(), maintenance.State{Enabled: true, Message: "migrating", Until: &until}))
+
+	w := do(http.MethodPost, "/v1/course/answers")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Contains(t, w.Body.String(), "migrating")
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	require.InDelta(t, 90, retryAfter, 2)
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/v1/course/list").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/v1/auth/login").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/v1/admin/gateway/maintenance").Code)
+
+	require.NoError(t, sw.Set(context.Background(), maintenance.State{Enabled: true}))
+	w = do(http.MethodDelete, "/v1/admin/users/1")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "300", w.Header().Get("Retry-After"))
+}
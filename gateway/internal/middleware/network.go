@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	sharedmiddleware "github.com/JojoWeyn/duo-proj/shared/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type networkRule struct {
+	name     string
+	prefixes []string
+	allow    []*net.IPNet
+	deny     []*net.IPNet
+}
+
+func (r networkRule) applies(path string) bool {
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r networkRule) permits(ip net.IP) bool {
+	if containsIP(r.deny, ip) {
+		return false
+	}
+	return len(r.allow) == 0 || containsIP(r.allow, ip)
+}
+
+// NetworkMiddleware применяет списки сетей таблицы к группам маршрутов до
+// проксирования. Адрес клиента берется из c.ClientIP, поэтому за балансировщиком
+// его адрес должен быть в TrustedProxies. Таблица уже проверена Validate.
+func NetworkMiddleware(table *routes.Table) gin.HandlerFunc {
+	rules := make([]networkRule, 0, len(table.Networks))
+	for name, policy := range table.Networks {
+		rule := networkRule{name: name}
+		for _, prefix := range policy.Prefixes {
+			rule.prefixes = append(rule.prefixes, table.Prefix+prefix)
+		}
+		rule.allow, _ = sharedmiddleware.ParseNetworks(policy.Allow)
+		rule.deny, _ = sharedmiddleware.ParseNetworks(policy.Deny)
+		rules = append(rules, rule)
+	}
+
+	return func(c *gin.Context) {
+		if len(rules) == 0 {
+			c.Next()
+			return
+		}
+
+		ip := net.ParseIP(c.ClientIP())
+		for _, rule := range rules {
+			if rule.applies(c.Request.URL.Path) && (ip == nil || !rule.permits(ip)) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "access from this network is not allowed"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
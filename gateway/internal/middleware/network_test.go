@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkMiddleware(t *testing.T) {
+	table := &routes.Table{
+		Prefix: "/v1",
+		Networks: map[string]routes.NetworkPolicy{
+			"admin": {
+				Prefixes: []string{"/admin/", "/auth/admin/"},
+				Allow:    []string{"10.0.0.0/8"},
+				Deny:     []string{"10.0.0.13"},
+			},
+		},
+	}
+	engine := gin.New()
+	engine.Use(middleware.NetworkMiddleware(table))
+	engine.NoRoute(func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name     string
+		path     string
+		remote   string
+		wantCode int
+	}{
+		{name: "admin from allowed network", path: "/v1/admin/users", remote: "10.1.2.3:5000", wantCode: http.StatusOK},
+		{name: "admin from outside", path: "/v1/admin/users", remote: "203.0.113.5:5000", wantCode: http.StatusForbidden},
+		{name: "identity admin from outside", path: "/v1/auth/admin/identities", remote: "203.0.113.5:5000", wantCode: http.StatusForbidden},
+		{name: "identity admin from allowed network", path: "/v1/auth/admin/identities", remote: "10.1.2.3:5000", wantCode: http.StatusOK},
+		{name: "denied address inside allowed network", path: "/v1/admin/users", remote: "10.0.0.13:5000", wantCode: http.StatusForbidden},
+		{name: "other routes from anywhere", path: "/v1/auth/login", remote: "203.0.113.5:5000", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remote
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			require.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
@@ -1,15 +1,38 @@
 package middleware
 
 import (
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/ratelimit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
 )
 
-func RateLimitMiddleware(limiter *rate.Limiter) gin.HandlerFunc {
+// RateLimitMiddleware ограничивает запросы по политике name. Корзина выбирается
+// по IP клиента, UUID пользователя или общая на группу маршрутов.
+// При недоступности хранилища запрос пропускается, чтобы не блокировать вход.
+func RateLimitMiddleware(limiter ratelimit.Limiter, name string, policy routes.RateLimitPolicy) gin.HandlerFunc {
+	p := ratelimit.Policy{Every: policy.Every, Burst: policy.Burst}
+
 	return func(c *gin.Context) {
-		if !limiter.Allow() {
+		result, err := limiter.Allow(c.Request.Context(), rateLimitKey(c, name, policy.Key), p)
+		if err != nil {
+			log.Printf("Rate limiter error: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error": "too many attempts, please try again later",
 			})
@@ -19,3 +42,21 @@ func RateLimitMiddleware(limiter *rate.Limiter) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func rateLimitKey(c *gin.Context, name, key string) string {
+	switch key {
+	case routes.RateLimitKeyRoute:
+		return name
+	case routes.RateLimitKeyUser:
+		if value, ok := c.Get(auth.ClaimsKey); ok {
+			if claims, ok := value.(*auth.Claims); ok {
+				return name + ":user:" + claims.Sub
+			}
+		}
+	}
+	return name + ":ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/ratelimit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLimiter запоминает ключи корзин и разрешает первые allow запросов.
+type recordingLimiter struct {
+	keys  []string
+	allow int
+	err   error
+}
+
+func (l *recordingLimiter) Allow(_ context.Context, key string, policy ratelimit.Policy) (ratelimit.Result, error) {
+	l.keys = append(l.keys, key)
+	if l.err != nil {
+		return ratelimit.Result{}, l.err
+	}
+	if len(l.keys) > l.allow {
+		return ratelimit.Result{Limit: policy.Burst, Reset: 1500 * time.Millisecond, RetryAfter: 1500 * time.Millisecond}, nil
+	}
+	return ratelimit.Result{Allowed: true, Limit: policy.Burst, Remaining: policy.Burst - len(l.keys)}, nil
+}
+
+func rateLimitRouter(limiter ratelimit.Limiter, key string, claims *auth.Claims) *gin.Engine {
+	router := gin.New()
+	router.GET("/x", func(c *gin.Context) {
+		if claims != nil {
+			c.Set(auth.ClaimsKey, claims)
+		}
+		c.Next()
+	}, middleware.RateLimitMiddleware(limiter, "answers", routes.RateLimitPolicy{Every: time.Second, Burst: 5, Key: key}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRateLimitMiddleware_Keys(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		claims  *auth.Claims
+		wantKey string
+	}{
+		{name: "ip", key: routes.RateLimitKeyIP, wantKey: "answers:ip:192.0.2.1"},
+		{name: "user", key: routes.RateLimitKeyUser, claims: &auth.Claims{Sub: "user-1"}, wantKey: "answers:user:user-1"},
+		{name: "route", key: routes.RateLimitKeyRoute, wantKey: "answers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &recordingLimiter{allow: 1}
+			req := httptest.NewRequest(http.MethodGet, "/x", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+
+			w := httptest.NewRecorder()
+			rateLimitRouter(limiter, tt.key, tt.claims).ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, []string{tt.wantKey}, limiter.keys)
+			require.Equal(t, "5", w.Header().Get("RateLimit-Limit"))
+			require.Equal(t, "4", w.Header().Get("RateLimit-Remaining"))
+		})
+	}
+}
+
+func TestRateLimitMiddleware_TooManyRequests(t *testing.T) {
+	router := rateLimitRouter(&recordingLimiter{allow: 0}, routes.RateLimitKeyIP, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddleware_StoreError(t *testing.T) {
+	router := rateLimitRouter(&recordingLimiter{err: errors.New("redis is down")}, routes.RateLimitKeyIP, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+}
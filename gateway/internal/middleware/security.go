@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// apiCSP запрещает браузеру исполнять и встраивать ответы API.
+	apiCSP = "default-src 'none'; frame-ancestors 'none'"
+	// swaggerCSP пропускает встроенные скрипты и стили Swagger UI, но не внешние ресурсы.
+	swaggerCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+)
+
+// SecurityPolicy - политика CORS и заголовков безопасности gateway для окружения.
+type SecurityPolicy struct {
+	// AllowOrigins - разрешенные Origin, "*" - любой, "https://*.example.com" - поддомены.
+	AllowOrigins     []string
+	AllowCredentials bool
+	// ExposeHeaders дополняют заголовки, которые gateway всегда открывает клиенту.
+	ExposeHeaders []string
+	// HSTSMaxAge - срок Strict-Transport-Security, 0 отключает заголовок.
+	HSTSMaxAge time.Duration
+}
+
+// exposeHeaders - заголовки ответов gateway, нужные клиентам в браузере.
+var exposeHeaders = []string{
+	"Authorization", "ETag", "X-Request-ID", "X-Cache", "Retry-After",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+	"X-Quota-Day-Remaining", "X-Quota-Month-Remaining",
+}
+
+// Validate проверяет политику до запуска: cors.New паникует на ошибках в Origin,
+// а браузеры не принимают credentials вместе с любым Origin.
+func (p SecurityPolicy) Validate() error {
+	if len(p.AllowOrigins) == 0 {
+		return errors.New("at least one allowed origin is required")
+	}
+	for _, origin := range p.AllowOrigins {
+		if origin == "*" && p.AllowCredentials {
+			return errors.New("credentials cannot be allowed for any origin")
+		}
+		if origin != "*" && strings.Count(origin, "*") > 1 {
+			return errors.New("origin " + origin + ": only one * is allowed")
+		}
+	}
+	if p.HSTSMaxAge < 0 {
+		return errors.New("hsts max age must not be negative")
+	}
+	return p.corsConfig().Validate()
+}
+
+func (p SecurityPolicy) corsConfig() cors.Config {
+	config := cors.DefaultConfig()
+	config.AllowOrigins = p.AllowOrigins
+	config.AllowWildcard = true
+	config.AllowCredentials = p.AllowCredentials
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "If-None-Match", "Cache-Control", "X-Request-ID", "X-API-Key", "X-Device-Name"}
+	config.ExposeHeaders = append(append([]string{}, exposeHeaders...), p.ExposeHeaders...)
+	return config
+}
+
+// CORSMiddleware отвечает на preflight и выставляет заголовки CORS по политике.
+// Запросы с Origin вне списка отклоняются с 403.
+func CORSMiddleware(policy SecurityPolicy) gin.HandlerFunc {
+	return cors.New(policy.corsConfig())
+}
+
+// SecurityHeadersMiddleware добавляет к ответам стандартные заголовки безопасности.
+// Swagger UI получает CSP, допускающий его встроенные скрипты, остальные ответы - строгий.
+func SecurityHeadersMiddleware(policy SecurityPolicy) gin.HandlerFunc {
+	var hsts string
+	if policy.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(policy.HSTSMaxAge.Seconds())) + "; includeSubDomains"
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		if strings.HasPrefix(c.Request.URL.Path, "/swagger/") {
+			header.Set("Content-Security-Policy", swaggerCSP)
+		} else {
+			header.Set("Content-Security-Policy", apiCSP)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,166 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func securityEngine(policy middleware.SecurityPolicy) *gin.Engine {
+	engine := gin.New()
+	engine.Use(middleware.SecurityHeadersMiddleware(policy), middleware.CORSMiddleware(policy))
+	engine.GET("/v1/course/list", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/swagger/index.html", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func TestCORSMiddleware_Origins(t *testing.T) {
+	engine := securityEngine(middleware.SecurityPolicy{
+		AllowOrigins: []string{"https://app.example.com", "https://*.example.org"},
+	})
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantCode   int
+		wantOrigin string
+	}{
+		{name: "listed origin", origin: "https://app.example.com", wantCode: http.StatusOK, wantOrigin: "https://app.example.com"},
+		{name: "wildcard subdomain", origin: "https://admin.example.org", wantCode: http.StatusOK, wantOrigin: "https://admin.example.org"},
+		{name: "unknown origin", origin: "https://evil.example.net", wantCode: http.StatusForbidden},
+		{name: "no origin", wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/course/list", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			require.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestCORSMiddleware_Preflight(t *testing.T) {
+	engine := securityEngine(middleware.SecurityPolicy{AllowOrigins: []string{"https://app.example.com"}})
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/course/list", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	req.Header.Set("Access-Control-Request-Headers", "Authorization, If-None-Match")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPut)
+	require.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+	require.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "If-None-Match")
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSMiddleware_CredentialsEchoOrigin(t *testing.T) {
+	engine := securityEngine(middleware.SecurityPolicy{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Custom"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/course/list", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	require.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Request-Id")
+	require.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Custom")
+}
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   middleware.SecurityPolicy
+		path     string
+		wantHSTS string
+		wantCSP  string
+	}{
+		{
+			name:     "api with hsts",
+			policy:   middleware.SecurityPolicy{AllowOrigins: []string{"*"}, HSTSMaxAge: 365 * 24 * time.Hour},
+			path:     "/v1/course/list",
+			wantHSTS: "max-age=31536000; includeSubDomains",
+			wantCSP:  "default-src 'none'; frame-ancestors 'none'",
+		},
+		{
+			name:    "swagger without hsts",
+			policy:  middleware.SecurityPolicy{AllowOrigins: []string{"*"}},
+			path:    "/swagger/index.html",
+			wantCSP: "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			securityEngine(tt.policy).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			require.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+			require.Equal(t, "strict-origin-when-cross-origin", w.Header().Get("Referrer-Policy"))
+			require.Equal(t, tt.wantHSTS, w.Header().Get("Strict-Transport-Security"))
+			require.Equal(t, tt.wantCSP, w.Header().Get("Content-Security-Policy"))
+		})
+	}
+}
+
+func TestSecurityHeadersMiddleware_RejectedOrigin(t *testing.T) {
+	engine := securityEngine(middleware.SecurityPolicy{AllowOrigins: []string{"https://app.example.com"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/course/list", nil)
+	req.Header.Set("Origin", "https://evil.example.net")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+}
+
+func TestSecurityPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  middleware.SecurityPolicy
+		wantErr string
+	}{
+		{name: "explicit origins with credentials", policy: middleware.SecurityPolicy{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true}},
+		{name: "any origin", policy: middleware.SecurityPolicy{AllowOrigins: []string{"*"}}},
+		{name: "no origins", policy: middleware.SecurityPolicy{}, wantErr: "at least one allowed origin is required"},
+		{name: "credentials with any origin", policy: middleware.SecurityPolicy{AllowOrigins: []string{"*"}, AllowCredentials: true}, wantErr: "credentials cannot be allowed for any origin"},
+		{name: "two wildcards", policy: middleware.SecurityPolicy{AllowOrigins: []string{"https://*.*.example.com"}}, wantErr: "only one * is allowed"},
+		{name: "negative hsts", policy: middleware.SecurityPolicy{AllowOrigins: []string{"*"}, HSTSMaxAge: -time.Second}, wantErr: "hsts max age must not be negative"},
+		{name: "origin without scheme", policy: middleware.SecurityPolicy{AllowOrigins: []string{"app.example.com"}}, wantErr: "bad origin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
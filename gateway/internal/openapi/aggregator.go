@@ -0,0 +1,151 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+)
+
+const specPath = "/swagger/doc.json"
+
+type cachedSpec struct {
+	spec      *swaggerSpec
+	fetchedAt time.Time
+}
+
+// builtDocument - документ, собранный для конкретной таблицы маршрутов.
+type builtDocument struct {
+	table   *routes.Table
+	doc     *Document
+	issues  []Issue
+	builtAt time.Time
+}
+
+// Aggregator подтягивает спецификации сервисов через их upstream и собирает
+// из них единый документ. Спецификации и собранный документ кэшируются на ttl,
+// а при недоступности сервиса используется последняя полученная версия.
+// Перезагрузка маршрутов дает новую таблицу, и документ собирается заново.
+type Aggregator struct {
+	upstreams *upstream.Registry
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	specs map[string]cachedSpec
+
+	buildMu sync.Mutex
+	built   builtDocument
+}
+
+func NewAggregator(upstreams *upstream.Registry, ttl time.Duration) *Aggregator {
+	return &Aggregator{
+		upstreams: upstreams,
+		ttl:       ttl,
+		specs:     make(map[string]cachedSpec),
+	}
+}
+
+// Build возвращает документ для таблицы маршрутов и найденные расхождения.
+// Документ только для чтения: он общий для всех запросов, пока не устареет.
+func (a *Aggregator) Build(ctx context.Context, table *routes.Table) (*Document, []Issue) {
+	a.buildMu.Lock()
+	defer a.buildMu.Unlock()
+
+	if a.built.table == table && time.Since(a.built.builtAt) < a.ttl {
+		return a.built.doc, a.built.issues
+	}
+
+	doc, issues := a.build(ctx, table)
+	a.built = builtDocument{table: table, doc: doc, issues: issues, builtAt: time.Now()}
+	return doc, issues
+}
+
+func (a *Aggregator) build(ctx context.Context, table *routes.Table) (*Document, []Issue) {
+	specs := make(map[string]*swaggerSpec)
+	var issues []Issue
+
+	for _, service := range services(table) {
+		spec, err := a.spec(ctx, service)
+		if err != nil {
+			issues = append(issues, Issue{Kind: IssueSpecUnavailable, Service: service, Detail: err.Error()})
+		}
+		if spec != nil {
+			specs[service] = spec
+		}
+	}
+
+	doc, mergeIssues := Merge(table, specs)
+	return doc, append(issues, mergeIssues...)
+}
+
+func (a *Aggregator) spec(ctx context.Context, service string) (*swaggerSpec, error) {
+	a.mu.Lock()
+	cached, ok := a.specs[service]
+	a.mu.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < a.ttl {
+		return cached.spec, nil
+	}
+
+	spec, err := a.fetch(ctx, service)
+	if err != nil {
+		return cached.spec, err
+	}
+
+	a.mu.Lock()
+	a.specs[service] = cachedSpec{spec: spec, fetchedAt: time.Now()}
+	a.mu.Unlock()
+
+	return spec, nil
+}
+
+func (a *Aggregator) fetch(ctx context.Context, service string) (*swaggerSpec, error) {
+	target, ok := a.upstreams.Get(service)
+	if !ok {
+		return nil, fmt.Errorf("unknown service")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL.JoinPath(specPath).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := target.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var spec swaggerSpec
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to decode spec: %w", err)
+	}
+
+	return &spec, nil
+}
+
+func services(table *routes.Table) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range table.Routes {
+		if !seen[r.Service] {
+			seen[r.Service] = true
+			names = append(names, r.Service)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
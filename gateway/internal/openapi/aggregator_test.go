@@ -0,0 +1,196 @@
+package openapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/openapi"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/stretchr/testify/require"
+)
+
+const userSpec = `{
+  "basePath": "/v1",
+  "paths": {
+    "/users/{id}": {
+      "get": {
+        "summary": "Профиль",
+        "parameters": [{"name": "id", "in": "path", "type": "string", "required": true}],
+        "responses": {"200": {"schema": {"$ref": "#/definitions/dto.Response"}}}
+      }
+    },
+    "/shared": {"get": {"summary": "user shared", "responses": {"200": {}}}}
+  },
+  "definitions": {
+    "dto.Response": {"type": "object", "properties": {"name": {"type": "string"}}}
+  }
+}`
+
+const courseSpec = `{
+  "basePath": "/v1",
+  "paths": {
+    "/course/list": {
+      "get": {
+        "summary": "Курсы",
+        "responses": {"200": {"schema": {"type": "array", "items": {"$ref": "#/definitions/dto.Response"}}}}
+      }
+    },
+    "/shared": {"get": {"summary": "course shared", "responses": {"200": {}}}}
+  },
+  "definitions": {
+    "dto.Response": {"type": "object", "properties": {"title": {"type": "string"}}}
+  }
+}`
+
+const table = `
+routes:
+  - { method: GET, path: /users/:uuid, service: user, auth: true }
+  - { method: GET, path: /shared, service: course }
+  - { method: GET, path: /course/list, service: course }
+  - { method: DELETE, path: /course/:id, service: course, auth: true, roles: [admin] }
+`
+
+func specServer(t *testing.T, spec string, hits *int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/swagger/doc.json" {
+			http.NotFound(w, r)
+			return
+		}
+		*hits++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(spec))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newAggregator(t *testing.T, ttl time.Duration, servers map[string]string) *openapi.Aggregator {
+	t.Helper()
+
+	services := make(map[string]upstream.ServiceConfig, len(servers))
+	for name, url := range servers {
+		services[name] = upstream.ServiceConfig{URLs: []string{url}}
+	}
+	registry, err := upstream.NewRegistry(services, upstream.Config{BreakerThreshold: 5, BreakerCooldown: time.Second})
+	require.NoError(t, err)
+
+	return openapi.NewAggregator(registry, ttl)
+}
+
+func parseTable(t *testing.T, data string) *routes.Table {
+	t.Helper()
+
+	parsed, err := routes.Parse([]byte(data))
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestBuild_Merge(t *testing.T) {
+	var userHits, courseHits int
+	aggregator := newAggregator(t, time.Minute, map[string]string{
+		"user":   specServer(t, userSpec, &userHits).URL,
+		"course": specServer(t, courseSpec, &courseHits).URL,
+	})
+
+	doc, issues := aggregator.Build(context.Background(), parseTable(t, table))
+
+	t.Run("schemas are prefixed with service", func(t *testing.T) {
+		schemas := doc.Components["schemas"].(map[string]any)
+		require.Contains(t, schemas, "user.dto.Response")
+		require.Contains(t, schemas, "course.dto.Response")
+
+		op := doc.Paths["/v1/course/list"]["get"]
+		schema := op["responses"].(map[string]any)["200"].(map[string]any)["content"].(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)
+		require.Equal(t, "#/components/schemas/course.dto.Response", schema["items"].(map[string]any)["$ref"])
+	})
+
+	t.Run("path params take gateway names", func(t *testing.T) {
+		op := doc.Paths["/v1/users/{uuid}"]["get"]
+		require.Equal(t, "Профиль", op["summary"])
+		param := op["parameters"].([]any)[0].(map[string]any)
+		require.Equal(t, "uuid", param["name"])
+		require.NotEmpty(t, op["security"])
+	})
+
+	t.Run("path documented by both services is taken from the routed one", func(t *testing.T) {
+		op := doc.Paths["/v1/shared"]["get"]
+		require.Equal(t, "course shared", op["summary"])
+		require.Equal(t, "course", op["x-service"])
+	})
+
+	t.Run("issues", func(t *testing.T) {
+		require.Equal(t, []openapi.Issue{
+			{Kind: openapi.IssueUndocumented, Service: "course", Method: "DELETE", Path: "/v1/course/:id"},
+			{Kind: openapi.IssueNotRouted, Service: "user", Method: "GET", Path: "/v1/shared"},
+		}, issues)
+	})
+
+	require.Contains(t, doc.Paths, "/v1/dashboard")
+}
+
+func TestBuild_SpecUnavailable(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	var userHits int
+	aggregator := newAggregator(t, time.Minute, map[string]string{
+		"user":   specServer(t, userSpec, &userHits).URL,
+		"course": failing.URL,
+	})
+
+	doc, issues := aggregator.Build(context.Background(), parseTable(t, table))
+
+	require.Equal(t, openapi.IssueSpecUnavailable, issues[0].Kind)
+	require.Equal(t, "course", issues[0].Service)
+	// Без спецификации маршрут все равно попадает в документ, но не считается расхождением.
+	require.Equal(t, "Нет описания в спецификации сервиса", doc.Paths["/v1/course/list"]["get"]["summary"])
+	for _, issue := range issues[1:] {
+		require.NotEqual(t, "course", issue.Service)
+	}
+}
+
+func TestBuild_Cache(t *testing.T) {
+	var userHits, courseHits int
+	servers := map[string]string{
+		"user":   specServer(t, userSpec, &userHits).URL,
+		"course": specServer(t, courseSpec, &courseHits).URL,
+	}
+
+	t.Run("same table reuses document", func(t *testing.T) {
+		aggregator := newAggregator(t, time.Minute, servers)
+		current := parseTable(t, table)
+
+		first, _ := aggregator.Build(context.Background(), current)
+		second, _ := aggregator.Build(context.Background(), current)
+		require.Same(t, first, second)
+	})
+
+	t.Run("reloaded table rebuilds document", func(t *testing.T) {
+		aggregator := newAggregator(t, time.Minute, servers)
+
+		first, _ := aggregator.Build(context.Background(), parseTable(t, table))
+		second, _ := aggregator.Build(context.Background(), parseTable(t, table))
+		require.NotSame(t, first, second)
+	})
+
+	t.Run("expired document is rebuilt", func(t *testing.T) {
+		aggregator := newAggregator(t, 20*time.Millisecond, servers)
+		current := parseTable(t, table)
+		userHits = 0
+
+		first, _ := aggregator.Build(context.Background(), current)
+		time.Sleep(30 * time.Millisecond)
+		second, _ := aggregator.Build(context.Background(), current)
+
+		require.NotSame(t, first, second)
+		require.Equal(t, 2, userHits)
+	})
+}
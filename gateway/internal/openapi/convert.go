@@ -0,0 +1,238 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// swaggerSpec - Swagger 2.0 документ сервиса в том виде, в каком его отдает swaggo.
+type swaggerSpec struct {
+	Info        map[string]any                       `json:"info"`
+	BasePath    string                               `json:"basePath"`
+	Consumes    []string                             `json:"consumes"`
+	Produces    []string                             `json:"produces"`
+	Paths       map[string]map[string]map[string]any `json:"paths"`
+	Definitions map[string]any                       `json:"definitions"`
+}
+
+// converter переводит операции и схемы сервиса из Swagger 2.0 в OpenAPI 3.
+// Имена схем получают префикс сервиса, чтобы dto разных сервисов не пересекались.
+type converter struct {
+	service string
+	spec    *swaggerSpec
+}
+
+func (c converter) schemaName(name string) string {
+	return c.service + "." + name
+}
+
+func (c converter) schemas() map[string]any {
+	schemas := make(map[string]any, len(c.spec.Definitions))
+	for name, schema := range c.spec.Definitions {
+		schemas[c.schemaName(name)] = c.schema(schema)
+	}
+	return schemas
+}
+
+// schema копирует схему, переписывая ссылки #/definitions на #/components/schemas.
+func (c converter) schema(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			switch {
+			case key == "$ref":
+				ref, _ := value.(string)
+				if name, ok := strings.CutPrefix(ref, "#/definitions/"); ok {
+					ref = "#/components/schemas/" + c.schemaName(name)
+				}
+				out[key] = ref
+			case key == "type" && value == "file":
+				out["type"] = "string"
+				out["format"] = "binary"
+			case key == "x-nullable":
+				out["nullable"] = value
+			default:
+				out[key] = c.schema(value)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = c.schema(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (c converter) operation(op map[string]any) map[string]any {
+	out := make(map[string]any)
+	for _, key := range []string{"summary", "description", "tags", "deprecated"} {
+		if value, ok := op[key]; ok {
+			out[key] = value
+		}
+	}
+
+	consumes := mediaTypes(op["consumes"], c.spec.Consumes)
+	produces := mediaTypes(op["produces"], c.spec.Produces)
+
+	var (
+		parameters []any
+		form       = map[string]any{}
+		formFields []string
+		required   []any
+		multipart  bool
+	)
+	for _, raw := range asSlice(op["parameters"]) {
+		p, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch p["in"] {
+		case "body":
+			content := make(map[string]any, len(consumes))
+			for _, ct := range consumes {
+				content[ct] = map[string]any{"schema": c.schema(p["schema"])}
+			}
+			body := map[string]any{"content": content}
+			copyKeys(body, p, "description", "required")
+			out["requestBody"] = body
+		case "formData":
+			name, _ := p["name"].(string)
+			field := c.simpleSchema(p)
+			copyKeys(field, p, "description")
+			form[name] = field
+			formFields = append(formFields, name)
+			if p["type"] == "file" {
+				multipart = true
+			}
+			if p["required"] == true {
+				required = append(required, name)
+			}
+		default:
+			param := map[string]any{"schema": c.simpleSchema(p)}
+			copyKeys(param, p, "name", "in", "description", "required")
+			if p["in"] == "path" {
+				param["required"] = true
+			}
+			parameters = append(parameters, param)
+		}
+	}
+
+	if len(formFields) > 0 {
+		ct := "application/x-www-form-urlencoded"
+		if multipart || contains(consumes, "multipart/form-data") {
+			ct = "multipart/form-data"
+		}
+		schema := map[string]any{"type": "object", "properties": form}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		out["requestBody"] = map[string]any{
+			"content": map[string]any{ct: map[string]any{"schema": schema}},
+		}
+	}
+	if len(parameters) > 0 {
+		out["parameters"] = parameters
+	}
+
+	responses := make(map[string]any)
+	for code, raw := range asMap(op["responses"]) {
+		r := asMap(raw)
+		resp := map[string]any{"description": responseDescription(code, r["description"])}
+		if schema, ok := r["schema"]; ok {
+			content := make(map[string]any, len(produces))
+			for _, ct := range produces {
+				content[ct] = map[string]any{"schema": c.schema(schema)}
+			}
+			resp["content"] = content
+		}
+		if headers := asMap(r["headers"]); len(headers) > 0 {
+			converted := make(map[string]any, len(headers))
+			for name, h := range headers {
+				header := map[string]any{"schema": c.simpleSchema(asMap(h))}
+				copyKeys(header, asMap(h), "description")
+				converted[name] = header
+			}
+			resp["headers"] = converted
+		}
+		responses[code] = resp
+	}
+	out["responses"] = responses
+
+	return out
+}
+
+// simpleSchema собирает схему для параметра не из body: в Swagger 2.0 тип
+// описывается прямо в параметре, а в OpenAPI 3 - в его schema.
+func (c converter) simpleSchema(p map[string]any) map[string]any {
+	schema := make(map[string]any)
+	for _, key := range []string{"type", "format", "items", "enum", "default", "minimum", "maximum"} {
+		if value, ok := p[key]; ok {
+			schema[key] = c.schema(value)
+		}
+	}
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	}
+	return schema
+}
+
+func responseDescription(code string, description any) string {
+	if s, ok := description.(string); ok && s != "" {
+		return s
+	}
+	if status, err := strconv.Atoi(code); err == nil && http.StatusText(status) != "" {
+		return http.StatusText(status)
+	}
+	return code
+}
+
+func mediaTypes(op any, fallback []string) []string {
+	var types []string
+	for _, v := range asSlice(op) {
+		if s, ok := v.(string); ok {
+			types = append(types, s)
+		}
+	}
+	if len(types) == 0 {
+		types = fallback
+	}
+	if len(types) == 0 {
+		types = []string{"application/json"}
+	}
+	return types
+}
+
+func copyKeys(dst, src map[string]any, keys ...string) {
+	for _, key := range keys {
+		if value, ok := src[key]; ok {
+			dst[key] = value
+		}
+	}
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
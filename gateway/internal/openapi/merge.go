@@ -0,0 +1,321 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+)
+
+type Document struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       map[string]any                       `json:"info"`
+	Servers    []map[string]any                     `json:"servers,omitempty"`
+	Paths      map[string]map[string]map[string]any `json:"paths"`
+	Components map[string]any                       `json:"components"`
+}
+
+const (
+	// IssueUndocumented - маршрут gateway, которого нет в спецификации сервиса.
+	IssueUndocumented = "undocumented_route"
+	// IssueNotRouted - операция сервиса, до которой нельзя дойти через gateway.
+	IssueNotRouted = "not_routed"
+	// IssueSpecUnavailable - спецификацию сервиса не удалось получить.
+	IssueSpecUnavailable = "spec_unavailable"
+)
+
+// Issue - расхождение между таблицей маршрутов и спецификациями сервисов.
+type Issue struct {
+	Kind    string `json:"kind"`
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func (i Issue) String() string {
+	if i.Method == "" {
+		return fmt.Sprintf("%s: %s %s", i.Kind, i.Service, i.Detail)
+	}
+	return fmt.Sprintf("%s: %s %s %s", i.Kind, i.Service, i.Method, i.Path)
+}
+
+const (
+	bearerScheme = "bearerAuth"
+	apiKeyScheme = "apiKeyAuth"
+)
+
+type serviceOperation struct {
+	path      string
+	operation map[string]any
+}
+
+// Merge собирает единый OpenAPI 3 документ по таблице маршрутов: в него попадают
+// только маршруты gateway с публичными путями, а описание операции берется из
+// спецификации сервиса. Попутно таблица сверяется со спецификациями.
+func Merge(table *routes.Table, specs map[string]*swaggerSpec) (*Document, []Issue) {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: map[string]any{
+			"title":   "Duo API",
+			"version": "1.0",
+		},
+		Servers: []map[string]any{{"url": "/"}},
+		Paths:   make(map[string]map[string]map[string]any),
+		Components: map[string]any{
+			"securitySchemes": map[string]any{
+				bearerScheme: map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				apiKeyScheme: map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+
+	var issues []Issue
+	schemas := make(map[string]any)
+	operations := make(map[string]map[string]serviceOperation, len(specs))
+	for service, spec := range specs {
+		c := converter{service: service, spec: spec}
+		for name, schema := range c.schemas() {
+			schemas[name] = schema
+		}
+
+		ops := make(map[string]serviceOperation)
+		for path, methods := range spec.Paths {
+			full := strings.TrimSuffix(spec.BasePath, "/") + path
+			for method, op := range methods {
+				method = strings.ToUpper(method)
+				ops[operationKey(method, full)] = serviceOperation{path: full, operation: c.operation(op)}
+			}
+		}
+		operations[service] = ops
+	}
+	doc.Components["schemas"] = schemas
+
+	routed := make(map[string]bool)
+	for _, r := range table.Routes {
+		path := table.Prefix + r.Path
+		key := operationKey(r.Method, path)
+
+		// Для сервиса без спецификации расхождения не считаются, о нем сообщает Aggregator.
+		ops := operations[r.Service]
+
+		var op map[string]any
+		if found, ok := ops[key]; ok {
+			op = found.operation
+			renamePathParams(op, found.path, path)
+			routed[r.Service+" "+key] = true
+		} else {
+			if ops != nil {
+				issues = append(issues, Issue{Kind: IssueUndocumented, Service: r.Service, Method: r.Method, Path: path})
+			}
+			op = undocumentedOperation(path)
+		}
+
+		describeAccess(op, table, r)
+
+		openAPIPath := toOpenAPIPath(path)
+		if doc.Paths[openAPIPath] == nil {
+			doc.Paths[openAPIPath] = make(map[string]map[string]any)
+		}
+		doc.Paths[openAPIPath][strings.ToLower(r.Method)] = op
+	}
+
+	doc.Paths[table.Prefix+"/dashboard"] = map[string]map[string]any{"get": dashboardOperation(table)}
+
+	for service, ops := range operations {
+		for key, op := range ops {
+			if !routed[service+" "+key] {
+				method, _, _ := strings.Cut(key, " ")
+				issues = append(issues, Issue{Kind: IssueNotRouted, Service: service, Method: method, Path: op.path})
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+
+	return doc, issues
+}
+
+// describeAccess дописывает в операцию требования gateway: токен, роли, права и лимиты.
+func describeAccess(op map[string]any, table *routes.Table, r routes.Route) {
+	responses, _ := op["responses"].(map[string]any)
+	if responses == nil {
+		responses = make(map[string]any)
+		op["responses"] = responses
+	}
+	addResponse := func(code, description string) {
+		if _, ok := responses[code]; !ok {
+			responses[code] = map[string]any{"description": description}
+		}
+	}
+
+	if r.Auth {
+		op["security"] = []any{map[string]any{bearerScheme: []any{}}}
+		addResponse("401", "Токен отсутствует, просрочен или отозван")
+		if r.APIKeyScope != "" {
+			op["security"] = []any{map[string]any{bearerScheme: []any{}}, map[string]any{apiKeyScheme: []any{}}}
+			op["x-api-key-scope"] = r.APIKeyScope
+			addResponse("429", "Превышен лимит запросов")
+		}
+	} else {
+		op["security"] = []any{}
+	}
+	if len(r.Roles) > 0 || len(r.Permissions) > 0 {
+		addResponse("403", "Недостаточно прав")
+	}
+	if r.RateLimit != "" {
+		addResponse("429", "Превышен лимит запросов")
+	}
+
+	op["x-service"] = r.Service
+	op["x-protected"] = r.Protected
+	if len(r.Roles) > 0 {
+		op["x-roles"] = r.Roles
+	}
+	if len(r.Permissions) > 0 {
+		op["x-permissions"] = r.Permissions
+	}
+	if r.Stream {
+		op["x-stream"] = map[string]any{
+			"idle_timeout":    table.Streams.IdleTimeout.String(),
+			"max_connections": table.Streams.MaxConnections,
+			"max_per_user":    table.Streams.MaxPerUser,
+		}
+	}
+	if r.Cache != "" {
+		op["x-cache"] = map[string]any{
+			"name": r.Cache,
+			"ttl":  table.Caches[r.Cache].TTL.String(),
+			"key":  table.Caches[r.Cache].Key,
+		}
+	}
+	if r.RateLimit != "" {
+		policy := table.RateLimits[r.RateLimit]
+		op["x-rate-limit"] = map[string]any{
+			"name":  r.RateLimit,
+			"every": policy.Every.String(),
+			"burst": policy.Burst,
+			"key":   policy.Key,
+		}
+	}
+}
+
+// dashboardOperation описывает агрегированный ответ, который gateway собирает сам.
+func dashboardOperation(table *routes.Table) map[string]any {
+	op := map[string]any{
+		"summary": "Данные главного экрана",
+		"description": "Профиль, серия, прогресс с названиями курсов, уроков и упражнений, достижения и каталог курсов одним ответом. " +
+			"Если часть сервисов не ответила, она равна null, а причина указана в errors.",
+		"tags":     []any{"Dashboard"},
+		"security": []any{map[string]any{bearerScheme: []any{}}},
+		"responses": map[string]any{
+			"200": map[string]any{"description": "OK"},
+			"401": map[string]any{"description": "Токен отсутствует, просрочен или отозван"},
+			"502": map[string]any{"description": "Ни один сервис не ответил"},
+		},
+		"x-timeout": table.Dashboard.Timeout.String(),
+	}
+	if name := table.Dashboard.Cache; name != "" {
+		op["x-cache"] = map[string]any{
+			"name": name,
+			"ttl":  table.Caches[name].TTL.String(),
+			"key":  table.Caches[name].Key,
+		}
+	}
+	return op
+}
+
+func undocumentedOperation(path string) map[string]any {
+	op := map[string]any{
+		"summary":   "Нет описания в спецификации сервиса",
+		"responses": map[string]any{"default": map[string]any{"description": "Ответ сервиса"}},
+	}
+
+	var parameters []any
+	for _, name := range pathParams(path) {
+		parameters = append(parameters, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	return op
+}
+
+// renamePathParams переименовывает параметры пути сервиса в имена из маршрута
+// gateway: шаблоны совпадают по позициям, но имена могут отличаться.
+func renamePathParams(op map[string]any, servicePath, gatewayPath string) {
+	from, to := pathParams(servicePath), pathParams(gatewayPath)
+	if len(from) != len(to) {
+		return
+	}
+
+	names := make(map[string]string, len(from))
+	for i := range from {
+		names[from[i]] = to[i]
+	}
+	for _, raw := range asSlice(op["parameters"]) {
+		p := asMap(raw)
+		if p["in"] != "path" {
+			continue
+		}
+		name, _ := p["name"].(string)
+		if renamed, ok := names[name]; ok {
+			p["name"] = renamed
+		}
+	}
+}
+
+// operationKey сводит пути gin (:id, *path) и OpenAPI ({id}) к общему виду,
+// чтобы сравнивать шаблоны без учета имен параметров.
+func operationKey(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if isParam(s) {
+			segments[i] = "{}"
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, s := range strings.Split(path, "/") {
+		if isParam(s) {
+			names = append(names, strings.Trim(s, ":*{}"))
+		}
+	}
+	return names
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") ||
+		(strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"))
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	idleTTL       = 10 * time.Minute
+	sweepInterval = time.Minute
+)
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// MemoryLimiter хранит корзины в памяти процесса. Подходит для одной реплики gateway.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, policy Policy) (Result, error) {
+	now := time.Now()
+	limit := rate.Every(policy.Every)
+
+	l.mu.Lock()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(limit, policy.Burst)}
+		l.buckets[key] = b
+	} else if b.limiter.Limit() != limit || b.limiter.Burst() != policy.Burst {
+		b.limiter.SetLimitAt(now, limit)
+		b.limiter.SetBurstAt(now, policy.Burst)
+	}
+	b.lastSeen = now
+	l.sweep(now)
+	l.mu.Unlock()
+
+	result := Result{Limit: policy.Burst}
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		result.RetryAfter = delay
+	} else {
+		result.Allowed = true
+	}
+
+	tokens := b.limiter.TokensAt(now)
+	result.Remaining = int(math.Max(0, math.Floor(tokens)))
+	result.Reset = time.Duration((float64(policy.Burst) - tokens) * float64(policy.Every))
+
+	return result, nil
+}
+
+// sweep удаляет давно не использовавшиеся корзины. Вызывается под мьютексом.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Policy - параметры token bucket: один токен раз в Every, не более Burst токенов.
+type Policy struct {
+	Every time.Duration
+	Burst int
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter расходует один токен из корзины key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, policy Policy) (Result, error)
+}
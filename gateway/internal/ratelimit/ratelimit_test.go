@@ -0,0 +1,55 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/ratelimit"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+func newRedisLimiter(t *testing.T) *ratelimit.RedisLimiter {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return ratelimit.NewRedisLimiter(client)
+}
+
+func TestLimiters(t *testing.T) {
+	limiters := map[string]func(t *testing.T) ratelimit.Limiter{
+		"memory": func(*testing.T) ratelimit.Limiter { return ratelimit.NewMemoryLimiter() },
+		"redis":  func(t *testing.T) ratelimit.Limiter { return newRedisLimiter(t) },
+	}
+	policy := ratelimit.Policy{Every: time.Minute, Burst: 2}
+
+	for name, newLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			limiter := newLimiter(t)
+
+			first, err := limiter.Allow(ctx, "login:ip:192.0.2.1", policy)
+			require.NoError(t, err)
+			require.True(t, first.Allowed)
+			require.Equal(t, 2, first.Limit)
+			require.Equal(t, 1, first.Remaining)
+
+			second, err := limiter.Allow(ctx, "login:ip:192.0.2.1", policy)
+			require.NoError(t, err)
+			require.True(t, second.Allowed)
+			require.Equal(t, 0, second.Remaining)
+
+			third, err := limiter.Allow(ctx, "login:ip:192.0.2.1", policy)
+			require.NoError(t, err)
+			require.False(t, third.Allowed)
+			require.InDelta(t, time.Minute.Seconds(), third.RetryAfter.Seconds(), 1)
+
+			// У другого ключа своя корзина.
+			other, err := limiter.Allow(ctx, "login:ip:192.0.2.2", policy)
+			require.NoError(t, err)
+			require.True(t, other.Allowed)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/apikey"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/audit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/auth"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/cache"
+	v1 "github.com/JojoWeyn/duo-proj/gateway/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/maintenance"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/middleware"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/openapi"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/ratelimit"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+type Deps struct {
+	Proxy     *v1.ProxyHandler
+	Dashboard *v1.DashboardHandler
+	Verifier  *auth.Verifier
+	Limiter   ratelimit.Limiter
+	Upstreams *upstream.Registry
+	OpenAPI   *openapi.Aggregator
+	// APIKeys - ключи интеграций, nil отключает вход по ключу.
+	APIKeys apikey.Store
+	// APIKeyQuotaFailOpen пропускает запросы по ключу без учета квот, если их
+	// счетчики недоступны; по умолчанию такие запросы отклоняются с 503.
+	APIKeyQuotaFailOpen bool
+	// Maintenance - переключатель режима обслуживания, nil отключает его.
+	Maintenance *maintenance.Switch
+	// Audit - журнал административных запросов, nil отключает аудит.
+	Audit audit.Store
+	// Cache - хранилище кэша ответов, nil отключает кэширование.
+	Cache          cache.Store
+	CachePublisher cache.Publisher
+	Security       middleware.SecurityPolicy
+	TrustedProxies []string
+}
+
+// Handler отдает запросы текущему gin.Engine. При перезагрузке маршрутов
+// engine подменяется атомарно, а уже начатые запросы дорабатывают на старом.
+type Handler struct {
+	current atomic.Pointer[gin.Engine]
+}
+
+func NewHandler(engine *gin.Engine) *Handler {
+	h := &Handler{}
+	h.current.Store(engine)
+	return h
+}
+
+func (h *Handler) Swap(engine *gin.Engine) {
+	h.current.Store(engine)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.current.Load().ServeHTTP(w, r)
+}
+
+// New собирает gin.Engine по таблице маршрутов.
+// gin паникует при конфликтующих путях, поэтому паника превращается в ошибку.
+func New(table *routes.Table, deps Deps) (engine *gin.Engine, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			engine = nil
+			err = fmt.Errorf("failed to register routes: %v", r)
+		}
+	}()
+
+	engine = gin.New()
+	engine.Use(middleware.AccessTokenMiddleware(), gin.Logger(), gin.Recovery())
+	if err := engine.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		return nil, err
+	}
+	engine.Use(tracing.Middleware(), metrics.Middleware(),
+		middleware.SecurityHeadersMiddleware(deps.Security), middleware.CORSMiddleware(deps.Security),
+		middleware.NetworkMiddleware(table))
+
+	maintenancePath := table.Prefix + "/admin/gateway/maintenance"
+	if deps.Maintenance != nil {
+		// Вход и обновление токенов остаются доступны, чтобы читать можно было и во время работ.
+		engine.Use(middleware.MaintenanceMiddleware(deps.Maintenance, maintenancePath,
+			table.Prefix+"/auth/login", table.Prefix+"/auth/refresh", table.Prefix+"/auth/logout"))
+	}
+
+	limiters := make(map[string]gin.HandlerFunc, len(table.RateLimits))
+	for name, policy := range table.RateLimits {
+		limiters[name] = middleware.RateLimitMiddleware(deps.Limiter, name, policy)
+	}
+
+	authMiddleware := middleware.AuthMiddleware(deps.Verifier)
+	streamAuthMiddleware := middleware.StreamAuthMiddleware(deps.Verifier)
+
+	for _, r := range table.Routes {
+		var handlers []gin.HandlerFunc
+		// Лимит по пользователю считается после проверки токена, остальные - до нее.
+		userLimited := r.RateLimit != "" && table.RateLimits[r.RateLimit].Key == routes.RateLimitKeyUser
+		if r.RateLimit != "" && !userLimited {
+			handlers = append(handlers, limiters[r.RateLimit])
+		}
+		switch {
+		case r.Auth && r.Stream:
+			handlers = append(handlers, streamAuthMiddleware)
+		case r.Auth && r.APIKeyScope != "" && deps.APIKeys != nil:
+			handlers = append(handlers, middleware.APIKeyMiddleware(deps.APIKeys, r.APIKeyScope, deps.APIKeyQuotaFailOpen, authMiddleware))
+		case r.Auth:
+			handlers = append(handlers, authMiddleware)
+		}
+		if deps.Audit != nil && r.Audited() {
+			handlers = append(handlers, middleware.AuditMiddleware(deps.Audit, r.Service, audit.Entity(r.Path)))
+		}
+		if len(r.Roles) > 0 || len(r.Permissions) > 0 {
+			handlers = append(handlers, middleware.AccessMiddleware(table, r.Roles, r.Permissions))
+		}
+		if userLimited {
+			handlers = append(handlers, limiters[r.RateLimit])
+		}
+		// Кэш стоит после проверки доступа: из кэша отдается только тем, кому можно.
+		if r.Cache != "" && deps.Cache != nil {
+			handlers = append(handlers, middleware.CacheMiddleware(deps.Cache, r.Service, r.Cache, table.Caches[r.Cache]))
+		}
+		if r.Stream {
+			handlers = append(handlers, deps.Proxy.ProxyStream(r.Service, r.Protected, table.Streams))
+		} else {
+			handlers = append(handlers, deps.Proxy.ProxyService(r.Service, r.Protected, r.Timeout))
+		}
+
+		engine.Handle(r.Method, table.Prefix+r.Path, handlers...)
+	}
+
+	if deps.Dashboard != nil {
+		handlers := []gin.HandlerFunc{authMiddleware}
+		if table.Dashboard.Cache != "" && deps.Cache != nil {
+			policy := table.Caches[table.Dashboard.Cache]
+			handlers = append(handlers, middleware.CacheMiddleware(deps.Cache, v1.DashboardService, table.Dashboard.Cache, policy))
+		}
+		handlers = append(handlers, deps.Dashboard.Get(table.Prefix, table.Dashboard.Timeout))
+		engine.GET(table.Prefix+"/dashboard", handlers...)
+	}
+
+	adminOnly := middleware.AccessMiddleware(table, []string{"admin"}, nil)
+	// adminChange - цепочка для изменяющих запросов к API самого gateway: они тоже попадают в аудит.
+	adminChange := func(handler gin.HandlerFunc) []gin.HandlerFunc {
+		if deps.Audit == nil {
+			return []gin.HandlerFunc{authMiddleware, adminOnly, handler}
+		}
+		return []gin.HandlerFunc{authMiddleware, middleware.AuditMiddleware(deps.Audit, "gateway", "gateway"), adminOnly, handler}
+	}
+
+	upstreams := v1.NewUpstreamsHandler(deps.Upstreams)
+	engine.GET(table.Prefix+"/admin/gateway/upstreams", authMiddleware, adminOnly, upstreams.Status)
+
+	if deps.Cache != nil {
+		purge := v1.NewCacheHandler(deps.Cache, deps.CachePublisher)
+		engine.POST(table.Prefix+"/admin/gateway/cache/purge", adminChange(purge.Purge)...)
+	}
+
+	if deps.APIKeys != nil {
+		keys := v1.NewAPIKeysHandler(deps.APIKeys, table.APIKeyScopes())
+		engine.GET(table.Prefix+"/admin/gateway/api-keys", authMiddleware, adminOnly, keys.List)
+		engine.POST(table.Prefix+"/admin/gateway/api-keys", adminChange(keys.Create)...)
+		engine.DELETE(table.Prefix+"/admin/gateway/api-keys/:id", adminChange(keys.Revoke)...)
+		engine.GET(table.Prefix+"/admin/gateway/api-keys/:id/usage", authMiddleware, adminOnly, keys.Usage)
+	}
+
+	if deps.Maintenance != nil {
+		mode := v1.NewMaintenanceHandler(deps.Maintenance)
+		engine.GET(maintenancePath, authMiddleware, adminOnly, mode.Get)
+		engine.PUT(maintenancePath, adminChange(mode.Set)...)
+	}
+
+	if deps.Audit != nil {
+		auditLog := v1.NewAuditHandler(deps.Audit)
+		engine.GET(table.Prefix+"/admin/gateway/audit", authMiddleware, adminOnly, auditLog.Query)
+	}
+
+	spec := v1.NewOpenAPIHandler(deps.OpenAPI, table)
+	engine.GET("/openapi.json", spec.Document)
+	engine.GET(table.Prefix+"/admin/gateway/openapi/check", authMiddleware, adminOnly, spec.Check)
+
+	engine.GET("/swagger/:service/*path", func(c *gin.Context) {
+		// Проксирование Swagger-запроса
+		deps.Proxy.ProxySwagger(c.Param("service"), c.Param("path"))(c)
+	})
+
+	return engine, nil
+}
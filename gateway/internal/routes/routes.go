@@ -0,0 +1,391 @@
+package routes
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	sharedmiddleware "github.com/JojoWeyn/duo-proj/shared/middleware"
+	"gopkg.in/yaml.v3"
+)
+
+// Route описывает один маршрут, проксируемый gateway в сервис.
+type Route struct {
+	Method    string        `yaml:"method" json:"method"`
+	Path      string        `yaml:"path" json:"path"`
+	Service   string        `yaml:"service" json:"service"`
+	Auth      bool          `yaml:"auth" json:"auth"`
+	Protected bool          `yaml:"protected" json:"protected"`
+	Timeout   time.Duration `yaml:"timeout" json:"timeout"`
+	RateLimit string        `yaml:"rate_limit" json:"rate_limit"`
+	// Cache - имя политики кэширования ответов, только для GET.
+	Cache string `yaml:"cache" json:"cache,omitempty"`
+	// Stream - долгоживущее соединение (WebSocket или SSE): вместо Timeout
+	// действуют ограничения Table.Streams, токен можно передать в access_token.
+	Stream bool `yaml:"stream" json:"stream,omitempty"`
+	// APIKeyScope - набор маршрутов, в который входит маршрут: вместо JWT
+	// принимается ключ интеграции, выпущенный с этим набором.
+	APIKeyScope string `yaml:"api_key_scope" json:"api_key_scope,omitempty"`
+	// Roles - допустимые роли (достаточно любой), Permissions - необходимые
+	// права (нужны все), права ролей задаются в Table.RolePermissions.
+	Roles       []string `yaml:"roles" json:"roles"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+	// Audit - изменяющие запросы к маршруту пишутся в журнал аудита.
+	// Для маршрутов с ролью admin аудит включен всегда.
+	Audit bool `yaml:"audit" json:"audit,omitempty"`
+}
+
+// Audited сообщает, попадает ли запрос к маршруту в журнал аудита: чтение не
+// записывается, а изменения - если маршрут помечен audit или доступен admin.
+func (r *Route) Audited() bool {
+	if r.Method == "GET" {
+		return false
+	}
+	if r.Audit {
+		return true
+	}
+	for _, role := range r.Roles {
+		if role == AdminRole {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitPolicy задает параметры token bucket для группы маршрутов.
+// Key определяет, чья это корзина: ip - клиента, user - пользователя из JWT,
+// route - одна корзина на всю группу маршрутов.
+type RateLimitPolicy struct {
+	Every time.Duration `yaml:"every" json:"every"`
+	Burst int           `yaml:"burst" json:"burst"`
+	Key   string        `yaml:"key" json:"key"`
+}
+
+// CachePolicy задает время жизни ответов в кэше gateway для группы маршрутов.
+// Key определяет, чьи ответы хранятся отдельно: role - общие для роли,
+// user - личные для пользователя из JWT.
+type CachePolicy struct {
+	TTL time.Duration `yaml:"ttl" json:"ttl"`
+	Key string        `yaml:"key" json:"key"`
+}
+
+// NetworkPolicy ограничивает доступ к группе маршрутов по адресу клиента.
+// Группа задается префиксами путей без префикса версии. Адрес из Deny
+// отклоняется всегда, а при непустом Allow пропускаются только адреса из него.
+type NetworkPolicy struct {
+	Prefixes []string `yaml:"prefixes" json:"prefixes"`
+	Allow    []string `yaml:"allow" json:"allow,omitempty"`
+	Deny     []string `yaml:"deny" json:"deny,omitempty"`
+}
+
+// DashboardPolicy настраивает агрегированный ответ GET <prefix>/dashboard:
+// Timeout ограничивает каждый запрос к сервису, Cache - имя политики кэша.
+type DashboardPolicy struct {
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+	Cache   string        `yaml:"cache" json:"cache,omitempty"`
+}
+
+// StreamPolicy ограничивает долгоживущие соединения: соединение закрывается
+// после IdleTimeout без данных в обе стороны, а число одновременных соединений
+// ограничено на весь gateway и на одного пользователя.
+type StreamPolicy struct {
+	IdleTimeout    time.Duration `yaml:"idle_timeout" json:"idle_timeout"`
+	MaxConnections int           `yaml:"max_connections" json:"max_connections"`
+	MaxPerUser     int           `yaml:"max_per_user" json:"max_per_user"`
+}
+
+const (
+	RateLimitKeyIP    = "ip"
+	RateLimitKeyUser  = "user"
+	RateLimitKeyRoute = "route"
+)
+
+const (
+	CacheKeyRole = "role"
+	CacheKeyUser = "user"
+)
+
+// Table - таблица маршрутов gateway, загружаемая из файла.
+type Table struct {
+	Prefix          string                     `yaml:"prefix" json:"prefix"`
+	DefaultTimeout  time.Duration              `yaml:"default_timeout" json:"default_timeout"`
+	RateLimits      map[string]RateLimitPolicy `yaml:"rate_limits" json:"rate_limits"`
+	Caches          map[string]CachePolicy     `yaml:"caches" json:"caches"`
+	Streams         StreamPolicy               `yaml:"streams" json:"streams"`
+	Dashboard       DashboardPolicy            `yaml:"dashboard" json:"dashboard"`
+	Networks        map[string]NetworkPolicy   `yaml:"networks" json:"networks"`
+	RolePermissions map[string][]string        `yaml:"role_permissions" json:"role_permissions"`
+	Routes          []Route                    `yaml:"routes" json:"routes"`
+}
+
+// AdminRole - роль администратора, изменения на ее маршрутах всегда аудируются.
+const AdminRole = "admin"
+
+// AllPermissions в списке прав роли дает ей любое право.
+const AllPermissions = "*"
+
+var allowedMethods = map[string]bool{
+	"GET":    true,
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// Load читает таблицу маршрутов из YAML или JSON файла.
+// JSON является подмножеством YAML, поэтому оба формата разбираются одним декодером.
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Table, error) {
+	table := &Table{}
+	if err := yaml.Unmarshal(data, table); err != nil {
+		return nil, fmt.Errorf("failed to parse routes file: %w", err)
+	}
+
+	if table.Prefix == "" {
+		table.Prefix = "/v1"
+	}
+	if table.DefaultTimeout == 0 {
+		table.DefaultTimeout = 10 * time.Second
+	}
+
+	if table.Streams.IdleTimeout == 0 {
+		table.Streams.IdleTimeout = 5 * time.Minute
+	}
+	if table.Streams.MaxConnections == 0 {
+		table.Streams.MaxConnections = 1000
+	}
+	if table.Streams.MaxPerUser == 0 {
+		table.Streams.MaxPerUser = 5
+	}
+
+	if table.Dashboard.Timeout == 0 {
+		table.Dashboard.Timeout = 2 * time.Second
+	}
+
+	for name, policy := range table.RateLimits {
+		if policy.Key == "" {
+			policy.Key = RateLimitKeyIP
+			table.RateLimits[name] = policy
+		}
+	}
+	for name, policy := range table.Caches {
+		if policy.Key == "" {
+			policy.Key = CacheKeyRole
+			table.Caches[name] = policy
+		}
+	}
+
+	for i := range table.Routes {
+		r := &table.Routes[i]
+		r.Method = strings.ToUpper(r.Method)
+		if r.Timeout == 0 {
+			r.Timeout = table.DefaultTimeout
+		}
+	}
+
+	return table, nil
+}
+
+// Services - набор известных gateway сервисов.
+type Services interface {
+	Has(name string) bool
+}
+
+// Validate проверяет таблицу на корректность относительно известных сервисов.
+func (t *Table) Validate(services Services) error {
+	if !strings.HasPrefix(t.Prefix, "/") {
+		return fmt.Errorf("prefix %q must start with /", t.Prefix)
+	}
+
+	for name, policy := range t.RateLimits {
+		if policy.Every < time.Millisecond || policy.Burst <= 0 {
+			return fmt.Errorf("rate limit %q: every must be at least 1ms and burst positive", name)
+		}
+		switch policy.Key {
+		case RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyRoute:
+		default:
+			return fmt.Errorf("rate limit %q: unknown key %q", name, policy.Key)
+		}
+	}
+
+	for name, policy := range t.Caches {
+		if !validCacheName(name) {
+			return fmt.Errorf("cache %q: name may contain only a-z, 0-9, - and _", name)
+		}
+		if policy.TTL < time.Second {
+			return fmt.Errorf("cache %q: ttl must be at least 1s", name)
+		}
+		switch policy.Key {
+		case CacheKeyRole, CacheKeyUser:
+		default:
+			return fmt.Errorf("cache %q: unknown key %q", name, policy.Key)
+		}
+	}
+
+	for name, policy := range t.Networks {
+		if len(policy.Prefixes) == 0 {
+			return fmt.Errorf("network %q: at least one prefix is required", name)
+		}
+		for _, prefix := range policy.Prefixes {
+			if !strings.HasPrefix(prefix, "/") {
+				return fmt.Errorf("network %q: prefix %q must start with /", name, prefix)
+			}
+		}
+		if _, err := sharedmiddleware.ParseNetworks(policy.Allow); err != nil {
+			return fmt.Errorf("network %q: %w", name, err)
+		}
+		if _, err := sharedmiddleware.ParseNetworks(policy.Deny); err != nil {
+			return fmt.Errorf("network %q: %w", name, err)
+		}
+	}
+
+	if t.Dashboard.Timeout < 0 {
+		return fmt.Errorf("dashboard: timeout must not be negative")
+	}
+	if t.Dashboard.Cache != "" {
+		if _, ok := t.Caches[t.Dashboard.Cache]; !ok {
+			return fmt.Errorf("dashboard: unknown cache %q", t.Dashboard.Cache)
+		}
+	}
+
+	if t.Streams.IdleTimeout < time.Second || t.Streams.MaxConnections < 0 || t.Streams.MaxPerUser < 0 {
+		return fmt.Errorf("streams: idle_timeout must be at least 1s and limits must not be negative")
+	}
+
+	seen := make(map[string]bool, len(t.Routes))
+	for i, r := range t.Routes {
+		if !allowedMethods[r.Method] {
+			return fmt.Errorf("route #%d: unsupported method %q", i, r.Method)
+		}
+		if !strings.HasPrefix(r.Path, "/") {
+			return fmt.Errorf("route %s %s: path must start with /", r.Method, r.Path)
+		}
+		if !services.Has(r.Service) {
+			return fmt.Errorf("route %s %s: unknown service %q", r.Method, r.Path, r.Service)
+		}
+		if r.Protected && !r.Auth {
+			return fmt.Errorf("route %s %s: protected route requires auth", r.Method, r.Path)
+		}
+		if (len(r.Roles) > 0 || len(r.Permissions) > 0) && !r.Auth {
+			return fmt.Errorf("route %s %s: roles and permissions require auth", r.Method, r.Path)
+		}
+		// Без auth в записи аудита не будет того, кто выполнил запрос.
+		if r.Audit && !r.Auth {
+			return fmt.Errorf("route %s %s: audit requires auth", r.Method, r.Path)
+		}
+		for _, permission := range r.Permissions {
+			if !t.permissionDefined(permission) {
+				return fmt.Errorf("route %s %s: permission %q is not granted to any role", r.Method, r.Path, permission)
+			}
+		}
+		if r.Timeout < 0 {
+			return fmt.Errorf("route %s %s: timeout must not be negative", r.Method, r.Path)
+		}
+		if r.RateLimit != "" {
+			policy, ok := t.RateLimits[r.RateLimit]
+			if !ok {
+				return fmt.Errorf("route %s %s: unknown rate limit %q", r.Method, r.Path, r.RateLimit)
+			}
+			// Без auth у запроса нет пользователя, которым ключуется корзина.
+			if policy.Key == RateLimitKeyUser && !r.Auth {
+				return fmt.Errorf("route %s %s: rate limit %q keyed by user requires auth", r.Method, r.Path, r.RateLimit)
+			}
+		}
+
+		if r.Cache != "" {
+			if _, ok := t.Caches[r.Cache]; !ok {
+				return fmt.Errorf("route %s %s: unknown cache %q", r.Method, r.Path, r.Cache)
+			}
+			if r.Method != "GET" {
+				return fmt.Errorf("route %s %s: only GET routes can be cached", r.Method, r.Path)
+			}
+		}
+
+		if r.Stream {
+			if r.Method != "GET" {
+				return fmt.Errorf("route %s %s: only GET routes can be streams", r.Method, r.Path)
+			}
+			if r.Cache != "" {
+				return fmt.Errorf("route %s %s: stream routes cannot be cached", r.Method, r.Path)
+			}
+		}
+
+		if r.APIKeyScope != "" {
+			if !r.Auth {
+				return fmt.Errorf("route %s %s: api key scope requires auth", r.Method, r.Path)
+			}
+			if len(r.Roles) > 0 || len(r.Permissions) > 0 || r.Stream {
+				return fmt.Errorf("route %s %s: api key scope cannot be combined with roles, permissions or stream", r.Method, r.Path)
+			}
+			if !validCacheName(r.APIKeyScope) {
+				return fmt.Errorf("route %s %s: api key scope may contain only a-z, 0-9, - and _", r.Method, r.Path)
+			}
+		}
+
+		key := r.Method + " " + r.Path
+		if seen[key] {
+			return fmt.Errorf("route %s: duplicate definition", key)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// APIKeyScopes - наборы маршрутов, на которые можно выпустить ключ интеграции.
+func (t *Table) APIKeyScopes() []string {
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, r := range t.Routes {
+		if r.APIKeyScope != "" && !seen[r.APIKeyScope] {
+			seen[r.APIKeyScope] = true
+			scopes = append(scopes, r.APIKeyScope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// validCacheName не пропускает символы, которые ломают ключи и шаблоны кэша в Redis.
+func validCacheName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Table) permissionDefined(permission string) bool {
+	for _, permissions := range t.RolePermissions {
+		for _, p := range permissions {
+			if p == permission || p == AllPermissions {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission сообщает, выдано ли право роли.
+func (t *Table) HasPermission(role, permission string) bool {
+	for _, p := range t.RolePermissions[role] {
+		if p == permission || p == AllPermissions {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package routes_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/stretchr/testify/require"
+)
+
+type services map[string]bool
+
+func (s services) Has(name string) bool { return s[name] }
+
+var knownServices = services{"identity": true, "user": true, "course": true}
+
+func TestParse_Defaults(t *testing.T) {
+	table, err := routes.Parse([]byte(`
+rate_limits:
+  login: { every: 1s, burst: 5 }
+caches:
+  catalog: { ttl: 30s }
+routes:
+  - { method: get, path: /courses, service: course }
+  - { method: POST, path: /auth/login, service: identity, timeout: 3s }
+`))
+
+	require.NoError(t, err)
+	require.Equal(t, "/v1", table.Prefix)
+	require.Equal(t, 10*time.Second, table.DefaultTimeout)
+	require.Equal(t, routes.RateLimitKeyIP, table.RateLimits["login"].Key)
+	require.Equal(t, routes.CacheKeyRole, table.Caches["catalog"].Key)
+	require.Equal(t, "GET", table.Routes[0].Method)
+	require.Equal(t, 10*time.Second, table.Routes[0].Timeout)
+	require.Equal(t, 3*time.Second, table.Routes[1].Timeout)
+}
+
+func TestParse_JSON(t *testing.T) {
+	table, err := routes.Parse([]byte(`{"prefix": "/v2", "routes": [{"method": "GET", "path": "/users/me", "service": "user", "auth": true}]}`))
+
+	require.NoError(t, err)
+	require.Equal(t, "/v2", table.Prefix)
+	require.True(t, table.Routes[0].Auth)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := routes.Parse([]byte("routes: [ {"))
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			yaml: `
+rate_limits:
+  per_user: { every: 1s, burst: 5, key: user }
+caches:
+  catalog: { ttl: 30s }
+role_permissions:
+  admin: ["*"]
+routes:
+  - { method: GET, path: /courses, service: course, cache: catalog }
+  - { method: GET, path: /users/me, service: user, auth: true, rate_limit: per_user }
+  - { method: DELETE, path: /admin/users/:uuid, service: user, auth: true, roles: [admin], permissions: [users.delete] }
+`,
+		},
+		{
+			name:    "unknown service",
+			yaml:    `routes: [ { method: GET, path: /x, service: billing } ]`,
+			wantErr: `unknown service "billing"`,
+		},
+		{
+			name:    "unsupported method",
+			yaml:    `routes: [ { method: TRACE, path: /x, service: user } ]`,
+			wantErr: `unsupported method "TRACE"`,
+		},
+		{
+			name:    "relative path",
+			yaml:    `routes: [ { method: GET, path: x, service: user } ]`,
+			wantErr: "path must start with /",
+		},
+		{
+			name:    "duplicate route",
+			yaml:    `routes: [ { method: GET, path: /x, service: user }, { method: get, path: /x, service: course } ]`,
+			wantErr: "duplicate definition",
+		},
+		{
+			name:    "protected without auth",
+			yaml:    `routes: [ { method: GET, path: /x, service: user, protected: true } ]`,
+			wantErr: "protected route requires auth",
+		},
+		{
+			name:    "roles without auth",
+			yaml:    `routes: [ { method: GET, path: /x, service: user, roles: [admin] } ]`,
+			wantErr: "roles and permissions require auth",
+		},
+		{
+			name:    "audit without auth",
+			yaml:    `routes: [ { method: POST, path: /x, service: user, audit: true } ]`,
+			wantErr: "audit requires auth",
+		},
+		{
+			name:    "permission not granted",
+			yaml:    `routes: [ { method: GET, path: /x, service: user, auth: true, permissions: [users.read] } ]`,
+			wantErr: `permission "users.read" is not granted to any role`,
+		},
+		{
+			name:    "unknown rate limit",
+			yaml:    `routes: [ { method: GET, path: /x, service: user, rate_limit: missing } ]`,
+			wantErr: `unknown rate limit "missing"`,
+		},
+		{
+			name: "user rate limit without auth",
+			yaml: `
+rate_limits:
+  per_user: { every: 1s, burst: 5, key: user }
+routes:
+  - { method: GET, path: /x, service: user, rate_limit: per_user }
+`,
+			wantErr: `rate limit "per_user" keyed by user requires auth`,
+		},
+		{
+			name: "invalid rate limit",
+			yaml: `
+rate_limits:
+  bad: { every: 0s, burst: 5 }
+`,
+			wantErr: "every must be at least 1ms",
+		},
+		{
+			name: "unknown rate limit key",
+			yaml: `
+rate_limits:
+  bad: { every: 1s, burst: 5, key: session }
+`,
+			wantErr: `unknown key "session"`,
+		},
+		{
+			name: "cache on POST",
+			yaml: `
+caches:
+  catalog: { ttl: 30s }
+routes:
+  - { method: POST, path: /x, service: course, cache: catalog }
+`,
+			wantErr: "only GET routes can be cached",
+		},
+		{
+			name: "invalid cache name",
+			yaml: `
+caches:
+  "Catalog:*": { ttl: 30s }
+`,
+			wantErr: "name may contain only",
+		},
+		{
+			name:    "stream on POST",
+			yaml:    `routes: [ { method: POST, path: /x, service: course, stream: true } ]`,
+			wantErr: "only GET routes can be streams",
+		},
+		{
+			name:    "api key scope without auth",
+			yaml:    `routes: [ { method: GET, path: /x, service: course, api_key_scope: reports } ]`,
+			wantErr: "api key scope requires auth",
+		},
+		{
+			name: "network without prefixes",
+			yaml: `
+networks:
+  admin: { allow: [10.0.0.0/8] }
+`,
+			wantErr: "at least one prefix is required",
+		},
+		{
+			name: "invalid network",
+			yaml: `
+networks:
+  admin: { prefixes: [/admin/], allow: [10.0.0.0/33] }
+`,
+			wantErr: `invalid network "10.0.0.0/33"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := routes.Parse([]byte(tt.yaml))
+			require.NoError(t, err)
+
+			err = table.Validate(knownServices)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	table := &routes.Table{RolePermissions: map[string][]string{
+		"admin":   {routes.AllPermissions},
+		"teacher": {"courses.edit"},
+	}}
+
+	require.True(t, table.HasPermission("admin", "users.delete"))
+	require.True(t, table.HasPermission("teacher", "courses.edit"))
+	require.False(t, table.HasPermission("teacher", "users.delete"))
+	require.False(t, table.HasPermission("user", "courses.edit"))
+}
+
+func TestRoute_Audited(t *testing.T) {
+	tests := []struct {
+		name  string
+		route routes.Route
+		want  bool
+	}{
+		{name: "admin change", route: routes.Route{Method: "DELETE", Path: "/admin/users/:uuid", Roles: []string{"admin"}}, want: true},
+		{name: "admin change outside /admin", route: routes.Route{Method: "PUT", Path: "/auth/admin/identities/:uuid/role", Roles: []string{"admin"}}, want: true},
+		{name: "marked route", route: routes.Route{Method: "POST", Path: "/reports", Audit: true}, want: true},
+		{name: "admin read", route: routes.Route{Method: "GET", Path: "/admin/users", Roles: []string{"admin"}}, want: false},
+		{name: "marked read", route: routes.Route{Method: "GET", Path: "/reports", Audit: true}, want: false},
+		{name: "user change under /admin path", route: routes.Route{Method: "POST", Path: "/admin/feedback", Roles: []string{"user"}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.route.Audited())
+		})
+	}
+}
+
+func TestLoad_DefaultRoutesFileRestrictsAdminNetworks(t *testing.T) {
+	table, err := routes.Load("../../routes.yaml")
+	require.NoError(t, err)
+
+	admin := table.Networks["admin"]
+	require.NotEmpty(t, admin.Allow)
+	for _, r := range table.Routes {
+		if !strings.Contains(r.Path, "/admin/") {
+			continue
+		}
+		covered := false
+		for _, prefix := range admin.Prefixes {
+			covered = covered || strings.HasPrefix(r.Path, prefix)
+		}
+		require.True(t, covered, "%s %s is reachable from any network", r.Method, r.Path)
+	}
+}
+
+func TestLoad_DefaultRoutesFileAuditsAdminChanges(t *testing.T) {
+	table, err := routes.Load("../../routes.yaml")
+	require.NoError(t, err)
+
+	for _, r := range table.Routes {
+		if r.Method != "GET" && strings.Contains(r.Path, "/admin/") {
+			require.True(t, r.Audited(), "%s %s is not audited", r.Method, r.Path)
+		}
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	table := &routes.Table{Routes: []routes.Route{
+		{APIKeyScope: "reports"},
+		{APIKeyScope: "catalog"},
+		{APIKeyScope: "reports"},
+		{},
+	}}
+
+	require.Equal(t, []string{"catalog", "reports"}, table.APIKeyScopes())
+}
+
+func TestLoad_DefaultRoutesFile(t *testing.T) {
+	table, err := routes.Load("../../routes.yaml")
+
+	require.NoError(t, err)
+	require.NoError(t, table.Validate(knownServices))
+}
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Watcher перечитывает файл маршрутов по SIGHUP или при изменении файла.
+// Если новая таблица не проходит проверку, продолжает работать старая.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	services Services
+	onReload func(*Table) error
+	modTime  time.Time
+}
+
+func NewWatcher(path string, interval time.Duration, services Services, onReload func(*Table) error) *Watcher {
+	w := &Watcher{
+		path:     path,
+		interval: interval,
+		services: services,
+		onReload: onReload,
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+	}
+
+	return w
+}
+
+func (w *Watcher) Start(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("SIGHUP received, reloading routes")
+			w.reload()
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil {
+				log.Printf("Failed to stat routes file: %v", err)
+				continue
+			}
+			if info.ModTime().Equal(w.modTime) {
+				continue
+			}
+			w.modTime = info.ModTime()
+			log.Println("Routes file changed, reloading routes")
+			w.reload()
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	table, err := Load(w.path)
+	if err != nil {
+		log.Printf("Failed to reload routes: %v", err)
+		return
+	}
+
+	if err := table.Validate(w.services); err != nil {
+		log.Printf("Invalid routes file, keeping previous routes: %v", err)
+		return
+	}
+
+	if err := w.onReload(table); err != nil {
+		log.Printf("Failed to apply routes, keeping previous routes: %v", err)
+		return
+	}
+
+	log.Printf("Routes reloaded: %d routes", len(table.Routes))
+}
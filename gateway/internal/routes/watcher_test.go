@@ -0,0 +1,49 @@
+package routes_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/routes"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRoutes(t *testing.T, path, data string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestWatcher_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	start := time.Now().Add(-time.Hour)
+	writeRoutes(t, path, `routes: [ { method: GET, path: /courses, service: course } ]`, start)
+
+	reloaded := make(chan *routes.Table, 1)
+	watcher := routes.NewWatcher(path, 10*time.Millisecond, knownServices, func(table *routes.Table) error {
+		reloaded <- table
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Start(ctx)
+
+	// Некорректная таблица не применяется.
+	writeRoutes(t, path, `routes: [ { method: GET, path: /courses, service: billing } ]`, start.Add(time.Minute))
+	select {
+	case <-reloaded:
+		t.Fatal("invalid table must not be applied")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	writeRoutes(t, path, `routes: [ { method: GET, path: /courses, service: course }, { method: GET, path: /users/me, service: user } ]`, start.Add(2*time.Minute))
+	select {
+	case table := <-reloaded:
+		require.Len(t, table.Routes, 2)
+	case <-time.After(time.Second):
+		t.Fatal("routes were not reloaded")
+	}
+}
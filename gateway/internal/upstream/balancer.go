@@ -0,0 +1,70 @@
+package upstream
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	BalancerRoundRobin = "round_robin"
+	BalancerLeastConn  = "least_conn"
+)
+
+// Balancer выбирает экземпляр среди доступных. exclude - уже опробованные
+// в этом запросе экземпляры, их стоит пропускать, если есть другие.
+type Balancer interface {
+	Pick(instances []*Instance, exclude map[*Instance]bool) *Instance
+}
+
+func NewBalancer(name string) (Balancer, error) {
+	switch name {
+	case "", BalancerRoundRobin:
+		return &roundRobin{}, nil
+	case BalancerLeastConn:
+		return leastConn{}, nil
+	default:
+		return nil, fmt.Errorf("unknown balancer %q", name)
+	}
+}
+
+func candidates(instances []*Instance, exclude map[*Instance]bool) []*Instance {
+	var fresh, tried []*Instance
+	for _, inst := range instances {
+		if !inst.available() {
+			continue
+		}
+		if exclude[inst] {
+			tried = append(tried, inst)
+		} else {
+			fresh = append(fresh, inst)
+		}
+	}
+	if len(fresh) > 0 {
+		return fresh
+	}
+	return tried
+}
+
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+func (b *roundRobin) Pick(instances []*Instance, exclude map[*Instance]bool) *Instance {
+	list := candidates(instances, exclude)
+	if len(list) == 0 {
+		return nil
+	}
+	return list[(b.next.Add(1)-1)%uint64(len(list))]
+}
+
+type leastConn struct{}
+
+func (leastConn) Pick(instances []*Instance, exclude map[*Instance]bool) *Instance {
+	var best *Instance
+	for _, inst := range candidates(instances, exclude) {
+		if best == nil || inst.active.Load() < best.active.Load() {
+			best = inst
+		}
+	}
+	return best
+}
@@ -0,0 +1,117 @@
+package upstream_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBalancer(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: ""},
+		{name: upstream.BalancerRoundRobin},
+		{name: upstream.BalancerLeastConn},
+		{name: "random", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := upstream.NewBalancer(tt.name)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func instances(t *testing.T, n int) []*upstream.Instance {
+	t.Helper()
+
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = "http://user-service-" + string(rune('a'+i)) + ":8082"
+	}
+	return newUpstream(t, upstream.Config{BreakerThreshold: 1, BreakerCooldown: time.Minute}, "", urls...).Instances
+}
+
+func TestRoundRobin(t *testing.T) {
+	list := instances(t, 3)
+	balancer, err := upstream.NewBalancer(upstream.BalancerRoundRobin)
+	require.NoError(t, err)
+
+	require.Equal(t, list[0], balancer.Pick(list, nil))
+	require.Equal(t, list[1], balancer.Pick(list, nil))
+	require.Equal(t, list[2], balancer.Pick(list, nil))
+	require.Equal(t, list[0], balancer.Pick(list, nil))
+}
+
+func TestRoundRobin_SkipsTriedAndOpen(t *testing.T) {
+	list := instances(t, 3)
+	balancer, err := upstream.NewBalancer(upstream.BalancerRoundRobin)
+	require.NoError(t, err)
+
+	list[1].Breaker.Failure()
+	require.Equal(t, list[2], balancer.Pick(list, map[*upstream.Instance]bool{list[0]: true}))
+
+	// Если свежих экземпляров не осталось, повторяем на уже опробованном.
+	tried := map[*upstream.Instance]bool{list[0]: true, list[2]: true}
+	require.Contains(t, []*upstream.Instance{list[0], list[2]}, balancer.Pick(list, tried))
+
+	list[0].Breaker.Failure()
+	list[2].Breaker.Failure()
+	require.Nil(t, balancer.Pick(list, nil))
+}
+
+func TestLeastConn(t *testing.T) {
+	release := make(chan struct{})
+	arrived := make(chan struct{})
+	var hits sync.Map
+
+	handler := func(name string, block bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			hits.Store(name, true)
+			if block {
+				arrived <- struct{}{}
+				<-release
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	busy := httptest.NewServer(handler("busy", true))
+	defer busy.Close()
+	idle := httptest.NewServer(handler("idle", false))
+	defer idle.Close()
+
+	u := newUpstream(t, upstream.Config{BreakerThreshold: 10, BreakerCooldown: time.Minute}, upstream.BalancerLeastConn, busy.URL, idle.URL)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := u.Client.Get(busy.URL + "/users/me")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-arrived
+
+	require.Equal(t, int64(1), u.Instances[0].Status().ActiveRequests)
+	resp, err := u.Client.Get(busy.URL + "/users/me")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, ok := hits.Load("idle")
+	require.True(t, ok, "second request goes to the idle instance")
+
+	close(release)
+	<-done
+}
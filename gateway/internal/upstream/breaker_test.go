@@ -0,0 +1,59 @@
+package upstream_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker_Transitions(t *testing.T) {
+	b := upstream.NewBreaker(2, 20*time.Millisecond)
+
+	require.True(t, b.Allow())
+	b.Failure()
+	require.Equal(t, upstream.StateClosed, b.State())
+
+	b.Failure()
+	require.Equal(t, upstream.StateOpen, b.State())
+	require.False(t, b.Allow())
+	require.False(t, b.Ready())
+	require.Positive(t, b.RetryAfter())
+
+	time.Sleep(30 * time.Millisecond)
+	require.True(t, b.Ready())
+	require.True(t, b.Allow())
+	require.Equal(t, upstream.StateHalfOpen, b.State())
+	require.False(t, b.Allow(), "only one probe at a time")
+
+	b.Success()
+	require.Equal(t, upstream.StateClosed, b.State())
+	require.True(t, b.Allow())
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	b := upstream.NewBreaker(1, 20*time.Millisecond)
+	b.Failure()
+
+	time.Sleep(30 * time.Millisecond)
+	require.True(t, b.Allow())
+
+	b.Failure()
+	require.Equal(t, upstream.StateOpen, b.State())
+	require.False(t, b.Allow())
+}
+
+func TestBreaker_ReleaseFreesProbe(t *testing.T) {
+	b := upstream.NewBreaker(1, 20*time.Millisecond)
+	b.Failure()
+
+	time.Sleep(30 * time.Millisecond)
+	require.True(t, b.Allow())
+	require.False(t, b.Ready())
+
+	b.Release()
+	require.Equal(t, upstream.StateHalfOpen, b.State())
+	require.True(t, b.Ready())
+	require.True(t, b.Allow())
+}
@@ -0,0 +1,71 @@
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/pkg/metrics"
+)
+
+// StartHealthChecks периодически опрашивает health-эндпоинт каждого экземпляра
+// до отмены контекста. Экземпляр, не ответивший 2xx, выводится из балансировки.
+// Экземпляры опрашиваются параллельно, а на ответ отводится половина интервала,
+// чтобы зависший экземпляр не задерживал проверку остальных.
+func (u *Upstream) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	if u.healthPath == "" {
+		return
+	}
+
+	client := &http.Client{Transport: u.base, Timeout: interval / 2}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var wg sync.WaitGroup
+		for _, inst := range u.Instances {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				u.check(ctx, client, inst)
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *Upstream) check(ctx context.Context, client *http.Client, inst *Instance) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, inst.URL.JoinPath(u.healthPath).String(), nil)
+	if err != nil {
+		inst.setHealth(err)
+		return
+	}
+
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			err = fmt.Errorf("health check returned %d", resp.StatusCode)
+		}
+	}
+
+	wasHealthy := inst.healthy.Load()
+	inst.setHealth(err)
+	metrics.SetUpstreamHealthy(u.Name, inst.URL.Host, err == nil)
+
+	switch {
+	case wasHealthy && err != nil:
+		log.Printf("Upstream %s instance %s is unhealthy: %v", u.Name, inst.URL, err)
+	case !wasHealthy && err == nil:
+		log.Printf("Upstream %s instance %s is healthy again", u.Name, inst.URL)
+	}
+}
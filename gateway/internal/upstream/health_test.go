@@ -0,0 +1,106 @@
+package upstream_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecks(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	var served atomic.Int64
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		served.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer flaky.Close()
+	stable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer stable.Close()
+
+	u, err := upstream.New("course", upstream.ServiceConfig{
+		URLs:       []string{flaky.URL, stable.URL},
+		HealthPath: "/health",
+	}, upstream.Config{BreakerThreshold: 10, BreakerCooldown: time.Minute})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go u.StartHealthChecks(ctx, 10*time.Millisecond)
+
+	healthy.Store(false)
+	require.Eventually(t, func() bool {
+		return u.Status().Available == 1
+	}, time.Second, 5*time.Millisecond)
+
+	status := u.Status().Instances[0]
+	require.False(t, status.Healthy)
+	require.Equal(t, "health check returned 503", status.LastError)
+
+	for range 4 {
+		resp, err := u.Client.Get(stable.URL + "/course/list")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	require.Zero(t, served.Load(), "unhealthy instance is out of rotation")
+
+	healthy.Store(true)
+	require.Eventually(t, func() bool {
+		return u.Status().Available == 2
+	}, time.Second, 5*time.Millisecond)
+	require.Empty(t, u.Status().Instances[0].LastError)
+}
+
+func TestHealthChecks_HangingInstanceDoesNotDelayOthers(t *testing.T) {
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hanging.Close()
+	defer close(release)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	u, err := upstream.New("course", upstream.ServiceConfig{
+		URLs:       []string{hanging.URL, failing.URL},
+		HealthPath: "/health",
+	}, upstream.Config{BreakerThreshold: 10, BreakerCooldown: time.Minute})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go u.StartHealthChecks(ctx, time.Second)
+
+	// Неисправный экземпляр выводится сразу, не дожидаясь таймаута зависшего.
+	require.Eventually(t, func() bool {
+		return !u.Status().Instances[1].Healthy
+	}, 300*time.Millisecond, 5*time.Millisecond)
+	require.True(t, u.Status().Instances[0].Healthy)
+
+	// Зависший экземпляр выводится по таймауту, который короче интервала.
+	require.Eventually(t, func() bool {
+		return !u.Status().Instances[0].Healthy
+	}, 900*time.Millisecond, 5*time.Millisecond)
+}
@@ -0,0 +1,71 @@
+package upstream
+
+import (
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Instance - один экземпляр сервиса. Активная проверка здоровья выставляет
+// healthy, а Breaker экземпляра временно исключает его после ошибок подряд.
+type Instance struct {
+	URL     *url.URL
+	Breaker *Breaker
+
+	healthy atomic.Bool
+	active  atomic.Int64
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	lastError string
+}
+
+func newInstance(u *url.URL, cfg Config) *Instance {
+	inst := &Instance{
+		URL:     u,
+		Breaker: NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+	inst.healthy.Store(true)
+	return inst
+}
+
+func (i *Instance) available() bool {
+	return i.healthy.Load() && i.Breaker.Ready()
+}
+
+func (i *Instance) setHealth(err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.lastCheck = time.Now()
+	if err != nil {
+		i.lastError = err.Error()
+	} else {
+		i.lastError = ""
+	}
+	i.healthy.Store(err == nil)
+}
+
+type InstanceStatus struct {
+	URL            string    `json:"url"`
+	Healthy        bool      `json:"healthy"`
+	State          string    `json:"state"`
+	ActiveRequests int64     `json:"active_requests"`
+	LastCheck      time.Time `json:"last_check"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+func (i *Instance) Status() InstanceStatus {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return InstanceStatus{
+		URL:            i.URL.String(),
+		Healthy:        i.healthy.Load(),
+		State:          i.Breaker.State().String(),
+		ActiveRequests: i.active.Load(),
+		LastCheck:      i.lastCheck,
+		LastError:      i.lastError,
+	}
+}
@@ -0,0 +1,210 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/pkg/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          200,
+		MaxIdleConnsPerHost:   64,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// balancedTransport для каждой попытки выбирает экземпляр через Balancer и
+// переписывает на него адрес запроса. Идемпотентные запросы повторяются при
+// сетевых ошибках и ответах 502/503/504 с экспоненциальной задержкой, по
+// возможности на другом экземпляре. Результаты учитываются в Breaker экземпляра.
+type balancedTransport struct {
+	upstream *Upstream
+}
+
+func (t *balancedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := t.upstream
+	retryable := isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	tried := make(map[*Instance]bool, len(u.Instances))
+
+	for attempt := 0; ; attempt++ {
+		inst := u.balancer.Pick(u.Instances, tried)
+		if inst == nil || !inst.Breaker.Allow() {
+			metrics.ObserveUpstreamRejected(u.Name)
+			return nil, ErrCircuitOpen
+		}
+		tried[inst] = true
+
+		ctx, span := startAttemptSpan(req, u.Name, inst, attempt)
+		out := req.Clone(ctx)
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(out.Header))
+		out.URL.Scheme = inst.URL.Scheme
+		out.URL.Host = inst.URL.Host
+		out.Host = inst.URL.Host
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				inst.Breaker.Release()
+				tracing.End(span, err)
+				return nil, err
+			}
+			out.Body = body
+		}
+
+		inst.active.Add(1)
+		start := time.Now()
+		resp, err := u.base.RoundTrip(out)
+		if resp != nil {
+			resp.Body = trackActive(resp.Body, inst)
+		} else {
+			inst.active.Add(-1)
+		}
+		metrics.ObserveUpstream(u.Name, inst.URL.Host, resp, err, time.Since(start))
+
+		failed := err != nil || isRetryableStatus(resp.StatusCode)
+		endAttemptSpan(span, resp, err)
+
+		switch {
+		case errors.Is(err, context.Canceled):
+			// Клиент ушел сам - это не проблема сервиса, но место пробного
+			// запроса нужно вернуть, иначе half-open breaker не пропустит следующий.
+			inst.Breaker.Release()
+		case failed:
+			inst.Breaker.Failure()
+		default:
+			inst.Breaker.Success()
+		}
+
+		if !failed || !retryable || attempt >= u.cfg.MaxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), u.delay(attempt)); err != nil {
+			return nil, err
+		}
+		metrics.ObserveUpstreamRetry(u.Name)
+	}
+}
+
+// trackActive держит запрос в счетчике активных запросов экземпляра, пока тело
+// ответа не закрыто: для SSE и WebSocket это все время жизни соединения, и
+// least_conn видит открытые потоки. После upgrade ReverseProxy пишет в тело,
+// поэтому io.ReadWriteCloser сохраняется.
+func trackActive(body io.ReadCloser, inst *Instance) io.ReadCloser {
+	var once sync.Once
+	release := func() { once.Do(func() { inst.active.Add(-1) }) }
+
+	if conn, ok := body.(io.ReadWriteCloser); ok {
+		return &activeConn{ReadWriteCloser: conn, release: release}
+	}
+	return &activeBody{ReadCloser: body, release: release}
+}
+
+type activeBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *activeBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+type activeConn struct {
+	io.ReadWriteCloser
+	release func()
+}
+
+func (c *activeConn) Close() error {
+	defer c.release()
+	return c.ReadWriteCloser.Close()
+}
+
+// startAttemptSpan открывает клиентский span на попытку, только если запрос уже
+// трассируется: фоновые запросы gateway (отзыв токенов, спецификации) не шумят.
+func startAttemptSpan(req *http.Request, service string, inst *Instance, attempt int) (context.Context, trace.Span) {
+	ctx := req.Context()
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	return tracing.Tracer().Start(ctx, "upstream "+service,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(inst.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+			attribute.Int("upstream.attempt", attempt),
+		),
+	)
+}
+
+func endAttemptSpan(span trace.Span, resp *http.Response, err error) {
+	if !span.SpanContext().IsValid() {
+		return
+	}
+	if resp != nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+	tracing.End(span, err)
+}
+
+func (u *Upstream) delay(attempt int) time.Duration {
+	d := u.cfg.RetryBackoff << attempt
+	return d/2 + time.Duration(rand.Int64N(int64(d/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
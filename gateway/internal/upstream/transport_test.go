@@ -0,0 +1,170 @@
+package upstream_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/gateway/internal/upstream"
+	"github.com/stretchr/testify/require"
+)
+
+func newUpstream(t *testing.T, cfg upstream.Config, balancer string, urls ...string) *upstream.Upstream {
+	t.Helper()
+
+	u, err := upstream.New("user", upstream.ServiceConfig{URLs: urls, Balancer: balancer}, cfg)
+	require.NoError(t, err)
+	return u
+}
+
+func TestTransport_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		wantCode int
+		wantHits int64
+	}{
+		{name: "idempotent is retried", method: http.MethodGet, wantCode: http.StatusOK, wantHits: 3},
+		{name: "put is retried", method: http.MethodPut, wantCode: http.StatusOK, wantHits: 3},
+		{name: "post is not retried", method: http.MethodPost, wantCode: http.StatusServiceUnavailable, wantHits: 1},
+		{name: "patch is not retried", method: http.MethodPatch, wantCode: http.StatusServiceUnavailable, wantHits: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if hits.Add(1) < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			u := newUpstream(t, upstream.Config{
+				MaxRetries:       2,
+				RetryBackoff:     time.Millisecond,
+				BreakerThreshold: 10,
+				BreakerCooldown:  time.Second,
+			}, "", server.URL)
+
+			req, err := http.NewRequest(tt.method, server.URL+"/users/me", strings.NewReader("{}"))
+			require.NoError(t, err)
+
+			resp, err := u.Client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+			require.Equal(t, tt.wantHits, hits.Load())
+		})
+	}
+}
+
+func TestTransport_RetriesOnAnotherInstance(t *testing.T) {
+	var failedHits, okHits atomic.Int64
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failedHits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		okHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	u := newUpstream(t, upstream.Config{
+		MaxRetries:       1,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 10,
+		BreakerCooldown:  time.Second,
+	}, "", failing.URL, healthy.URL)
+
+	resp, err := u.Client.Get(failing.URL + "/users/me")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int64(1), failedHits.Load())
+	require.Equal(t, int64(1), okHits.Load())
+}
+
+func TestTransport_CircuitOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	u := newUpstream(t, upstream.Config{
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 1,
+		BreakerCooldown:  time.Minute,
+	}, "", server.URL)
+
+	resp, err := u.Client.Get(server.URL + "/users/me")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, upstream.StateOpen, u.Instances[0].Breaker.State())
+
+	_, err = u.Client.Get(server.URL + "/users/me")
+	require.ErrorIs(t, err, upstream.ErrCircuitOpen)
+
+	w := httptest.NewRecorder()
+	u.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "60", w.Header().Get("Retry-After"))
+}
+
+func TestTransport_CancelledProbeReleasesBreaker(t *testing.T) {
+	var mode atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch mode.Load() {
+		case 0:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 1:
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	u := newUpstream(t, upstream.Config{
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 1,
+		BreakerCooldown:  20 * time.Millisecond,
+	}, "", server.URL)
+	breaker := u.Instances[0].Breaker
+
+	resp, err := u.Client.Get(server.URL + "/users/me")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, upstream.StateOpen, breaker.State())
+
+	time.Sleep(30 * time.Millisecond)
+	mode.Store(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/users/me", nil)
+	require.NoError(t, err)
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err = u.Client.Do(req)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, upstream.StateHalfOpen, breaker.State())
+	require.True(t, breaker.Ready(), "cancelled probe must free the slot")
+
+	mode.Store(2)
+	resp, err = u.Client.Get(server.URL + "/users/me")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, upstream.StateClosed, breaker.State())
+}
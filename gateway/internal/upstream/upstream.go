@@ -0,0 +1,199 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type Config struct {
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// ServiceConfig описывает экземпляры сервиса. В URL учитываются только схема и хост.
+type ServiceConfig struct {
+	URLs       []string
+	Balancer   string
+	HealthPath string
+}
+
+// Upstream - долгоживущий reverse proxy к сервису с общим пулом соединений
+// и балансировкой между его экземплярами.
+type Upstream struct {
+	Name      string
+	URL       *url.URL
+	Instances []*Instance
+	Client    *http.Client
+
+	balancerName string
+	balancer     Balancer
+	healthPath   string
+	cfg          Config
+	base         *http.Transport
+	proxy        *httputil.ReverseProxy
+}
+
+func New(name string, svc ServiceConfig, cfg Config) (*Upstream, error) {
+	if len(svc.URLs) == 0 {
+		return nil, fmt.Errorf("service %q has no instances", name)
+	}
+
+	balancer, err := NewBalancer(svc.Balancer)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", name, err)
+	}
+
+	u := &Upstream{
+		Name:         name,
+		balancerName: svc.Balancer,
+		balancer:     balancer,
+		healthPath:   svc.HealthPath,
+		cfg:          cfg,
+		base:         newTransport(),
+	}
+	if u.balancerName == "" {
+		u.balancerName = BalancerRoundRobin
+	}
+
+	for _, rawURL := range svc.URLs {
+		target, err := url.Parse(rawURL)
+		if err != nil || target.Host == "" {
+			return nil, fmt.Errorf("invalid url %q for service %q", rawURL, name)
+		}
+		u.Instances = append(u.Instances, newInstance(target, cfg))
+	}
+	u.URL = u.Instances[0].URL
+
+	transport := &balancedTransport{upstream: u}
+	u.Client = &http.Client{Transport: transport}
+
+	u.proxy = httputil.NewSingleHostReverseProxy(&url.URL{Scheme: u.URL.Scheme, Host: u.URL.Host})
+	u.proxy.Transport = transport
+	u.proxy.ErrorHandler = u.errorHandler
+
+	return u, nil
+}
+
+func (u *Upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.proxy.ServeHTTP(w, r)
+}
+
+// RetryAfter - через сколько освободится ближайший исключенный breaker'ом экземпляр.
+// Если экземпляры исключены только проверкой здоровья, срок неизвестен.
+func (u *Upstream) RetryAfter() time.Duration {
+	wait := time.Duration(0)
+	for _, inst := range u.Instances {
+		if d := inst.Breaker.RetryAfter(); d > 0 && (wait == 0 || d < wait) {
+			wait = d
+		}
+	}
+	return max(wait, time.Second)
+}
+
+func (u *Upstream) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		retryAfter := int(math.Ceil(u.RetryAfter().Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"error":       "service unavailable",
+			"service":     u.Name,
+			"reason":      "circuit_open",
+			"retry_after": retryAfter,
+		})
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSON(w, http.StatusGatewayTimeout, map[string]any{
+			"error":   "service timeout",
+			"service": u.Name,
+		})
+	case errors.Is(err, context.Canceled):
+		// Клиент закрыл соединение, отвечать некому.
+	default:
+		writeJSON(w, http.StatusBadGateway, map[string]any{
+			"error":   "service unavailable",
+			"service": u.Name,
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+type Status struct {
+	Service   string           `json:"service"`
+	Balancer  string           `json:"balancer"`
+	Available int              `json:"available"`
+	Instances []InstanceStatus `json:"instances"`
+}
+
+func (u *Upstream) Status() Status {
+	status := Status{
+		Service:  u.Name,
+		Balancer: u.balancerName,
+	}
+	for _, inst := range u.Instances {
+		if inst.available() {
+			status.Available++
+		}
+		status.Instances = append(status.Instances, inst.Status())
+	}
+	return status
+}
+
+// Registry хранит upstream'ы по имени сервиса.
+type Registry struct {
+	upstreams map[string]*Upstream
+}
+
+func NewRegistry(services map[string]ServiceConfig, cfg Config) (*Registry, error) {
+	r := &Registry{upstreams: make(map[string]*Upstream, len(services))}
+	for name, svc := range services {
+		u, err := New(name, svc, cfg)
+		if err != nil {
+			return nil, err
+		}
+		r.upstreams[name] = u
+	}
+	return r, nil
+}
+
+func (r *Registry) Get(name string) (*Upstream, bool) {
+	u, ok := r.upstreams[name]
+	return u, ok
+}
+
+func (r *Registry) Has(name string) bool {
+	_, ok := r.upstreams[name]
+	return ok
+}
+
+func (r *Registry) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	for _, u := range r.upstreams {
+		go u.StartHealthChecks(ctx, interval)
+	}
+}
+
+func (r *Registry) Status() []Status {
+	statuses := make([]Status, 0, len(r.upstreams))
+	for _, u := range r.upstreams {
+		statuses = append(statuses, u.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Service < statuses[j].Service
+	})
+	return statuses
+}
@@ -0,0 +1,25 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type Config struct {
+	Addr string
+	DB   int
+}
+
+func NewRedisClient(ctx context.Context, cfg Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr: cfg.Addr,
+		DB:   cfg.DB,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
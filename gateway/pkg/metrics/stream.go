@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	streamsActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stream_connections_active",
+		Help: "Количество открытых долгоживущих соединений (WebSocket и SSE).",
+	}, []string{"service"})
+
+	streamsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stream_connections_rejected_total",
+		Help: "Количество долгоживущих соединений, отклоненных по лимиту. reason - total или user.",
+	}, []string{"service", "reason"})
+)
+
+func StreamOpened(service string) {
+	streamsActive.WithLabelValues(service).Inc()
+}
+
+func StreamClosed(service string) {
+	streamsActive.WithLabelValues(service).Dec()
+}
+
+func ObserveStreamRejected(service, reason string) {
+	streamsRejected.WithLabelValues(service, reason).Inc()
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_requests_total",
+		Help: "Количество попыток запроса к экземплярам сервисов. status - код ответа или error.",
+	}, []string{"service", "instance", "status"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upstream_request_duration_seconds",
+		Help:    "Время ответа экземпляра сервиса на одну попытку.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "instance"})
+
+	upstreamRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_retries_total",
+		Help: "Количество повторных попыток запроса к сервису.",
+	}, []string{"service"})
+
+	upstreamRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_rejected_total",
+		Help: "Количество запросов, не отправленных в сервис: нет доступных экземпляров или открыт circuit breaker.",
+	}, []string{"service"})
+
+	upstreamHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "upstream_instance_healthy",
+		Help: "Результат последней проверки здоровья экземпляра: 1 - здоров, 0 - нет.",
+	}, []string{"service", "instance"})
+)
+
+// ObserveUpstream учитывает одну попытку запроса к экземпляру сервиса.
+func ObserveUpstream(service, instance string, resp *http.Response, err error, d time.Duration) {
+	status := "error"
+	switch {
+	case resp != nil:
+		status = strconv.Itoa(resp.StatusCode)
+	case errors.Is(err, context.Canceled):
+		status = "canceled"
+	}
+
+	upstreamDuration.WithLabelValues(service, instance).Observe(d.Seconds())
+	upstreamRequests.WithLabelValues(service, instance, status).Inc()
+}
+
+func ObserveUpstreamRetry(service string) {
+	upstreamRetries.WithLabelValues(service).Inc()
+}
+
+func ObserveUpstreamRejected(service string) {
+	upstreamRejected.WithLabelValues(service).Inc()
+}
+
+func SetUpstreamHealthy(service, instance string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	upstreamHealthy.WithLabelValues(service, instance).Set(value)
+}
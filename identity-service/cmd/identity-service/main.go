@@ -1,21 +1,33 @@
 package main
 
 import (
+	"context"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 
 	_ "github.com/JojoWeyn/duo-proj/identity-service/docs"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/composite"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/keyset"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/notifier"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/oidc"
 	"github.com/JojoWeyn/duo-proj/identity-service/pkg/client/postgresql"
+	"github.com/JojoWeyn/duo-proj/identity-service/pkg/client/smtp"
+	"github.com/JojoWeyn/duo-proj/shared/env"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	"github.com/joho/godotenv"
 )
 
@@ -33,6 +45,16 @@ func main() {
 		log.Printf("Warning: .env file not found")
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "identity-service"),
+		Exporter:    getEnv("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
+		SampleRatio: env.Float("OTEL_TRACES_SAMPLER_ARG", 1),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %s", err.Error())
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := postgresql.NewPostgresDB(postgresql.Config{
 		Host:     getEnv("DB_HOST", "localhost"),
 		User:     getEnv("DB_USER", "postgres"),
@@ -45,43 +67,109 @@ func main() {
 		log.Fatalf("Failed to initialize db: %s", err.Error())
 	}
 
-	if err := db.AutoMigrate(&entity.Identity{}, entity.BlacklistedToken{}); err != nil {
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		log.Fatalf("Failed to register tracing plugin: %s", err.Error())
+	}
+	if err := db.Use(metrics.GormPlugin()); err != nil {
+		log.Fatalf("Failed to register metrics plugin: %s", err.Error())
+	}
+
+	if err := db.AutoMigrate(&entity.Identity{}, entity.BlacklistedToken{}, &entity.VerificationCode{}, &entity.ExternalAccount{}, &entity.OIDCState{},
+		&entity.TOTPCredential{}, &entity.RecoveryCode{}, &entity.MFAChallenge{}, &entity.MFARolePolicy{}, &entity.Session{}, &entity.SigningKey{},
+		&entity.FailureCounter{}, &entity.LockoutEvent{}); err != nil {
 		log.Fatalf("Failed to migrate db: %s", err.Error())
 	}
 
-	privateKeySigned, err := loadPrivateKey("/app/private.pem")
+	// Ключи из PEM-файлов нужны только для импорта в пустой набор ключей подписи.
+	privateKeySigned, err := loadLegacyPrivateKey("/app/private.pem")
 	if err != nil {
 		log.Fatalf("Failed to load private key: %v", err)
 	}
 
-	publicKeySigned, err := loadPublicKey("/app/public.pem")
+	privateKeyRef, err := loadLegacyPrivateKey("/app/privateRef.pem")
 	if err != nil {
-		log.Fatalf("Failed to load public key: %v", err)
+		log.Fatalf("Failed to load private key: %v", err)
 	}
 
-	privateKeyRef, err := loadPrivateKey("/app/privateRef.pem")
+	// Без ключей секреты TOTP и ключи подписи лежат в базе открыто, поэтому это
+	// допускается только явно, например для локальной разработки.
+	allowUnencrypted := getEnv("ALLOW_UNENCRYPTED_KEYS", "false") == "true"
+
+	mfaKey, err := getEncryptionKey("MFA_ENCRYPTION_KEY", "TOTP secrets", allowUnencrypted)
 	if err != nil {
-		log.Fatalf("Failed to load private key: %v", err)
+		log.Fatalf("Failed to load MFA encryption key: %v", err)
 	}
 
-	publicKeyRef, err := loadPublicKey("/app/publicRef.pem")
+	keysetKey, err := getEncryptionKey("KEYSET_ENCRYPTION_KEY", "signing keys", allowUnencrypted)
 	if err != nil {
-		log.Fatalf("Failed to load public key: %v", err)
+		log.Fatalf("Failed to load keyset encryption key: %v", err)
 	}
 
 	identityComposite, err := composite.NewIdentityComposite(db, composite.Config{
-		AccessTokenTTL:  time.Duration(getEnvAsInt("ACCESS_TOKEN_TTL", 15)) * time.Minute,
-		RefreshTokenTTL: time.Duration(getEnvAsInt("REFRESH_TOKEN_TTL", 24)) * time.Minute,
-		SigningKey:      privateKeySigned,
-		SigningPublic:   publicKeySigned,
-		RefreshKey:      privateKeyRef,
-		RefreshPublic:   publicKeyRef,
-		GatewayURL:      getEnv("GATEWAY_URL", "176.109.108.209:3211"),
-		KafkaBrokers:    getEnv("KAFKA_BROKERS", "kafka:29092"),
-		SmtpServer:      getEnv("SMTP_SERVER", ""),
-		SmtpPort:        getEnv("SMTP_PORT", "443"),
-		SmtpSender:      getEnv("SMTP_SENDER", ""),
-		SmtpPassword:    getEnv("SMTP_PASSWORD", ""),
+		AccessTokenTTL:      time.Duration(getEnvAsInt("ACCESS_TOKEN_TTL", 15)) * time.Minute,
+		RefreshTokenTTL:     time.Duration(getEnvAsInt("REFRESH_TOKEN_TTL", 24)) * time.Minute,
+		KafkaBrokers:        getEnv("KAFKA_BROKERS", "kafka:29092"),
+		IdentityEventsTopic: getEnv("KAFKA_IDENTITY_TOPIC", "identity_changed"),
+		SmtpServer:          getEnv("SMTP_SERVER", ""),
+		SmtpPort:            getEnv("SMTP_PORT", "443"),
+		SmtpSender:          getEnv("SMTP_SENDER", ""),
+		SmtpPassword:        getEnv("SMTP_PASSWORD", ""),
+
+		SmtpSecurity: getEnv("SMTP_SECURITY", smtp.SecurityTLS),
+		Notifier:     getEnv("NOTIFIER", "smtp"),
+		NotifierFile: getEnv("NOTIFIER_FILE", "notifications.log"),
+		NotificationQueue: notifier.QueueConfig{
+			Size:        getEnvAsNumber("NOTIFICATION_QUEUE_SIZE", notifier.DefaultQueueConfig.Size),
+			Workers:     getEnvAsNumber("NOTIFICATION_WORKERS", notifier.DefaultQueueConfig.Workers),
+			MaxAttempts: getEnvAsNumber("NOTIFICATION_MAX_ATTEMPTS", notifier.DefaultQueueConfig.MaxAttempts),
+			Backoff:     getEnvAsDuration("NOTIFICATION_RETRY_BACKOFF", notifier.DefaultQueueConfig.Backoff),
+			SendTimeout: getEnvAsDuration("NOTIFICATION_SEND_TIMEOUT", notifier.DefaultQueueConfig.SendTimeout),
+		},
+
+		VerificationPolicy: usecase.VerificationPolicy{
+			TTL:            getEnvAsDuration("VERIFICATION_CODE_TTL", usecase.DefaultVerificationPolicy.TTL),
+			MaxAttempts:    getEnvAsNumber("VERIFICATION_CODE_MAX_ATTEMPTS", usecase.DefaultVerificationPolicy.MaxAttempts),
+			ResendCooldown: getEnvAsDuration("VERIFICATION_CODE_RESEND_COOLDOWN", usecase.DefaultVerificationPolicy.ResendCooldown),
+		},
+
+		Lockout: usecase.LockoutPolicy{
+			Account: usecase.LockoutLimits{
+				FreeFailures: getEnvAsNumber("LOCKOUT_ACCOUNT_FREE_ATTEMPTS", usecase.DefaultLockoutPolicy.Account.FreeFailures),
+				BaseDelay:    getEnvAsDuration("LOCKOUT_BASE_DELAY", usecase.DefaultLockoutPolicy.Account.BaseDelay),
+				MaxDelay:     getEnvAsDuration("LOCKOUT_MAX_DELAY", usecase.DefaultLockoutPolicy.Account.MaxDelay),
+				LockAfter:    getEnvAsNumber("LOCKOUT_ACCOUNT_THRESHOLD", usecase.DefaultLockoutPolicy.Account.LockAfter),
+				LockDuration: getEnvAsDuration("LOCKOUT_DURATION", usecase.DefaultLockoutPolicy.Account.LockDuration),
+			},
+			IP: usecase.LockoutLimits{
+				FreeFailures: getEnvAsNumber("LOCKOUT_IP_FREE_ATTEMPTS", usecase.DefaultLockoutPolicy.IP.FreeFailures),
+				BaseDelay:    getEnvAsDuration("LOCKOUT_BASE_DELAY", usecase.DefaultLockoutPolicy.IP.BaseDelay),
+				MaxDelay:     getEnvAsDuration("LOCKOUT_MAX_DELAY", usecase.DefaultLockoutPolicy.IP.MaxDelay),
+				LockAfter:    getEnvAsNumber("LOCKOUT_IP_THRESHOLD", usecase.DefaultLockoutPolicy.IP.LockAfter),
+				LockDuration: getEnvAsDuration("LOCKOUT_DURATION", usecase.DefaultLockoutPolicy.IP.LockDuration),
+			},
+			Window: getEnvAsDuration("LOCKOUT_WINDOW", usecase.DefaultLockoutPolicy.Window),
+		},
+
+		TrustedNetworks: env.List("TRUSTED_NETWORKS"),
+
+		OIDCProviders: getOIDCProviders(),
+
+		MFA: usecase.MFAConfig{
+			Issuer:        getEnv("MFA_ISSUER", usecase.DefaultMFAConfig.Issuer),
+			ChallengeTTL:  getEnvAsDuration("MFA_CHALLENGE_TTL", usecase.DefaultMFAConfig.ChallengeTTL),
+			MaxAttempts:   getEnvAsNumber("MFA_MAX_ATTEMPTS", usecase.DefaultMFAConfig.MaxAttempts),
+			RecoveryCodes: getEnvAsNumber("MFA_RECOVERY_CODES", usecase.DefaultMFAConfig.RecoveryCodes),
+		},
+		MFAEncryptionKey: mfaKey,
+
+		Keyset: keyset.Config{
+			RotationInterval: getEnvAsDuration("KEY_ROTATION_INTERVAL", keyset.DefaultConfig.RotationInterval),
+			PublishAhead:     getEnvAsDuration("KEY_PUBLISH_AHEAD", keyset.DefaultConfig.PublishAhead),
+			SyncInterval:     getEnvAsDuration("KEY_SYNC_INTERVAL", keyset.DefaultConfig.SyncInterval),
+		},
+		KeysetEncryptionKey: keysetKey,
+		SigningKey:          privateKeySigned,
+		RefreshKey:          privateKeyRef,
 	})
 	if err != nil {
 		log.Fatalf("Failed to initialize composite: %s", err.Error())
@@ -110,8 +198,30 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsNumber(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// loadLegacyPrivateKey читает ключ из PEM-файла; отсутствие файла не ошибка.
+func loadLegacyPrivateKey(path string) (*rsa.PrivateKey, error) {
 	privData, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read private key file: %v", err)
 	}
@@ -132,26 +242,44 @@ func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
 	return privKey.(*rsa.PrivateKey), nil
 }
 
-func loadPublicKey(path string) (*rsa.PublicKey, error) {
-	keyData, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+// getOIDCProviders читает провайдеров из OIDC_PROVIDERS (список имен) и
+// переменных OIDC_<ИМЯ>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL,
+// _SCOPES и, для провайдеров без discovery, _AUTH_URL, _TOKEN_URL,
+// _USERINFO_URL, _JWKS_URL.
+func getOIDCProviders() []oidc.Config {
+	var providers []oidc.Config
+	for _, name := range env.List("OIDC_PROVIDERS") {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, oidc.Config{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       env.List(prefix + "SCOPES"),
+			AuthURL:      getEnv(prefix+"AUTH_URL", ""),
+			TokenURL:     getEnv(prefix+"TOKEN_URL", ""),
+			UserInfoURL:  getEnv(prefix+"USERINFO_URL", ""),
+			JWKSURL:      getEnv(prefix+"JWKS_URL", ""),
+		})
 	}
+	return providers
+}
 
-	block, _ := pem.Decode(keyData)
-	if block == nil || block.Type != "PUBLIC KEY" {
-		return nil, errors.New("failed to decode PEM block with public key")
+// getEncryptionKey читает ключ шифрования хранимых секретов (base64, 32 байта).
+func getEncryptionKey(name, secrets string, allowUnencrypted bool) ([]byte, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		if !allowUnencrypted {
+			return nil, fmt.Errorf("%s is not set; set ALLOW_UNENCRYPTED_KEYS=true to store %s unencrypted", name, secrets)
+		}
+		log.Printf("Warning: %s is not set, %s are stored unencrypted", name, secrets)
+		return nil, nil
 	}
 
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	key, err := base64.StdEncoding.DecodeString(value)
 	if err != nil {
-		return nil, err
-	}
-
-	publicKey, ok := pub.(*rsa.PublicKey)
-	if !ok {
-		return nil, errors.New("not RSA public key")
+		return nil, fmt.Errorf("%s must be base64: %w", name, err)
 	}
-
-	return publicKey, nil
+	return key, nil
 }
@@ -1,16 +1,29 @@
 package composite
 
 import (
+	"context"
 	"crypto/rsa"
+	"fmt"
 	"github.com/JojoWeyn/duo-proj/identity-service/pkg/client/smtp"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	"os"
 	"time"
 
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/middleware"
 	v1 "github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/wellknown"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/kafka"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/crypto/secretbox"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/keyset"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/notifier"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/oidc"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/service"
+	"github.com/JojoWeyn/duo-proj/shared/health"
+	sharedmiddleware "github.com/JojoWeyn/duo-proj/shared/middleware"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -22,66 +35,248 @@ type IdentityComposite struct {
 type Config struct {
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
-	SigningKey      *rsa.PrivateKey
-	RefreshKey      *rsa.PrivateKey
-	SigningPublic   *rsa.PublicKey
-	RefreshPublic   *rsa.PublicKey
-	GatewayURL      string
 	KafkaBrokers    string
-	SmtpServer      string
-	SmtpPort        string
-	SmtpSender      string
-	SmtpPassword    string
+	// IdentityEventsTopic - топик Kafka для событий об изменении пользователей администратором.
+	IdentityEventsTopic string
+	SmtpServer          string
+	SmtpPort            string
+	SmtpSender          string
+	SmtpPassword        string
+
+	// SmtpSecurity - защита соединения с SMTP: tls, starttls или none.
+	SmtpSecurity string
+	// Notifier - доставка писем: smtp, console (в stdout) или file (в NotifierFile).
+	Notifier          string
+	NotifierFile      string
+	NotificationQueue notifier.QueueConfig
+
+	// VerificationPolicy - срок действия, попытки и интервал повторной отправки кодов подтверждения.
+	VerificationPolicy usecase.VerificationPolicy
+
+	// Lockout - задержки и временная блокировка после неудачных попыток входа и ввода кодов.
+	Lockout usecase.LockoutPolicy
+
+	// TrustedNetworks - сети (CIDR или адреса), из которых принимаются запросы; пустой список не ограничивает.
+	// Только от них же принимается X-Forwarded-For.
+	TrustedNetworks []string
+
+	// OIDCProviders - внешние провайдеры входа (Google, Яндекс, VK ID и др.).
+	OIDCProviders []oidc.Config
+
+	// MFA - параметры второго фактора входа.
+	MFA usecase.MFAConfig
+	// MFAEncryptionKey - 32-байтный ключ шифрования секретов TOTP; без него секреты хранятся открыто.
+	MFAEncryptionKey []byte
+
+	// Keyset - ротация ключей подписи токенов.
+	Keyset keyset.Config
+	// KeysetEncryptionKey - 32-байтный ключ шифрования закрытых ключей подписи в базе.
+	KeysetEncryptionKey []byte
+	// SigningKey и RefreshKey - ключи из PEM-файлов прежней конфигурации.
+	// Импортируются в пустой набор, чтобы выданные ими токены остались действительными.
+	SigningKey *rsa.PrivateKey
+	RefreshKey *rsa.PrivateKey
 }
 
 func NewIdentityComposite(db *gorm.DB, cfg Config) (*IdentityComposite, error) {
-	if err := db.AutoMigrate(&entity.Identity{}, &entity.BlacklistedToken{}); err != nil {
+	if err := db.AutoMigrate(&entity.Identity{}, &entity.BlacklistedToken{}, &entity.VerificationCode{}, &entity.ExternalAccount{}, &entity.OIDCState{},
+		&entity.TOTPCredential{}, &entity.RecoveryCode{}, &entity.MFAChallenge{}, &entity.MFARolePolicy{}, &entity.Session{}, &entity.SigningKey{},
+		&entity.FailureCounter{}, &entity.LockoutEvent{}); err != nil {
+		return nil, err
+	}
+	// Коды подтверждения раньше хранились открытым текстом в identities.
+	if err := db.Exec("ALTER TABLE identities DROP COLUMN IF EXISTS verification_code").Error; err != nil {
 		return nil, err
 	}
 
 	identityRepo := postgres.NewIdentityRepository(db)
 	tokenRepo := postgres.NewTokenRepository(db)
+	codeRepo := postgres.NewVerificationCodeRepository(db)
+	accountRepo := postgres.NewExternalAccountRepository(db)
+	stateRepo := postgres.NewOIDCStateRepository(db)
+	mfaRepo := postgres.NewMFARepository(db)
+	challengeRepo := postgres.NewMFAChallengeRepository(db)
+	policyRepo := postgres.NewMFAPolicyRepository(db)
+	sessionRepo := postgres.NewSessionRepository(db)
+	lockoutRepo := postgres.NewLockoutRepository(db)
+
+	keys, err := newKeyset(db, cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	tokenService := service.NewTokenService(
-		cfg.SigningKey,
-		cfg.SigningPublic,
-		cfg.RefreshKey,
-		cfg.RefreshPublic,
+		keys,
 		cfg.AccessTokenTTL,
 		cfg.RefreshTokenTTL,
 		tokenRepo,
 	)
 
-	smtpClient := smtp.NewSMTPClient(smtp.SMTPConfig{
-		Server:   cfg.SmtpServer,
-		Port:     cfg.SmtpPort,
-		Sender:   cfg.SmtpSender,
-		Password: cfg.SmtpPassword,
-	})
+	mailer, err := newNotifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := notifier.NewTemplates()
+	if err != nil {
+		return nil, err
+	}
+	notifications := notifier.NewQueue(mailer, cfg.NotificationQueue)
+	notifications.Start(context.Background())
 
-	verificationService := service.NewVerificationService(smtpClient)
+	verificationService := service.NewVerificationService(templates, notifications, cfg.VerificationPolicy.WithDefaults().TTL)
 
 	producer, err := kafka.NewProducer(cfg.KafkaBrokers, "user_create")
 	if err != nil {
 		return nil, err
 	}
 
+	identityProducer, err := kafka.NewProducer(cfg.KafkaBrokers, cfg.IdentityEventsTopic)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepo, identityRepo, tokenService)
+	lockoutUseCase := usecase.NewLockoutUseCase(lockoutRepo, cfg.Lockout)
+
+	secrets, err := secretbox.New(cfg.MFAEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaUseCase := usecase.NewMFAUseCase(
+		identityRepo,
+		mfaRepo,
+		challengeRepo,
+		policyRepo,
+		secrets,
+		sessionUseCase,
+		producer,
+		lockoutUseCase,
+		cfg.MFA,
+	)
+
 	identityUseCase := usecase.NewIdentityUseCase(
 		identityRepo,
 		tokenService,
 		tokenRepo,
+		codeRepo,
 		producer,
+		cfg.VerificationPolicy,
+		mfaUseCase,
+		sessionUseCase,
+		lockoutUseCase,
 	)
 
+	providers := make([]usecase.OIDCProvider, 0, len(cfg.OIDCProviders))
+	for _, providerCfg := range cfg.OIDCProviders {
+		provider, err := oidc.NewProvider(providerCfg, nil)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	oidcUseCase := usecase.NewOIDCUseCase(
+		providers,
+		identityRepo,
+		accountRepo,
+		stateRepo,
+		sessionUseCase,
+		producer,
+		mfaUseCase,
+	)
+
+	adminUseCase := usecase.NewAdminUseCase(identityRepo, sessionUseCase, identityProducer)
+
+	networks, err := sharedmiddleware.ParseNetworks(cfg.TrustedNetworks)
+	if err != nil {
+		return nil, err
+	}
+
 	handler := gin.Default()
+	// Адрес клиента берется из X-Forwarded-For только от gateway и соседей;
+	// без доверенных сетей - адрес соединения.
+	if err := handler.SetTrustedProxies(cfg.TrustedNetworks); err != nil {
+		return nil, err
+	}
+	handler.Use(sharedmiddleware.InternalNetwork(networks))
+	handler.Use(tracing.Middleware(), metrics.Middleware())
+	handler.Use(middleware.ClientInfo())
 
-	v1.NewRouter(handler, verificationService, identityUseCase, cfg.GatewayURL)
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	health.NewRouter(handler, sqlDB)
+	wellknown.NewRouter(handler, keys)
+	handler.GET(metrics.Path, gin.WrapH(metrics.Handler()))
+	v1.NewRouter(handler, verificationService, identityUseCase, oidcUseCase, mfaUseCase, sessionUseCase, lockoutUseCase, adminUseCase)
 
 	return &IdentityComposite{
 		handler: handler,
 	}, nil
 }
 
+// newKeyset загружает набор ключей подписи, при первом запуске импортирует
+// в него ключи из PEM-файлов и запускает ротацию.
+func newKeyset(db *gorm.DB, cfg Config) (*keyset.Keyset, error) {
+	sealer, err := secretbox.New(cfg.KeysetEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("keyset encryption key: %w", err)
+	}
+
+	keysetCfg := cfg.Keyset
+	keysetCfg.Lifetimes = map[string]time.Duration{
+		entity.SigningKeyAccess:  cfg.AccessTokenTTL,
+		entity.SigningKeyRefresh: cfg.RefreshTokenTTL,
+	}
+	keys := keyset.New(postgres.NewSigningKeyRepository(db), sealer, keysetCfg)
+
+	ctx := context.Background()
+	legacy := map[string]*rsa.PrivateKey{
+		entity.SigningKeyAccess:  cfg.SigningKey,
+		entity.SigningKeyRefresh: cfg.RefreshKey,
+	}
+	for use, key := range legacy {
+		if key == nil {
+			continue
+		}
+		if err := keys.Import(ctx, use, key); err != nil {
+			return nil, fmt.Errorf("failed to import %s signing key: %w", use, err)
+		}
+	}
+
+	if err := keys.Sync(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	go keys.Start(ctx)
+
+	return keys, nil
+}
+
+func newNotifier(cfg Config) (notifier.Notifier, error) {
+	switch cfg.Notifier {
+	case "", "smtp":
+		return notifier.NewSMTPNotifier(smtp.NewSMTPClient(smtp.SMTPConfig{
+			Server:   cfg.SmtpServer,
+			Port:     cfg.SmtpPort,
+			Sender:   cfg.SmtpSender,
+			Password: cfg.SmtpPassword,
+			Security: cfg.SmtpSecurity,
+		})), nil
+	case "console":
+		return notifier.NewConsoleNotifier(os.Stdout), nil
+	case "file":
+		file, err := os.OpenFile(cfg.NotifierFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open notifier file: %w", err)
+		}
+		return notifier.NewConsoleNotifier(file), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", cfg.Notifier)
+	}
+}
+
 func (c *IdentityComposite) Handler() *gin.Engine {
 	return c.handler
 }
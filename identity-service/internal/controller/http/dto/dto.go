@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=7"`
@@ -23,6 +25,20 @@ type TokenStatusResponse struct {
 	IsBlacklisted string `json:"is_blacklisted"`
 }
 
+type RevokedToken struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RevokedTokensResponse struct {
+	Tokens []RevokedToken `json:"tokens"`
+	Cursor time.Time      `json:"cursor"`
+}
+
+type DisabledIdentitiesResponse struct {
+	UUIDs []string `json:"uuids"`
+}
+
 type PasswordResetRequest struct {
 	Email       string `json:"email" binding:"required,email"`
 	NewPassword string `json:"new_password" binding:"required"`
@@ -33,3 +49,127 @@ type ConfirmEmailRequest struct {
 	Email string `json:"email" binding:"required,email"`
 	Code  string `json:"code" binding:"required"`
 }
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type ExternalAccountResponse struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MFAChallengeResponse struct {
+	Error              string    `json:"error"`
+	MFARequired        bool      `json:"mfa_required"`
+	MFAToken           string    `json:"mfa_token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type MFAConfirmRequest struct {
+	Code     string `json:"code" binding:"required"`
+	MFAToken string `json:"mfa_token"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	AccessToken   string   `json:"access_token,omitempty"`
+	RefreshToken  string   `json:"refresh_token,omitempty"`
+}
+
+type MFAPolicyRequest struct {
+	Role     string `json:"role" binding:"required"`
+	Required bool   `json:"required"`
+}
+
+type MFAPolicyResponse struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type LockoutResponse struct {
+	Kind          string     `json:"kind"`
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"`
+	Locked        bool       `json:"locked"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until"`
+}
+
+type LockoutEventResponse struct {
+	ID          int        `json:"id"`
+	Kind        string     `json:"kind"`
+	Key         string     `json:"key"`
+	Action      string     `json:"action"`
+	ActorUUID   string     `json:"actor_uuid,omitempty"`
+	IP          string     `json:"ip,omitempty"`
+	Failures    int        `json:"failures"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type IdentityResponse struct {
+	UserUUID       string     `json:"user_uuid"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	Provider       string     `json:"provider"`
+	IsConfirmEmail bool       `json:"is_confirm_email"`
+	Disabled       bool       `json:"disabled"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type IdentityListResponse struct {
+	Identities []IdentityResponse `json:"identities"`
+	Total      int64              `json:"total"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
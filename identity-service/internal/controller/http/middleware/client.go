@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceHeader - необязательное название устройства, которое клиент
+// показывает в списке сессий.
+const DeviceHeader = "X-Device-Name"
+
+const maxDeviceLength = 128
+
+// ClientInfo передает в контекст запроса устройство клиента: название,
+// User-Agent и адрес. Они сохраняются в сессии при входе и обновлении токенов.
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		device := c.GetHeader(DeviceHeader)
+		if len(device) > maxDeviceLength {
+			device = strings.ToValidUTF8(device[:maxDeviceLength], "")
+		}
+
+		ctx := usecase.ContextWithClient(c.Request.Context(), entity.ClientInfo{
+			Device:    device,
+			UserAgent: c.Request.UserAgent(),
+			IP:        c.ClientIP(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
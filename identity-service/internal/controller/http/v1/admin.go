@@ -0,0 +1,261 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/dto"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminUseCase interface {
+	List(ctx context.Context, filter entity.IdentityFilter) (*usecase.IdentityPage, error)
+	Get(ctx context.Context, userUUID string) (*entity.Identity, error)
+	SetRole(ctx context.Context, actorUUID, userUUID, role string) (*entity.Identity, error)
+	Disable(ctx context.Context, actorUUID, userUUID string) (*entity.Identity, error)
+	Enable(ctx context.Context, actorUUID, userUUID string) (*entity.Identity, error)
+	Logout(ctx context.Context, actorUUID, userUUID string) error
+}
+
+type adminRoutes struct {
+	adminUseCase    AdminUseCase
+	identityUseCase IdentityUseCase
+}
+
+func NewAdminRoutes(handler *gin.RouterGroup, adminUseCase AdminUseCase, identityUseCase IdentityUseCase) {
+	r := &adminRoutes{
+		adminUseCase:    adminUseCase,
+		identityUseCase: identityUseCase,
+	}
+
+	a := handler.Group("/auth/admin/identities")
+	{
+		a.GET("", r.list)
+		a.GET("/:uuid", r.get)
+		a.PUT("/:uuid/role", r.setRole)
+		a.POST("/:uuid/disable", r.disable)
+		a.POST("/:uuid/enable", r.enable)
+		a.DELETE("/:uuid/sessions", r.logout)
+	}
+}
+
+// @Summary Список пользователей
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param email query string false "Часть email"
+// @Param role query string false "user или admin"
+// @Param disabled query bool false "Только отключенные (true) или только активные (false)"
+// @Param limit query int false "Размер страницы, не больше 200"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} dto.IdentityListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/admin/identities [get]
+func (r *adminRoutes) list(c *gin.Context) {
+	if _, ok := authorizeAdmin(c, r.identityUseCase); !ok {
+		return
+	}
+
+	filter := entity.IdentityFilter{
+		Email: c.Query("email"),
+		Role:  c.Query("role"),
+	}
+	if raw := c.Query("disabled"); raw != "" {
+		disabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid disabled"})
+			return
+		}
+		filter.Disabled = &disabled
+	}
+	for name, dest := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return
+		}
+		*dest = value
+	}
+
+	page, err := r.adminUseCase.List(c.Request.Context(), filter)
+	if err != nil {
+		adminError(c, err)
+		return
+	}
+
+	resp := dto.IdentityListResponse{
+		Identities: make([]dto.IdentityResponse, 0, len(page.Identities)),
+		Total:      page.Total,
+	}
+	for i := range page.Identities {
+		resp.Identities = append(resp.Identities, identityResponse(&page.Identities[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Пользователь
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param uuid path string true "UUID пользователя"
+// @Success 200 {object} dto.IdentityResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/admin/identities/{uuid} [get]
+func (r *adminRoutes) get(c *gin.Context) {
+	if _, ok := authorizeAdmin(c, r.identityUseCase); !ok {
+		return
+	}
+
+	identity, err := r.adminUseCase.Get(c.Request.Context(), c.Param("uuid"))
+	if err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, identityResponse(identity))
+}
+
+// @Summary Изменение роли
+// @Description Сессии пользователя завершаются, чтобы токены со старой ролью перестали действовать.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param uuid path string true "UUID пользователя"
+// @Param data body dto.ChangeRoleRequest true "Новая роль"
+// @Success 200 {object} dto.IdentityResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/admin/identities/{uuid}/role [put]
+func (r *adminRoutes) setRole(c *gin.Context) {
+	adminUUID, ok := authorizeAdmin(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	var req dto.ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identity, err := r.adminUseCase.SetRole(c.Request.Context(), adminUUID, c.Param("uuid"), req.Role)
+	if err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, identityResponse(identity))
+}
+
+// @Summary Отключение аккаунта
+// @Description Вход в аккаунт запрещается, все его сессии и токены отзываются.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param uuid path string true "UUID пользователя"
+// @Success 200 {object} dto.IdentityResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/admin/identities/{uuid}/disable [post]
+func (r *adminRoutes) disable(c *gin.Context) {
+	adminUUID, ok := authorizeAdmin(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	identity, err := r.adminUseCase.Disable(c.Request.Context(), adminUUID, c.Param("uuid"))
+	if err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, identityResponse(identity))
+}
+
+// @Summary Включение аккаунта
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param uuid path string true "UUID пользователя"
+// @Success 200 {object} dto.IdentityResponse
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/admin/identities/{uuid}/enable [post]
+func (r *adminRoutes) enable(c *gin.Context) {
+	adminUUID, ok := authorizeAdmin(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	identity, err := r.adminUseCase.Enable(c.Request.Context(), adminUUID, c.Param("uuid"))
+	if err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, identityResponse(identity))
+}
+
+// @Summary Принудительный выход
+// @Description Завершает все сессии пользователя и отзывает их токены.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param uuid path string true "UUID пользователя"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/admin/identities/{uuid}/sessions [delete]
+func (r *adminRoutes) logout(c *gin.Context) {
+	adminUUID, ok := authorizeAdmin(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	if err := r.adminUseCase.Logout(c.Request.Context(), adminUUID, c.Param("uuid")); err != nil {
+		adminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked successfully"})
+}
+
+func identityResponse(identity *entity.Identity) dto.IdentityResponse {
+	return dto.IdentityResponse{
+		UserUUID:       identity.UserUUID.String(),
+		Email:          identity.Email,
+		Role:           identity.Role,
+		Provider:       identity.Provider,
+		IsConfirmEmail: identity.IsConfirmEmail,
+		Disabled:       identity.IsDisabled(),
+		DisabledAt:     identity.DisabledAt,
+		CreatedAt:      identity.CreatedAt,
+		UpdatedAt:      identity.UpdatedAt,
+	}
+}
+
+func adminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entity.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrSelfManagement):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrIdentityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,124 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdminRouter(adminUseCase *mocks.AdminUseCaseMock, role string) *gin.Engine {
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("ValidateToken", context.Background(), "valid_token", false).Return("admin123", nil)
+	identityUseCase.On("GetByUserUUID", context.Background(), "admin123").Return(&entity.Identity{Role: role}, nil)
+
+	router := gin.New()
+	v1.NewAdminRoutes(router.Group("/v1"), adminUseCase, identityUseCase)
+	return router
+}
+
+// Тест для PUT /auth/admin/identities/:uuid/role - Только для администратора
+func TestSetRole_Forbidden(t *testing.T) {
+	adminUseCase := new(mocks.AdminUseCaseMock)
+
+	w := httptest.NewRecorder()
+	newAdminRouter(adminUseCase, entity.RoleUser).ServeHTTP(w, jsonRequest(http.MethodPut, "/v1/auth/admin/identities/user123/role", "valid_token",
+		map[string]string{"role": entity.RoleAdmin}))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	adminUseCase.AssertNotCalled(t, "SetRole")
+}
+
+// Тест для GET /auth/admin/identities - Список пользователей с фильтром
+func TestListIdentities(t *testing.T) {
+	disabledAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	disabled := true
+	identity := entity.Identity{UserUUID: uuid.New(), Email: "user@example.com", Role: entity.RoleUser, DisabledAt: &disabledAt}
+
+	adminUseCase := new(mocks.AdminUseCaseMock)
+	adminUseCase.On("List", context.Background(), entity.IdentityFilter{Email: "user", Disabled: &disabled, Limit: 20}).
+		Return(&usecase.IdentityPage{Identities: []entity.Identity{identity}, Total: 41}, nil)
+
+	w := httptest.NewRecorder()
+	newAdminRouter(adminUseCase, entity.RoleAdmin).ServeHTTP(w, sessionRequest(http.MethodGet, "/v1/auth/admin/identities?email=user&disabled=true&limit=20"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Identities []map[string]any `json:"identities"`
+		Total      int64            `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, int64(41), resp.Total)
+	require.Len(t, resp.Identities, 1)
+	require.Equal(t, identity.UserUUID.String(), resp.Identities[0]["user_uuid"])
+	require.Equal(t, true, resp.Identities[0]["disabled"])
+}
+
+// Тест для GET /auth/admin/identities - Неверный фильтр
+func TestListIdentities_InvalidDisabled(t *testing.T) {
+	adminUseCase := new(mocks.AdminUseCaseMock)
+
+	w := httptest.NewRecorder()
+	newAdminRouter(adminUseCase, entity.RoleAdmin).ServeHTTP(w, sessionRequest(http.MethodGet, "/v1/auth/admin/identities?disabled=maybe"))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	adminUseCase.AssertNotCalled(t, "List")
+}
+
+// Тест для PUT /auth/admin/identities/:uuid/role - Изменение роли
+func TestSetRole(t *testing.T) {
+	identity := &entity.Identity{UserUUID: uuid.New(), Email: "user@example.com", Role: entity.RoleAdmin}
+	adminUseCase := new(mocks.AdminUseCaseMock)
+	adminUseCase.On("SetRole", context.Background(), "admin123", identity.UserUUID.String(), entity.RoleAdmin).Return(identity, nil)
+
+	w := httptest.NewRecorder()
+	newAdminRouter(adminUseCase, entity.RoleAdmin).ServeHTTP(w, jsonRequest(http.MethodPut, "/v1/auth/admin/identities/"+identity.UserUUID.String()+"/role", "valid_token",
+		map[string]string{"role": entity.RoleAdmin}))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"role":"admin"`)
+}
+
+// Тест для PUT /auth/admin/identities/:uuid/role - Неизвестная роль
+func TestSetRole_InvalidRole(t *testing.T) {
+	adminUseCase := new(mocks.AdminUseCaseMock)
+	adminUseCase.On("SetRole", context.Background(), "admin123", "user123", "root").Return(nil, entity.ErrInvalidRole)
+
+	w := httptest.NewRecorder()
+	newAdminRouter(adminUseCase, entity.RoleAdmin).ServeHTTP(w, jsonRequest(http.MethodPut, "/v1/auth/admin/identities/user123/role", "valid_token",
+		map[string]string{"role": "root"}))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Тест для POST /auth/admin/identities/:uuid/disable - Отключение себя
+func TestDisableIdentity_Self(t *testing.T) {
+	adminUseCase := new(mocks.AdminUseCaseMock)
+	adminUseCase.On("Disable", context.Background(), "admin123", "admin123").Return(nil, usecase.ErrSelfManagement)
+
+	w := httptest.NewRecorder()
+	newAdminRouter(adminUseCase, entity.RoleAdmin).ServeHTTP(w, sessionRequest(http.MethodPost, "/v1/auth/admin/identities/admin123/disable"))
+
+	require.Equal(t, http.StatusConflict, w.Code)
+}
+
+// Тест для DELETE /auth/admin/identities/:uuid/sessions - Пользователь не найден
+func TestForceLogout_NotFound(t *testing.T) {
+	adminUseCase := new(mocks.AdminUseCaseMock)
+	adminUseCase.On("Logout", context.Background(), "admin123", "missing").Return(usecase.ErrIdentityNotFound)
+
+	w := httptest.NewRecorder()
+	newAdminRouter(adminUseCase, entity.RoleAdmin).ServeHTTP(w, sessionRequest(http.MethodDelete, "/v1/auth/admin/identities/missing/sessions"))
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -5,12 +5,17 @@ import (
 	"errors"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/dto"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/notifier"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type IdentityUseCase interface {
@@ -20,16 +25,21 @@ type IdentityUseCase interface {
 	Login(ctx context.Context, email, password string) (*usecase.Tokens, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*usecase.Tokens, error)
 	Logout(ctx context.Context, token string) error
-	ResetPassword(ctx context.Context, email, newPassword string) error
-	AddVerificationCode(ctx context.Context, email, code string) error
-	VerifyCode(ctx context.Context, email, code string) (bool, error)
+	ResetPassword(ctx context.Context, email, code, newPassword string) error
+	AddVerificationCode(ctx context.Context, email string, purpose entity.VerificationPurpose, code string) error
+	RequestEmailChange(ctx context.Context, userUUID, newEmail, code string) error
+	DiscardVerificationCode(ctx context.Context, email string, purpose entity.VerificationPurpose, code string) error
+	DiscardEmailChangeCode(ctx context.Context, userUUID, code string) error
+	ChangeEmail(ctx context.Context, userUUID, code string) error
 	ValidateToken(ctx context.Context, token string, isRefreshToken bool) (string, error)
 	ConfirmEmail(ctx context.Context, email, code string) error
+	RevokedTokensSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error)
+	DisabledIdentities(ctx context.Context) ([]uuid.UUID, error)
 }
 
 type VerificationService interface {
 	GenerateVerificationCode() string
-	SendVerificationCode(email, code string) error
+	SendVerificationCode(email, code string, purpose entity.VerificationPurpose, locale string) error
 }
 
 type identityRoutes struct {
@@ -50,9 +60,13 @@ func NewIdentityRoutes(handler *gin.RouterGroup, verificationService Verificatio
 		h.POST("/refresh", r.refresh)
 		h.POST("/logout", r.logout)
 		h.GET("/token/status", r.checkToken)
+		h.GET("/token/revoked", r.revokedTokens)
+		h.GET("/identities/disabled", r.disabledIdentities)
 		h.POST("/password/reset", r.resetPassword)
 		h.POST("/verification/code", r.sendVerificationCode)
 		h.POST("/verification/email", r.confirmEmail)
+		h.POST("/email/change", r.requestEmailChange)
+		h.POST("/email/change/confirm", r.changeEmail)
 		h.GET("/me", r.getIdentity)
 	}
 }
@@ -90,11 +104,15 @@ func (r *identityRoutes) getIdentity(c *gin.Context) {
 }
 
 // @Summary Отправка кода подтверждения на email
+// @Description Код действует ограниченное время и только для указанного назначения. Повторно код можно запросить после паузы.
 // @Tags Verification
 // @Produce json
 // @Param email query string true "Email пользователя"
+// @Param purpose query string false "Назначение кода: confirm_email (по умолчанию) или reset_password"
+// @Param Accept-Language header string false "Язык письма: ru (по умолчанию) или en"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Router /auth/verification/code [post]
 func (r *identityRoutes) sendVerificationCode(c *gin.Context) {
 	email := c.Query("email")
@@ -103,24 +121,44 @@ func (r *identityRoutes) sendVerificationCode(c *gin.Context) {
 		return
 	}
 
+	purpose := entity.PurposeConfirmEmail
+	if value := c.Query("purpose"); value != "" {
+		parsed, err := entity.ParseVerificationPurpose(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		purpose = parsed
+	}
+
 	code := r.verificationService.GenerateVerificationCode()
-	if err := r.identityUseCase.AddVerificationCode(c.Request.Context(), email, code); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := r.identityUseCase.AddVerificationCode(c.Request.Context(), email, purpose, code); err != nil {
+		verificationError(c, err, http.StatusInternalServerError)
 		return
 	}
 
-	go func() {
-		if err := r.verificationService.SendVerificationCode(email, code); err != nil {
-			log.Println(err)
+	if err := r.verificationService.SendVerificationCode(email, code, purpose, notifier.ParseLocale(c.GetHeader("Accept-Language"))); err != nil {
+		log.Printf("Failed to send verification code: %v", err)
+		// Неотправленный код удаляется, иначе повторный запрос упрется в паузу.
+		if err := r.identityUseCase.DiscardVerificationCode(c.Request.Context(), email, purpose, code); err != nil {
+			log.Printf("Failed to discard unsent verification code: %v", err)
 		}
-	}()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to send verification code"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "verification code generated",
-		"code":    code,
-	})
+	c.JSON(http.StatusOK, gin.H{"message": "verification code sent"})
 }
 
+// @Summary Подтверждение email
+// @Tags Verification
+// @Accept json
+// @Produce json
+// @Param data body dto.ConfirmEmailRequest true "Email и код подтверждения"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/verification/email [post]
 func (r *identityRoutes) confirmEmail(c *gin.Context) {
 	var req dto.ConfirmEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -129,13 +167,23 @@ func (r *identityRoutes) confirmEmail(c *gin.Context) {
 	}
 
 	if err := r.identityUseCase.ConfirmEmail(c.Request.Context(), req.Email, req.Code); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		verificationError(c, err, http.StatusBadRequest)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "email confirmed successfully"})
 }
 
+// @Summary Сброс пароля по коду подтверждения
+// @Description Все сессии пользователя завершаются, войти нужно с новым паролем.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param data body dto.PasswordResetRequest true "Email, код и новый пароль"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password/reset [post]
 func (r *identityRoutes) resetPassword(c *gin.Context) {
 	var req dto.PasswordResetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -143,23 +191,84 @@ func (r *identityRoutes) resetPassword(c *gin.Context) {
 		return
 	}
 
-	code, err := r.identityUseCase.VerifyCode(c.Request.Context(), req.Email, req.Code)
-	if err != nil {
+	if err := r.identityUseCase.ResetPassword(c.Request.Context(), req.Email, req.Code, req.NewPassword); err != nil {
+		verificationError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset successfully"})
+}
+
+// @Summary Запрос смены email
+// @Description Отправляет код на новый адрес; email меняется после подтверждения кода.
+// @Tags Verification
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body dto.ChangeEmailRequest true "Новый email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/email/change [post]
+func (r *identityRoutes) requestEmailChange(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	var req dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if !code {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid verification code"})
+
+	code := r.verificationService.GenerateVerificationCode()
+	if err := r.identityUseCase.RequestEmailChange(c.Request.Context(), userUUID, req.Email, code); err != nil {
+		verificationError(c, err, http.StatusBadRequest)
 		return
 	}
 
-	err = r.identityUseCase.ResetPassword(c.Request.Context(), req.Email, req.NewPassword)
-	if err != nil {
+	if err := r.verificationService.SendVerificationCode(req.Email, code, entity.PurposeChangeEmail, notifier.ParseLocale(c.GetHeader("Accept-Language"))); err != nil {
+		log.Printf("Failed to send verification code: %v", err)
+		if err := r.identityUseCase.DiscardEmailChangeCode(c.Request.Context(), userUUID, code); err != nil {
+			log.Printf("Failed to discard unsent verification code: %v", err)
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to send verification code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification code sent"})
+}
+
+// @Summary Подтверждение смены email
+// @Description Все сессии пользователя, включая текущую, завершаются.
+// @Tags Verification
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body dto.ConfirmEmailChangeRequest true "Код, отправленный на новый email"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/email/change/confirm [post]
+func (r *identityRoutes) changeEmail(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	var req dto.ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "password reset successfully"})
+	if err := r.identityUseCase.ChangeEmail(c.Request.Context(), userUUID, req.Code); err != nil {
+		verificationError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email changed successfully"})
 }
 
 // @Summary Проверка токена
@@ -188,6 +297,66 @@ func (r *identityRoutes) checkToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"is_blacklisted": false})
 }
 
+// @Summary Список отозванных токенов
+// @Description Возвращает jti отозванных и еще не истекших токенов, добавленных после курсора since (RFC3339). Используется gateway для локальной проверки токенов.
+// @Tags Auth
+// @Produce json
+// @Param since query string false "Курсор из предыдущего ответа"
+// @Success 200 {object} dto.RevokedTokensResponse
+// @Failure 400 {object} map[string]string
+// @Router /auth/token/revoked [get]
+func (r *identityRoutes) revokedTokens(c *gin.Context) {
+	var since time.Time
+	if s := c.Query("since"); s != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since format"})
+			return
+		}
+		since = parsed
+	}
+
+	tokens, err := r.identityUseCase.RevokedTokensSince(c.Request.Context(), since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := dto.RevokedTokensResponse{
+		Tokens: make([]dto.RevokedToken, 0, len(tokens)),
+		Cursor: since,
+	}
+	for _, t := range tokens {
+		resp.Tokens = append(resp.Tokens, dto.RevokedToken{JTI: t.JTI, ExpiresAt: t.ExpiresAt})
+		if t.CreatedAt.After(resp.Cursor) {
+			resp.Cursor = t.CreatedAt
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Отключенные пользователи
+// @Description Полный список UUID отключенных пользователей. Gateway загружает его при старте, чтобы не зависеть от пропущенных событий.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dto.DisabledIdentitiesResponse
+// @Router /auth/identities/disabled [get]
+func (r *identityRoutes) disabledIdentities(c *gin.Context) {
+	uuids, err := r.identityUseCase.DisabledIdentities(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := dto.DisabledIdentitiesResponse{UUIDs: make([]string, 0, len(uuids))}
+	for _, id := range uuids {
+		resp.UUIDs = append(resp.UUIDs, id.String())
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // @Summary Регистрация пользователя
 // @Tags Auth
 // @Accept json
@@ -214,12 +383,15 @@ func (r *identityRoutes) register(c *gin.Context) {
 }
 
 // @Summary Авторизация пользователя
+// @Description Если у пользователя включен второй фактор, возвращает 403 с mfa_token для /auth/mfa/verify.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param data body dto.LoginRequest true "Данные для логина"
 // @Success 200 {object} dto.TokenResponse
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} dto.MFAChallengeResponse "Нужен второй фактор или аккаунт отключен"
+// @Failure 429 {object} map[string]string
 // @Router /auth/login [post]
 func (r *identityRoutes) login(c *gin.Context) {
 	var req dto.LoginRequest
@@ -229,6 +401,13 @@ func (r *identityRoutes) login(c *gin.Context) {
 	}
 
 	tokens, err := r.identityUseCase.Login(c.Request.Context(), req.Email, req.Password)
+	if tooManyAttempts(c, err) || mfaChallenge(c, err) {
+		return
+	}
+	if errors.Is(err, usecase.ErrIdentityDisabled) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -241,6 +420,7 @@ func (r *identityRoutes) login(c *gin.Context) {
 }
 
 // @Summary Обновление токена
+// @Description Каждый refresh токен одноразовый. Повторное использование уже обмененного токена завершает сессию.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -289,6 +469,63 @@ func (r *identityRoutes) logout(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+type tokenValidator interface {
+	ValidateToken(ctx context.Context, token string, isRefreshToken bool) (string, error)
+}
+
+// authenticate проверяет access токен запроса и возвращает UUID пользователя.
+// При ошибке ответ уже записан.
+func authenticate(c *gin.Context, validator tokenValidator) (string, bool) {
+	token, err := extractToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return "", false
+	}
+
+	userUUID, err := validator.ValidateToken(c.Request.Context(), token, false)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return userUUID, true
+}
+
+// verificationError отвечает на ошибку работы с кодом подтверждения:
+// частые запросы - 429 с Retry-After, остальные ошибки - status.
+func verificationError(c *gin.Context, err error, status int) {
+	if tooManyAttempts(c, err) {
+		return
+	}
+
+	var cooldown *usecase.ResendCooldownError
+	switch {
+	case errors.As(err, &cooldown):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(cooldown.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrVerificationCodeInvalid),
+		errors.Is(err, usecase.ErrVerificationCodeExpired),
+		errors.Is(err, usecase.ErrVerificationCodeExhausted):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrEmailAlreadyConfirmed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(status, gin.H{"error": err.Error()})
+	}
+}
+
+// tooManyAttempts отвечает 429 с Retry-After, если попытки входа или ввода
+// кода временно не принимаются.
+func tooManyAttempts(c *gin.Context, err error) bool {
+	var locked *usecase.LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	return true
+}
+
 func extractToken(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
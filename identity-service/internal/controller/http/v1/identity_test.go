@@ -8,12 +8,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	v1 "github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/v1"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/mocks"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
@@ -279,8 +281,7 @@ func TestCheckToken_Invalid(t *testing.T) {
 // Тест для POST /auth/password/reset - Успешный сброс
 func TestResetPassword_Success(t *testing.T) {
 	mockUseCase := new(mocks.IdentityUseCaseMock)
-	mockUseCase.On("VerifyCode", context.Background(), "test@example.com", "123456").Return(true, nil)
-	mockUseCase.On("ResetPassword", context.Background(), "test@example.com", "newpassword").Return(nil)
+	mockUseCase.On("ResetPassword", context.Background(), "test@example.com", "123456", "newpassword").Return(nil)
 
 	mockVerification := new(mocks.VerificationServiceMock)
 
@@ -307,7 +308,7 @@ func TestResetPassword_Success(t *testing.T) {
 // Тест для POST /auth/password/reset - Неверный код
 func TestResetPassword_InvalidCode(t *testing.T) {
 	mockUseCase := new(mocks.IdentityUseCaseMock)
-	mockUseCase.On("VerifyCode", context.Background(), "test@example.com", "wrongcode").Return(false, nil)
+	mockUseCase.On("ResetPassword", context.Background(), "test@example.com", "wrongcode", "newpassword").Return(usecase.ErrVerificationCodeInvalid)
 
 	mockVerification := new(mocks.VerificationServiceMock)
 
@@ -334,7 +335,7 @@ func TestResetPassword_InvalidCode(t *testing.T) {
 // Тест для POST /auth/verification/code - Неверный email
 func TestSendVerificationCode_InvalidEmail(t *testing.T) {
 	mockUseCase := new(mocks.IdentityUseCaseMock)
-	mockUseCase.On("AddVerificationCode", context.Background(), "invalid@example.com", mock.Anything).Return(errors.New("email not found"))
+	mockUseCase.On("AddVerificationCode", context.Background(), "invalid@example.com", entity.PurposeConfirmEmail, mock.Anything).Return(errors.New("email not found"))
 
 	mockVerification := new(mocks.VerificationServiceMock)
 	mockVerification.On("GenerateVerificationCode").Return("123456")
@@ -354,6 +355,92 @@ func TestSendVerificationCode_InvalidEmail(t *testing.T) {
 	mockVerification.AssertExpectations(t)
 }
 
+// Тест для POST /auth/verification/code - Код не возвращается в ответе
+func TestSendVerificationCode_Success(t *testing.T) {
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockUseCase.On("AddVerificationCode", context.Background(), "test@example.com", entity.PurposeResetPassword, "123456").Return(nil)
+
+	mockVerification := new(mocks.VerificationServiceMock)
+	mockVerification.On("GenerateVerificationCode").Return("123456")
+	mockVerification.On("SendVerificationCode", "test@example.com", "123456", entity.PurposeResetPassword, "en").Return(nil)
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("POST", "/v1/auth/verification/code?email=test@example.com&purpose=reset_password", nil)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9,ru;q=0.8")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"message":"verification code sent"}`, w.Body.String())
+
+	mockUseCase.AssertExpectations(t)
+	mockVerification.AssertExpectations(t)
+}
+
+// Тест для POST /auth/verification/code - Повторный запрос до истечения паузы
+func TestSendVerificationCode_Cooldown(t *testing.T) {
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockUseCase.On("AddVerificationCode", context.Background(), "test@example.com", entity.PurposeConfirmEmail, "123456").
+		Return(&usecase.ResendCooldownError{RetryAfter: 30 * time.Second})
+
+	mockVerification := new(mocks.VerificationServiceMock)
+	mockVerification.On("GenerateVerificationCode").Return("123456")
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("POST", "/v1/auth/verification/code?email=test@example.com", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "30", w.Header().Get("Retry-After"))
+	mockVerification.AssertNotCalled(t, "SendVerificationCode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Тест для POST /auth/verification/code - Неотправленный код удаляется
+func TestSendVerificationCode_SendFailure(t *testing.T) {
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockUseCase.On("AddVerificationCode", context.Background(), "test@example.com", entity.PurposeConfirmEmail, "123456").Return(nil)
+	mockUseCase.On("DiscardVerificationCode", context.Background(), "test@example.com", entity.PurposeConfirmEmail, "123456").Return(nil)
+
+	mockVerification := new(mocks.VerificationServiceMock)
+	mockVerification.On("GenerateVerificationCode").Return("123456")
+	mockVerification.On("SendVerificationCode", "test@example.com", "123456", entity.PurposeConfirmEmail, mock.Anything).Return(errors.New("queue is down"))
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("POST", "/v1/auth/verification/code?email=test@example.com", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+// Тест для POST /auth/verification/code - Неизвестное назначение кода
+func TestSendVerificationCode_UnknownPurpose(t *testing.T) {
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockVerification := new(mocks.VerificationServiceMock)
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("POST", "/v1/auth/verification/code?email=test@example.com&purpose=login", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	mockUseCase.AssertNotCalled(t, "AddVerificationCode", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 // Тест для POST /auth/verification/email - Успешное подтверждение
 func TestConfirmEmail_Success(t *testing.T) {
 	mockUseCase := new(mocks.IdentityUseCaseMock)
@@ -450,3 +537,88 @@ func TestGetIdentity_InvalidToken(t *testing.T) {
 
 	mockUseCase.AssertExpectations(t)
 }
+
+// Тест для GET /auth/token/revoked - Инкрементальный список отозванных токенов
+func TestRevokedTokens_Success(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := since.Add(time.Minute)
+	expiresAt := since.Add(time.Hour)
+
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockUseCase.On("RevokedTokensSince", mock.Anything, since).Return([]entity.BlacklistedToken{
+		{JTI: "jti-1", ExpiresAt: expiresAt, CreatedAt: createdAt},
+	}, nil)
+
+	mockVerification := new(mocks.VerificationServiceMock)
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("GET", "/v1/auth/token/revoked?since="+since.Format(time.RFC3339Nano), nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"tokens":[{"jti":"jti-1","expires_at":"2025-01-01T01:00:00Z"}],"cursor":"2025-01-01T00:01:00Z"}`, w.Body.String())
+
+	mockUseCase.AssertExpectations(t)
+}
+
+// Тест для GET /auth/token/revoked - Неверный курсор
+func TestRevokedTokens_InvalidSince(t *testing.T) {
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockVerification := new(mocks.VerificationServiceMock)
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("GET", "/v1/auth/token/revoked?since=yesterday", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"error":"invalid since format"}`, w.Body.String())
+}
+
+// Тест для GET /auth/identities/disabled - Список отключенных пользователей
+func TestDisabledIdentities_Success(t *testing.T) {
+	disabled := uuid.New()
+
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockUseCase.On("DisabledIdentities", mock.Anything).Return([]uuid.UUID{disabled}, nil)
+
+	mockVerification := new(mocks.VerificationServiceMock)
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("GET", "/v1/auth/identities/disabled", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"uuids":["`+disabled.String()+`"]}`, w.Body.String())
+
+	mockUseCase.AssertExpectations(t)
+}
+
+// Тест для GET /auth/identities/disabled - Ошибка базы данных
+func TestDisabledIdentities_Failure(t *testing.T) {
+	mockUseCase := new(mocks.IdentityUseCaseMock)
+	mockUseCase.On("DisabledIdentities", mock.Anything).Return([]uuid.UUID(nil), errors.New("db down"))
+
+	mockVerification := new(mocks.VerificationServiceMock)
+
+	router := gin.Default()
+	v1.NewIdentityRoutes(router.Group("/v1"), mockVerification, mockUseCase)
+
+	req, _ := http.NewRequest("GET", "/v1/auth/identities/disabled", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+}
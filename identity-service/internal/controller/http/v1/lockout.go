@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/dto"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+type LockoutUseCase interface {
+	Locked(ctx context.Context, kind string) ([]entity.FailureCounter, error)
+	Unlock(ctx context.Context, actorUUID, kind, key string) error
+	Events(ctx context.Context, kind, key string, limit int) ([]entity.LockoutEvent, error)
+}
+
+type lockoutRoutes struct {
+	lockoutUseCase  LockoutUseCase
+	identityUseCase IdentityUseCase
+}
+
+func NewLockoutRoutes(handler *gin.RouterGroup, lockoutUseCase LockoutUseCase, identityUseCase IdentityUseCase) {
+	r := &lockoutRoutes{
+		lockoutUseCase:  lockoutUseCase,
+		identityUseCase: identityUseCase,
+	}
+
+	a := handler.Group("/auth/admin/lockouts")
+	{
+		a.GET("", r.list)
+		a.GET("/events", r.events)
+		a.DELETE("/:kind/:key", r.unlock)
+	}
+}
+
+// @Summary Действующие блокировки
+// @Description Аккаунты и IP, временно заблокированные после неудачных попыток входа или ввода кодов.
+// @Tags Lockouts
+// @Security ApiKeyAuth
+// @Produce json
+// @Param kind query string false "account или ip"
+// @Success 200 {array} dto.LockoutResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/admin/lockouts [get]
+func (r *lockoutRoutes) list(c *gin.Context) {
+	if _, ok := authorizeAdmin(c, r.identityUseCase); !ok {
+		return
+	}
+
+	counters, err := r.lockoutUseCase.Locked(c.Request.Context(), c.Query("kind"))
+	if err != nil {
+		lockoutError(c, err)
+		return
+	}
+
+	resp := make([]dto.LockoutResponse, 0, len(counters))
+	for _, counter := range counters {
+		resp = append(resp, dto.LockoutResponse{
+			Kind:          counter.Kind,
+			Key:           counter.Key,
+			Failures:      counter.Failures,
+			Locked:        counter.Locked,
+			LastFailureAt: counter.LastFailureAt,
+			BlockedUntil:  counter.BlockedUntil,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Снятие блокировки
+// @Description Сбрасывает счетчик неудачных попыток аккаунта (email) или IP. Действие записывается в журнал.
+// @Tags Lockouts
+// @Security ApiKeyAuth
+// @Produce json
+// @Param kind path string true "account или ip"
+// @Param key path string true "Email или IP"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/admin/lockouts/{kind}/{key} [delete]
+func (r *lockoutRoutes) unlock(c *gin.Context) {
+	adminUUID, ok := authorizeAdmin(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	if err := r.lockoutUseCase.Unlock(c.Request.Context(), adminUUID, c.Param("kind"), c.Param("key")); err != nil {
+		lockoutError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "lockout removed successfully"})
+}
+
+// @Summary Журнал блокировок
+// @Tags Lockouts
+// @Security ApiKeyAuth
+// @Produce json
+// @Param kind query string false "account или ip"
+// @Param key query string false "Email или IP"
+// @Param limit query int false "Число записей, не больше 500"
+// @Success 200 {array} dto.LockoutEventResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/admin/lockouts/events [get]
+func (r *lockoutRoutes) events(c *gin.Context) {
+	if _, ok := authorizeAdmin(c, r.identityUseCase); !ok {
+		return
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := r.lockoutUseCase.Events(c.Request.Context(), c.Query("kind"), c.Query("key"), limit)
+	if err != nil {
+		lockoutError(c, err)
+		return
+	}
+
+	resp := make([]dto.LockoutEventResponse, 0, len(events))
+	for _, event := range events {
+		resp = append(resp, dto.LockoutEventResponse{
+			ID:          event.ID,
+			Kind:        event.Kind,
+			Key:         event.Key,
+			Action:      event.Action,
+			ActorUUID:   event.ActorUUID,
+			IP:          event.IP,
+			Failures:    event.Failures,
+			LockedUntil: event.LockedUntil,
+			CreatedAt:   event.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func lockoutError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrLockoutKindInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrLockoutNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
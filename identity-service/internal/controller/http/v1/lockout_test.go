@@ -0,0 +1,125 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newLockoutRouter(lockoutUseCase *mocks.LockoutUseCaseMock, role string) *gin.Engine {
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("ValidateToken", context.Background(), "valid_token", false).Return("admin123", nil)
+	identityUseCase.On("GetByUserUUID", context.Background(), "admin123").Return(&entity.Identity{Role: role}, nil)
+
+	router := gin.New()
+	v1.NewLockoutRoutes(router.Group("/v1"), lockoutUseCase, identityUseCase)
+	return router
+}
+
+// Тест для POST /auth/login - Попытки временно не принимаются
+func TestLogin_Locked(t *testing.T) {
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("Login", context.Background(), "test@example.com", "password123").
+		Return((*usecase.Tokens)(nil), &usecase.LockedError{RetryAfter: 90 * time.Second, Locked: true})
+
+	router := gin.New()
+	v1.NewIdentityRoutes(router.Group("/v1"), new(mocks.VerificationServiceMock), identityUseCase)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, jsonRequest(http.MethodPost, "/v1/auth/login", "", map[string]string{
+		"email":    "test@example.com",
+		"password": "password123",
+	}))
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "90", w.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"error":"too many failed attempts, temporarily locked"}`, w.Body.String())
+}
+
+// Тест для POST /auth/mfa/verify - Задержка после неверных кодов
+func TestMFAVerify_Throttled(t *testing.T) {
+	mfaUseCase := new(mocks.MFAUseCaseMock)
+	mfaUseCase.On("Verify", context.Background(), "mfa-token", "123456").
+		Return(nil, &usecase.LockedError{RetryAfter: 1500 * time.Millisecond})
+
+	w := httptest.NewRecorder()
+	newMFARouter(mfaUseCase, new(mocks.IdentityUseCaseMock)).ServeHTTP(w, jsonRequest(http.MethodPost, "/v1/auth/mfa/verify", "",
+		map[string]string{"mfa_token": "mfa-token", "code": "123456"}))
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+// Тест для GET /auth/admin/lockouts - Только для администратора
+func TestListLockouts_Forbidden(t *testing.T) {
+	lockoutUseCase := new(mocks.LockoutUseCaseMock)
+
+	w := httptest.NewRecorder()
+	newLockoutRouter(lockoutUseCase, "user").ServeHTTP(w, sessionRequest(http.MethodGet, "/v1/auth/admin/lockouts"))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	lockoutUseCase.AssertNotCalled(t, "Locked")
+}
+
+// Тест для GET /auth/admin/lockouts - Список блокировок
+func TestListLockouts(t *testing.T) {
+	until := time.Date(2025, 1, 1, 12, 15, 0, 0, time.UTC)
+	lockoutUseCase := new(mocks.LockoutUseCaseMock)
+	lockoutUseCase.On("Locked", context.Background(), entity.LockoutAccount).Return([]entity.FailureCounter{
+		{Kind: entity.LockoutAccount, Key: "test@example.com", Failures: 10, Locked: true, BlockedUntil: &until},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	newLockoutRouter(lockoutUseCase, "admin").ServeHTTP(w, sessionRequest(http.MethodGet, "/v1/auth/admin/lockouts?kind=account"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp, 1)
+	require.Equal(t, "test@example.com", resp[0]["key"])
+	require.Equal(t, "2025-01-01T12:15:00Z", resp[0]["blocked_until"])
+}
+
+// Тест для DELETE /auth/admin/lockouts/:kind/:key - Снятие блокировки
+func TestUnlock(t *testing.T) {
+	lockoutUseCase := new(mocks.LockoutUseCaseMock)
+	lockoutUseCase.On("Unlock", context.Background(), "admin123", entity.LockoutIP, "192.0.2.1").Return(nil)
+
+	w := httptest.NewRecorder()
+	newLockoutRouter(lockoutUseCase, "admin").ServeHTTP(w, sessionRequest(http.MethodDelete, "/v1/auth/admin/lockouts/ip/192.0.2.1"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	lockoutUseCase.AssertExpectations(t)
+}
+
+// Тест для DELETE /auth/admin/lockouts/:kind/:key - Блокировки нет
+func TestUnlock_NotFound(t *testing.T) {
+	lockoutUseCase := new(mocks.LockoutUseCaseMock)
+	lockoutUseCase.On("Unlock", context.Background(), "admin123", entity.LockoutAccount, "test@example.com").Return(usecase.ErrLockoutNotFound)
+
+	w := httptest.NewRecorder()
+	newLockoutRouter(lockoutUseCase, "admin").ServeHTTP(w, sessionRequest(http.MethodDelete, "/v1/auth/admin/lockouts/account/test@example.com"))
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// Тест для GET /auth/admin/lockouts/events - Неверный limit
+func TestLockoutEvents_InvalidLimit(t *testing.T) {
+	lockoutUseCase := new(mocks.LockoutUseCaseMock)
+
+	w := httptest.NewRecorder()
+	newLockoutRouter(lockoutUseCase, "admin").ServeHTTP(w, sessionRequest(http.MethodGet, "/v1/auth/admin/lockouts/events?limit=many"))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	lockoutUseCase.AssertNotCalled(t, "Events")
+}
@@ -0,0 +1,382 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/dto"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAUseCase interface {
+	Verify(ctx context.Context, token, code string) (*usecase.Tokens, error)
+	Status(ctx context.Context, userUUID string) (*usecase.MFAStatus, error)
+	Enroll(ctx context.Context, userUUID string) (*usecase.TOTPEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userUUID, code string) ([]string, error)
+	Disable(ctx context.Context, userUUID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) ([]string, error)
+	EnrollmentUser(ctx context.Context, token string) (string, error)
+	CompleteEnrollment(ctx context.Context, token string) (*usecase.Tokens, error)
+	Policies(ctx context.Context) ([]entity.MFARolePolicy, error)
+	SetPolicy(ctx context.Context, role string, required bool, updatedBy string) error
+}
+
+type mfaRoutes struct {
+	mfaUseCase      MFAUseCase
+	identityUseCase IdentityUseCase
+}
+
+func NewMFARoutes(handler *gin.RouterGroup, mfaUseCase MFAUseCase, identityUseCase IdentityUseCase) {
+	r := &mfaRoutes{
+		mfaUseCase:      mfaUseCase,
+		identityUseCase: identityUseCase,
+	}
+
+	h := handler.Group("/auth/mfa")
+	{
+		h.GET("", r.status)
+		h.POST("/verify", r.verify)
+		h.POST("/totp/enroll", r.enroll)
+		h.POST("/totp/confirm", r.confirm)
+		h.DELETE("/totp", r.disable)
+		h.POST("/recovery-codes", r.regenerateRecoveryCodes)
+	}
+
+	a := handler.Group("/auth/admin/mfa")
+	{
+		a.GET("/policy", r.policies)
+		a.PUT("/policy", r.setPolicy)
+	}
+}
+
+// @Summary Второй шаг входа
+// @Description Принимает mfa_token из ответа /auth/login и код из приложения-аутентификатора или код восстановления.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param data body dto.MFAVerifyRequest true "Токен входа и код"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa/verify [post]
+func (r *mfaRoutes) verify(c *gin.Context) {
+	var req dto.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := r.mfaUseCase.Verify(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// @Summary Состояние второго фактора
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} usecase.MFAStatus
+// @Failure 401 {object} map[string]string
+// @Router /auth/mfa [get]
+func (r *mfaRoutes) status(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	status, err := r.mfaUseCase.Status(c.Request.Context(), userUUID)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// @Summary Начать настройку TOTP
+// @Description Возвращает секрет и otpauth:// ссылку для QR-кода. Если роль требует второй фактор при входе, вместо Authorization передается mfa_token.
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body dto.MFAEnrollRequest false "mfa_token входа, если нет access токена"
+// @Success 200 {object} usecase.TOTPEnrollment
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/mfa/totp/enroll [post]
+func (r *mfaRoutes) enroll(c *gin.Context) {
+	var req dto.MFAEnrollRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userUUID, ok := r.enrollingUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	enrollment, err := r.mfaUseCase.Enroll(c.Request.Context(), userUUID)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// @Summary Подтвердить настройку TOTP
+// @Description Включает второй фактор и возвращает коды восстановления; они показываются один раз. При входе по mfa_token дополнительно выдает токены.
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body dto.MFAConfirmRequest true "Код из приложения"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/mfa/totp/confirm [post]
+func (r *mfaRoutes) confirm(c *gin.Context) {
+	var req dto.MFAConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userUUID, ok := r.enrollingUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	codes, err := r.mfaUseCase.ConfirmEnrollment(c.Request.Context(), userUUID, req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	resp := dto.RecoveryCodesResponse{RecoveryCodes: codes}
+	if req.MFAToken != "" {
+		tokens, err := r.mfaUseCase.CompleteEnrollment(c.Request.Context(), req.MFAToken)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+		resp.AccessToken = tokens.AccessToken
+		resp.RefreshToken = tokens.RefreshToken
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Отключить второй фактор
+// @Description Удаляет TOTP и коды восстановления. Недоступно, если второй фактор обязателен для роли.
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body dto.MFACodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa/totp [delete]
+func (r *mfaRoutes) disable(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.mfaUseCase.Disable(c.Request.Context(), userUUID, req.Code); err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// @Summary Выпустить новые коды восстановления
+// @Description Старые коды перестают действовать.
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body dto.MFACodeRequest true "Код из приложения"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/mfa/recovery-codes [post]
+func (r *mfaRoutes) regenerateRecoveryCodes(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := r.mfaUseCase.RegenerateRecoveryCodes(c.Request.Context(), userUUID, req.Code)
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @Summary Требования второго фактора по ролям
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.MFAPolicyResponse
+// @Failure 403 {object} map[string]string
+// @Router /auth/admin/mfa/policy [get]
+func (r *mfaRoutes) policies(c *gin.Context) {
+	if _, ok := r.authorizeAdmin(c); !ok {
+		return
+	}
+
+	policies, err := r.mfaUseCase.Policies(c.Request.Context())
+	if err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	resp := make([]dto.MFAPolicyResponse, 0, len(policies))
+	for _, policy := range policies {
+		resp = append(resp, dto.MFAPolicyResponse{
+			Role:      policy.Role,
+			Required:  policy.Required,
+			UpdatedBy: policy.UpdatedBy,
+			UpdatedAt: policy.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Обязательный второй фактор для роли
+// @Description Пользователи роли без второго фактора при следующем входе должны будут его настроить.
+// @Tags MFA
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body dto.MFAPolicyRequest true "Роль и требование"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/admin/mfa/policy [put]
+func (r *mfaRoutes) setPolicy(c *gin.Context) {
+	adminUUID, ok := r.authorizeAdmin(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MFAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := r.mfaUseCase.SetPolicy(c.Request.Context(), req.Role, req.Required, adminUUID); err != nil {
+		mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "policy updated successfully"})
+}
+
+// enrollingUser определяет пользователя настройки TOTP: по mfa_token входа,
+// если он передан, иначе по access токену.
+func (r *mfaRoutes) enrollingUser(c *gin.Context, mfaToken string) (string, bool) {
+	if mfaToken == "" {
+		return authenticate(c, r.identityUseCase)
+	}
+
+	userUUID, err := r.mfaUseCase.EnrollmentUser(c.Request.Context(), mfaToken)
+	if err != nil {
+		mfaError(c, err)
+		return "", false
+	}
+	return userUUID, true
+}
+
+func (r *mfaRoutes) authorizeAdmin(c *gin.Context) (string, bool) {
+	return authorizeAdmin(c, r.identityUseCase)
+}
+
+// authorizeAdmin пропускает только пользователей с ролью admin и возвращает
+// UUID администратора. При отказе ответ уже записан.
+func authorizeAdmin(c *gin.Context, identityUseCase IdentityUseCase) (string, bool) {
+	userUUID, ok := authenticate(c, identityUseCase)
+	if !ok {
+		return "", false
+	}
+
+	identity, err := identityUseCase.GetByUserUUID(c.Request.Context(), userUUID)
+	if err != nil || identity == nil || identity.Role != entity.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return "", false
+	}
+	return userUUID, true
+}
+
+// mfaChallenge отвечает 403 с mfa_token, если для входа нужен второй фактор.
+func mfaChallenge(c *gin.Context, err error) bool {
+	var required *usecase.MFARequiredError
+	if !errors.As(err, &required) {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, dto.MFAChallengeResponse{
+		Error:              required.Error(),
+		MFARequired:        true,
+		MFAToken:           required.Token,
+		ExpiresAt:          required.ExpiresAt,
+		EnrollmentRequired: required.EnrollmentRequired,
+	})
+	return true
+}
+
+func mfaError(c *gin.Context, err error) {
+	if tooManyAttempts(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, usecase.ErrMFACodeInvalid),
+		errors.Is(err, usecase.ErrMFANotEnabled),
+		errors.Is(err, usecase.ErrMFAEnrollmentRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrMFAChallengeInvalid),
+		errors.Is(err, usecase.ErrMFAChallengeExhausted):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrIdentityDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrMFAAlreadyEnabled),
+		errors.Is(err, usecase.ErrMFARequiredByPolicy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
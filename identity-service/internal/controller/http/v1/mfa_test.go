@@ -0,0 +1,133 @@
+package v1_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newMFARouter(mfaUseCase *mocks.MFAUseCaseMock, identityUseCase *mocks.IdentityUseCaseMock) *gin.Engine {
+	router := gin.New()
+	v1.NewMFARoutes(router.Group("/v1"), mfaUseCase, identityUseCase)
+	return router
+}
+
+func jsonRequest(method, path, token string, body any) *http.Request {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// Тест для POST /auth/login - Нужен второй фактор
+func TestLogin_MFARequired(t *testing.T) {
+	expiresAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("Login", context.Background(), "test@example.com", "password123").
+		Return((*usecase.Tokens)(nil), &usecase.MFARequiredError{Token: "mfa-token", ExpiresAt: expiresAt})
+
+	router := gin.New()
+	v1.NewIdentityRoutes(router.Group("/v1"), new(mocks.VerificationServiceMock), identityUseCase)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, jsonRequest(http.MethodPost, "/v1/auth/login", "", map[string]string{
+		"email":    "test@example.com",
+		"password": "password123",
+	}))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.JSONEq(t, `{
+		"error": "two-factor authentication required",
+		"mfa_required": true,
+		"mfa_token": "mfa-token",
+		"expires_at": "2025-01-01T12:00:00Z",
+		"enrollment_required": false
+	}`, w.Body.String())
+}
+
+// Тест для POST /auth/mfa/verify - Успешный вход
+func TestMFAVerify_Success(t *testing.T) {
+	mfaUseCase := new(mocks.MFAUseCaseMock)
+	mfaUseCase.On("Verify", context.Background(), "mfa-token", "123456").
+		Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	w := httptest.NewRecorder()
+	newMFARouter(mfaUseCase, new(mocks.IdentityUseCaseMock)).ServeHTTP(w, jsonRequest(http.MethodPost, "/v1/auth/mfa/verify", "",
+		map[string]string{"mfa_token": "mfa-token", "code": "123456"}))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"access_token":"access","refresh_token":"refresh"}`, w.Body.String())
+}
+
+// Тест для POST /auth/mfa/verify - Неверный код
+func TestMFAVerify_InvalidCode(t *testing.T) {
+	mfaUseCase := new(mocks.MFAUseCaseMock)
+	mfaUseCase.On("Verify", context.Background(), "mfa-token", "000000").Return(nil, usecase.ErrMFACodeInvalid)
+
+	w := httptest.NewRecorder()
+	newMFARouter(mfaUseCase, new(mocks.IdentityUseCaseMock)).ServeHTTP(w, jsonRequest(http.MethodPost, "/v1/auth/mfa/verify", "",
+		map[string]string{"mfa_token": "mfa-token", "code": "000000"}))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Тест для POST /auth/mfa/totp/confirm - Настройка при входе по mfa_token
+func TestMFAConfirm_WithChallenge(t *testing.T) {
+	mfaUseCase := new(mocks.MFAUseCaseMock)
+	mfaUseCase.On("EnrollmentUser", context.Background(), "mfa-token").Return("user123", nil)
+	mfaUseCase.On("ConfirmEnrollment", context.Background(), "user123", "123456").Return([]string{"aaaa-bbbb-cccc-dddd"}, nil)
+	mfaUseCase.On("CompleteEnrollment", context.Background(), "mfa-token").
+		Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	w := httptest.NewRecorder()
+	newMFARouter(mfaUseCase, new(mocks.IdentityUseCaseMock)).ServeHTTP(w, jsonRequest(http.MethodPost, "/v1/auth/mfa/totp/confirm", "",
+		map[string]string{"mfa_token": "mfa-token", "code": "123456"}))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"recovery_codes":["aaaa-bbbb-cccc-dddd"],"access_token":"access","refresh_token":"refresh"}`, w.Body.String())
+}
+
+// Тест для PUT /auth/admin/mfa/policy - Только для администратора
+func TestMFASetPolicy_Forbidden(t *testing.T) {
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("ValidateToken", context.Background(), "valid_token", false).Return("user123", nil)
+	identityUseCase.On("GetByUserUUID", context.Background(), "user123").Return(&entity.Identity{Role: "user"}, nil)
+	mfaUseCase := new(mocks.MFAUseCaseMock)
+
+	w := httptest.NewRecorder()
+	newMFARouter(mfaUseCase, identityUseCase).ServeHTTP(w, jsonRequest(http.MethodPut, "/v1/auth/admin/mfa/policy", "valid_token",
+		map[string]any{"role": "admin", "required": true}))
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	mfaUseCase.AssertNotCalled(t, "SetPolicy")
+}
+
+// Тест для PUT /auth/admin/mfa/policy - Успешное изменение
+func TestMFASetPolicy_Success(t *testing.T) {
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("ValidateToken", context.Background(), "valid_token", false).Return("admin123", nil)
+	identityUseCase.On("GetByUserUUID", context.Background(), "admin123").Return(&entity.Identity{Role: "admin"}, nil)
+	mfaUseCase := new(mocks.MFAUseCaseMock)
+	mfaUseCase.On("SetPolicy", context.Background(), "admin", true, "admin123").Return(nil)
+
+	w := httptest.NewRecorder()
+	newMFARouter(mfaUseCase, identityUseCase).ServeHTTP(w, jsonRequest(http.MethodPut, "/v1/auth/admin/mfa/policy", "valid_token",
+		map[string]any{"role": "admin", "required": true}))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	mfaUseCase.AssertExpectations(t)
+}
@@ -0,0 +1,221 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/dto"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/oidc"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCUseCase interface {
+	Providers() []string
+	Start(ctx context.Context, providerName, linkUserUUID string) (string, error)
+	Callback(ctx context.Context, providerName, code, state, userUUID string) (*usecase.OIDCResult, error)
+	Unlink(ctx context.Context, userUUID, providerName string) error
+	Accounts(ctx context.Context, userUUID string) ([]entity.ExternalAccount, error)
+}
+
+type oidcRoutes struct {
+	oidcUseCase     OIDCUseCase
+	identityUseCase IdentityUseCase
+}
+
+func NewOIDCRoutes(handler *gin.RouterGroup, oidcUseCase OIDCUseCase, identityUseCase IdentityUseCase) {
+	r := &oidcRoutes{
+		oidcUseCase:     oidcUseCase,
+		identityUseCase: identityUseCase,
+	}
+
+	h := handler.Group("/auth/oidc")
+	{
+		h.GET("/providers", r.providers)
+		h.GET("/accounts", r.accounts)
+		h.GET("/:provider/start", r.start)
+		h.POST("/:provider/callback", r.callback)
+		h.POST("/:provider/link", r.link)
+		h.DELETE("/:provider", r.unlink)
+	}
+}
+
+// @Summary Список внешних провайдеров входа
+// @Tags OIDC
+// @Produce json
+// @Success 200 {object} dto.OIDCProvidersResponse
+// @Router /auth/oidc/providers [get]
+func (r *oidcRoutes) providers(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.OIDCProvidersResponse{Providers: r.oidcUseCase.Providers()})
+}
+
+// @Summary Начать вход через внешнего провайдера
+// @Description Возвращает адрес страницы входа провайдера. После входа провайдер перенаправляет пользователя на redirect_uri с параметрами code и state, которые нужно передать в callback.
+// @Tags OIDC
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Success 200 {object} dto.OIDCStartResponse
+// @Failure 404 {object} map[string]string
+// @Router /auth/oidc/{provider}/start [get]
+func (r *oidcRoutes) start(c *gin.Context) {
+	authURL, err := r.oidcUseCase.Start(c.Request.Context(), c.Param("provider"), "")
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.OIDCStartResponse{AuthorizationURL: authURL})
+}
+
+// @Summary Завершить вход через внешнего провайдера
+// @Description Обменивает code на токены. Для привязки провайдера запрос должен быть от пользователя, который ее начал.
+// @Tags OIDC
+// @Accept json
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Param data body dto.OIDCCallbackRequest true "code и state из ответа провайдера"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} dto.MFAChallengeResponse
+// @Router /auth/oidc/{provider}/callback [post]
+func (r *oidcRoutes) callback(c *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userUUID string
+	if c.GetHeader("Authorization") != "" {
+		var ok bool
+		if userUUID, ok = authenticate(c, r.identityUseCase); !ok {
+			return
+		}
+	}
+
+	result, err := r.oidcUseCase.Callback(c.Request.Context(), c.Param("provider"), req.Code, req.State, userUUID)
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	if result.Linked {
+		c.JSON(http.StatusOK, gin.H{"message": "provider linked successfully"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TokenResponse{
+		AccessToken:  result.Tokens.AccessToken,
+		RefreshToken: result.Tokens.RefreshToken,
+	})
+}
+
+// @Summary Начать привязку внешнего провайдера
+// @Tags OIDC
+// @Security ApiKeyAuth
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Success 200 {object} dto.OIDCStartResponse
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/oidc/{provider}/link [post]
+func (r *oidcRoutes) link(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	authURL, err := r.oidcUseCase.Start(c.Request.Context(), c.Param("provider"), userUUID)
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.OIDCStartResponse{AuthorizationURL: authURL})
+}
+
+// @Summary Отвязать внешнего провайдера
+// @Description Нельзя отвязать последний способ входа пользователя без пароля.
+// @Tags OIDC
+// @Security ApiKeyAuth
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/oidc/{provider} [delete]
+func (r *oidcRoutes) unlink(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	if err := r.oidcUseCase.Unlink(c.Request.Context(), userUUID, c.Param("provider")); err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "provider unlinked successfully"})
+}
+
+// @Summary Привязанные внешние провайдеры
+// @Tags OIDC
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.ExternalAccountResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/oidc/accounts [get]
+func (r *oidcRoutes) accounts(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	accounts, err := r.oidcUseCase.Accounts(c.Request.Context(), userUUID)
+	if err != nil {
+		oidcError(c, err)
+		return
+	}
+
+	resp := make([]dto.ExternalAccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		resp = append(resp, dto.ExternalAccountResponse{
+			Provider:  account.Provider,
+			Email:     account.Email,
+			CreatedAt: account.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func oidcError(c *gin.Context, err error) {
+	if mfaChallenge(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, usecase.ErrOIDCProviderNotFound),
+		errors.Is(err, usecase.ErrOIDCAccountNotLinked):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrOIDCStateInvalid),
+		errors.Is(err, usecase.ErrOIDCEmailNotVerified):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrIdentityDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrOIDCAccountLinked),
+		errors.Is(err, usecase.ErrOIDCProviderLinked),
+		errors.Is(err, usecase.ErrLastLoginMethod):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, oidc.ErrInvalidIDToken),
+		errors.Is(err, oidc.ErrProviderResponse):
+		log.Printf("OIDC login failed: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider rejected the login"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,99 @@
+package v1_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newOIDCRouter(oidcUseCase *mocks.OIDCUseCaseMock, identityUseCase *mocks.IdentityUseCaseMock) *gin.Engine {
+	router := gin.New()
+	v1.NewOIDCRoutes(router.Group("/v1"), oidcUseCase, identityUseCase)
+	return router
+}
+
+func oidcCallbackRequest(token string) *http.Request {
+	body, _ := json.Marshal(map[string]string{"code": "code", "state": "state"})
+	req, _ := http.NewRequest(http.MethodPost, "/v1/auth/oidc/google/callback", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// Тест для POST /auth/oidc/:provider/callback - Вход
+func TestOIDCCallback_Login(t *testing.T) {
+	oidcUseCase := new(mocks.OIDCUseCaseMock)
+	oidcUseCase.On("Callback", context.Background(), "google", "code", "state", "").
+		Return(&usecase.OIDCResult{Tokens: &usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}}, nil)
+
+	w := httptest.NewRecorder()
+	newOIDCRouter(oidcUseCase, new(mocks.IdentityUseCaseMock)).ServeHTTP(w, oidcCallbackRequest(""))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"access_token":"access","refresh_token":"refresh"}`, w.Body.String())
+}
+
+// Тест для POST /auth/oidc/:provider/callback - Привязка текущим пользователем
+func TestOIDCCallback_Link(t *testing.T) {
+	oidcUseCase := new(mocks.OIDCUseCaseMock)
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("ValidateToken", context.Background(), "valid_token", false).Return("user123", nil)
+	oidcUseCase.On("Callback", context.Background(), "google", "code", "state", "user123").
+		Return(&usecase.OIDCResult{Linked: true}, nil)
+
+	w := httptest.NewRecorder()
+	newOIDCRouter(oidcUseCase, identityUseCase).ServeHTTP(w, oidcCallbackRequest("valid_token"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"message":"provider linked successfully"}`, w.Body.String())
+}
+
+// Тест для POST /auth/oidc/:provider/callback - Неверный state
+func TestOIDCCallback_InvalidState(t *testing.T) {
+	oidcUseCase := new(mocks.OIDCUseCaseMock)
+	oidcUseCase.On("Callback", context.Background(), "google", "code", "state", "").
+		Return(nil, usecase.ErrOIDCStateInvalid)
+
+	w := httptest.NewRecorder()
+	newOIDCRouter(oidcUseCase, new(mocks.IdentityUseCaseMock)).ServeHTTP(w, oidcCallbackRequest(""))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Тест для POST /auth/oidc/:provider/link - Без токена
+func TestOIDCLink_Unauthorized(t *testing.T) {
+	oidcUseCase := new(mocks.OIDCUseCaseMock)
+
+	req, _ := http.NewRequest(http.MethodPost, "/v1/auth/oidc/google/link", nil)
+	w := httptest.NewRecorder()
+	newOIDCRouter(oidcUseCase, new(mocks.IdentityUseCaseMock)).ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	oidcUseCase.AssertNotCalled(t, "Start")
+}
+
+// Тест для DELETE /auth/oidc/:provider - Последний способ входа
+func TestOIDCUnlink_LastLoginMethod(t *testing.T) {
+	oidcUseCase := new(mocks.OIDCUseCaseMock)
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("ValidateToken", context.Background(), "valid_token", false).Return("user123", nil)
+	oidcUseCase.On("Unlink", context.Background(), "user123", "google").Return(usecase.ErrLastLoginMethod)
+
+	req, _ := http.NewRequest(http.MethodDelete, "/v1/auth/oidc/google", nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+	w := httptest.NewRecorder()
+	newOIDCRouter(oidcUseCase, identityUseCase).ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusConflict, w.Code)
+}
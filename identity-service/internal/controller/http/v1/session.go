@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/dto"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionUseCase interface {
+	List(ctx context.Context, userUUID string) ([]entity.Session, error)
+	CurrentSession(accessToken string) string
+	Revoke(ctx context.Context, userUUID, sessionID string) error
+	RevokeAll(ctx context.Context, userUUID, exceptSessionID string) error
+}
+
+type sessionRoutes struct {
+	sessionUseCase  SessionUseCase
+	identityUseCase IdentityUseCase
+}
+
+func NewSessionRoutes(handler *gin.RouterGroup, sessionUseCase SessionUseCase, identityUseCase IdentityUseCase) {
+	r := &sessionRoutes{
+		sessionUseCase:  sessionUseCase,
+		identityUseCase: identityUseCase,
+	}
+
+	h := handler.Group("/auth/sessions")
+	{
+		h.GET("", r.list)
+		h.DELETE("", r.revokeAll)
+		h.DELETE("/:id", r.revoke)
+	}
+}
+
+// @Summary Активные сессии пользователя
+// @Description Устройства, на которых выполнен вход. Сессия текущего токена отмечена current.
+// @Tags Sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} dto.SessionResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/sessions [get]
+func (r *sessionRoutes) list(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	sessions, err := r.sessionUseCase.List(c.Request.Context(), userUUID)
+	if err != nil {
+		sessionError(c, err)
+		return
+	}
+
+	current := r.currentSession(c)
+	resp := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, dto.SessionResponse{
+			ID:         session.ID.String(),
+			Device:     session.Device,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID.String() == current,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Завершить сессию
+// @Description Отзывает refresh и access токены сессии.
+// @Tags Sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "ID сессии"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/sessions/{id} [delete]
+func (r *sessionRoutes) revoke(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	if err := r.sessionUseCase.Revoke(c.Request.Context(), userUUID, c.Param("id")); err != nil {
+		sessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+// @Summary Выйти на всех устройствах
+// @Description Завершает все сессии пользователя. С keep_current=true текущая сессия остается.
+// @Tags Sessions
+// @Security ApiKeyAuth
+// @Produce json
+// @Param keep_current query bool false "Не завершать текущую сессию"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/sessions [delete]
+func (r *sessionRoutes) revokeAll(c *gin.Context) {
+	userUUID, ok := authenticate(c, r.identityUseCase)
+	if !ok {
+		return
+	}
+
+	var except string
+	if keep, _ := strconv.ParseBool(c.Query("keep_current")); keep {
+		except = r.currentSession(c)
+	}
+
+	if err := r.sessionUseCase.RevokeAll(c.Request.Context(), userUUID, except); err != nil {
+		sessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked successfully"})
+}
+
+func (r *sessionRoutes) currentSession(c *gin.Context) string {
+	token, err := extractToken(c)
+	if err != nil {
+		return ""
+	}
+	return r.sessionUseCase.CurrentSession(token)
+}
+
+func sessionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
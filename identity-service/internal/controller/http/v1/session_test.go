@@ -0,0 +1,94 @@
+package v1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/JojoWeyn/duo-proj/identity-service/internal/controller/http/v1"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newSessionRouter(sessionUseCase *mocks.SessionUseCaseMock) (*gin.Engine, *mocks.IdentityUseCaseMock) {
+	identityUseCase := new(mocks.IdentityUseCaseMock)
+	identityUseCase.On("ValidateToken", context.Background(), "valid_token", false).Return("user123", nil)
+
+	router := gin.New()
+	v1.NewSessionRoutes(router.Group("/v1"), sessionUseCase, identityUseCase)
+	return router, identityUseCase
+}
+
+func sessionRequest(method, path string) *http.Request {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+	return req
+}
+
+// Тест для GET /auth/sessions - Текущая сессия отмечена
+func TestListSessions(t *testing.T) {
+	current, other := uuid.New(), uuid.New()
+	sessionUseCase := new(mocks.SessionUseCaseMock)
+	sessionUseCase.On("List", context.Background(), "user123").Return([]entity.Session{
+		{ID: current, Device: "laptop"},
+		{ID: other, Device: "phone"},
+	}, nil)
+	sessionUseCase.On("CurrentSession", "valid_token").Return(current.String())
+	router, _ := newSessionRouter(sessionUseCase)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, sessionRequest(http.MethodGet, "/v1/auth/sessions"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp, 2)
+	require.Equal(t, true, resp[0]["current"])
+	require.Equal(t, false, resp[1]["current"])
+	require.Equal(t, "phone", resp[1]["device"])
+}
+
+// Тест для DELETE /auth/sessions/:id - Чужая или несуществующая сессия
+func TestRevokeSession_NotFound(t *testing.T) {
+	sessionUseCase := new(mocks.SessionUseCaseMock)
+	sessionUseCase.On("Revoke", context.Background(), "user123", "missing").Return(usecase.ErrSessionNotFound)
+	router, _ := newSessionRouter(sessionUseCase)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, sessionRequest(http.MethodDelete, "/v1/auth/sessions/missing"))
+
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// Тест для DELETE /auth/sessions - Выход на всех устройствах
+func TestRevokeAllSessions(t *testing.T) {
+	sessionUseCase := new(mocks.SessionUseCaseMock)
+	sessionUseCase.On("RevokeAll", context.Background(), "user123", "").Return(nil)
+	router, _ := newSessionRouter(sessionUseCase)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, sessionRequest(http.MethodDelete, "/v1/auth/sessions"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	sessionUseCase.AssertExpectations(t)
+}
+
+// Тест для DELETE /auth/sessions?keep_current=true - Текущая сессия остается
+func TestRevokeAllSessions_KeepCurrent(t *testing.T) {
+	sessionUseCase := new(mocks.SessionUseCaseMock)
+	sessionUseCase.On("CurrentSession", "valid_token").Return("current-session")
+	sessionUseCase.On("RevokeAll", context.Background(), "user123", "current-session").Return(nil)
+	router, _ := newSessionRouter(sessionUseCase)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, sessionRequest(http.MethodDelete, "/v1/auth/sessions?keep_current=true"))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	sessionUseCase.AssertExpectations(t)
+}
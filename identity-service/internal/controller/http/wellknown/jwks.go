@@ -0,0 +1,23 @@
+package wellknown
+
+import (
+	"net/http"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/keyset"
+	"github.com/gin-gonic/gin"
+)
+
+type KeySource interface {
+	JWKS(use string) keyset.JWKS
+}
+
+// NewRouter регистрирует /.well-known/jwks.json - открытые ключи, которыми
+// gateway и другие сервисы проверяют access токены. Ключи refresh токенов
+// не публикуются: их проверяет только identity-service.
+func NewRouter(handler *gin.Engine, keys KeySource) {
+	handler.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS(entity.SigningKeyAccess))
+	})
+}
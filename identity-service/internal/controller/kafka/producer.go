@@ -1,9 +1,14 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 )
 
 type Producer struct {
@@ -22,6 +27,18 @@ type UserLoginEvent struct {
 	Action string `json:"action"`
 }
 
+// IdentityChangedEvent - изменение пользователя администратором: роль,
+// отключение или принудительный выход. Role и Disabled - состояние после изменения.
+type IdentityChangedEvent struct {
+	UUID      string    `json:"uuid"`
+	Login     string    `json:"login"`
+	Action    string    `json:"action"`
+	Role      string    `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	ActorUUID string    `json:"actor_uuid"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 func NewProducer(brokers, topic string) (*Producer, error) {
 	config := sarama.NewConfig()
 	config.Producer.Return.Successes = true
@@ -37,7 +54,7 @@ func NewProducer(brokers, topic string) (*Producer, error) {
 	}, nil
 }
 
-func (p *Producer) SendUserCreated(uuid, login string) error {
+func (p *Producer) SendUserCreated(ctx context.Context, uuid, login string) error {
 	event := UserCreatedEvent{
 		UUID:  uuid,
 		Login: login,
@@ -53,11 +70,10 @@ func (p *Producer) SendUserCreated(uuid, login string) error {
 		Value: sarama.ByteEncoder(value),
 	}
 
-	_, _, err = p.producer.SendMessage(msg)
-	return err
+	return p.send(ctx, msg)
 }
 
-func (p *Producer) SendUserLogin(uuid, login string) error {
+func (p *Producer) SendUserLogin(ctx context.Context, uuid, login string) error {
 	event := UserLoginEvent{
 		UUID:   uuid,
 		Login:  login,
@@ -74,7 +90,41 @@ func (p *Producer) SendUserLogin(uuid, login string) error {
 		Value: sarama.ByteEncoder(value),
 	}
 
-	_, _, err = p.producer.SendMessage(msg)
+	return p.send(ctx, msg)
+}
+
+// SendIdentityChanged публикует изменение пользователя. Ключ сообщения - UUID
+// пользователя, чтобы изменения одного пользователя читались по порядку.
+func (p *Producer) SendIdentityChanged(ctx context.Context, identity *entity.Identity, action, actorUUID string) error {
+	event := IdentityChangedEvent{
+		UUID:      identity.UserUUID.String(),
+		Login:     identity.Email,
+		Action:    action,
+		Role:      identity.Role,
+		Disabled:  identity.IsDisabled(),
+		ActorUUID: actorUUID,
+		Timestamp: time.Now(),
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(event.UUID),
+		Value: sarama.ByteEncoder(value),
+	}
+
+	return p.send(ctx, msg)
+}
+
+func (p *Producer) send(ctx context.Context, msg *sarama.ProducerMessage) error {
+	span := tracing.StartProduce(ctx, msg)
+	_, _, err := p.producer.SendMessage(msg)
+	tracing.End(span, err)
+	metrics.ObserveProduce(msg.Topic, err)
 	return err
 }
 
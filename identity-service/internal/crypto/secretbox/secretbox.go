@@ -0,0 +1,78 @@
+// Package secretbox шифрует секреты, которые хранятся в базе.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealedPrefix отмечает зашифрованные секреты.
+const sealedPrefix = "v1:"
+
+// Box шифрует секреты для хранения в базе (AES-256-GCM): секреты TOTP и
+// закрытые ключи подписи. Без ключа секреты хранятся как есть; уже
+// зашифрованные секреты без ключа не читаются.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New принимает ключ длиной 32 байта или nil.
+func New(key []byte) (*Box, error) {
+	if len(key) == 0 {
+		return &Box{}, nil
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+func (b *Box) Seal(secret string) (string, error) {
+	if b.aead == nil {
+		return secret, nil
+	}
+
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *Box) Open(stored string) (string, error) {
+	if !strings.HasPrefix(stored, sealedPrefix) {
+		return stored, nil
+	}
+	if b.aead == nil {
+		return "", errors.New("secret is encrypted but no encryption key is configured")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, sealedPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(data) < b.aead.NonceSize() {
+		return "", errors.New("sealed secret is corrupted")
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,29 @@
+package secretbox_test
+
+import (
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/crypto/secretbox"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox(t *testing.T) {
+	box, err := secretbox.New([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal("SECRET")
+	require.NoError(t, err)
+	require.NotContains(t, sealed, "SECRET")
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, "SECRET", opened)
+
+	plain, err := secretbox.New(nil)
+	require.NoError(t, err)
+	_, err = plain.Open(sealed)
+	require.Error(t, err)
+
+	_, err = secretbox.New([]byte("short"))
+	require.Error(t, err)
+}
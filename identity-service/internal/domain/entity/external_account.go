@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExternalAccount - учетная запись внешнего OIDC провайдера, привязанная к
+// пользователю. Пользователь провайдера определяется парой Provider и Subject.
+type ExternalAccount struct {
+	ID       int       `json:"id" gorm:"primaryKey"`
+	UserUUID uuid.UUID `json:"user_uuid" gorm:"index"`
+	Provider string    `json:"provider" gorm:"uniqueIndex:idx_external_accounts_provider_subject"`
+	Subject  string    `json:"-" gorm:"uniqueIndex:idx_external_accounts_provider_subject"`
+	// Email - адрес из провайдера на момент привязки.
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewExternalAccount(userUUID uuid.UUID, provider, subject, email string) *ExternalAccount {
+	return &ExternalAccount{
+		UserUUID:  userUUID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+}
+
+// OIDCState - незавершенный вход через провайдера. Хранится хэш state,
+// nonce и PKCE verifier, которые нужны при обмене кода.
+type OIDCState struct {
+	ID           int    `gorm:"primaryKey"`
+	StateHash    string `gorm:"uniqueIndex"`
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	// LinkUserUUID - пользователь, к которому привязывается учетная запись;
+	// пусто при входе.
+	LinkUserUUID string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+func (s *OIDCState) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
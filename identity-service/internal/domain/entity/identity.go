@@ -1,22 +1,50 @@
 package entity
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Роли пользователей.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Изменения пользователя администратором, о которых сообщается другим сервисам.
+const (
+	IdentityRoleChanged     = "role_changed"
+	IdentityDisabled        = "disabled"
+	IdentityEnabled         = "enabled"
+	IdentitySessionsRevoked = "sessions_revoked"
+)
+
+var ErrInvalidRole = errors.New("role must be user or admin")
+
 type Identity struct {
-	ID               int       `json:"id" gorm:"primaryKey"`
-	UserUUID         uuid.UUID `json:"user_uuid" gorm:"unique"`
-	Provider         string    `json:"provider"`
-	Role             string    `json:"role"`
-	Email            string    `json:"email"`
-	PasswordHash     string    `json:"-"`
-	IsConfirmEmail   bool      `json:"is_confirm_email"`
-	VerificationCode string    `json:"verification_code"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID             int       `json:"id" gorm:"primaryKey"`
+	UserUUID       uuid.UUID `json:"user_uuid" gorm:"unique"`
+	Provider       string    `json:"provider"`
+	Role           string    `json:"role"`
+	Email          string    `json:"email"`
+	PasswordHash   string    `json:"-"`
+	IsConfirmEmail bool      `json:"is_confirm_email"`
+	// DisabledAt - когда администратор отключил аккаунт; nil - аккаунт активен.
+	DisabledAt *time.Time `json:"disabled_at" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// IdentityFilter - условия выборки пользователей для администратора.
+// Пустые поля не ограничивают выборку.
+type IdentityFilter struct {
+	Email    string
+	Role     string
+	Disabled *bool
+	Limit    int
+	Offset   int
 }
 
 func NewIdentity(email, passwordHash string) (*Identity, error) {
@@ -30,12 +58,25 @@ func NewIdentity(email, passwordHash string) (*Identity, error) {
 		Email:          email,
 		PasswordHash:   passwordHash,
 		IsConfirmEmail: false,
-		Role:           "user",
+		Role:           RoleUser,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}, nil
 }
 
+// NewExternalIdentity создает пользователя, вошедшего через внешнего провайдера.
+// Email уже подтвержден провайдером, пароля у пользователя нет.
+func NewExternalIdentity(email, provider string) (*Identity, error) {
+	identity, err := NewIdentity(email, "")
+	if err != nil {
+		return nil, err
+	}
+
+	identity.Provider = provider
+	identity.IsConfirmEmail = true
+	return identity, nil
+}
+
 func (i *Identity) UpdateEmail(email string) error {
 	if err := ValidateEmail(email); err != nil {
 		return err
@@ -51,12 +92,15 @@ func (i *Identity) UpdatePassword(passwordHash string) {
 	i.UpdatedAt = time.Now()
 }
 
-func (i *Identity) AddVerificationCode(code string) {
-	i.VerificationCode = code
+// RemovePassword удаляет пароль, после этого вход возможен только через
+// привязанных провайдеров.
+func (i *Identity) RemovePassword() {
+	i.PasswordHash = ""
+	i.UpdatedAt = time.Now()
 }
 
-func (i *Identity) RemoveVerificationCode() {
-	i.VerificationCode = ""
+func (i *Identity) HasPassword() bool {
+	return i.PasswordHash != ""
 }
 
 func (i *Identity) ConfirmEmail() {
@@ -69,3 +113,38 @@ func (i *Identity) IsConfirmedEmail() bool {
 	}
 	return false
 }
+
+// ValidateRole проверяет, что роль известна.
+func ValidateRole(role string) error {
+	switch role {
+	case RoleUser, RoleAdmin:
+		return nil
+	default:
+		return ErrInvalidRole
+	}
+}
+
+func (i *Identity) ChangeRole(role string) error {
+	if err := ValidateRole(role); err != nil {
+		return err
+	}
+
+	i.Role = role
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// Disable отключает аккаунт: войти в него нельзя, пока его не включат.
+func (i *Identity) Disable(now time.Time) {
+	i.DisabledAt = &now
+	i.UpdatedAt = now
+}
+
+func (i *Identity) Enable() {
+	i.DisabledAt = nil
+	i.UpdatedAt = time.Now()
+}
+
+func (i *Identity) IsDisabled() bool {
+	return i.DisabledAt != nil
+}
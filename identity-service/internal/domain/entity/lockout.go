@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Счетчики неудачных попыток входа и ввода кодов ведутся по аккаунту (email)
+// и по IP клиента.
+const (
+	LockoutAccount = "account"
+	LockoutIP      = "ip"
+)
+
+// Действия в журнале блокировок.
+const (
+	LockoutActionLocked   = "locked"
+	LockoutActionUnlocked = "unlocked"
+)
+
+// FailureCounter - неудачные попытки подряд по аккаунту или IP. После
+// нескольких попыток следующая разрешена только после BlockedUntil
+// (экспоненциальная задержка), после порога - временная блокировка.
+type FailureCounter struct {
+	ID            int        `json:"-" gorm:"primaryKey"`
+	Kind          string     `json:"kind" gorm:"uniqueIndex:idx_failure_counter_key"`
+	Key           string     `json:"key" gorm:"uniqueIndex:idx_failure_counter_key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until" gorm:"index"`
+	// Locked - блокировка по порогу, а не задержка между попытками.
+	Locked bool `json:"locked"`
+}
+
+// IsBlocked сообщает, что попытки пока не принимаются.
+func (c *FailureCounter) IsBlocked(now time.Time) bool {
+	return c.BlockedUntil != nil && now.Before(*c.BlockedUntil)
+}
+
+// LockoutEvent - запись журнала блокировок и разблокировок.
+type LockoutEvent struct {
+	ID     int    `json:"id" gorm:"primaryKey"`
+	Kind   string `json:"kind" gorm:"index:idx_lockout_event_key"`
+	Key    string `json:"key" gorm:"index:idx_lockout_event_key"`
+	Action string `json:"action"`
+	// ActorUUID - администратор, снявший блокировку; пусто для автоматических событий.
+	ActorUUID   string     `json:"actor_uuid,omitempty"`
+	IP          string     `json:"ip,omitempty"`
+	Failures    int        `json:"failures"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+}
+
+// NormalizeLockoutKey приводит email к одному виду, чтобы регистр не давал
+// обойти счетчик аккаунта.
+func NormalizeLockoutKey(kind, key string) string {
+	key = strings.TrimSpace(key)
+	if kind == LockoutAccount {
+		key = strings.ToLower(key)
+	}
+	return key
+}
@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPCredential - секрет приложения-аутентификатора пользователя. Второй
+// фактор включается только после подтверждения первым кодом.
+type TOTPCredential struct {
+	ID       int       `json:"id" gorm:"primaryKey"`
+	UserUUID uuid.UUID `json:"user_uuid" gorm:"uniqueIndex"`
+	// Secret - секрет в base32, зашифрованный при заданном ключе.
+	Secret    string `json:"-"`
+	Confirmed bool   `json:"confirmed"`
+	// LastUsedStep - шаг времени последнего принятого кода, защищает от
+	// повторного использования кода.
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+}
+
+func NewTOTPCredential(userUUID uuid.UUID, secret string) *TOTPCredential {
+	return &TOTPCredential{
+		UserUUID:  userUUID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (c *TOTPCredential) Confirm(step int64) {
+	now := time.Now()
+	c.Confirmed = true
+	c.ConfirmedAt = &now
+	c.LastUsedStep = step
+}
+
+// RecoveryCode - одноразовый код входа без приложения-аутентификатора.
+// Коды случайные и длинные, поэтому хранится их SHA-256.
+type RecoveryCode struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserUUID  uuid.UUID  `json:"user_uuid" gorm:"index"`
+	CodeHash  string     `json:"-" gorm:"index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MFAChallenge - вход, ожидающий второго фактора. Клиент получает токен,
+// в базе хранится его хэш.
+type MFAChallenge struct {
+	ID        int       `gorm:"primaryKey"`
+	TokenHash string    `gorm:"uniqueIndex"`
+	UserUUID  uuid.UUID `gorm:"index"`
+	// EnrollmentRequired - роль требует второй фактор, а он не настроен:
+	// токен позволяет только настроить его.
+	EnrollmentRequired bool
+	Attempts           int
+	MaxAttempts        int
+	ExpiresAt          time.Time
+	CreatedAt          time.Time
+}
+
+func (c *MFAChallenge) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// MFARolePolicy - требование второго фактора для роли, задается администратором.
+type MFARolePolicy struct {
+	Role      string    `json:"role" gorm:"primaryKey"`
+	Required  bool      `json:"required"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
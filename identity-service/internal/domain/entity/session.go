@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SessionRevokedLogout  = "logout"
+	SessionRevokedByUser  = "revoked"
+	SessionRevokedReuse   = "refresh_token_reuse"
+	SessionRevokedByAdmin = "admin"
+)
+
+// Session - вход пользователя на устройстве. Refresh токены сессии образуют
+// семейство: каждое обновление выдает новый токен, и действителен только
+// последний (RefreshJTI). Предъявление уже замененного токена означает его
+// кражу, и сессия отзывается целиком.
+type Session struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey"`
+	UserUUID   uuid.UUID `json:"user_uuid" gorm:"index"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	RefreshJTI string    `json:"-" gorm:"index"`
+	// AccessJTI - последний выданный access токен, отзывается вместе с сессией.
+	AccessJTI       string     `json:"-"`
+	AccessExpiresAt time.Time  `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastUsedAt      time.Time  `json:"last_used_at"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+	RevokeReason    string     `json:"revoke_reason"`
+}
+
+func NewSession(userUUID uuid.UUID, client ClientInfo) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         uuid.New(),
+		UserUUID:   userUUID,
+		Device:     client.Device,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+}
+
+// Issue запоминает выданную сессии пару токенов.
+func (s *Session) Issue(pair *TokenPair) {
+	s.RefreshJTI = pair.RefreshJTI
+	s.AccessJTI = pair.AccessJTI
+	s.AccessExpiresAt = pair.AccessExpiresAt
+	s.ExpiresAt = pair.RefreshExpiresAt
+	s.LastUsedAt = time.Now()
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// ClientInfo - устройство, с которого пришел запрос.
+type ClientInfo struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
+
+// TokenPair - выпущенные токены с их jti и сроками действия.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessJTI        string
+	RefreshJTI       string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// TokenClaims - проверенное содержимое токена.
+type TokenClaims struct {
+	UserUUID  string
+	Role      string
+	SessionID string
+	JTI       string
+	ExpiresAt time.Time
+}
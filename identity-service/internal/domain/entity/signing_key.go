@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// Назначение ключа подписи: access токены проверяют другие сервисы,
+// refresh токены - только identity-service.
+const (
+	SigningKeyAccess  = "access"
+	SigningKeyRefresh = "refresh"
+)
+
+// SigningKey - ключ подписи токенов из набора с ротацией. Ключ публикуется
+// с момента создания, подписывает токены с ActiveAt до RetiredAt и остается
+// пригодным для проверки до ExpiresAt, пока не истекут выданные им токены.
+type SigningKey struct {
+	KID        string `gorm:"column:kid;primaryKey"`
+	Use        string `gorm:"index"`
+	Algorithm  string
+	PrivateKey string // PEM (PKCS #8), зашифрован, если задан ключ шифрования
+	CreatedAt  time.Time
+	ActiveAt   time.Time
+	RetiredAt  *time.Time
+	ExpiresAt  *time.Time `gorm:"index"`
+}
+
+// IsExpired сообщает, что токены ключа больше не принимаются.
+func (k *SigningKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
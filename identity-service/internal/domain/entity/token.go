@@ -7,14 +7,22 @@ import (
 type BlacklistedToken struct {
 	ID        int       `json:"id" gorm:"primaryKey"`
 	Token     string    `json:"token" gorm:"unique"`
+	JTI       string    `json:"jti" gorm:"index"`
 	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
 }
 
-func NewBlacklistedToken(token string, expiresAt time.Time) *BlacklistedToken {
+func NewBlacklistedToken(token, jti string, expiresAt time.Time) *BlacklistedToken {
 	return &BlacklistedToken{
 		Token:     token,
+		JTI:       jti,
 		ExpiresAt: expiresAt,
 		CreatedAt: time.Now(),
 	}
 }
+
+// NewRevokedJTI отзывает токен, известный только по jti (например, access
+// токен отозванной сессии). Token уникален, поэтому в нем хранится jti.
+func NewRevokedJTI(jti string, expiresAt time.Time) *BlacklistedToken {
+	return NewBlacklistedToken("jti:"+jti, jti, expiresAt)
+}
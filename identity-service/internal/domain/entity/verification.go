@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// VerificationPurpose - действие, которое подтверждает код. Код одного
+// назначения не принимается для другого.
+type VerificationPurpose string
+
+const (
+	PurposeConfirmEmail  VerificationPurpose = "confirm_email"
+	PurposeResetPassword VerificationPurpose = "reset_password"
+	PurposeChangeEmail   VerificationPurpose = "change_email"
+)
+
+func ParseVerificationPurpose(value string) (VerificationPurpose, error) {
+	switch purpose := VerificationPurpose(value); purpose {
+	case PurposeConfirmEmail, PurposeResetPassword, PurposeChangeEmail:
+		return purpose, nil
+	default:
+		return "", errors.New("unknown verification purpose")
+	}
+}
+
+// VerificationCode - выданный пользователю код подтверждения. У пользователя
+// не больше одного кода на назначение, новый код заменяет предыдущий.
+// Хранится только хэш кода.
+type VerificationCode struct {
+	ID       int                 `json:"id" gorm:"primaryKey"`
+	UserUUID uuid.UUID           `json:"user_uuid" gorm:"uniqueIndex:idx_verification_codes_user_purpose"`
+	Purpose  VerificationPurpose `json:"purpose" gorm:"uniqueIndex:idx_verification_codes_user_purpose"`
+	// Email - адрес, на который отправлен код; для change_email - новый адрес.
+	Email       string    `json:"email"`
+	CodeHash    string    `json:"-"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewVerificationCode(userUUID uuid.UUID, email string, purpose VerificationPurpose, code string, ttl time.Duration, maxAttempts int) (*VerificationCode, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &VerificationCode{
+		UserUUID:    userUUID,
+		Purpose:     purpose,
+		Email:       email,
+		CodeHash:    string(hash),
+		MaxAttempts: maxAttempts,
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}, nil
+}
+
+func (v *VerificationCode) Matches(code string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(v.CodeHash), []byte(code)) == nil
+}
+
+func (v *VerificationCode) IsExpired(now time.Time) bool {
+	return !now.Before(v.ExpiresAt)
+}
+
+func (v *VerificationCode) IsExhausted() bool {
+	return v.Attempts >= v.MaxAttempts
+}
+
+// ResendAt - момент, с которого можно выдать новый код того же назначения.
+func (v *VerificationCode) ResendAt(cooldown time.Duration) time.Time {
+	return v.CreatedAt.Add(cooldown)
+}
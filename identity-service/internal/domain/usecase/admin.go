@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrSelfManagement   = errors.New("administrators cannot change their own role or status")
+)
+
+const (
+	defaultIdentityPageSize = 50
+	maxIdentityPageSize     = 200
+)
+
+type AdminIdentityRepository interface {
+	FindByUUID(ctx context.Context, userID string) (*entity.Identity, error)
+	Update(ctx context.Context, identity *entity.Identity) error
+	List(ctx context.Context, filter entity.IdentityFilter) ([]entity.Identity, int64, error)
+}
+
+// SessionTerminator завершает все сессии пользователя, см. SessionUseCase.Terminate.
+type SessionTerminator interface {
+	Terminate(ctx context.Context, userUUID uuid.UUID) error
+}
+
+// IdentityEventProducer сообщает другим сервисам об изменениях пользователей.
+type IdentityEventProducer interface {
+	SendIdentityChanged(ctx context.Context, identity *entity.Identity, action, actorUUID string) error
+}
+
+// IdentityPage - страница списка пользователей и их общее число.
+type IdentityPage struct {
+	Identities []entity.Identity
+	Total      int64
+}
+
+// AdminUseCase - управление пользователями администратором: роли,
+// отключение аккаунтов и принудительный выход. Каждое изменение публикуется
+// для других сервисов.
+type AdminUseCase struct {
+	identityRepo AdminIdentityRepository
+	sessions     SessionTerminator
+	producer     IdentityEventProducer
+}
+
+func NewAdminUseCase(identityRepo AdminIdentityRepository, sessions SessionTerminator, producer IdentityEventProducer) *AdminUseCase {
+	return &AdminUseCase{
+		identityRepo: identityRepo,
+		sessions:     sessions,
+		producer:     producer,
+	}
+}
+
+// List возвращает пользователей по фильтру, новые сначала.
+func (uc *AdminUseCase) List(ctx context.Context, filter entity.IdentityFilter) (*IdentityPage, error) {
+	if filter.Role != "" {
+		if err := entity.ValidateRole(filter.Role); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultIdentityPageSize
+	}
+	filter.Limit = min(filter.Limit, maxIdentityPageSize)
+	filter.Offset = max(filter.Offset, 0)
+
+	identities, total, err := uc.identityRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &IdentityPage{Identities: identities, Total: total}, nil
+}
+
+func (uc *AdminUseCase) Get(ctx context.Context, userUUID string) (*entity.Identity, error) {
+	return uc.find(ctx, userUUID)
+}
+
+// SetRole меняет роль пользователя. Сессии пользователя завершаются, чтобы
+// токены со старой ролью перестали действовать.
+func (uc *AdminUseCase) SetRole(ctx context.Context, actorUUID, userUUID, role string) (*entity.Identity, error) {
+	if err := entity.ValidateRole(role); err != nil {
+		return nil, err
+	}
+	if actorUUID == userUUID {
+		return nil, ErrSelfManagement
+	}
+
+	identity, err := uc.find(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if identity.Role == role {
+		return identity, nil
+	}
+
+	if err := identity.ChangeRole(role); err != nil {
+		return nil, err
+	}
+	if err := uc.apply(ctx, identity, entity.IdentityRoleChanged, actorUUID); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// Disable отключает аккаунт и завершает все его сессии.
+func (uc *AdminUseCase) Disable(ctx context.Context, actorUUID, userUUID string) (*entity.Identity, error) {
+	if actorUUID == userUUID {
+		return nil, ErrSelfManagement
+	}
+
+	identity, err := uc.find(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if identity.IsDisabled() {
+		return identity, nil
+	}
+
+	identity.Disable(time.Now())
+	if err := uc.apply(ctx, identity, entity.IdentityDisabled, actorUUID); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// Enable снова разрешает вход в отключенный аккаунт.
+func (uc *AdminUseCase) Enable(ctx context.Context, actorUUID, userUUID string) (*entity.Identity, error) {
+	identity, err := uc.find(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !identity.IsDisabled() {
+		return identity, nil
+	}
+
+	identity.Enable()
+	if err := uc.identityRepo.Update(ctx, identity); err != nil {
+		return nil, err
+	}
+	uc.publish(ctx, identity, entity.IdentityEnabled, actorUUID)
+	return identity, nil
+}
+
+// Logout завершает все сессии пользователя и отзывает их токены.
+func (uc *AdminUseCase) Logout(ctx context.Context, actorUUID, userUUID string) error {
+	identity, err := uc.find(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.sessions.Terminate(ctx, identity.UserUUID); err != nil {
+		return err
+	}
+	uc.publish(ctx, identity, entity.IdentitySessionsRevoked, actorUUID)
+	return nil
+}
+
+func (uc *AdminUseCase) find(ctx context.Context, userUUID string) (*entity.Identity, error) {
+	if _, err := uuid.Parse(userUUID); err != nil {
+		return nil, ErrIdentityNotFound
+	}
+
+	// Ошибки базы не выдаются за отсутствие пользователя.
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && identity == nil) {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// apply сохраняет изменение, завершает сессии пользователя и публикует событие.
+func (uc *AdminUseCase) apply(ctx context.Context, identity *entity.Identity, action, actorUUID string) error {
+	if err := uc.identityRepo.Update(ctx, identity); err != nil {
+		return err
+	}
+	if err := uc.sessions.Terminate(ctx, identity.UserUUID); err != nil {
+		return err
+	}
+	uc.publish(ctx, identity, action, actorUUID)
+	return nil
+}
+
+// publish сообщает об изменении другим сервисам. Изменение уже сохранено,
+// поэтому ошибка отправки только логируется.
+func (uc *AdminUseCase) publish(ctx context.Context, identity *entity.Identity, action, actorUUID string) {
+	log.Printf("Identity %s: %s by %s", identity.UserUUID, action, actorUUID)
+
+	if err := uc.producer.SendIdentityChanged(ctx, identity, action, actorUUID); err != nil {
+		log.Printf("Failed to send identity changed event: %v", err)
+	}
+}
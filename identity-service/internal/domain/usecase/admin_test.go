@@ -0,0 +1,147 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testAdminUUID = "00000000-0000-0000-0000-00000000000a"
+
+type adminFixture struct {
+	identityRepo *mocks.AdminIdentityRepositoryMock
+	sessions     *mocks.SessionTerminatorMock
+	producer     *mocks.IdentityEventProducerMock
+	identity     *entity.Identity
+	uc           *usecase.AdminUseCase
+}
+
+func newAdminFixture() *adminFixture {
+	f := &adminFixture{
+		identityRepo: new(mocks.AdminIdentityRepositoryMock),
+		sessions:     new(mocks.SessionTerminatorMock),
+		producer:     new(mocks.IdentityEventProducerMock),
+		identity:     &entity.Identity{UserUUID: uuid.New(), Role: entity.RoleUser, Email: "user@example.com"},
+	}
+	f.identityRepo.On("FindByUUID", mock.Anything, f.identity.UserUUID.String()).Return(f.identity, nil).Maybe()
+
+	f.uc = usecase.NewAdminUseCase(f.identityRepo, f.sessions, f.producer)
+	return f
+}
+
+func TestAdminList_Defaults(t *testing.T) {
+	ctx := context.TODO()
+	f := newAdminFixture()
+	f.identityRepo.On("List", ctx, entity.IdentityFilter{Email: "user", Limit: 200}).
+		Return([]entity.Identity{*f.identity}, int64(1), nil)
+
+	page, err := f.uc.List(ctx, entity.IdentityFilter{Email: "user", Limit: 1000, Offset: -5})
+
+	require.NoError(t, err)
+	require.Equal(t, int64(1), page.Total)
+	require.Len(t, page.Identities, 1)
+}
+
+func TestAdminList_InvalidRole(t *testing.T) {
+	f := newAdminFixture()
+
+	_, err := f.uc.List(context.TODO(), entity.IdentityFilter{Role: "root"})
+
+	require.ErrorIs(t, err, entity.ErrInvalidRole)
+	f.identityRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestAdminSetRole(t *testing.T) {
+	ctx := context.TODO()
+	f := newAdminFixture()
+	f.identityRepo.On("Update", ctx, mock.MatchedBy(func(identity *entity.Identity) bool {
+		return identity.Role == entity.RoleAdmin
+	})).Return(nil)
+	f.sessions.On("Terminate", ctx, f.identity.UserUUID).Return(nil)
+	f.producer.On("SendIdentityChanged", ctx, f.identity, entity.IdentityRoleChanged, testAdminUUID).Return(nil)
+
+	identity, err := f.uc.SetRole(ctx, testAdminUUID, f.identity.UserUUID.String(), entity.RoleAdmin)
+
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleAdmin, identity.Role)
+	f.sessions.AssertExpectations(t)
+	f.producer.AssertExpectations(t)
+}
+
+func TestAdminSetRole_Unchanged(t *testing.T) {
+	f := newAdminFixture()
+
+	_, err := f.uc.SetRole(context.TODO(), testAdminUUID, f.identity.UserUUID.String(), entity.RoleUser)
+
+	require.NoError(t, err)
+	f.sessions.AssertNotCalled(t, "Terminate", mock.Anything, mock.Anything)
+	f.producer.AssertNotCalled(t, "SendIdentityChanged", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminSetRole_Self(t *testing.T) {
+	f := newAdminFixture()
+
+	_, err := f.uc.SetRole(context.TODO(), testAdminUUID, testAdminUUID, entity.RoleUser)
+
+	require.ErrorIs(t, err, usecase.ErrSelfManagement)
+}
+
+func TestAdminDisable(t *testing.T) {
+	ctx := context.TODO()
+	f := newAdminFixture()
+	f.identityRepo.On("Update", ctx, f.identity).Return(nil)
+	f.sessions.On("Terminate", ctx, f.identity.UserUUID).Return(nil)
+	// Ошибка отправки события не отменяет отключение.
+	f.producer.On("SendIdentityChanged", ctx, f.identity, entity.IdentityDisabled, testAdminUUID).Return(errors.New("kafka is down"))
+
+	identity, err := f.uc.Disable(ctx, testAdminUUID, f.identity.UserUUID.String())
+
+	require.NoError(t, err)
+	require.True(t, identity.IsDisabled())
+	f.sessions.AssertExpectations(t)
+	f.producer.AssertExpectations(t)
+}
+
+func TestAdminEnable(t *testing.T) {
+	ctx := context.TODO()
+	f := newAdminFixture()
+	f.identity.Disable(f.identity.CreatedAt)
+	f.identityRepo.On("Update", ctx, f.identity).Return(nil)
+	f.producer.On("SendIdentityChanged", ctx, f.identity, entity.IdentityEnabled, testAdminUUID).Return(nil)
+
+	identity, err := f.uc.Enable(ctx, testAdminUUID, f.identity.UserUUID.String())
+
+	require.NoError(t, err)
+	require.False(t, identity.IsDisabled())
+	f.sessions.AssertNotCalled(t, "Terminate", mock.Anything, mock.Anything)
+}
+
+func TestAdminLogout_NotFound(t *testing.T) {
+	ctx := context.TODO()
+	f := newAdminFixture()
+	missing := uuid.NewString()
+	f.identityRepo.On("FindByUUID", ctx, missing).Return(nil, gorm.ErrRecordNotFound)
+
+	require.ErrorIs(t, f.uc.Logout(ctx, testAdminUUID, missing), usecase.ErrIdentityNotFound)
+	require.ErrorIs(t, f.uc.Logout(ctx, testAdminUUID, "not-a-uuid"), usecase.ErrIdentityNotFound)
+}
+
+func TestAdminLogout_RepositoryError(t *testing.T) {
+	ctx := context.TODO()
+	f := newAdminFixture()
+	userUUID := uuid.NewString()
+	dbErr := errors.New("connection refused")
+	f.identityRepo.On("FindByUUID", ctx, userUUID).Return(nil, dbErr)
+
+	err := f.uc.Logout(ctx, testAdminUUID, userUUID)
+	require.ErrorIs(t, err, dbErr)
+	require.NotErrorIs(t, err, usecase.ErrIdentityNotFound)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -20,43 +22,85 @@ type IdentityRepository interface {
 	FindByLogin(ctx context.Context, login string) (*entity.Identity, error)
 	FindByEmail(ctx context.Context, email string) (*entity.Identity, error)
 	Update(ctx context.Context, identity *entity.Identity) error
+	ListDisabledUUIDs(ctx context.Context) ([]uuid.UUID, error)
 }
 
 type TokenRepository interface {
 	BlacklistToken(ctx context.Context, token *entity.BlacklistedToken) error
 	IsBlacklisted(ctx context.Context, token string) (bool, error)
+	ListRevokedSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error)
 	CleanupExpired(ctx context.Context) error
 }
 
 type TokenService interface {
-	GenerateTokenPair(userID, userRole string) (accessToken string, refreshToken string, err error)
 	ValidateToken(token string, isRefreshToken bool) (userID, userRole string, err error)
 	BlacklistToken(ctx context.Context, token string) error
 }
 
+type VerificationCodeRepository interface {
+	Save(ctx context.Context, code *entity.VerificationCode) error
+	Find(ctx context.Context, userUUID uuid.UUID, purpose entity.VerificationPurpose) (*entity.VerificationCode, error)
+	RegisterAttempt(ctx context.Context, id int) (bool, error)
+	Delete(ctx context.Context, id int, codeHash string) (bool, error)
+}
+
 type EventProducer interface {
-	SendUserCreated(userUUID string, email string) error
-	SendUserLogin(userUUID string, email string) error
+	SendUserCreated(ctx context.Context, userUUID string, email string) error
+	SendUserLogin(ctx context.Context, userUUID string, email string) error
+}
+
+// MFAGate решает, нужен ли второй фактор для входа пользователя, см. MFAUseCase.Begin.
+type MFAGate interface {
+	Begin(ctx context.Context, identity *entity.Identity) error
+}
+
+// SessionManager ведет сессии пользователей, см. SessionUseCase.
+type SessionManager interface {
+	SessionIssuer
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)
+	Verify(ctx context.Context, token string, isRefreshToken bool) error
+	End(ctx context.Context, accessToken string) error
+	RevokeAll(ctx context.Context, userUUID, exceptSessionID string) error
+}
+
+// Throttle ограничивает перебор паролей и кодов, см. LockoutUseCase.
+type Throttle interface {
+	Check(ctx context.Context, email string) error
+	Fail(ctx context.Context, email string) error
+	Succeed(ctx context.Context, email string) error
 }
 
 type IdentityUseCase struct {
 	identityRepo IdentityRepository
 	tokenService TokenService
 	tokenRepo    TokenRepository
+	codeRepo     VerificationCodeRepository
 	producer     EventProducer
+	verification VerificationPolicy
+	mfa          MFAGate
+	sessions     SessionManager
+	throttle     Throttle
 }
 
-func NewIdentityUseCase(identityRepo IdentityRepository, tokenService TokenService, tokenRepo TokenRepository, producer EventProducer) *IdentityUseCase {
+func NewIdentityUseCase(identityRepo IdentityRepository, tokenService TokenService, tokenRepo TokenRepository, codeRepo VerificationCodeRepository, producer EventProducer, verification VerificationPolicy, mfa MFAGate, sessions SessionManager, throttle Throttle) *IdentityUseCase {
 	return &IdentityUseCase{
 		identityRepo: identityRepo,
 		tokenService: tokenService,
 		tokenRepo:    tokenRepo,
+		codeRepo:     codeRepo,
 		producer:     producer,
+		verification: verification.WithDefaults(),
+		mfa:          mfa,
+		sessions:     sessions,
+		throttle:     throttle,
 	}
 }
 
 func (uc *IdentityUseCase) Logout(ctx context.Context, token string) error {
-	return uc.tokenService.BlacklistToken(ctx, token)
+	if err := uc.tokenService.BlacklistToken(ctx, token); err != nil {
+		return err
+	}
+	return uc.sessions.End(ctx, token)
 }
 
 func (uc *IdentityUseCase) ValidateToken(ctx context.Context, token string, isRefreshToken bool) (string, error) {
@@ -73,25 +117,15 @@ func (uc *IdentityUseCase) ValidateToken(ctx context.Context, token string, isRe
 		return "", err
 	}
 
+	if err := uc.sessions.Verify(ctx, token, isRefreshToken); err != nil {
+		return "", err
+	}
+
 	return userID, nil
 }
 
 func (uc *IdentityUseCase) RefreshToken(ctx context.Context, refreshToken string) (*Tokens, error) {
-	userID, userRole, err := uc.tokenService.ValidateToken(refreshToken, true)
-	if err != nil {
-		return nil, errors.New("invalid refresh token")
-	}
-
-	if err := uc.tokenService.BlacklistToken(ctx, refreshToken); err != nil {
-		return nil, err
-	}
-
-	tokens, err := uc.generateTokens(userID, userRole)
-	if err != nil {
-		return nil, err
-	}
-
-	return tokens, nil
+	return uc.sessions.Refresh(ctx, refreshToken)
 }
 
 func (uc *IdentityUseCase) Register(ctx context.Context, email, password string) error {
@@ -120,80 +154,47 @@ func (uc *IdentityUseCase) Register(ctx context.Context, email, password string)
 	return uc.identityRepo.Create(ctx, identity)
 }
 
-func (uc *IdentityUseCase) ConfirmEmail(ctx context.Context, email, code string) error {
-	identity, err := uc.identityRepo.FindByEmail(ctx, email)
-	if err != nil {
-		return errors.New("user not found")
-	}
-
-	if identity.VerificationCode != code {
-		return errors.New("verification code is incorrect")
-	}
-
-	identity.ConfirmEmail()
-
-	identity.RemoveVerificationCode()
-
-	if err := uc.identityRepo.Update(ctx, identity); err != nil {
-		return err
-	}
-
-	if err := uc.producer.SendUserCreated(identity.UserUUID.String(), email); err != nil {
-		log.Printf("Failed to send user created event: %v", err)
-	}
-
-	return nil
-
-}
-
-func (uc *IdentityUseCase) VerifyCode(ctx context.Context, email, code string) (bool, error) {
-	identity, err := uc.identityRepo.FindByEmail(ctx, email)
-	if err != nil {
-		return false, errors.New("user not found")
-	}
-	if identity.VerificationCode != code {
-		return false, errors.New("invalid verification code")
-	}
-
-	identity.RemoveVerificationCode()
-	if err := uc.identityRepo.Update(ctx, identity); err != nil {
-		return false, err
-	}
-	return true, nil
-
-}
-
-func (uc *IdentityUseCase) AddVerificationCode(ctx context.Context, email, code string) error {
-	identity, err := uc.identityRepo.FindByEmail(ctx, email)
-	if err != nil {
-		return errors.New("user not found")
+func (uc *IdentityUseCase) Login(ctx context.Context, email, password string) (*Tokens, error) {
+	if err := uc.throttle.Check(ctx, email); err != nil {
+		return nil, err
 	}
-	identity.AddVerificationCode(code)
-
-	return uc.identityRepo.Update(ctx, identity)
-}
 
-func (uc *IdentityUseCase) Login(ctx context.Context, email, password string) (*Tokens, error) {
 	identity, err := uc.identityRepo.FindByEmail(ctx, email)
 	if err != nil {
+		uc.registerFailure(ctx, email)
 		return nil, errors.New("invalid email or password")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(identity.PasswordHash), []byte(password)); err != nil {
+		uc.registerFailure(ctx, email)
 		return nil, errors.New("invalid email or password")
 	}
 
+	if identity.IsDisabled() {
+		return nil, ErrIdentityDisabled
+	}
+
 	if !identity.IsConfirmEmail {
 		return nil, errors.New("emails is not confirmed")
 	}
 
-	tokens, err := uc.generateTokens(identity.UserUUID.String(), identity.Role)
+	if err := uc.mfa.Begin(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	tokens, err := uc.sessions.Start(ctx, identity)
 	if err != nil {
 		return nil, err
 	}
 
+	// Счетчик сбрасывается только после полного входа: пароль без второго
+	// фактора не должен обнулять перебор кодов MFA.
+	if err := uc.throttle.Succeed(ctx, email); err != nil {
+		log.Printf("Failed to reset failed login attempts: %v", err)
+	}
+
 	go func() {
-		if err := uc.producer.SendUserLogin(identity.UserUUID.String(), email); err != nil {
+		if err := uc.producer.SendUserLogin(ctx, identity.UserUUID.String(), email); err != nil {
 			log.Printf("Failed to send user login event: %v", err)
 		}
 	}()
@@ -202,13 +203,27 @@ func (uc *IdentityUseCase) Login(ctx context.Context, email, password string) (*
 
 }
 
-func (uc *IdentityUseCase) ResetPassword(ctx context.Context, email, newPassword string) error {
+// registerFailure засчитывает неудачную попытку. Ошибка счетчика не должна
+// подменять ответ на саму попытку, поэтому только логируется.
+func (uc *IdentityUseCase) registerFailure(ctx context.Context, email string) {
+	if err := uc.throttle.Fail(ctx, email); err != nil {
+		log.Printf("Failed to register failed attempt: %v", err)
+	}
+}
+
+// ResetPassword меняет пароль по коду сброса. Пароль проверяется до кода,
+// чтобы слабый пароль не погасил код и пользователю не пришлось запрашивать новый.
+func (uc *IdentityUseCase) ResetPassword(ctx context.Context, email, code, newPassword string) error {
+	if err := entity.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
 	identity, err := uc.identityRepo.FindByEmail(ctx, email)
 	if err != nil {
 		return errors.New("user not found")
 	}
 
-	if err := entity.ValidatePassword(newPassword); err != nil {
+	if _, err := uc.useThrottledCode(ctx, identity, entity.PurposeResetPassword, code); err != nil {
 		return err
 	}
 
@@ -218,13 +233,27 @@ func (uc *IdentityUseCase) ResetPassword(ctx context.Context, email, newPassword
 	}
 
 	identity.UpdatePassword(string(hashedPassword))
-	return uc.identityRepo.Update(ctx, identity)
+	if err := uc.identityRepo.Update(ctx, identity); err != nil {
+		return err
+	}
+
+	// Сброс пароля обычно означает, что старый мог попасть к чужим: его сессии завершаются.
+	return uc.sessions.RevokeAll(ctx, identity.UserUUID.String(), "")
 }
 
 func (uc *IdentityUseCase) IsBlacklisted(ctx context.Context, token string) (bool, error) {
 	return uc.tokenRepo.IsBlacklisted(ctx, token)
 }
 
+func (uc *IdentityUseCase) RevokedTokensSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error) {
+	return uc.tokenRepo.ListRevokedSince(ctx, since)
+}
+
+// DisabledIdentities возвращает UUID отключенных пользователей, см. AdminUseCase.Disable.
+func (uc *IdentityUseCase) DisabledIdentities(ctx context.Context) ([]uuid.UUID, error) {
+	return uc.identityRepo.ListDisabledUUIDs(ctx)
+}
+
 func (uc *IdentityUseCase) GetByUserUUID(ctx context.Context, userUUID string) (*entity.Identity, error) {
 	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
 	if err != nil {
@@ -233,11 +262,3 @@ func (uc *IdentityUseCase) GetByUserUUID(ctx context.Context, userUUID string) (
 
 	return identity, nil
 }
-
-func (uc *IdentityUseCase) generateTokens(userID, userRole string) (*Tokens, error) {
-	accessToken, refreshToken, err := uc.tokenService.GenerateTokenPair(userID, userRole)
-	if err != nil {
-		return nil, err
-	}
-	return &Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
-}
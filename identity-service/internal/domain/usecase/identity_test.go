@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -25,7 +26,7 @@ func TestRegister_Success(t *testing.T) {
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(nil, errors.New("not found"))
 	identityRepo.On("Create", ctx, mock.AnythingOfType("*entity.Identity")).Return(nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, nil, producer, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	err := uc.Register(ctx, "test@example.com", "StrongP@ssw0rd")
 
@@ -43,7 +44,7 @@ func TestLogin_InvalidEmail(t *testing.T) {
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(nil, errors.New("not found"))
 
-	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, nil, producer, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	tokens, err := uc.Login(ctx, "test@example.com", "wrongpass")
 
@@ -68,7 +69,7 @@ func TestLogin_EmailNotConfirmed(t *testing.T) {
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, nil, producer, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	tokens, err := uc.Login(ctx, "test@example.com", "password123")
 
@@ -83,24 +84,30 @@ func TestConfirmEmail_Success(t *testing.T) {
 	identityRepo := new(mocks.IdentityRepositoryMock)
 	tokenService := new(mocks.TokenServiceMock)
 	tokenRepo := new(mocks.TokenRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
 	producer := new(mocks.ProducerMock)
 
 	identity := &entity.Identity{
-		Email:            "test@example.com",
-		UserUUID:         uuid.New(),
-		VerificationCode: "123456",
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
 	}
+	code := newVerificationCode(t, identity, entity.PurposeConfirmEmail, "123456")
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
 	identityRepo.On("Update", ctx, mock.AnythingOfType("*entity.Identity")).Return(nil)
-	producer.On("SendUserCreated", identity.UserUUID.String(), "test@example.com").Return(nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeConfirmEmail).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(true, nil)
+	codeRepo.On("Delete", ctx, code.ID, code.CodeHash).Return(true, nil)
+	producer.On("SendUserCreated", mock.Anything, identity.UserUUID.String(), "test@example.com").Return(nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, codeRepo, producer, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	err := uc.ConfirmEmail(ctx, "test@example.com", "123456")
 
 	require.NoError(t, err)
+	require.True(t, identity.IsConfirmEmail)
 	identityRepo.AssertExpectations(t)
+	codeRepo.AssertExpectations(t)
 	producer.AssertExpectations(t)
 }
 
@@ -119,10 +126,13 @@ func TestLogin_Success(t *testing.T) {
 	require.NoError(t, err)
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
-	tokenService.On("GenerateTokenPair", identity.UserUUID.String(), "user").Return("access", "refresh", nil)
-	producer.On("SendUserLogin", identity.UserUUID.String(), "test@example.com").Return(nil)
+	producer.On("SendUserLogin", mock.Anything, identity.UserUUID.String(), "test@example.com").Return(nil)
+	mfa := new(mocks.MFAGateMock)
+	mfa.On("Begin", ctx, identity).Return(nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("Start", ctx, identity).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, nil, producer, usecase.VerificationPolicy{}, mfa, sessions, allowAttempts())
 
 	tokens, err := uc.Login(ctx, "test@example.com", "password123")
 
@@ -131,6 +141,32 @@ func TestLogin_Success(t *testing.T) {
 	require.Equal(t, "refresh", tokens.RefreshToken)
 }
 
+func TestLogin_MFARequired(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	tokenService := new(mocks.TokenServiceMock)
+	sessions := new(mocks.SessionManagerMock)
+
+	identity, err := entity.NewIdentity("test@example.com", hashPassword("password123"))
+	require.NoError(t, err)
+	identity.IsConfirmEmail = true
+
+	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	mfa := new(mocks.MFAGateMock)
+	mfa.On("Begin", ctx, identity).Return(&usecase.MFARequiredError{Token: "challenge"})
+
+	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, nil, nil, nil, usecase.VerificationPolicy{}, mfa, sessions, allowAttempts())
+
+	tokens, err := uc.Login(ctx, "test@example.com", "password123")
+
+	require.Nil(t, tokens)
+	var required *usecase.MFARequiredError
+	require.ErrorAs(t, err, &required)
+	require.Equal(t, "challenge", required.Token)
+	sessions.AssertNotCalled(t, "Start", mock.Anything, mock.Anything)
+}
+
 func TestRefreshToken_Success(t *testing.T) {
 	ctx := context.TODO()
 
@@ -139,11 +175,10 @@ func TestRefreshToken_Success(t *testing.T) {
 	tokenRepo := new(mocks.TokenRepositoryMock)
 	producer := new(mocks.ProducerMock)
 
-	tokenService.On("ValidateToken", "refresh_token", true).Return("user-id", "user", nil)
-	tokenService.On("BlacklistToken", ctx, "refresh_token").Return(nil)
-	tokenService.On("GenerateTokenPair", "user-id", "user").Return("new_access", "new_refresh", nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("Refresh", ctx, "refresh_token").Return(&usecase.Tokens{AccessToken: "new_access", RefreshToken: "new_refresh"}, nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, tokenService, tokenRepo, nil, producer, usecase.VerificationPolicy{}, nil, sessions, allowAttempts())
 
 	tokens, err := uc.RefreshToken(ctx, "refresh_token")
 
@@ -158,11 +193,14 @@ func TestLogout_Success(t *testing.T) {
 	tokenService := new(mocks.TokenServiceMock)
 
 	tokenService.On("BlacklistToken", ctx, "some_token").Return(nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("End", ctx, "some_token").Return(nil)
 
-	uc := usecase.NewIdentityUseCase(nil, tokenService, nil, nil)
+	uc := usecase.NewIdentityUseCase(nil, tokenService, nil, nil, nil, usecase.VerificationPolicy{}, nil, sessions, allowAttempts())
 
 	err := uc.Logout(ctx, "some_token")
 	require.NoError(t, err)
+	sessions.AssertExpectations(t)
 }
 
 func TestValidateToken_Blacklisted(t *testing.T) {
@@ -173,7 +211,7 @@ func TestValidateToken_Blacklisted(t *testing.T) {
 
 	tokenRepo.On("IsBlacklisted", ctx, "some_token").Return(true, nil)
 
-	uc := usecase.NewIdentityUseCase(nil, tokenService, tokenRepo, nil)
+	uc := usecase.NewIdentityUseCase(nil, tokenService, tokenRepo, nil, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	uid, err := uc.ValidateToken(ctx, "some_token", false)
 	require.Error(t, err)
@@ -189,7 +227,7 @@ func TestConfirmEmail_UserNotFound(t *testing.T) {
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(nil, errors.New("not found"))
 
-	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil, producer, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	err := uc.ConfirmEmail(ctx, "test@example.com", "123456")
 
@@ -201,22 +239,95 @@ func TestConfirmEmail_WrongCode(t *testing.T) {
 	ctx := context.TODO()
 
 	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
 	producer := new(mocks.ProducerMock)
 
 	identity := &entity.Identity{
-		Email:            "test@example.com",
-		UserUUID:         uuid.New(),
-		VerificationCode: "123456",
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
 	}
+	code := newVerificationCode(t, identity, entity.PurposeConfirmEmail, "123456")
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeConfirmEmail).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(true, nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, producer)
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, producer, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	err := uc.ConfirmEmail(ctx, "test@example.com", "000000")
 
 	require.Error(t, err)
-	require.EqualError(t, err, "verification code is incorrect")
+	require.ErrorIs(t, err, usecase.ErrVerificationCodeInvalid)
+	codeRepo.AssertNotCalled(t, "Delete", ctx, code.ID, code.CodeHash)
+}
+
+func TestConfirmEmail_ExpiredCode(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+
+	identity := &entity.Identity{
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
+	}
+	code := newVerificationCode(t, identity, entity.PurposeConfirmEmail, "123456")
+	code.ExpiresAt = time.Now().Add(-time.Second)
+
+	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeConfirmEmail).Return(code, nil)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
+
+	err := uc.ConfirmEmail(ctx, "test@example.com", "123456")
+
+	require.ErrorIs(t, err, usecase.ErrVerificationCodeExpired)
+	codeRepo.AssertNotCalled(t, "RegisterAttempt", ctx, code.ID)
+}
+
+func TestConfirmEmail_AttemptsExhausted(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+
+	identity := &entity.Identity{
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
+	}
+	code := newVerificationCode(t, identity, entity.PurposeConfirmEmail, "123456")
+
+	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeConfirmEmail).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(false, nil)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
+
+	err := uc.ConfirmEmail(ctx, "test@example.com", "123456")
+
+	require.ErrorIs(t, err, usecase.ErrVerificationCodeExhausted)
+	require.False(t, identity.IsConfirmEmail)
+}
+
+func TestConfirmEmail_CodeForOtherPurpose(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+
+	identity := &entity.Identity{
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
+	}
+
+	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeConfirmEmail).Return(nil, nil)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
+
+	err := uc.ConfirmEmail(ctx, "test@example.com", "123456")
+
+	require.ErrorIs(t, err, usecase.ErrVerificationCodeInvalid)
 }
 
 func TestValidateToken_Success(t *testing.T) {
@@ -227,65 +338,253 @@ func TestValidateToken_Success(t *testing.T) {
 
 	tokenRepo.On("IsBlacklisted", ctx, "valid_token").Return(false, nil)
 	tokenService.On("ValidateToken", "valid_token", false).Return("user-id", "user", nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("Verify", ctx, "valid_token", false).Return(nil)
 
-	uc := usecase.NewIdentityUseCase(nil, tokenService, tokenRepo, nil)
+	uc := usecase.NewIdentityUseCase(nil, tokenService, tokenRepo, nil, nil, usecase.VerificationPolicy{}, nil, sessions, allowAttempts())
 
 	uid, err := uc.ValidateToken(ctx, "valid_token", false)
 	require.NoError(t, err)
 	require.Equal(t, "user-id", uid)
 }
 
-func TestVerifyCode_Success(t *testing.T) {
+func TestValidateToken_SessionRevoked(t *testing.T) {
+	ctx := context.TODO()
+
+	tokenService := new(mocks.TokenServiceMock)
+	tokenRepo := new(mocks.TokenRepositoryMock)
+
+	tokenRepo.On("IsBlacklisted", ctx, "valid_token").Return(false, nil)
+	tokenService.On("ValidateToken", "valid_token", false).Return("user-id", "user", nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("Verify", ctx, "valid_token", false).Return(usecase.ErrSessionRevoked)
+
+	uc := usecase.NewIdentityUseCase(nil, tokenService, tokenRepo, nil, nil, usecase.VerificationPolicy{}, nil, sessions, allowAttempts())
+
+	uid, err := uc.ValidateToken(ctx, "valid_token", false)
+	require.ErrorIs(t, err, usecase.ErrSessionRevoked)
+	require.Empty(t, uid)
+}
+
+func TestConfirmEmail_CodeUsedConcurrently(t *testing.T) {
 	ctx := context.TODO()
 
 	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
 
 	identity := &entity.Identity{
-		Email:            "test@example.com",
-		VerificationCode: "123456",
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
 	}
+	code := newVerificationCode(t, identity, entity.PurposeConfirmEmail, "123456")
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
-	identityRepo.On("Update", ctx, mock.AnythingOfType("*entity.Identity")).Return(nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeConfirmEmail).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(true, nil)
+	// Параллельный запрос уже погасил код.
+	codeRepo.On("Delete", ctx, code.ID, code.CodeHash).Return(false, nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil)
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
-	ok, err := uc.VerifyCode(ctx, "test@example.com", "123456")
+	err := uc.ConfirmEmail(ctx, "test@example.com", "123456")
 
-	require.True(t, ok)
-	require.NoError(t, err)
+	require.ErrorIs(t, err, usecase.ErrVerificationCodeInvalid)
+	require.False(t, identity.IsConfirmEmail)
+	identityRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 }
 
 func TestAddVerificationCode_Success(t *testing.T) {
 	ctx := context.TODO()
 
 	identityRepo := new(mocks.IdentityRepositoryMock)
-	identity := &entity.Identity{Email: "test@example.com"}
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+	identity := &entity.Identity{Email: "test@example.com", UserUUID: uuid.New()}
+
+	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeConfirmEmail).Return(nil, nil)
+	codeRepo.On("Save", ctx, mock.MatchedBy(func(code *entity.VerificationCode) bool {
+		return code.Purpose == entity.PurposeConfirmEmail && code.CodeHash != "654321" && code.Matches("654321") &&
+			code.MaxAttempts == usecase.DefaultVerificationPolicy.MaxAttempts
+	})).Return(nil)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
+
+	err := uc.AddVerificationCode(ctx, "test@example.com", entity.PurposeConfirmEmail, "654321")
+	require.NoError(t, err)
+	codeRepo.AssertExpectations(t)
+}
+
+func TestAddVerificationCode_ResendCooldown(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+	identity := &entity.Identity{Email: "test@example.com", UserUUID: uuid.New()}
+	previous := newVerificationCode(t, identity, entity.PurposeResetPassword, "123456")
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeResetPassword).Return(previous, nil)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{ResendCooldown: time.Minute}, nil, nil, allowAttempts())
+
+	err := uc.AddVerificationCode(ctx, "test@example.com", entity.PurposeResetPassword, "654321")
+
+	var cooldown *usecase.ResendCooldownError
+	require.ErrorAs(t, err, &cooldown)
+	require.Greater(t, cooldown.RetryAfter, time.Duration(0))
+	codeRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestDiscardVerificationCode(t *testing.T) {
+	ctx := context.TODO()
+	identity := &entity.Identity{Email: "test@example.com", UserUUID: uuid.New()}
+
+	tests := []struct {
+		name       string
+		code       string
+		wantDelete bool
+	}{
+		{name: "unsent code", code: "123456", wantDelete: true},
+		{name: "code replaced by a newer one", code: "654321"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identityRepo := new(mocks.IdentityRepositoryMock)
+			codeRepo := new(mocks.VerificationCodeRepositoryMock)
+			stored := newVerificationCode(t, identity, entity.PurposeResetPassword, "123456")
+
+			identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+			codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeResetPassword).Return(stored, nil)
+			codeRepo.On("Delete", ctx, stored.ID, stored.CodeHash).Return(true, nil)
+
+			uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
+
+			require.NoError(t, uc.DiscardVerificationCode(ctx, "test@example.com", entity.PurposeResetPassword, tt.code))
+			if tt.wantDelete {
+				codeRepo.AssertCalled(t, "Delete", ctx, stored.ID, stored.CodeHash)
+			} else {
+				codeRepo.AssertNotCalled(t, "Delete", ctx, stored.ID, stored.CodeHash)
+			}
+		})
+	}
+}
+
+func TestChangeEmail_Success(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+	identity := &entity.Identity{ID: 1, Email: "old@example.com", UserUUID: uuid.New()}
+	code := newVerificationCode(t, identity, entity.PurposeChangeEmail, "123456")
+	code.Email = "new@example.com"
+
+	identityRepo.On("FindByUUID", ctx, identity.UserUUID.String()).Return(identity, nil)
+	identityRepo.On("FindByEmail", ctx, "new@example.com").Return(nil, errors.New("not found"))
 	identityRepo.On("Update", ctx, identity).Return(nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeChangeEmail).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(true, nil)
+	codeRepo.On("Delete", ctx, code.ID, code.CodeHash).Return(true, nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("RevokeAll", ctx, identity.UserUUID.String(), "").Return(nil)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, sessions, allowAttempts())
 
-	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil)
+	err := uc.ChangeEmail(ctx, identity.UserUUID.String(), "123456")
 
-	err := uc.AddVerificationCode(ctx, "test@example.com", "654321")
 	require.NoError(t, err)
+	require.Equal(t, "new@example.com", identity.Email)
+	identityRepo.AssertExpectations(t)
+	sessions.AssertExpectations(t)
 }
 
 func TestResetPassword_Success(t *testing.T) {
 	ctx := context.TODO()
 
 	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
 	identity := &entity.Identity{
-		Email: "test@example.com",
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
 	}
+	code := newVerificationCode(t, identity, entity.PurposeResetPassword, "123456")
 
 	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
 	identityRepo.On("Update", ctx, identity).Return(nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeResetPassword).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(true, nil)
+	codeRepo.On("Delete", ctx, code.ID, code.CodeHash).Return(true, nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("RevokeAll", ctx, identity.UserUUID.String(), "").Return(nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil)
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, sessions, allowAttempts())
 
-	err := uc.ResetPassword(ctx, "test@example.com", "NewP@ssw0rd")
+	err := uc.ResetPassword(ctx, "test@example.com", "123456", "NewP@ssw0rd")
 	require.NoError(t, err)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(identity.PasswordHash), []byte("NewP@ssw0rd")))
+	codeRepo.AssertExpectations(t)
+	sessions.AssertExpectations(t)
+}
+
+func TestResetPassword_RevokeFailure(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+	identity := &entity.Identity{
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
+	}
+	code := newVerificationCode(t, identity, entity.PurposeResetPassword, "123456")
+
+	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	identityRepo.On("Update", ctx, identity).Return(nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeResetPassword).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(true, nil)
+	codeRepo.On("Delete", ctx, code.ID, code.CodeHash).Return(true, nil)
+	sessions := new(mocks.SessionManagerMock)
+	sessions.On("RevokeAll", ctx, identity.UserUUID.String(), "").Return(errors.New("db down"))
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, sessions, allowAttempts())
+
+	err := uc.ResetPassword(ctx, "test@example.com", "123456", "NewP@ssw0rd")
+	require.EqualError(t, err, "db down")
+}
+
+func TestResetPassword_WeakPasswordKeepsCode(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
+
+	err := uc.ResetPassword(ctx, "test@example.com", "123456", "weak")
+	require.Error(t, err)
+	codeRepo.AssertNotCalled(t, "RegisterAttempt", mock.Anything, mock.Anything)
+	codeRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_InvalidCode(t *testing.T) {
+	ctx := context.TODO()
+
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	codeRepo := new(mocks.VerificationCodeRepositoryMock)
+	identity := &entity.Identity{
+		Email:    "test@example.com",
+		UserUUID: uuid.New(),
+	}
+	code := newVerificationCode(t, identity, entity.PurposeResetPassword, "123456")
+
+	identityRepo.On("FindByEmail", ctx, "test@example.com").Return(identity, nil)
+	codeRepo.On("Find", ctx, identity.UserUUID, entity.PurposeResetPassword).Return(code, nil)
+	codeRepo.On("RegisterAttempt", ctx, code.ID).Return(true, nil)
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, codeRepo, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
+
+	err := uc.ResetPassword(ctx, "test@example.com", "000000", "NewP@ssw0rd")
+	require.ErrorIs(t, err, usecase.ErrVerificationCodeInvalid)
+	identityRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
 }
 
 func TestIsBlacklisted_Success(t *testing.T) {
@@ -294,7 +593,7 @@ func TestIsBlacklisted_Success(t *testing.T) {
 	tokenRepo := new(mocks.TokenRepositoryMock)
 	tokenRepo.On("IsBlacklisted", ctx, "some_token").Return(true, nil)
 
-	uc := usecase.NewIdentityUseCase(nil, nil, tokenRepo, nil)
+	uc := usecase.NewIdentityUseCase(nil, nil, tokenRepo, nil, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	result, err := uc.IsBlacklisted(ctx, "some_token")
 	require.NoError(t, err)
@@ -312,14 +611,30 @@ func TestGetByUserUUID_Success(t *testing.T) {
 
 	identityRepo.On("FindByUUID", ctx, identity.UserUUID.String()).Return(identity, nil)
 
-	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil)
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil, nil, usecase.VerificationPolicy{}, nil, nil, allowAttempts())
 
 	result, err := uc.GetByUserUUID(ctx, identity.UserUUID.String())
 	require.NoError(t, err)
 	require.Equal(t, identity, result)
 }
 
+func newVerificationCode(t *testing.T, identity *entity.Identity, purpose entity.VerificationPurpose, code string) *entity.VerificationCode {
+	verificationCode, err := entity.NewVerificationCode(identity.UserUUID, identity.Email, purpose, code, time.Minute, 3)
+	require.NoError(t, err)
+	verificationCode.ID = 7
+	return verificationCode
+}
+
 func hashPassword(pwd string) string {
 	hash, _ := bcrypt.GenerateFromPassword([]byte(pwd), bcrypt.DefaultCost)
 	return string(hash)
 }
+
+// allowAttempts - счетчик неудач, который не ограничивает попытки.
+func allowAttempts() *mocks.ThrottleMock {
+	throttle := new(mocks.ThrottleMock)
+	throttle.On("Check", mock.Anything, mock.Anything).Return(nil).Maybe()
+	throttle.On("Fail", mock.Anything, mock.Anything).Return(nil).Maybe()
+	throttle.On("Succeed", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return throttle
+}
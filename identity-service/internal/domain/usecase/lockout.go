@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+)
+
+var (
+	ErrLockoutNotFound    = errors.New("lockout not found")
+	ErrLockoutKindInvalid = errors.New("lockout kind must be account or ip")
+)
+
+// maxLockoutEvents ограничивает выдачу журнала блокировок.
+const maxLockoutEvents = 500
+
+// LockedError - попытки по аккаунту или IP временно не принимаются.
+type LockedError struct {
+	RetryAfter time.Duration
+	// Locked - блокировка по порогу неудач, а не задержка между попытками.
+	Locked bool
+}
+
+func (e *LockedError) Error() string {
+	if e.Locked {
+		return "too many failed attempts, temporarily locked"
+	}
+	return "too many failed attempts, try again later"
+}
+
+type LockoutRepository interface {
+	Find(ctx context.Context, kind, key string) (*entity.FailureCounter, error)
+	RegisterFailure(ctx context.Context, kind, key string, window time.Duration) (*entity.FailureCounter, error)
+	Block(ctx context.Context, id int, until *time.Time, locked bool) error
+	Reset(ctx context.Context, kind, key string) (bool, error)
+	ListLocked(ctx context.Context, kind string) ([]entity.FailureCounter, error)
+	CleanupStale(ctx context.Context, before time.Time) error
+	SaveEvent(ctx context.Context, event *entity.LockoutEvent) error
+	ListEvents(ctx context.Context, kind, key string, limit int) ([]entity.LockoutEvent, error)
+}
+
+// LockoutLimits - задержки и блокировка для одного вида счетчика. Первые
+// FreeFailures неудач проходят без задержки, дальше задержка начинается с
+// BaseDelay и удваивается до MaxDelay; после LockAfter неудач попытки не
+// принимаются LockDuration.
+type LockoutLimits struct {
+	FreeFailures int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int
+	LockDuration time.Duration
+}
+
+// LockoutPolicy - ограничения перебора по аккаунту и по IP. Нулевые поля
+// заменяются значениями по умолчанию.
+type LockoutPolicy struct {
+	Account LockoutLimits
+	IP      LockoutLimits
+	// Window - через столько после последней неудачи счет начинается заново.
+	Window time.Duration
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	Account: LockoutLimits{
+		FreeFailures: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockAfter:    10,
+		LockDuration: 15 * time.Minute,
+	},
+	IP: LockoutLimits{
+		FreeFailures: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockAfter:    50,
+		LockDuration: 15 * time.Minute,
+	},
+	Window: time.Hour,
+}
+
+func (p LockoutPolicy) WithDefaults() LockoutPolicy {
+	p.Account = p.Account.withDefaults(DefaultLockoutPolicy.Account)
+	p.IP = p.IP.withDefaults(DefaultLockoutPolicy.IP)
+	if p.Window <= 0 {
+		p.Window = DefaultLockoutPolicy.Window
+	}
+	return p
+}
+
+func (l LockoutLimits) withDefaults(defaults LockoutLimits) LockoutLimits {
+	if l.FreeFailures <= 0 {
+		l.FreeFailures = defaults.FreeFailures
+	}
+	if l.BaseDelay <= 0 {
+		l.BaseDelay = defaults.BaseDelay
+	}
+	if l.MaxDelay <= 0 {
+		l.MaxDelay = defaults.MaxDelay
+	}
+	if l.LockAfter <= 0 {
+		l.LockAfter = defaults.LockAfter
+	}
+	if l.LockDuration <= 0 {
+		l.LockDuration = defaults.LockDuration
+	}
+	return l
+}
+
+// block возвращает, до какого момента не принимать попытки после failures неудач.
+func (l LockoutLimits) block(failures int, now time.Time) (*time.Time, bool) {
+	if failures >= l.LockAfter {
+		until := now.Add(l.LockDuration)
+		return &until, true
+	}
+	if failures <= l.FreeFailures {
+		return nil, false
+	}
+
+	delay := l.MaxDelay
+	if shift := failures - l.FreeFailures - 1; shift < 32 {
+		delay = min(l.BaseDelay<<shift, l.MaxDelay)
+	}
+	until := now.Add(delay)
+	return &until, false
+}
+
+// LockoutUseCase - защита от перебора паролей и кодов: счетчики неудач по
+// аккаунту и IP клиента, экспоненциальная задержка и временная блокировка.
+// Блокировки и их снятие записываются в журнал.
+type LockoutUseCase struct {
+	repo   LockoutRepository
+	policy LockoutPolicy
+}
+
+func NewLockoutUseCase(repo LockoutRepository, policy LockoutPolicy) *LockoutUseCase {
+	return &LockoutUseCase{
+		repo:   repo,
+		policy: policy.WithDefaults(),
+	}
+}
+
+type lockoutTarget struct {
+	kind string
+	key  string
+}
+
+// targets - счетчики попытки: аккаунт email и IP клиента из контекста.
+func (uc *LockoutUseCase) targets(ctx context.Context, email string) []lockoutTarget {
+	targets := []lockoutTarget{{kind: entity.LockoutAccount, key: entity.NormalizeLockoutKey(entity.LockoutAccount, email)}}
+	if ip := ClientFromContext(ctx).IP; ip != "" {
+		targets = append(targets, lockoutTarget{kind: entity.LockoutIP, key: ip})
+	}
+	return targets
+}
+
+func (uc *LockoutUseCase) limits(kind string) LockoutLimits {
+	if kind == entity.LockoutIP {
+		return uc.policy.IP
+	}
+	return uc.policy.Account
+}
+
+// Check возвращает *LockedError, если попытку входа или ввода кода для email
+// с адреса клиента сейчас принимать нельзя.
+func (uc *LockoutUseCase) Check(ctx context.Context, email string) error {
+	now := time.Now()
+	for _, target := range uc.targets(ctx, email) {
+		counter, err := uc.repo.Find(ctx, target.kind, target.key)
+		if err != nil {
+			return err
+		}
+		if counter != nil && counter.IsBlocked(now) {
+			return &LockedError{RetryAfter: counter.BlockedUntil.Sub(now), Locked: counter.Locked}
+		}
+	}
+	return nil
+}
+
+// Fail засчитывает неудачную попытку аккаунту email и IP клиента.
+func (uc *LockoutUseCase) Fail(ctx context.Context, email string) error {
+	now := time.Now()
+	for _, target := range uc.targets(ctx, email) {
+		counter, err := uc.repo.RegisterFailure(ctx, target.kind, target.key, uc.policy.Window)
+		if err != nil {
+			return err
+		}
+
+		until, locked := uc.limits(target.kind).block(counter.Failures, now)
+		if err := uc.repo.Block(ctx, counter.ID, until, locked); err != nil {
+			return err
+		}
+		if locked && !(counter.Locked && counter.IsBlocked(now)) {
+			if err := uc.recordLock(ctx, counter, until); err != nil {
+				return err
+			}
+		}
+	}
+
+	go func() {
+		if err := uc.repo.CleanupStale(context.WithoutCancel(ctx), now.Add(-uc.policy.Window)); err != nil {
+			log.Printf("Failed to clean up failure counters: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Succeed сбрасывает счетчик аккаунта после успешного входа или ввода кода.
+// Счетчик IP не сбрасывается: иначе перебор можно было бы чередовать со
+// входом в собственный аккаунт.
+func (uc *LockoutUseCase) Succeed(ctx context.Context, email string) error {
+	_, err := uc.repo.Reset(ctx, entity.LockoutAccount, entity.NormalizeLockoutKey(entity.LockoutAccount, email))
+	return err
+}
+
+// Locked возвращает действующие блокировки вида kind (пустой - всех видов).
+func (uc *LockoutUseCase) Locked(ctx context.Context, kind string) ([]entity.FailureCounter, error) {
+	if err := validateLockoutKind(kind, true); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListLocked(ctx, kind)
+}
+
+// Unlock снимает блокировку или задержку аккаунта или IP по решению администратора actorUUID.
+func (uc *LockoutUseCase) Unlock(ctx context.Context, actorUUID, kind, key string) error {
+	if err := validateLockoutKind(kind, false); err != nil {
+		return err
+	}
+	key = entity.NormalizeLockoutKey(kind, key)
+
+	counter, err := uc.repo.Find(ctx, kind, key)
+	if err != nil {
+		return err
+	}
+	if counter == nil || !counter.IsBlocked(time.Now()) {
+		return ErrLockoutNotFound
+	}
+
+	reset, err := uc.repo.Reset(ctx, kind, key)
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrLockoutNotFound
+	}
+
+	log.Printf("Lockout of %s %s removed by %s", kind, key, actorUUID)
+	return uc.repo.SaveEvent(ctx, &entity.LockoutEvent{
+		Kind:      kind,
+		Key:       key,
+		Action:    entity.LockoutActionUnlocked,
+		ActorUUID: actorUUID,
+		IP:        ClientFromContext(ctx).IP,
+		Failures:  counter.Failures,
+		CreatedAt: time.Now(),
+	})
+}
+
+// Events возвращает журнал блокировок, последние сначала.
+func (uc *LockoutUseCase) Events(ctx context.Context, kind, key string, limit int) ([]entity.LockoutEvent, error) {
+	if err := validateLockoutKind(kind, true); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxLockoutEvents {
+		limit = maxLockoutEvents
+	}
+	if key != "" {
+		key = entity.NormalizeLockoutKey(kind, key)
+	}
+	return uc.repo.ListEvents(ctx, kind, key, limit)
+}
+
+func (uc *LockoutUseCase) recordLock(ctx context.Context, counter *entity.FailureCounter, until *time.Time) error {
+	log.Printf("Locked %s %s after %d failed attempts until %s", counter.Kind, counter.Key, counter.Failures, until.Format(time.RFC3339))
+	return uc.repo.SaveEvent(ctx, &entity.LockoutEvent{
+		Kind:        counter.Kind,
+		Key:         counter.Key,
+		Action:      entity.LockoutActionLocked,
+		IP:          ClientFromContext(ctx).IP,
+		Failures:    counter.Failures,
+		LockedUntil: until,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func validateLockoutKind(kind string, allowEmpty bool) error {
+	switch {
+	case kind == entity.LockoutAccount, kind == entity.LockoutIP:
+		return nil
+	case kind == "" && allowEmpty:
+		return nil
+	default:
+		return ErrLockoutKindInvalid
+	}
+}
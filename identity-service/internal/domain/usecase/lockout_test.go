@@ -0,0 +1,151 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testClientIP = "192.0.2.1"
+
+func newLockoutUseCase() (*usecase.LockoutUseCase, *mocks.LockoutRepositoryMock, context.Context) {
+	repo := new(mocks.LockoutRepositoryMock)
+	repo.On("CleanupStale", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	ctx := usecase.ContextWithClient(context.Background(), entity.ClientInfo{IP: testClientIP})
+	return usecase.NewLockoutUseCase(repo, usecase.LockoutPolicy{}), repo, ctx
+}
+
+func TestLockoutCheck_Blocked(t *testing.T) {
+	uc, repo, ctx := newLockoutUseCase()
+	until := time.Now().Add(time.Minute)
+	repo.On("Find", ctx, entity.LockoutAccount, "test@example.com").Return(nil, nil)
+	repo.On("Find", ctx, entity.LockoutIP, testClientIP).
+		Return(&entity.FailureCounter{Kind: entity.LockoutIP, Key: testClientIP, BlockedUntil: &until, Locked: true}, nil)
+
+	// Регистр email не влияет на счетчик аккаунта.
+	err := uc.Check(ctx, " Test@Example.com")
+
+	var locked *usecase.LockedError
+	require.ErrorAs(t, err, &locked)
+	require.True(t, locked.Locked)
+	require.InDelta(t, time.Minute.Seconds(), locked.RetryAfter.Seconds(), 1)
+}
+
+func TestLockoutFail_Backoff(t *testing.T) {
+	uc, repo, ctx := newLockoutUseCase()
+	policy := usecase.DefaultLockoutPolicy
+	repo.On("RegisterFailure", ctx, entity.LockoutAccount, "test@example.com", policy.Window).
+		Return(&entity.FailureCounter{ID: 1, Kind: entity.LockoutAccount, Key: "test@example.com", Failures: policy.Account.FreeFailures + 2}, nil)
+	repo.On("RegisterFailure", ctx, entity.LockoutIP, testClientIP, policy.Window).
+		Return(&entity.FailureCounter{ID: 2, Kind: entity.LockoutIP, Key: testClientIP, Failures: 1}, nil)
+
+	// Вторая неудача сверх бесплатных - задержка удваивается.
+	expected := time.Now().Add(2 * policy.Account.BaseDelay)
+	repo.On("Block", ctx, 1, mock.MatchedBy(func(until *time.Time) bool {
+		return until != nil && until.Sub(expected).Abs() < time.Second
+	}), false).Return(nil)
+	repo.On("Block", ctx, 2, (*time.Time)(nil), false).Return(nil)
+
+	require.NoError(t, uc.Fail(ctx, "test@example.com"))
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "SaveEvent", mock.Anything, mock.Anything)
+}
+
+func TestLockoutFail_Locks(t *testing.T) {
+	uc, repo, ctx := newLockoutUseCase()
+	policy := usecase.DefaultLockoutPolicy
+	repo.On("RegisterFailure", ctx, entity.LockoutAccount, "test@example.com", policy.Window).
+		Return(&entity.FailureCounter{ID: 1, Kind: entity.LockoutAccount, Key: "test@example.com", Failures: policy.Account.LockAfter}, nil)
+	repo.On("RegisterFailure", ctx, entity.LockoutIP, testClientIP, policy.Window).
+		Return(&entity.FailureCounter{ID: 2, Kind: entity.LockoutIP, Key: testClientIP, Failures: 1}, nil)
+	repo.On("Block", ctx, 1, mock.AnythingOfType("*time.Time"), true).Return(nil)
+	repo.On("Block", ctx, 2, (*time.Time)(nil), false).Return(nil)
+	repo.On("SaveEvent", ctx, mock.MatchedBy(func(event *entity.LockoutEvent) bool {
+		return event.Action == entity.LockoutActionLocked &&
+			event.Kind == entity.LockoutAccount &&
+			event.Key == "test@example.com" &&
+			event.IP == testClientIP &&
+			event.LockedUntil != nil
+	})).Return(nil).Once()
+
+	require.NoError(t, uc.Fail(ctx, "test@example.com"))
+	repo.AssertExpectations(t)
+}
+
+func TestLockoutSucceed_KeepsIPCounter(t *testing.T) {
+	uc, repo, ctx := newLockoutUseCase()
+	repo.On("Reset", ctx, entity.LockoutAccount, "test@example.com").Return(true, nil)
+
+	require.NoError(t, uc.Succeed(ctx, "test@example.com"))
+	repo.AssertNotCalled(t, "Reset", ctx, entity.LockoutIP, testClientIP)
+}
+
+func TestLockoutUnlock(t *testing.T) {
+	uc, repo, ctx := newLockoutUseCase()
+	until := time.Now().Add(10 * time.Minute)
+	repo.On("Find", ctx, entity.LockoutAccount, "test@example.com").
+		Return(&entity.FailureCounter{Kind: entity.LockoutAccount, Key: "test@example.com", Failures: 10, BlockedUntil: &until, Locked: true}, nil)
+	repo.On("Reset", ctx, entity.LockoutAccount, "test@example.com").Return(true, nil)
+	repo.On("SaveEvent", ctx, mock.MatchedBy(func(event *entity.LockoutEvent) bool {
+		return event.Action == entity.LockoutActionUnlocked && event.ActorUUID == "admin123" && event.Failures == 10
+	})).Return(nil)
+
+	require.NoError(t, uc.Unlock(ctx, "admin123", entity.LockoutAccount, "Test@Example.com"))
+	repo.AssertExpectations(t)
+}
+
+func TestLockoutUnlock_NotLocked(t *testing.T) {
+	uc, repo, ctx := newLockoutUseCase()
+	repo.On("Find", ctx, entity.LockoutIP, testClientIP).Return(nil, nil)
+
+	err := uc.Unlock(ctx, "admin123", entity.LockoutIP, testClientIP)
+	require.ErrorIs(t, err, usecase.ErrLockoutNotFound)
+}
+
+func TestLockoutUnlock_InvalidKind(t *testing.T) {
+	uc, _, ctx := newLockoutUseCase()
+
+	err := uc.Unlock(ctx, "admin123", "user", "test@example.com")
+	require.ErrorIs(t, err, usecase.ErrLockoutKindInvalid)
+}
+
+func TestLogin_Locked(t *testing.T) {
+	ctx := context.TODO()
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	throttle := new(mocks.ThrottleMock)
+	throttle.On("Check", ctx, "test@example.com").Return(&usecase.LockedError{RetryAfter: time.Minute, Locked: true})
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil, nil, usecase.VerificationPolicy{}, nil, nil, throttle)
+
+	tokens, err := uc.Login(ctx, "test@example.com", "password123")
+
+	require.Nil(t, tokens)
+	var locked *usecase.LockedError
+	require.ErrorAs(t, err, &locked)
+	identityRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+}
+
+func TestLogin_WrongPasswordCountsFailure(t *testing.T) {
+	ctx := context.TODO()
+	identityRepo := new(mocks.IdentityRepositoryMock)
+	identityRepo.On("FindByEmail", ctx, "test@example.com").
+		Return(&entity.Identity{Email: "test@example.com", PasswordHash: hashPassword("password123")}, nil)
+	throttle := new(mocks.ThrottleMock)
+	throttle.On("Check", ctx, "test@example.com").Return(nil)
+	throttle.On("Fail", ctx, "test@example.com").Return(errors.New("db is down"))
+
+	uc := usecase.NewIdentityUseCase(identityRepo, nil, nil, nil, nil, usecase.VerificationPolicy{}, nil, nil, throttle)
+
+	// Ошибка счетчика не меняет ответ на попытку входа.
+	_, err := uc.Login(ctx, "test@example.com", "wrongpass")
+	require.EqualError(t, err, "invalid email or password")
+	throttle.AssertExpectations(t)
+}
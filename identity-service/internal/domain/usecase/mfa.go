@@ -0,0 +1,578 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/totp"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMFAAlreadyEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled         = errors.New("two-factor authentication is not enabled")
+	ErrMFACodeInvalid        = errors.New("invalid two-factor authentication code")
+	ErrMFAChallengeInvalid   = errors.New("two-factor login session is invalid or expired")
+	ErrMFAChallengeExhausted = errors.New("too many attempts, log in again")
+	ErrMFARequiredByPolicy   = errors.New("two-factor authentication is required for your role")
+	ErrMFAEnrollmentRequired = errors.New("two-factor authentication must be set up first")
+)
+
+// MFARequiredError - пароль верный, но для входа нужен второй фактор. Token
+// передается в /auth/mfa/verify вместе с кодом; при EnrollmentRequired роль
+// пользователя требует второй фактор, и токен позволяет только настроить его.
+type MFARequiredError struct {
+	Token              string
+	ExpiresAt          time.Time
+	EnrollmentRequired bool
+}
+
+func (e *MFARequiredError) Error() string {
+	if e.EnrollmentRequired {
+		return "two-factor authentication must be set up to log in"
+	}
+	return "two-factor authentication required"
+}
+
+// MFAConfig - параметры второго фактора. Нулевые поля заменяются значениями по умолчанию.
+type MFAConfig struct {
+	// Issuer - название сервиса в приложении-аутентификаторе.
+	Issuer string
+	// ChallengeTTL - время на ввод кода после пароля.
+	ChallengeTTL time.Duration
+	// MaxAttempts - число попыток ввода кода на один вход.
+	MaxAttempts int
+	// RecoveryCodes - число выдаваемых кодов восстановления.
+	RecoveryCodes int
+}
+
+var DefaultMFAConfig = MFAConfig{
+	Issuer:        "duo-proj",
+	ChallengeTTL:  5 * time.Minute,
+	MaxAttempts:   5,
+	RecoveryCodes: 10,
+}
+
+func (c MFAConfig) WithDefaults() MFAConfig {
+	if c.Issuer == "" {
+		c.Issuer = DefaultMFAConfig.Issuer
+	}
+	if c.ChallengeTTL <= 0 {
+		c.ChallengeTTL = DefaultMFAConfig.ChallengeTTL
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMFAConfig.MaxAttempts
+	}
+	if c.RecoveryCodes <= 0 {
+		c.RecoveryCodes = DefaultMFAConfig.RecoveryCodes
+	}
+	return c
+}
+
+type MFARepository interface {
+	FindTOTP(ctx context.Context, userUUID uuid.UUID) (*entity.TOTPCredential, error)
+	SaveTOTP(ctx context.Context, credential *entity.TOTPCredential) error
+	UseTOTPStep(ctx context.Context, id int, step int64) (bool, error)
+	DeleteFactors(ctx context.Context, userUUID uuid.UUID) error
+	ReplaceRecoveryCodes(ctx context.Context, userUUID uuid.UUID, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userUUID uuid.UUID, hash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userUUID uuid.UUID) (int, error)
+}
+
+type MFAChallengeRepository interface {
+	Save(ctx context.Context, challenge *entity.MFAChallenge) error
+	Find(ctx context.Context, tokenHash string) (*entity.MFAChallenge, error)
+	RegisterAttempt(ctx context.Context, id int) (bool, error)
+	Delete(ctx context.Context, id int) (bool, error)
+	CleanupExpired(ctx context.Context) error
+}
+
+type MFAPolicyRepository interface {
+	List(ctx context.Context) ([]entity.MFARolePolicy, error)
+	Find(ctx context.Context, role string) (*entity.MFARolePolicy, error)
+	Save(ctx context.Context, policy *entity.MFARolePolicy) error
+}
+
+// SecretBox шифрует секреты TOTP перед сохранением.
+type SecretBox interface {
+	Seal(secret string) (string, error)
+	Open(stored string) (string, error)
+}
+
+type MFAStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// MFAUseCase - второй фактор входа: TOTP и одноразовые коды восстановления.
+type MFAUseCase struct {
+	identityRepo IdentityRepository
+	factors      MFARepository
+	challenges   MFAChallengeRepository
+	policies     MFAPolicyRepository
+	secrets      SecretBox
+	sessions     SessionIssuer
+	producer     EventProducer
+	throttle     Throttle
+	cfg          MFAConfig
+}
+
+func NewMFAUseCase(identityRepo IdentityRepository, factors MFARepository, challenges MFAChallengeRepository, policies MFAPolicyRepository, secrets SecretBox, sessions SessionIssuer, producer EventProducer, throttle Throttle, cfg MFAConfig) *MFAUseCase {
+	return &MFAUseCase{
+		identityRepo: identityRepo,
+		factors:      factors,
+		challenges:   challenges,
+		policies:     policies,
+		secrets:      secrets,
+		sessions:     sessions,
+		producer:     producer,
+		throttle:     throttle,
+		cfg:          cfg.WithDefaults(),
+	}
+}
+
+// Begin вызывается после проверки пароля (или входа через провайдера).
+// Возвращает nil, если второй фактор не нужен, иначе *MFARequiredError.
+func (uc *MFAUseCase) Begin(ctx context.Context, identity *entity.Identity) error {
+	enabled, err := uc.isEnabled(ctx, identity.UserUUID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		required, err := uc.isRequired(ctx, identity.Role)
+		if err != nil {
+			return err
+		}
+		if !required {
+			return nil
+		}
+	}
+
+	if err := uc.challenges.CleanupExpired(ctx); err != nil {
+		log.Printf("Failed to cleanup expired mfa challenges: %v", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	challenge := &entity.MFAChallenge{
+		TokenHash:          hashToken(token),
+		UserUUID:           identity.UserUUID,
+		EnrollmentRequired: !enabled,
+		MaxAttempts:        uc.cfg.MaxAttempts,
+		ExpiresAt:          now.Add(uc.cfg.ChallengeTTL),
+		CreatedAt:          now,
+	}
+	if err := uc.challenges.Save(ctx, challenge); err != nil {
+		return err
+	}
+
+	return &MFARequiredError{Token: token, ExpiresAt: challenge.ExpiresAt, EnrollmentRequired: !enabled}
+}
+
+// Verify завершает вход кодом из приложения или кодом восстановления.
+// Неверные коды учитываются в счетчиках неудач аккаунта и IP.
+func (uc *MFAUseCase) Verify(ctx context.Context, token, code string) (*Tokens, error) {
+	challenge, err := uc.findChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.EnrollmentRequired {
+		return nil, ErrMFAEnrollmentRequired
+	}
+
+	identity, err := uc.identityRepo.FindByUUID(ctx, challenge.UserUUID.String())
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := uc.throttle.Check(ctx, identity.Email); err != nil {
+		return nil, err
+	}
+
+	counted, err := uc.challenges.RegisterAttempt(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !counted {
+		return nil, ErrMFAChallengeExhausted
+	}
+
+	ok, err := uc.checkCode(ctx, challenge.UserUUID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := uc.throttle.Fail(ctx, identity.Email); err != nil {
+			log.Printf("Failed to register failed mfa attempt: %v", err)
+		}
+		return nil, ErrMFACodeInvalid
+	}
+
+	tokens, err := uc.finish(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.throttle.Succeed(ctx, identity.Email); err != nil {
+		log.Printf("Failed to reset failed login attempts: %v", err)
+	}
+	return tokens, nil
+}
+
+// Status возвращает состояние второго фактора пользователя.
+func (uc *MFAUseCase) Status(ctx context.Context, userUUID string) (*MFAStatus, error) {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	status := &MFAStatus{}
+	if status.Enabled, err = uc.isEnabled(ctx, identity.UserUUID); err != nil {
+		return nil, err
+	}
+	if status.Required, err = uc.isRequired(ctx, identity.Role); err != nil {
+		return nil, err
+	}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = uc.factors.CountRecoveryCodes(ctx, identity.UserUUID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Enroll выдает новый секрет TOTP. Второй фактор включается после
+// подтверждения кодом из приложения в ConfirmEnrollment.
+func (uc *MFAUseCase) Enroll(ctx context.Context, userUUID string) (*TOTPEnrollment, error) {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	enabled, err := uc.isEnabled(ctx, identity.UserUUID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := uc.secrets.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.factors.SaveTOTP(ctx, entity.NewTOTPCredential(identity.UserUUID, sealed)); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(uc.cfg.Issuer, identity.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment включает второй фактор и возвращает коды восстановления.
+// Коды показываются один раз.
+func (uc *MFAUseCase) ConfirmEnrollment(ctx context.Context, userUUID, code string) ([]string, error) {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	credential, err := uc.factors.FindTOTP(ctx, identity.UserUUID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, ErrMFANotEnabled
+	}
+	if credential.Confirmed {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := uc.secrets.Open(credential.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrMFACodeInvalid
+	}
+
+	credential.Confirm(step)
+	if err := uc.factors.SaveTOTP(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	return uc.issueRecoveryCodes(ctx, identity.UserUUID)
+}
+
+// Disable выключает второй фактор по коду из приложения или коду восстановления.
+func (uc *MFAUseCase) Disable(ctx context.Context, userUUID, code string) error {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	required, err := uc.isRequired(ctx, identity.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredByPolicy
+	}
+
+	if err := uc.requireCode(ctx, identity, code); err != nil {
+		return err
+	}
+	return uc.factors.DeleteFactors(ctx, identity.UserUUID)
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления новыми.
+func (uc *MFAUseCase) RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) ([]string, error) {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := uc.requireCode(ctx, identity, code); err != nil {
+		return nil, err
+	}
+	return uc.issueRecoveryCodes(ctx, identity.UserUUID)
+}
+
+// EnrollmentUser возвращает пользователя входа, для которого роль требует
+// настроить второй фактор.
+func (uc *MFAUseCase) EnrollmentUser(ctx context.Context, token string) (string, error) {
+	challenge, err := uc.findChallenge(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if !challenge.EnrollmentRequired {
+		return "", ErrMFAChallengeInvalid
+	}
+	return challenge.UserUUID.String(), nil
+}
+
+// CompleteEnrollment завершает вход после того, как пользователь настроил
+// обязательный для его роли второй фактор.
+func (uc *MFAUseCase) CompleteEnrollment(ctx context.Context, token string) (*Tokens, error) {
+	challenge, err := uc.findChallenge(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.EnrollmentRequired {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	enabled, err := uc.isEnabled(ctx, challenge.UserUUID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrMFAEnrollmentRequired
+	}
+
+	return uc.finish(ctx, challenge)
+}
+
+// Policies возвращает требования второго фактора по ролям.
+func (uc *MFAUseCase) Policies(ctx context.Context) ([]entity.MFARolePolicy, error) {
+	return uc.policies.List(ctx)
+}
+
+// SetPolicy включает или выключает обязательный второй фактор для роли.
+func (uc *MFAUseCase) SetPolicy(ctx context.Context, role string, required bool, updatedBy string) error {
+	if strings.TrimSpace(role) == "" {
+		return errors.New("role is required")
+	}
+
+	return uc.policies.Save(ctx, &entity.MFARolePolicy{
+		Role:      role,
+		Required:  required,
+		UpdatedBy: updatedBy,
+		UpdatedAt: time.Now(),
+	})
+}
+
+func (uc *MFAUseCase) isEnabled(ctx context.Context, userUUID uuid.UUID) (bool, error) {
+	credential, err := uc.factors.FindTOTP(ctx, userUUID)
+	if err != nil {
+		return false, err
+	}
+	return credential != nil && credential.Confirmed, nil
+}
+
+func (uc *MFAUseCase) isRequired(ctx context.Context, role string) (bool, error) {
+	policy, err := uc.policies.Find(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return policy != nil && policy.Required, nil
+}
+
+func (uc *MFAUseCase) findChallenge(ctx context.Context, token string) (*entity.MFAChallenge, error) {
+	challenge, err := uc.challenges.Find(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || challenge.IsExpired(time.Now()) {
+		return nil, ErrMFAChallengeInvalid
+	}
+	return challenge, nil
+}
+
+// finish погашает вход и выдает токены. Вход погашается один раз, даже если
+// код пришел в нескольких параллельных запросах.
+func (uc *MFAUseCase) finish(ctx context.Context, challenge *entity.MFAChallenge) (*Tokens, error) {
+	deleted, err := uc.challenges.Delete(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	identity, err := uc.identityRepo.FindByUUID(ctx, challenge.UserUUID.String())
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	tokens, err := uc.sessions.Start(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := uc.producer.SendUserLogin(context.WithoutCancel(ctx), identity.UserUUID.String(), identity.Email); err != nil {
+			log.Printf("Failed to send user login event: %v", err)
+		}
+	}()
+
+	return tokens, nil
+}
+
+// requireCode проверяет код для действий со вторым фактором. Неверные коды
+// учитываются в тех же счетчиках неудач, что и при входе, иначе украденная
+// сессия позволила бы перебирать коды без ограничений.
+func (uc *MFAUseCase) requireCode(ctx context.Context, identity *entity.Identity, code string) error {
+	enabled, err := uc.isEnabled(ctx, identity.UserUUID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrMFANotEnabled
+	}
+	if err := uc.throttle.Check(ctx, identity.Email); err != nil {
+		return err
+	}
+
+	ok, err := uc.checkCode(ctx, identity.UserUUID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := uc.throttle.Fail(ctx, identity.Email); err != nil {
+			log.Printf("Failed to register failed mfa attempt: %v", err)
+		}
+		return ErrMFACodeInvalid
+	}
+
+	if err := uc.throttle.Succeed(ctx, identity.Email); err != nil {
+		log.Printf("Failed to reset failed mfa attempts: %v", err)
+	}
+	return nil
+}
+
+// checkCode принимает код из приложения (один раз) или неиспользованный код
+// восстановления.
+func (uc *MFAUseCase) checkCode(ctx context.Context, userUUID uuid.UUID, code string) (bool, error) {
+	credential, err := uc.factors.FindTOTP(ctx, userUUID)
+	if err != nil {
+		return false, err
+	}
+	if credential == nil || !credential.Confirmed {
+		return false, nil
+	}
+
+	if len(strings.TrimSpace(code)) == totp.Digits {
+		secret, err := uc.secrets.Open(credential.Secret)
+		if err != nil {
+			return false, err
+		}
+		step, ok := totp.Validate(secret, code, time.Now(), credential.LastUsedStep)
+		if !ok {
+			return false, nil
+		}
+		return uc.factors.UseTOTPStep(ctx, credential.ID, step)
+	}
+
+	return uc.factors.UseRecoveryCode(ctx, userUUID, hashToken(normalizeRecoveryCode(code)))
+}
+
+func (uc *MFAUseCase) issueRecoveryCodes(ctx context.Context, userUUID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, uc.cfg.RecoveryCodes)
+	hashes := make([]string, 0, uc.cfg.RecoveryCodes)
+	for i := 0; i < uc.cfg.RecoveryCodes; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := uc.factors.ReplaceRecoveryCodes(ctx, userUUID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode возвращает код вида xxxx-xxxx-xxxx-xxxx (80 бит).
+func newRecoveryCode() (string, error) {
+	data := make([]byte, 10)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(recoveryEncoding.EncodeToString(data))
+	return raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func randomToken() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
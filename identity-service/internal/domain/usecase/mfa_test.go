@@ -0,0 +1,300 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/crypto/secretbox"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase/mocks"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/totp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+type mfaFixture struct {
+	identityRepo *mocks.IdentityRepositoryMock
+	factors      *mocks.MFARepositoryMock
+	challenges   *mocks.MFAChallengeRepositoryMock
+	policies     *mocks.MFAPolicyRepositoryMock
+	sessions     *mocks.SessionManagerMock
+	throttle     *mocks.ThrottleMock
+	identity     *entity.Identity
+	uc           *usecase.MFAUseCase
+}
+
+func newMFAFixture(t *testing.T) *mfaFixture {
+	secrets, err := secretbox.New(nil)
+	require.NoError(t, err)
+
+	f := &mfaFixture{
+		identityRepo: new(mocks.IdentityRepositoryMock),
+		factors:      new(mocks.MFARepositoryMock),
+		challenges:   new(mocks.MFAChallengeRepositoryMock),
+		policies:     new(mocks.MFAPolicyRepositoryMock),
+		sessions:     new(mocks.SessionManagerMock),
+		throttle:     new(mocks.ThrottleMock),
+		identity:     &entity.Identity{UserUUID: uuid.New(), Role: "admin", Email: "admin@example.com", IsConfirmEmail: true},
+	}
+	producer := new(mocks.ProducerMock)
+	producer.On("SendUserLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	f.challenges.On("CleanupExpired", mock.Anything).Return(nil).Maybe()
+	f.identityRepo.On("FindByUUID", mock.Anything, f.identity.UserUUID.String()).Return(f.identity, nil).Maybe()
+
+	f.throttle.On("Check", mock.Anything, f.identity.Email).Return(nil).Maybe()
+	f.throttle.On("Fail", mock.Anything, f.identity.Email).Return(nil).Maybe()
+	f.throttle.On("Succeed", mock.Anything, f.identity.Email).Return(nil).Maybe()
+
+	f.uc = usecase.NewMFAUseCase(f.identityRepo, f.factors, f.challenges, f.policies, secrets, f.sessions, producer, f.throttle, usecase.MFAConfig{})
+	return f
+}
+
+func (f *mfaFixture) enabled(ctx context.Context) *entity.TOTPCredential {
+	credential := entity.NewTOTPCredential(f.identity.UserUUID, testTOTPSecret)
+	credential.ID = 3
+	credential.Confirm(0)
+	f.factors.On("FindTOTP", ctx, f.identity.UserUUID).Return(credential, nil)
+	return credential
+}
+
+func (f *mfaFixture) challenge(ctx context.Context, token string, enrollment bool) *entity.MFAChallenge {
+	challenge := &entity.MFAChallenge{
+		ID:                 9,
+		UserUUID:           f.identity.UserUUID,
+		EnrollmentRequired: enrollment,
+		MaxAttempts:        5,
+		ExpiresAt:          time.Now().Add(time.Minute),
+	}
+	f.challenges.On("Find", ctx, mock.Anything).Return(challenge, nil)
+	return challenge
+}
+
+func currentCode(t *testing.T) string {
+	code, err := totp.Code(testTOTPSecret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestMFABegin_NotEnrolled(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.factors.On("FindTOTP", ctx, f.identity.UserUUID).Return(nil, nil)
+	f.policies.On("Find", ctx, "admin").Return(nil, nil)
+
+	require.NoError(t, f.uc.Begin(ctx, f.identity))
+	f.challenges.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestMFABegin_Enrolled(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.enabled(ctx)
+	f.challenges.On("Save", ctx, mock.MatchedBy(func(c *entity.MFAChallenge) bool {
+		return c.UserUUID == f.identity.UserUUID && !c.EnrollmentRequired && c.MaxAttempts == 5
+	})).Return(nil)
+
+	err := f.uc.Begin(ctx, f.identity)
+
+	var required *usecase.MFARequiredError
+	require.ErrorAs(t, err, &required)
+	require.NotEmpty(t, required.Token)
+	require.False(t, required.EnrollmentRequired)
+	f.challenges.AssertExpectations(t)
+}
+
+func TestMFABegin_RequiredByPolicy(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.factors.On("FindTOTP", ctx, f.identity.UserUUID).Return(nil, nil)
+	f.policies.On("Find", ctx, "admin").Return(&entity.MFARolePolicy{Role: "admin", Required: true}, nil)
+	f.challenges.On("Save", ctx, mock.AnythingOfType("*entity.MFAChallenge")).Return(nil)
+
+	err := f.uc.Begin(ctx, f.identity)
+
+	var required *usecase.MFARequiredError
+	require.ErrorAs(t, err, &required)
+	require.True(t, required.EnrollmentRequired)
+}
+
+func TestMFAVerify_TOTP(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	credential := f.enabled(ctx)
+	challenge := f.challenge(ctx, "token", false)
+	f.challenges.On("RegisterAttempt", ctx, challenge.ID).Return(true, nil)
+	f.factors.On("UseTOTPStep", ctx, credential.ID, totp.Step(time.Now())).Return(true, nil)
+	f.challenges.On("Delete", ctx, challenge.ID).Return(true, nil)
+	f.sessions.On("Start", ctx, f.identity).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	tokens, err := f.uc.Verify(ctx, "token", currentCode(t))
+
+	require.NoError(t, err)
+	require.Equal(t, "access", tokens.AccessToken)
+}
+
+func TestMFAVerify_ReusedTOTP(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	credential := f.enabled(ctx)
+	challenge := f.challenge(ctx, "token", false)
+	f.challenges.On("RegisterAttempt", ctx, challenge.ID).Return(true, nil)
+	f.factors.On("UseTOTPStep", ctx, credential.ID, totp.Step(time.Now())).Return(false, nil)
+
+	_, err := f.uc.Verify(ctx, "token", currentCode(t))
+
+	require.ErrorIs(t, err, usecase.ErrMFACodeInvalid)
+	f.sessions.AssertNotCalled(t, "Start", mock.Anything, mock.Anything)
+	f.throttle.AssertCalled(t, "Fail", ctx, f.identity.Email)
+}
+
+func TestMFAVerify_RecoveryCode(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.enabled(ctx)
+	challenge := f.challenge(ctx, "token", false)
+	f.challenges.On("RegisterAttempt", ctx, challenge.ID).Return(true, nil)
+	f.factors.On("UseRecoveryCode", ctx, f.identity.UserUUID, mock.Anything).Return(true, nil)
+	f.challenges.On("Delete", ctx, challenge.ID).Return(true, nil)
+	f.sessions.On("Start", ctx, f.identity).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	tokens, err := f.uc.Verify(ctx, "token", "ABCD-efgh-ijkl-mnop")
+
+	require.NoError(t, err)
+	require.NotNil(t, tokens)
+}
+
+func TestMFAVerify_Exhausted(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	challenge := f.challenge(ctx, "token", false)
+	f.challenges.On("RegisterAttempt", ctx, challenge.ID).Return(false, nil)
+
+	_, err := f.uc.Verify(ctx, "token", "123456")
+
+	require.ErrorIs(t, err, usecase.ErrMFAChallengeExhausted)
+}
+
+func TestMFAVerify_EnrollmentChallenge(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.challenge(ctx, "token", true)
+
+	_, err := f.uc.Verify(ctx, "token", "123456")
+
+	require.ErrorIs(t, err, usecase.ErrMFAEnrollmentRequired)
+}
+
+func TestMFAEnroll_AndConfirm(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+
+	var saved *entity.TOTPCredential
+	f.factors.On("FindTOTP", ctx, f.identity.UserUUID).Return(func() *entity.TOTPCredential { return saved }(), nil).Once()
+	f.factors.On("SaveTOTP", ctx, mock.AnythingOfType("*entity.TOTPCredential")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*entity.TOTPCredential) }).
+		Return(nil)
+
+	enrollment, err := f.uc.Enroll(ctx, f.identity.UserUUID.String())
+	require.NoError(t, err)
+	require.Contains(t, enrollment.URI, "otpauth://totp/duo-proj:admin@example.com")
+	require.Equal(t, enrollment.Secret, saved.Secret)
+	require.False(t, saved.Confirmed)
+
+	f.factors.On("FindTOTP", ctx, f.identity.UserUUID).Return(saved, nil)
+	f.factors.On("ReplaceRecoveryCodes", ctx, f.identity.UserUUID, mock.MatchedBy(func(hashes []string) bool {
+		return len(hashes) == usecase.DefaultMFAConfig.RecoveryCodes
+	})).Return(nil)
+
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	codes, err := f.uc.ConfirmEnrollment(ctx, f.identity.UserUUID.String(), code)
+
+	require.NoError(t, err)
+	require.Len(t, codes, usecase.DefaultMFAConfig.RecoveryCodes)
+	require.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, codes[0])
+	require.True(t, saved.Confirmed)
+}
+
+func TestMFAEnroll_AlreadyEnabled(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.enabled(ctx)
+
+	_, err := f.uc.Enroll(ctx, f.identity.UserUUID.String())
+
+	require.ErrorIs(t, err, usecase.ErrMFAAlreadyEnabled)
+}
+
+func TestMFADisable_RequiredByPolicy(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.policies.On("Find", ctx, "admin").Return(&entity.MFARolePolicy{Role: "admin", Required: true}, nil)
+
+	err := f.uc.Disable(ctx, f.identity.UserUUID.String(), "123456")
+
+	require.ErrorIs(t, err, usecase.ErrMFARequiredByPolicy)
+	f.factors.AssertNotCalled(t, "DeleteFactors", mock.Anything, mock.Anything)
+}
+
+func TestMFADisable(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	credential := f.enabled(ctx)
+	f.policies.On("Find", ctx, "admin").Return(nil, nil)
+	f.factors.On("UseTOTPStep", ctx, credential.ID, totp.Step(time.Now())).Return(true, nil)
+	f.factors.On("DeleteFactors", ctx, f.identity.UserUUID).Return(nil)
+
+	require.NoError(t, f.uc.Disable(ctx, f.identity.UserUUID.String(), currentCode(t)))
+	f.factors.AssertExpectations(t)
+}
+
+func TestMFADisable_InvalidCodeCountsFailure(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.enabled(ctx)
+	f.policies.On("Find", ctx, "admin").Return(nil, nil)
+	f.factors.On("UseRecoveryCode", ctx, f.identity.UserUUID, mock.Anything).Return(false, nil)
+
+	err := f.uc.Disable(ctx, f.identity.UserUUID.String(), "wrong-code")
+
+	require.ErrorIs(t, err, usecase.ErrMFACodeInvalid)
+	f.throttle.AssertCalled(t, "Fail", ctx, f.identity.Email)
+	f.factors.AssertNotCalled(t, "DeleteFactors", mock.Anything, mock.Anything)
+}
+
+func TestMFARegenerateRecoveryCodes_Locked(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.enabled(ctx)
+	f.throttle.ExpectedCalls = nil
+	f.throttle.On("Check", ctx, f.identity.Email).Return(&usecase.LockedError{RetryAfter: time.Minute, Locked: true})
+
+	_, err := f.uc.RegenerateRecoveryCodes(ctx, f.identity.UserUUID.String(), currentCode(t))
+
+	var locked *usecase.LockedError
+	require.ErrorAs(t, err, &locked)
+	f.factors.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+	f.factors.AssertNotCalled(t, "ReplaceRecoveryCodes", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMFACompleteEnrollment(t *testing.T) {
+	ctx := context.TODO()
+	f := newMFAFixture(t)
+	f.enabled(ctx)
+	challenge := f.challenge(ctx, "token", true)
+	f.challenges.On("Delete", ctx, challenge.ID).Return(true, nil)
+	f.sessions.On("Start", ctx, f.identity).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	userUUID, err := f.uc.EnrollmentUser(ctx, "token")
+	require.NoError(t, err)
+	require.Equal(t, f.identity.UserUUID.String(), userUUID)
+
+	tokens, err := f.uc.CompleteEnrollment(ctx, "token")
+	require.NoError(t, err)
+	require.Equal(t, "access", tokens.AccessToken)
+}
@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type AdminIdentityRepositoryMock struct {
+	IdentityRepositoryMock
+}
+
+func (m *AdminIdentityRepositoryMock) FindByUUID(ctx context.Context, userID string) (*entity.Identity, error) {
+	args := m.Called(ctx, userID)
+	identity := args.Get(0)
+	if identity == nil {
+		return nil, args.Error(1)
+	}
+	return identity.(*entity.Identity), args.Error(1)
+}
+
+func (m *AdminIdentityRepositoryMock) List(ctx context.Context, filter entity.IdentityFilter) ([]entity.Identity, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.Identity), args.Get(1).(int64), args.Error(2)
+}
+
+type SessionTerminatorMock struct {
+	mock.Mock
+}
+
+func (m *SessionTerminatorMock) Terminate(ctx context.Context, userUUID uuid.UUID) error {
+	args := m.Called(ctx, userUUID)
+	return args.Error(0)
+}
+
+type IdentityEventProducerMock struct {
+	mock.Mock
+}
+
+func (m *IdentityEventProducerMock) SendIdentityChanged(ctx context.Context, identity *entity.Identity, action, actorUUID string) error {
+	args := m.Called(ctx, identity, action, actorUUID)
+	return args.Error(0)
+}
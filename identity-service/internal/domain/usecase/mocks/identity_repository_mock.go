@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -39,3 +40,8 @@ func (m *IdentityRepositoryMock) Update(ctx context.Context, identity *entity.Id
 	args := m.Called(ctx, identity)
 	return args.Error(0)
 }
+
+func (m *IdentityRepositoryMock) ListDisabledUUIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"context"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -8,12 +10,12 @@ type ProducerMock struct {
 	mock.Mock
 }
 
-func (m *ProducerMock) SendUserCreated(userUUID string, email string) error {
-	args := m.Called(userUUID, email)
+func (m *ProducerMock) SendUserCreated(ctx context.Context, userUUID string, email string) error {
+	args := m.Called(ctx, userUUID, email)
 	return args.Error(0)
 }
 
-func (m *ProducerMock) SendUserLogin(userUUID string, email string) error {
-	args := m.Called(userUUID, email)
+func (m *ProducerMock) SendUserLogin(ctx context.Context, userUUID string, email string) error {
+	args := m.Called(ctx, userUUID, email)
 	return args.Error(0)
 }
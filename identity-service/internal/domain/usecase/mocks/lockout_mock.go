@@ -0,0 +1,88 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+type ThrottleMock struct {
+	mock.Mock
+}
+
+func (m *ThrottleMock) Check(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *ThrottleMock) Fail(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *ThrottleMock) Succeed(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+type LockoutRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *LockoutRepositoryMock) Find(ctx context.Context, kind, key string) (*entity.FailureCounter, error) {
+	args := m.Called(ctx, kind, key)
+	counter := args.Get(0)
+	if counter == nil {
+		return nil, args.Error(1)
+	}
+	return counter.(*entity.FailureCounter), args.Error(1)
+}
+
+func (m *LockoutRepositoryMock) RegisterFailure(ctx context.Context, kind, key string, window time.Duration) (*entity.FailureCounter, error) {
+	args := m.Called(ctx, kind, key, window)
+	counter := args.Get(0)
+	if counter == nil {
+		return nil, args.Error(1)
+	}
+	return counter.(*entity.FailureCounter), args.Error(1)
+}
+
+func (m *LockoutRepositoryMock) Block(ctx context.Context, id int, until *time.Time, locked bool) error {
+	args := m.Called(ctx, id, until, locked)
+	return args.Error(0)
+}
+
+func (m *LockoutRepositoryMock) Reset(ctx context.Context, kind, key string) (bool, error) {
+	args := m.Called(ctx, kind, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *LockoutRepositoryMock) ListLocked(ctx context.Context, kind string) ([]entity.FailureCounter, error) {
+	args := m.Called(ctx, kind)
+	counters := args.Get(0)
+	if counters == nil {
+		return nil, args.Error(1)
+	}
+	return counters.([]entity.FailureCounter), args.Error(1)
+}
+
+func (m *LockoutRepositoryMock) CleanupStale(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
+	return args.Error(0)
+}
+
+func (m *LockoutRepositoryMock) SaveEvent(ctx context.Context, event *entity.LockoutEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *LockoutRepositoryMock) ListEvents(ctx context.Context, kind, key string, limit int) ([]entity.LockoutEvent, error) {
+	args := m.Called(ctx, kind, key, limit)
+	events := args.Get(0)
+	if events == nil {
+		return nil, args.Error(1)
+	}
+	return events.([]entity.LockoutEvent), args.Error(1)
+}
@@ -0,0 +1,117 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MFAGateMock struct {
+	mock.Mock
+}
+
+func (m *MFAGateMock) Begin(ctx context.Context, identity *entity.Identity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+type MFARepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MFARepositoryMock) FindTOTP(ctx context.Context, userUUID uuid.UUID) (*entity.TOTPCredential, error) {
+	args := m.Called(ctx, userUUID)
+	credential := args.Get(0)
+	if credential == nil {
+		return nil, args.Error(1)
+	}
+	return credential.(*entity.TOTPCredential), args.Error(1)
+}
+
+func (m *MFARepositoryMock) SaveTOTP(ctx context.Context, credential *entity.TOTPCredential) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MFARepositoryMock) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MFARepositoryMock) DeleteFactors(ctx context.Context, userUUID uuid.UUID) error {
+	args := m.Called(ctx, userUUID)
+	return args.Error(0)
+}
+
+func (m *MFARepositoryMock) ReplaceRecoveryCodes(ctx context.Context, userUUID uuid.UUID, hashes []string) error {
+	args := m.Called(ctx, userUUID, hashes)
+	return args.Error(0)
+}
+
+func (m *MFARepositoryMock) UseRecoveryCode(ctx context.Context, userUUID uuid.UUID, hash string) (bool, error) {
+	args := m.Called(ctx, userUUID, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MFARepositoryMock) CountRecoveryCodes(ctx context.Context, userUUID uuid.UUID) (int, error) {
+	args := m.Called(ctx, userUUID)
+	return args.Int(0), args.Error(1)
+}
+
+type MFAChallengeRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MFAChallengeRepositoryMock) Save(ctx context.Context, challenge *entity.MFAChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *MFAChallengeRepositoryMock) Find(ctx context.Context, tokenHash string) (*entity.MFAChallenge, error) {
+	args := m.Called(ctx, tokenHash)
+	challenge := args.Get(0)
+	if challenge == nil {
+		return nil, args.Error(1)
+	}
+	return challenge.(*entity.MFAChallenge), args.Error(1)
+}
+
+func (m *MFAChallengeRepositoryMock) RegisterAttempt(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MFAChallengeRepositoryMock) Delete(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MFAChallengeRepositoryMock) CleanupExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+type MFAPolicyRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MFAPolicyRepositoryMock) List(ctx context.Context) ([]entity.MFARolePolicy, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.MFARolePolicy), args.Error(1)
+}
+
+func (m *MFAPolicyRepositoryMock) Find(ctx context.Context, role string) (*entity.MFARolePolicy, error) {
+	args := m.Called(ctx, role)
+	policy := args.Get(0)
+	if policy == nil {
+		return nil, args.Error(1)
+	}
+	return policy.(*entity.MFARolePolicy), args.Error(1)
+}
+
+func (m *MFAPolicyRepositoryMock) Save(ctx context.Context, policy *entity.MFARolePolicy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
@@ -0,0 +1,88 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/oidc"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type OIDCProviderMock struct {
+	mock.Mock
+	ProviderName string
+}
+
+func (m *OIDCProviderMock) Name() string {
+	return m.ProviderName
+}
+
+func (m *OIDCProviderMock) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	args := m.Called(ctx, state, nonce, verifier)
+	return args.String(0), args.Error(1)
+}
+
+func (m *OIDCProviderMock) Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.UserInfo, error) {
+	args := m.Called(ctx, code, verifier, nonce)
+	info := args.Get(0)
+	if info == nil {
+		return nil, args.Error(1)
+	}
+	return info.(*oidc.UserInfo), args.Error(1)
+}
+
+type ExternalAccountRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *ExternalAccountRepositoryMock) Create(ctx context.Context, account *entity.ExternalAccount) error {
+	args := m.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (m *ExternalAccountRepositoryMock) FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.ExternalAccount, error) {
+	args := m.Called(ctx, provider, subject)
+	account := args.Get(0)
+	if account == nil {
+		return nil, args.Error(1)
+	}
+	return account.(*entity.ExternalAccount), args.Error(1)
+}
+
+func (m *ExternalAccountRepositoryMock) ListByUser(ctx context.Context, userUUID uuid.UUID) ([]entity.ExternalAccount, error) {
+	args := m.Called(ctx, userUUID)
+	accounts := args.Get(0)
+	if accounts == nil {
+		return nil, args.Error(1)
+	}
+	return accounts.([]entity.ExternalAccount), args.Error(1)
+}
+
+func (m *ExternalAccountRepositoryMock) Delete(ctx context.Context, userUUID uuid.UUID, provider string) (bool, error) {
+	args := m.Called(ctx, userUUID, provider)
+	return args.Bool(0), args.Error(1)
+}
+
+type OIDCStateRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *OIDCStateRepositoryMock) Save(ctx context.Context, state *entity.OIDCState) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+func (m *OIDCStateRepositoryMock) Take(ctx context.Context, stateHash string) (*entity.OIDCState, error) {
+	args := m.Called(ctx, stateHash)
+	state := args.Get(0)
+	if state == nil {
+		return nil, args.Error(1)
+	}
+	return state.(*entity.OIDCState), args.Error(1)
+}
+
+func (m *OIDCStateRepositoryMock) CleanupExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
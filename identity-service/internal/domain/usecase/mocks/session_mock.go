@@ -0,0 +1,90 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionManagerMock struct {
+	mock.Mock
+}
+
+func (m *SessionManagerMock) Start(ctx context.Context, identity *entity.Identity) (*usecase.Tokens, error) {
+	args := m.Called(ctx, identity)
+	tokens := args.Get(0)
+	if tokens == nil {
+		return nil, args.Error(1)
+	}
+	return tokens.(*usecase.Tokens), args.Error(1)
+}
+
+func (m *SessionManagerMock) Refresh(ctx context.Context, refreshToken string) (*usecase.Tokens, error) {
+	args := m.Called(ctx, refreshToken)
+	tokens := args.Get(0)
+	if tokens == nil {
+		return nil, args.Error(1)
+	}
+	return tokens.(*usecase.Tokens), args.Error(1)
+}
+
+func (m *SessionManagerMock) Verify(ctx context.Context, token string, isRefreshToken bool) error {
+	args := m.Called(ctx, token, isRefreshToken)
+	return args.Error(0)
+}
+
+func (m *SessionManagerMock) End(ctx context.Context, accessToken string) error {
+	args := m.Called(ctx, accessToken)
+	return args.Error(0)
+}
+
+func (m *SessionManagerMock) RevokeAll(ctx context.Context, userUUID, exceptSessionID string) error {
+	args := m.Called(ctx, userUUID, exceptSessionID)
+	return args.Error(0)
+}
+
+type SessionRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *SessionRepositoryMock) Create(ctx context.Context, session *entity.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *SessionRepositoryMock) Find(ctx context.Context, id uuid.UUID) (*entity.Session, error) {
+	args := m.Called(ctx, id)
+	session := args.Get(0)
+	if session == nil {
+		return nil, args.Error(1)
+	}
+	return session.(*entity.Session), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) ListActive(ctx context.Context, userUUID uuid.UUID) ([]entity.Session, error) {
+	args := m.Called(ctx, userUUID)
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) Rotate(ctx context.Context, session *entity.Session, previousJTI string) (bool, error) {
+	args := m.Called(ctx, session, previousJTI)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) Revoke(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	args := m.Called(ctx, id, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) RevokeAll(ctx context.Context, userUUID uuid.UUID, except uuid.UUID, reason string) ([]entity.Session, error) {
+	args := m.Called(ctx, userUUID, except, reason)
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *SessionRepositoryMock) CleanupExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 	"github.com/stretchr/testify/mock"
@@ -21,6 +22,11 @@ func (m *TokenRepositoryMock) IsBlacklisted(ctx context.Context, token string) (
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *TokenRepositoryMock) ListRevokedSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]entity.BlacklistedToken), args.Error(1)
+}
+
 func (m *TokenRepositoryMock) CleanupExpired(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -2,6 +2,9 @@ package mocks
 
 import (
 	"context"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -24,3 +27,31 @@ func (m *TokenServiceMock) BlacklistToken(ctx context.Context, token string) err
 	args := m.Called(ctx, token)
 	return args.Error(0)
 }
+
+func (m *TokenServiceMock) GenerateSessionTokens(userID, userRole, sessionID string) (*entity.TokenPair, error) {
+	args := m.Called(userID, userRole, sessionID)
+	pair := args.Get(0)
+	if pair == nil {
+		return nil, args.Error(1)
+	}
+	return pair.(*entity.TokenPair), args.Error(1)
+}
+
+func (m *TokenServiceMock) ParseSessionToken(token string, isRefreshToken bool) (*entity.TokenClaims, error) {
+	args := m.Called(token, isRefreshToken)
+	claims := args.Get(0)
+	if claims == nil {
+		return nil, args.Error(1)
+	}
+	return claims.(*entity.TokenClaims), args.Error(1)
+}
+
+func (m *TokenServiceMock) IsTokenBlacklisted(ctx context.Context, token string) (bool, error) {
+	args := m.Called(ctx, token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *TokenServiceMock) RevokeJTI(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type VerificationCodeRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *VerificationCodeRepositoryMock) Save(ctx context.Context, code *entity.VerificationCode) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *VerificationCodeRepositoryMock) Find(ctx context.Context, userUUID uuid.UUID, purpose entity.VerificationPurpose) (*entity.VerificationCode, error) {
+	args := m.Called(ctx, userUUID, purpose)
+	code := args.Get(0)
+	if code == nil {
+		return nil, args.Error(1)
+	}
+	return code.(*entity.VerificationCode), args.Error(1)
+}
+
+func (m *VerificationCodeRepositoryMock) RegisterAttempt(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *VerificationCodeRepositoryMock) Delete(ctx context.Context, id int, codeHash string) (bool, error) {
+	args := m.Called(ctx, id, codeHash)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,314 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/oidc"
+	"github.com/google/uuid"
+)
+
+// oidcStateTTL - время, за которое пользователь должен вернуться от провайдера.
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	ErrOIDCProviderNotFound = errors.New("unknown identity provider")
+	ErrOIDCStateInvalid     = errors.New("login session is invalid or expired")
+	ErrOIDCEmailNotVerified = errors.New("identity provider did not confirm the email")
+	ErrOIDCAccountLinked    = errors.New("provider account is already linked to another user")
+	ErrOIDCProviderLinked   = errors.New("provider is already linked")
+	ErrOIDCAccountNotLinked = errors.New("provider is not linked")
+	ErrLastLoginMethod      = errors.New("cannot unlink the only login method")
+)
+
+type OIDCProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.UserInfo, error)
+}
+
+type ExternalAccountRepository interface {
+	Create(ctx context.Context, account *entity.ExternalAccount) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.ExternalAccount, error)
+	ListByUser(ctx context.Context, userUUID uuid.UUID) ([]entity.ExternalAccount, error)
+	Delete(ctx context.Context, userUUID uuid.UUID, provider string) (bool, error)
+}
+
+type OIDCStateRepository interface {
+	Save(ctx context.Context, state *entity.OIDCState) error
+	Take(ctx context.Context, stateHash string) (*entity.OIDCState, error)
+	CleanupExpired(ctx context.Context) error
+}
+
+// OIDCResult - итог возврата от провайдера: токены при входе или отметка
+// о привязке учетной записи к текущему пользователю.
+type OIDCResult struct {
+	Tokens *Tokens
+	Linked bool
+}
+
+// OIDCUseCase - вход через внешних OpenID Connect провайдеров и привязка их
+// учетных записей к пользователям.
+type OIDCUseCase struct {
+	providers    map[string]OIDCProvider
+	identityRepo IdentityRepository
+	accountRepo  ExternalAccountRepository
+	stateRepo    OIDCStateRepository
+	sessions     SessionIssuer
+	producer     EventProducer
+	mfa          MFAGate
+}
+
+func NewOIDCUseCase(providers []OIDCProvider, identityRepo IdentityRepository, accountRepo ExternalAccountRepository, stateRepo OIDCStateRepository, sessions SessionIssuer, producer EventProducer, mfa MFAGate) *OIDCUseCase {
+	byName := make(map[string]OIDCProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &OIDCUseCase{
+		providers:    byName,
+		identityRepo: identityRepo,
+		accountRepo:  accountRepo,
+		stateRepo:    stateRepo,
+		sessions:     sessions,
+		producer:     producer,
+		mfa:          mfa,
+	}
+}
+
+// Providers возвращает имена настроенных провайдеров.
+func (uc *OIDCUseCase) Providers() []string {
+	names := make([]string, 0, len(uc.providers))
+	for name := range uc.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start начинает вход через провайдера и возвращает адрес его страницы входа.
+// Если linkUserUUID задан, после возврата учетная запись провайдера
+// привязывается к этому пользователю.
+func (uc *OIDCUseCase) Start(ctx context.Context, providerName, linkUserUUID string) (string, error) {
+	provider, ok := uc.providers[providerName]
+	if !ok {
+		return "", ErrOIDCProviderNotFound
+	}
+
+	if err := uc.stateRepo.CleanupExpired(ctx); err != nil {
+		log.Printf("Failed to cleanup expired oidc states: %v", err)
+	}
+
+	var state, nonce, verifier string
+	for _, value := range []*string{&state, &nonce, &verifier} {
+		random, err := oidc.RandomString()
+		if err != nil {
+			return "", err
+		}
+		*value = random
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = uc.stateRepo.Save(ctx, &entity.OIDCState{
+		StateHash:    oidc.Hash(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserUUID: linkUserUUID,
+		ExpiresAt:    now.Add(oidcStateTTL),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// Callback завершает вход по коду и state из ответа провайдера. userUUID -
+// текущий пользователь запроса (пусто для анонимного). Привязку можно
+// завершить только тем же пользователем, который ее начал.
+func (uc *OIDCUseCase) Callback(ctx context.Context, providerName, code, state, userUUID string) (*OIDCResult, error) {
+	provider, ok := uc.providers[providerName]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	pending, err := uc.stateRepo.Take(ctx, oidc.Hash(state))
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil || pending.Provider != providerName || pending.IsExpired(time.Now()) {
+		return nil, ErrOIDCStateInvalid
+	}
+	if pending.LinkUserUUID != userUUID && pending.LinkUserUUID != "" {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	info, err := provider.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending.LinkUserUUID != "" {
+		if err := uc.link(ctx, pending.LinkUserUUID, providerName, info); err != nil {
+			return nil, err
+		}
+		return &OIDCResult{Linked: true}, nil
+	}
+
+	identity, err := uc.resolveIdentity(ctx, providerName, info)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.mfa.Begin(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	tokens, err := uc.sessions.Start(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := uc.producer.SendUserLogin(context.WithoutCancel(ctx), identity.UserUUID.String(), identity.Email); err != nil {
+			log.Printf("Failed to send user login event: %v", err)
+		}
+	}()
+
+	return &OIDCResult{Tokens: tokens}, nil
+}
+
+// resolveIdentity находит пользователя по учетной записи провайдера. Новая
+// учетная запись привязывается к пользователю с тем же подтвержденным
+// провайдером email или к новому пользователю.
+func (uc *OIDCUseCase) resolveIdentity(ctx context.Context, providerName string, info *oidc.UserInfo) (*entity.Identity, error) {
+	account, err := uc.accountRepo.FindByProviderSubject(ctx, providerName, info.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if account != nil {
+		identity, err := uc.identityRepo.FindByUUID(ctx, account.UserUUID.String())
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		return identity, nil
+	}
+
+	if info.Email == "" || !info.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	identity, err := uc.identityRepo.FindByEmail(ctx, info.Email)
+	if err == nil {
+		if !identity.IsConfirmedEmail() {
+			// Неподтвержденный аккаунт мог зарегистрировать кто угодно:
+			// его пароль сбрасывается, владельцем становится владелец email.
+			identity.RemovePassword()
+			identity.ConfirmEmail()
+			if err := uc.identityRepo.Update(ctx, identity); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		identity, err = entity.NewExternalIdentity(info.Email, providerName)
+		if err != nil {
+			return nil, err
+		}
+		if err := uc.identityRepo.Create(ctx, identity); err != nil {
+			return nil, err
+		}
+		if err := uc.producer.SendUserCreated(ctx, identity.UserUUID.String(), identity.Email); err != nil {
+			log.Printf("Failed to send user created event: %v", err)
+		}
+	}
+
+	if err := uc.accountRepo.Create(ctx, entity.NewExternalAccount(identity.UserUUID, providerName, info.Subject, info.Email)); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (uc *OIDCUseCase) link(ctx context.Context, userUUID, providerName string, info *oidc.UserInfo) error {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	account, err := uc.accountRepo.FindByProviderSubject(ctx, providerName, info.Subject)
+	if err != nil {
+		return err
+	}
+	if account != nil {
+		if account.UserUUID == identity.UserUUID {
+			return nil
+		}
+		return ErrOIDCAccountLinked
+	}
+
+	accounts, err := uc.accountRepo.ListByUser(ctx, identity.UserUUID)
+	if err != nil {
+		return err
+	}
+	for _, linked := range accounts {
+		if linked.Provider == providerName {
+			return ErrOIDCProviderLinked
+		}
+	}
+
+	return uc.accountRepo.Create(ctx, entity.NewExternalAccount(identity.UserUUID, providerName, info.Subject, info.Email))
+}
+
+// Unlink отвязывает провайдера. Последний способ входа пользователя без
+// пароля отвязать нельзя.
+func (uc *OIDCUseCase) Unlink(ctx context.Context, userUUID, providerName string) error {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	accounts, err := uc.accountRepo.ListByUser(ctx, identity.UserUUID)
+	if err != nil {
+		return err
+	}
+	linked := false
+	for _, account := range accounts {
+		if account.Provider == providerName {
+			linked = true
+		}
+	}
+	if !linked {
+		return ErrOIDCAccountNotLinked
+	}
+	if !identity.HasPassword() && len(accounts) == 1 {
+		return ErrLastLoginMethod
+	}
+
+	deleted, err := uc.accountRepo.Delete(ctx, identity.UserUUID, providerName)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrOIDCAccountNotLinked
+	}
+	return nil
+}
+
+// Accounts возвращает привязанные к пользователю учетные записи провайдеров.
+func (uc *OIDCUseCase) Accounts(ctx context.Context, userUUID string) ([]entity.ExternalAccount, error) {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return uc.accountRepo.ListByUser(ctx, identity.UserUUID)
+}
@@ -0,0 +1,293 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase/mocks"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/oidc"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type oidcFixture struct {
+	provider     *mocks.OIDCProviderMock
+	identityRepo *mocks.IdentityRepositoryMock
+	accountRepo  *mocks.ExternalAccountRepositoryMock
+	stateRepo    *mocks.OIDCStateRepositoryMock
+	sessions     *mocks.SessionManagerMock
+	producer     *mocks.ProducerMock
+	mfa          *mocks.MFAGateMock
+	uc           *usecase.OIDCUseCase
+}
+
+func newOIDCFixture() *oidcFixture {
+	f := &oidcFixture{
+		provider:     &mocks.OIDCProviderMock{ProviderName: "google"},
+		identityRepo: new(mocks.IdentityRepositoryMock),
+		accountRepo:  new(mocks.ExternalAccountRepositoryMock),
+		stateRepo:    new(mocks.OIDCStateRepositoryMock),
+		sessions:     new(mocks.SessionManagerMock),
+		producer:     new(mocks.ProducerMock),
+		mfa:          new(mocks.MFAGateMock),
+	}
+	f.mfa.On("Begin", mock.Anything, mock.Anything).Return(nil).Maybe()
+	f.producer.On("SendUserLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	f.uc = usecase.NewOIDCUseCase(
+		[]usecase.OIDCProvider{f.provider},
+		f.identityRepo,
+		f.accountRepo,
+		f.stateRepo,
+		f.sessions,
+		f.producer,
+		f.mfa,
+	)
+	return f
+}
+
+// pending ожидает обмен кода для state с указанным пользователем привязки.
+func (f *oidcFixture) pending(ctx context.Context, linkUserUUID string, info *oidc.UserInfo) {
+	f.stateRepo.On("Take", ctx, oidc.Hash("state")).Return(&entity.OIDCState{
+		Provider:     "google",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		LinkUserUUID: linkUserUUID,
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}, nil)
+	f.provider.On("Exchange", ctx, "code", "verifier", "nonce").Return(info, nil)
+}
+
+func TestOIDCStart_SavesState(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+
+	var state, verifier string
+	f.stateRepo.On("CleanupExpired", ctx).Return(nil)
+	f.provider.On("AuthCodeURL", ctx, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			state, verifier = args.String(1), args.String(3)
+		}).
+		Return("https://accounts.example.com/authorize", nil)
+	f.stateRepo.On("Save", ctx, mock.MatchedBy(func(s *entity.OIDCState) bool {
+		return s.StateHash == oidc.Hash(state) && s.CodeVerifier == verifier && s.Provider == "google" && s.ExpiresAt.After(time.Now())
+	})).Return(nil)
+
+	authURL, err := f.uc.Start(ctx, "google", "")
+
+	require.NoError(t, err)
+	require.Equal(t, "https://accounts.example.com/authorize", authURL)
+	require.NotEmpty(t, state)
+	f.stateRepo.AssertExpectations(t)
+}
+
+func TestOIDCStart_UnknownProvider(t *testing.T) {
+	f := newOIDCFixture()
+
+	_, err := f.uc.Start(context.TODO(), "facebook", "")
+
+	require.ErrorIs(t, err, usecase.ErrOIDCProviderNotFound)
+}
+
+func TestOIDCCallback_InvalidState(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	f.stateRepo.On("Take", ctx, oidc.Hash("state")).Return(nil, nil)
+
+	_, err := f.uc.Callback(ctx, "google", "code", "state", "")
+
+	require.ErrorIs(t, err, usecase.ErrOIDCStateInvalid)
+	f.provider.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCCallback_ExistingAccount(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{UserUUID: uuid.New(), Role: "user", Email: "user@example.com", IsConfirmEmail: true}
+
+	f.pending(ctx, "", &oidc.UserInfo{Subject: "sub-1"})
+	f.accountRepo.On("FindByProviderSubject", ctx, "google", "sub-1").
+		Return(entity.NewExternalAccount(identity.UserUUID, "google", "sub-1", "user@example.com"), nil)
+	f.identityRepo.On("FindByUUID", ctx, identity.UserUUID.String()).Return(identity, nil)
+	f.sessions.On("Start", ctx, identity).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	result, err := f.uc.Callback(ctx, "google", "code", "state", "")
+
+	require.NoError(t, err)
+	require.Equal(t, &usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, result.Tokens)
+}
+
+func TestOIDCCallback_LinksByVerifiedEmail(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{ID: 1, UserUUID: uuid.New(), Role: "user", Email: "user@example.com", PasswordHash: "hash", IsConfirmEmail: true}
+
+	f.pending(ctx, "", &oidc.UserInfo{Subject: "sub-1", Email: "user@example.com", EmailVerified: true})
+	f.accountRepo.On("FindByProviderSubject", ctx, "google", "sub-1").Return(nil, nil)
+	f.identityRepo.On("FindByEmail", ctx, "user@example.com").Return(identity, nil)
+	f.accountRepo.On("Create", ctx, mock.MatchedBy(func(a *entity.ExternalAccount) bool {
+		return a.UserUUID == identity.UserUUID && a.Subject == "sub-1"
+	})).Return(nil)
+	f.sessions.On("Start", ctx, identity).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	result, err := f.uc.Callback(ctx, "google", "code", "state", "")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	require.True(t, identity.HasPassword())
+	f.identityRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	f.accountRepo.AssertExpectations(t)
+}
+
+func TestOIDCCallback_TakesOverUnconfirmedAccount(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{ID: 1, UserUUID: uuid.New(), Role: "user", Email: "user@example.com", PasswordHash: "hash"}
+
+	f.pending(ctx, "", &oidc.UserInfo{Subject: "sub-1", Email: "user@example.com", EmailVerified: true})
+	f.accountRepo.On("FindByProviderSubject", ctx, "google", "sub-1").Return(nil, nil)
+	f.identityRepo.On("FindByEmail", ctx, "user@example.com").Return(identity, nil)
+	f.identityRepo.On("Update", ctx, identity).Return(nil)
+	f.accountRepo.On("Create", ctx, mock.AnythingOfType("*entity.ExternalAccount")).Return(nil)
+	f.sessions.On("Start", ctx, identity).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	_, err := f.uc.Callback(ctx, "google", "code", "state", "")
+
+	require.NoError(t, err)
+	require.True(t, identity.IsConfirmedEmail())
+	require.False(t, identity.HasPassword())
+	f.identityRepo.AssertExpectations(t)
+}
+
+func TestOIDCCallback_CreatesIdentity(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+
+	f.pending(ctx, "", &oidc.UserInfo{Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+	f.accountRepo.On("FindByProviderSubject", ctx, "google", "sub-1").Return(nil, nil)
+	f.identityRepo.On("FindByEmail", ctx, "new@example.com").Return(nil, errors.New("not found"))
+	f.identityRepo.On("Create", ctx, mock.MatchedBy(func(i *entity.Identity) bool {
+		return i.Provider == "google" && i.IsConfirmEmail && !i.HasPassword()
+	})).Return(nil)
+	f.producer.On("SendUserCreated", ctx, mock.Anything, "new@example.com").Return(nil)
+	f.accountRepo.On("Create", ctx, mock.AnythingOfType("*entity.ExternalAccount")).Return(nil)
+	f.sessions.On("Start", ctx, mock.AnythingOfType("*entity.Identity")).Return(&usecase.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	result, err := f.uc.Callback(ctx, "google", "code", "state", "")
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	f.identityRepo.AssertExpectations(t)
+	f.producer.AssertExpectations(t)
+}
+
+func TestOIDCCallback_UnverifiedEmail(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+
+	f.pending(ctx, "", &oidc.UserInfo{Subject: "sub-1", Email: "user@example.com"})
+	f.accountRepo.On("FindByProviderSubject", ctx, "google", "sub-1").Return(nil, nil)
+
+	_, err := f.uc.Callback(ctx, "google", "code", "state", "")
+
+	require.ErrorIs(t, err, usecase.ErrOIDCEmailNotVerified)
+	f.identityRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+}
+
+func TestOIDCCallback_LinkRequiresSameUser(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	f.stateRepo.On("Take", ctx, oidc.Hash("state")).Return(&entity.OIDCState{
+		Provider:     "google",
+		LinkUserUUID: uuid.NewString(),
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}, nil)
+
+	_, err := f.uc.Callback(ctx, "google", "code", "state", uuid.NewString())
+
+	require.ErrorIs(t, err, usecase.ErrOIDCStateInvalid)
+}
+
+func TestOIDCCallback_Link(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{UserUUID: uuid.New(), Email: "user@example.com"}
+	userUUID := identity.UserUUID.String()
+
+	f.pending(ctx, userUUID, &oidc.UserInfo{Subject: "sub-1", Email: "other@example.com"})
+	f.identityRepo.On("FindByUUID", ctx, userUUID).Return(identity, nil)
+	f.accountRepo.On("FindByProviderSubject", ctx, "google", "sub-1").Return(nil, nil)
+	f.accountRepo.On("ListByUser", ctx, identity.UserUUID).Return([]entity.ExternalAccount{}, nil)
+	f.accountRepo.On("Create", ctx, mock.AnythingOfType("*entity.ExternalAccount")).Return(nil)
+
+	result, err := f.uc.Callback(ctx, "google", "code", "state", userUUID)
+
+	require.NoError(t, err)
+	require.True(t, result.Linked)
+	require.Nil(t, result.Tokens)
+	f.sessions.AssertNotCalled(t, "Start", mock.Anything, mock.Anything)
+}
+
+func TestOIDCCallback_LinkAccountOfAnotherUser(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{UserUUID: uuid.New()}
+	userUUID := identity.UserUUID.String()
+
+	f.pending(ctx, userUUID, &oidc.UserInfo{Subject: "sub-1"})
+	f.identityRepo.On("FindByUUID", ctx, userUUID).Return(identity, nil)
+	f.accountRepo.On("FindByProviderSubject", ctx, "google", "sub-1").
+		Return(entity.NewExternalAccount(uuid.New(), "google", "sub-1", ""), nil)
+
+	_, err := f.uc.Callback(ctx, "google", "code", "state", userUUID)
+
+	require.ErrorIs(t, err, usecase.ErrOIDCAccountLinked)
+}
+
+func TestOIDCUnlink_LastLoginMethod(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{UserUUID: uuid.New()}
+
+	f.identityRepo.On("FindByUUID", ctx, identity.UserUUID.String()).Return(identity, nil)
+	f.accountRepo.On("ListByUser", ctx, identity.UserUUID).
+		Return([]entity.ExternalAccount{*entity.NewExternalAccount(identity.UserUUID, "google", "sub-1", "")}, nil)
+
+	err := f.uc.Unlink(ctx, identity.UserUUID.String(), "google")
+
+	require.ErrorIs(t, err, usecase.ErrLastLoginMethod)
+	f.accountRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCUnlink_WithPassword(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{UserUUID: uuid.New(), PasswordHash: "hash"}
+
+	f.identityRepo.On("FindByUUID", ctx, identity.UserUUID.String()).Return(identity, nil)
+	f.accountRepo.On("ListByUser", ctx, identity.UserUUID).
+		Return([]entity.ExternalAccount{*entity.NewExternalAccount(identity.UserUUID, "google", "sub-1", "")}, nil)
+	f.accountRepo.On("Delete", ctx, identity.UserUUID, "google").Return(true, nil)
+
+	err := f.uc.Unlink(ctx, identity.UserUUID.String(), "google")
+
+	require.NoError(t, err)
+	f.accountRepo.AssertExpectations(t)
+}
+
+func TestOIDCUnlink_NotLinked(t *testing.T) {
+	ctx := context.TODO()
+	f := newOIDCFixture()
+	identity := &entity.Identity{UserUUID: uuid.New(), PasswordHash: "hash"}
+
+	f.identityRepo.On("FindByUUID", ctx, identity.UserUUID.String()).Return(identity, nil)
+	f.accountRepo.On("ListByUser", ctx, identity.UserUUID).Return([]entity.ExternalAccount{}, nil)
+
+	err := f.uc.Unlink(ctx, identity.UserUUID.String(), "google")
+
+	require.ErrorIs(t, err, usecase.ErrOIDCAccountNotLinked)
+}
@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, session revoked")
+	ErrIdentityDisabled    = errors.New("account is disabled")
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *entity.Session) error
+	Find(ctx context.Context, id uuid.UUID) (*entity.Session, error)
+	ListActive(ctx context.Context, userUUID uuid.UUID) ([]entity.Session, error)
+	Rotate(ctx context.Context, session *entity.Session, previousJTI string) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID, reason string) (bool, error)
+	RevokeAll(ctx context.Context, userUUID uuid.UUID, except uuid.UUID, reason string) ([]entity.Session, error)
+	CleanupExpired(ctx context.Context) error
+}
+
+type SessionTokenService interface {
+	GenerateSessionTokens(userID, userRole, sessionID string) (*entity.TokenPair, error)
+	ParseSessionToken(token string, isRefreshToken bool) (*entity.TokenClaims, error)
+	BlacklistToken(ctx context.Context, token string) error
+	IsTokenBlacklisted(ctx context.Context, token string) (bool, error)
+	RevokeJTI(ctx context.Context, jti string, expiresAt time.Time) error
+}
+
+// SessionIssuer начинает сессию пользователя и выдает ее токены.
+type SessionIssuer interface {
+	Start(ctx context.Context, identity *entity.Identity) (*Tokens, error)
+}
+
+type clientKey struct{}
+
+// ContextWithClient добавляет в контекст запроса сведения об устройстве клиента.
+func ContextWithClient(ctx context.Context, client entity.ClientInfo) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext возвращает устройство клиента из контекста запроса.
+func ClientFromContext(ctx context.Context) entity.ClientInfo {
+	client, _ := ctx.Value(clientKey{}).(entity.ClientInfo)
+	return client
+}
+
+// SessionUseCase - сессии пользователей и ротация refresh токенов.
+type SessionUseCase struct {
+	sessions     SessionRepository
+	identityRepo IdentityRepository
+	tokenService SessionTokenService
+}
+
+func NewSessionUseCase(sessions SessionRepository, identityRepo IdentityRepository, tokenService SessionTokenService) *SessionUseCase {
+	return &SessionUseCase{
+		sessions:     sessions,
+		identityRepo: identityRepo,
+		tokenService: tokenService,
+	}
+}
+
+// Start создает сессию для устройства из контекста и выдает первую пару токенов.
+func (uc *SessionUseCase) Start(ctx context.Context, identity *entity.Identity) (*Tokens, error) {
+	if identity.IsDisabled() {
+		return nil, ErrIdentityDisabled
+	}
+
+	session := entity.NewSession(identity.UserUUID, ClientFromContext(ctx))
+
+	pair, err := uc.tokenService.GenerateSessionTokens(identity.UserUUID.String(), identity.Role, session.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	session.Issue(pair)
+
+	if err := uc.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := uc.sessions.CleanupExpired(context.WithoutCancel(ctx)); err != nil {
+			log.Printf("Failed to clean up expired sessions: %v", err)
+		}
+	}()
+
+	return &Tokens{AccessToken: pair.AccessToken, RefreshToken: pair.RefreshToken}, nil
+}
+
+// Refresh заменяет refresh токен сессии новым. Повторное предъявление уже
+// замененного токена отзывает всю сессию.
+func (uc *SessionUseCase) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	claims, err := uc.tokenService.ParseSessionToken(refreshToken, true)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if claims.SessionID == "" {
+		return uc.refreshLegacy(ctx, refreshToken, claims)
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	session, err := uc.sessions.Find(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserUUID.String() != claims.UserUUID {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+	if session.RefreshJTI != claims.JTI {
+		return nil, uc.revokeReused(ctx, session)
+	}
+
+	identity, err := uc.identityRepo.FindByUUID(ctx, claims.UserUUID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if identity.IsDisabled() {
+		return nil, ErrIdentityDisabled
+	}
+
+	pair, err := uc.tokenService.GenerateSessionTokens(claims.UserUUID, identity.Role, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := ClientFromContext(ctx)
+	if client.UserAgent != "" {
+		session.UserAgent = client.UserAgent
+	}
+	if client.IP != "" {
+		session.IP = client.IP
+	}
+	session.Issue(pair)
+
+	rotated, err := uc.sessions.Rotate(ctx, session, claims.JTI)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Токен успели обменять параллельно - это тоже повторное использование.
+		return nil, uc.revokeReused(ctx, session)
+	}
+
+	return &Tokens{AccessToken: pair.AccessToken, RefreshToken: pair.RefreshToken}, nil
+}
+
+// Verify проверяет, что сессия, которой выдан токен, не отозвана. Токены,
+// выданные до появления сессий, проверяются только по черному списку.
+func (uc *SessionUseCase) Verify(ctx context.Context, token string, isRefreshToken bool) error {
+	claims, err := uc.tokenService.ParseSessionToken(token, isRefreshToken)
+	if err != nil {
+		return err
+	}
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	session, err := uc.sessions.Find(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || !session.IsActive() {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// End завершает сессию, которой выдан access токен, при выходе.
+func (uc *SessionUseCase) End(ctx context.Context, accessToken string) error {
+	claims, err := uc.tokenService.ParseSessionToken(accessToken, false)
+	if err != nil || claims.SessionID == "" {
+		return nil
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil
+	}
+	_, err = uc.sessions.Revoke(ctx, sessionID, entity.SessionRevokedLogout)
+	return err
+}
+
+// List возвращает активные сессии пользователя.
+func (uc *SessionUseCase) List(ctx context.Context, userUUID string) ([]entity.Session, error) {
+	id, err := uuid.Parse(userUUID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	return uc.sessions.ListActive(ctx, id)
+}
+
+// CurrentSession возвращает идентификатор сессии access токена.
+func (uc *SessionUseCase) CurrentSession(accessToken string) string {
+	claims, err := uc.tokenService.ParseSessionToken(accessToken, false)
+	if err != nil {
+		return ""
+	}
+	return claims.SessionID
+}
+
+// Revoke отзывает сессию пользователя и ее действующий access токен.
+func (uc *SessionUseCase) Revoke(ctx context.Context, userUUID, sessionID string) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	session, err := uc.sessions.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserUUID.String() != userUUID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	if _, err := uc.sessions.Revoke(ctx, id, entity.SessionRevokedByUser); err != nil {
+		return err
+	}
+	return uc.tokenService.RevokeJTI(ctx, session.AccessJTI, session.AccessExpiresAt)
+}
+
+// RevokeAll завершает все сессии пользователя, кроме exceptSessionID
+// (пустое значение - выход везде, включая текущую).
+func (uc *SessionUseCase) RevokeAll(ctx context.Context, userUUID, exceptSessionID string) error {
+	id, err := uuid.Parse(userUUID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	except, _ := uuid.Parse(exceptSessionID)
+
+	return uc.revokeAll(ctx, id, except, entity.SessionRevokedByUser)
+}
+
+// Terminate завершает все сессии пользователя по решению администратора.
+func (uc *SessionUseCase) Terminate(ctx context.Context, userUUID uuid.UUID) error {
+	return uc.revokeAll(ctx, userUUID, uuid.Nil, entity.SessionRevokedByAdmin)
+}
+
+// revokeAll отзывает сессии пользователя, кроме except, и их действующие access токены.
+func (uc *SessionUseCase) revokeAll(ctx context.Context, userUUID, except uuid.UUID, reason string) error {
+	revoked, err := uc.sessions.RevokeAll(ctx, userUUID, except, reason)
+	if err != nil {
+		return err
+	}
+	for _, session := range revoked {
+		if err := uc.tokenService.RevokeJTI(ctx, session.AccessJTI, session.AccessExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *SessionUseCase) revokeReused(ctx context.Context, session *entity.Session) error {
+	log.Printf("Refresh token reuse detected for session %s of user %s, revoking session", session.ID, session.UserUUID)
+
+	if _, err := uc.sessions.Revoke(ctx, session.ID, entity.SessionRevokedReuse); err != nil {
+		return err
+	}
+	if err := uc.tokenService.RevokeJTI(ctx, session.AccessJTI, session.AccessExpiresAt); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// refreshLegacy обменивает refresh токен, выданный до появления сессий:
+// старый токен попадает в черный список, а пользователь получает новую сессию.
+func (uc *SessionUseCase) refreshLegacy(ctx context.Context, refreshToken string, claims *entity.TokenClaims) (*Tokens, error) {
+	blacklisted, err := uc.tokenService.IsTokenBlacklisted(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if blacklisted {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	identity, err := uc.identityRepo.FindByUUID(ctx, claims.UserUUID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err := uc.tokenService.BlacklistToken(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+	return uc.Start(ctx, identity)
+}
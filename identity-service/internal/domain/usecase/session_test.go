@@ -0,0 +1,252 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionFixture struct {
+	sessions     *mocks.SessionRepositoryMock
+	identityRepo *mocks.IdentityRepositoryMock
+	tokenService *mocks.TokenServiceMock
+	identity     *entity.Identity
+	uc           *usecase.SessionUseCase
+}
+
+func newSessionFixture() *sessionFixture {
+	f := &sessionFixture{
+		sessions:     new(mocks.SessionRepositoryMock),
+		identityRepo: new(mocks.IdentityRepositoryMock),
+		tokenService: new(mocks.TokenServiceMock),
+		identity:     &entity.Identity{UserUUID: uuid.New(), Role: "user", Email: "user@example.com"},
+	}
+	f.sessions.On("CleanupExpired", mock.Anything).Return(nil).Maybe()
+	f.identityRepo.On("FindByUUID", mock.Anything, f.identity.UserUUID.String()).Return(f.identity, nil).Maybe()
+
+	f.uc = usecase.NewSessionUseCase(f.sessions, f.identityRepo, f.tokenService)
+	return f
+}
+
+// session возвращает активную сессию, последний refresh токен которой - "current".
+func (f *sessionFixture) session(ctx context.Context) *entity.Session {
+	session := entity.NewSession(f.identity.UserUUID, entity.ClientInfo{UserAgent: "old-agent", IP: "192.0.2.1"})
+	session.Issue(&entity.TokenPair{
+		RefreshJTI:       "current",
+		AccessJTI:        "access-jti",
+		AccessExpiresAt:  time.Now().Add(time.Minute),
+		RefreshExpiresAt: time.Now().Add(time.Hour),
+	})
+	f.sessions.On("Find", ctx, session.ID).Return(session, nil)
+	return session
+}
+
+func (f *sessionFixture) refreshClaims(session *entity.Session, jti string) {
+	f.tokenService.On("ParseSessionToken", "refresh_token", true).Return(&entity.TokenClaims{
+		UserUUID:  f.identity.UserUUID.String(),
+		Role:      "user",
+		SessionID: session.ID.String(),
+		JTI:       jti,
+	}, nil)
+}
+
+func newPair() *entity.TokenPair {
+	return &entity.TokenPair{
+		AccessToken:      "new_access",
+		RefreshToken:     "new_refresh",
+		AccessJTI:        "new-access-jti",
+		RefreshJTI:       "next",
+		AccessExpiresAt:  time.Now().Add(time.Minute),
+		RefreshExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestSessionStart(t *testing.T) {
+	ctx := usecase.ContextWithClient(context.TODO(), entity.ClientInfo{Device: "phone", UserAgent: "agent", IP: "198.51.100.7"})
+	f := newSessionFixture()
+
+	f.tokenService.On("GenerateSessionTokens", f.identity.UserUUID.String(), "user", mock.AnythingOfType("string")).Return(newPair(), nil)
+	f.sessions.On("Create", ctx, mock.MatchedBy(func(s *entity.Session) bool {
+		return s.UserUUID == f.identity.UserUUID && s.Device == "phone" && s.UserAgent == "agent" &&
+			s.IP == "198.51.100.7" && s.RefreshJTI == "next" && s.AccessJTI == "new-access-jti"
+	})).Return(nil)
+
+	tokens, err := f.uc.Start(ctx, f.identity)
+
+	require.NoError(t, err)
+	require.Equal(t, &usecase.Tokens{AccessToken: "new_access", RefreshToken: "new_refresh"}, tokens)
+	f.sessions.AssertExpectations(t)
+}
+
+func TestSessionRefresh_Rotates(t *testing.T) {
+	ctx := usecase.ContextWithClient(context.TODO(), entity.ClientInfo{UserAgent: "new-agent", IP: "198.51.100.7"})
+	f := newSessionFixture()
+	session := f.session(ctx)
+	f.refreshClaims(session, "current")
+
+	f.tokenService.On("GenerateSessionTokens", f.identity.UserUUID.String(), "user", session.ID.String()).Return(newPair(), nil)
+	f.sessions.On("Rotate", ctx, mock.MatchedBy(func(s *entity.Session) bool {
+		return s.RefreshJTI == "next" && s.UserAgent == "new-agent" && s.IP == "198.51.100.7"
+	}), "current").Return(true, nil)
+
+	tokens, err := f.uc.Refresh(ctx, "refresh_token")
+
+	require.NoError(t, err)
+	require.Equal(t, "new_refresh", tokens.RefreshToken)
+}
+
+func TestSessionRefresh_ReuseRevokesFamily(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	session := f.session(ctx)
+	f.refreshClaims(session, "rotated-earlier")
+
+	f.sessions.On("Revoke", ctx, session.ID, entity.SessionRevokedReuse).Return(true, nil)
+	f.tokenService.On("RevokeJTI", ctx, "access-jti", session.AccessExpiresAt).Return(nil)
+
+	_, err := f.uc.Refresh(ctx, "refresh_token")
+
+	require.ErrorIs(t, err, usecase.ErrRefreshTokenReused)
+	f.sessions.AssertExpectations(t)
+	f.tokenService.AssertExpectations(t)
+	f.tokenService.AssertNotCalled(t, "GenerateSessionTokens", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSessionRefresh_ConcurrentRotation(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	session := f.session(ctx)
+	accessJTI := session.AccessJTI
+	f.refreshClaims(session, "current")
+
+	f.tokenService.On("GenerateSessionTokens", f.identity.UserUUID.String(), "user", session.ID.String()).Return(newPair(), nil)
+	f.sessions.On("Rotate", ctx, session, "current").Return(false, nil)
+	f.sessions.On("Revoke", ctx, session.ID, entity.SessionRevokedReuse).Return(true, nil)
+	f.tokenService.On("RevokeJTI", ctx, mock.Anything, mock.Anything).Return(nil)
+
+	_, err := f.uc.Refresh(ctx, "refresh_token")
+
+	require.ErrorIs(t, err, usecase.ErrRefreshTokenReused)
+	require.NotEqual(t, accessJTI, session.AccessJTI)
+}
+
+func TestSessionRefresh_RevokedSession(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	session := f.session(ctx)
+	revokedAt := time.Now()
+	session.RevokedAt = &revokedAt
+	f.refreshClaims(session, "current")
+
+	_, err := f.uc.Refresh(ctx, "refresh_token")
+
+	require.ErrorIs(t, err, usecase.ErrSessionRevoked)
+	f.sessions.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSessionRefresh_LegacyToken(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	f.tokenService.On("ParseSessionToken", "refresh_token", true).
+		Return(&entity.TokenClaims{UserUUID: f.identity.UserUUID.String(), Role: "user", JTI: "legacy"}, nil)
+	f.tokenService.On("IsTokenBlacklisted", ctx, "refresh_token").Return(false, nil)
+	f.tokenService.On("BlacklistToken", ctx, "refresh_token").Return(nil)
+	f.tokenService.On("GenerateSessionTokens", f.identity.UserUUID.String(), "user", mock.AnythingOfType("string")).Return(newPair(), nil)
+	f.sessions.On("Create", ctx, mock.AnythingOfType("*entity.Session")).Return(nil)
+
+	tokens, err := f.uc.Refresh(ctx, "refresh_token")
+
+	require.NoError(t, err)
+	require.Equal(t, "new_refresh", tokens.RefreshToken)
+	f.tokenService.AssertExpectations(t)
+}
+
+func TestSessionRefresh_InvalidToken(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	f.tokenService.On("ParseSessionToken", "refresh_token", true).Return(nil, usecase.ErrInvalidRefreshToken)
+
+	_, err := f.uc.Refresh(ctx, "refresh_token")
+
+	require.ErrorIs(t, err, usecase.ErrInvalidRefreshToken)
+}
+
+func TestSessionVerify_Revoked(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	session := f.session(ctx)
+	revokedAt := time.Now()
+	session.RevokedAt = &revokedAt
+	f.tokenService.On("ParseSessionToken", "access_token", false).Return(&entity.TokenClaims{SessionID: session.ID.String()}, nil)
+
+	require.ErrorIs(t, f.uc.Verify(ctx, "access_token", false), usecase.ErrSessionRevoked)
+}
+
+func TestSessionRevoke_OtherUser(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	session := f.session(ctx)
+
+	err := f.uc.Revoke(ctx, uuid.NewString(), session.ID.String())
+
+	require.ErrorIs(t, err, usecase.ErrSessionNotFound)
+	f.sessions.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSessionRevoke(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	session := f.session(ctx)
+	f.sessions.On("Revoke", ctx, session.ID, entity.SessionRevokedByUser).Return(true, nil)
+	f.tokenService.On("RevokeJTI", ctx, "access-jti", session.AccessExpiresAt).Return(nil)
+
+	require.NoError(t, f.uc.Revoke(ctx, f.identity.UserUUID.String(), session.ID.String()))
+	f.tokenService.AssertExpectations(t)
+}
+
+func TestSessionRevokeAll(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	current := uuid.New()
+	expiresAt := time.Now().Add(time.Minute)
+	f.sessions.On("RevokeAll", ctx, f.identity.UserUUID, current, entity.SessionRevokedByUser).Return([]entity.Session{
+		{ID: uuid.New(), AccessJTI: "first", AccessExpiresAt: expiresAt},
+		{ID: uuid.New(), AccessJTI: "second", AccessExpiresAt: expiresAt},
+	}, nil)
+	f.tokenService.On("RevokeJTI", ctx, "first", expiresAt).Return(nil)
+	f.tokenService.On("RevokeJTI", ctx, "second", expiresAt).Return(nil)
+
+	require.NoError(t, f.uc.RevokeAll(ctx, f.identity.UserUUID.String(), current.String()))
+	f.tokenService.AssertExpectations(t)
+}
+
+func TestSessionStart_Disabled(t *testing.T) {
+	f := newSessionFixture()
+	f.identity.Disable(time.Now())
+
+	tokens, err := f.uc.Start(context.TODO(), f.identity)
+
+	require.Nil(t, tokens)
+	require.ErrorIs(t, err, usecase.ErrIdentityDisabled)
+	f.tokenService.AssertNotCalled(t, "GenerateSessionTokens", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSessionTerminate(t *testing.T) {
+	ctx := context.TODO()
+	f := newSessionFixture()
+	expiresAt := time.Now().Add(time.Minute)
+	f.sessions.On("RevokeAll", ctx, f.identity.UserUUID, uuid.Nil, entity.SessionRevokedByAdmin).Return([]entity.Session{
+		{ID: uuid.New(), AccessJTI: "first", AccessExpiresAt: expiresAt},
+	}, nil)
+	f.tokenService.On("RevokeJTI", ctx, "first", expiresAt).Return(nil)
+
+	require.NoError(t, f.uc.Terminate(ctx, f.identity.UserUUID))
+	f.tokenService.AssertExpectations(t)
+}
@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrVerificationCodeInvalid   = errors.New("invalid verification code")
+	ErrVerificationCodeExpired   = errors.New("verification code has expired")
+	ErrVerificationCodeExhausted = errors.New("too many attempts, request a new verification code")
+	ErrEmailAlreadyConfirmed     = errors.New("email is already confirmed")
+)
+
+// ResendCooldownError - новый код запрошен раньше, чем истек интервал повторной отправки.
+type ResendCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ResendCooldownError) Error() string {
+	return "verification code was sent recently, try again later"
+}
+
+// VerificationPolicy - срок действия кодов подтверждения, число попыток ввода
+// и интервал между отправками. Нулевые поля заменяются значениями по умолчанию.
+type VerificationPolicy struct {
+	TTL            time.Duration
+	MaxAttempts    int
+	ResendCooldown time.Duration
+}
+
+var DefaultVerificationPolicy = VerificationPolicy{
+	TTL:            15 * time.Minute,
+	MaxAttempts:    5,
+	ResendCooldown: time.Minute,
+}
+
+// WithDefaults заменяет незаданные поля значениями DefaultVerificationPolicy.
+func (p VerificationPolicy) WithDefaults() VerificationPolicy {
+	if p.TTL <= 0 {
+		p.TTL = DefaultVerificationPolicy.TTL
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultVerificationPolicy.MaxAttempts
+	}
+	if p.ResendCooldown < 0 {
+		p.ResendCooldown = 0
+	}
+	return p
+}
+
+// AddVerificationCode сохраняет хэш кода подтверждения email или сброса пароля.
+// Код, выданный ранее для того же назначения, перестает действовать.
+func (uc *IdentityUseCase) AddVerificationCode(ctx context.Context, email string, purpose entity.VerificationPurpose, code string) error {
+	if purpose == entity.PurposeChangeEmail {
+		return errors.New("email change code must be requested by the account owner")
+	}
+
+	identity, err := uc.identityRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if purpose == entity.PurposeConfirmEmail && identity.IsConfirmedEmail() {
+		return ErrEmailAlreadyConfirmed
+	}
+
+	return uc.saveCode(ctx, identity.UserUUID, email, purpose, code)
+}
+
+func (uc *IdentityUseCase) ConfirmEmail(ctx context.Context, email, code string) error {
+	identity, err := uc.identityRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if _, err := uc.useThrottledCode(ctx, identity, entity.PurposeConfirmEmail, code); err != nil {
+		return err
+	}
+
+	identity.ConfirmEmail()
+
+	if err := uc.identityRepo.Update(ctx, identity); err != nil {
+		return err
+	}
+
+	if err := uc.producer.SendUserCreated(ctx, identity.UserUUID.String(), email); err != nil {
+		log.Printf("Failed to send user created event: %v", err)
+	}
+
+	return nil
+}
+
+// RequestEmailChange сохраняет код для смены email пользователя на newEmail.
+// Код отправляется на новый адрес, email меняется только после его ввода.
+func (uc *IdentityUseCase) RequestEmailChange(ctx context.Context, userUUID, newEmail, code string) error {
+	if err := entity.ValidateEmail(newEmail); err != nil {
+		return err
+	}
+
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if identity.Email == newEmail {
+		return errors.New("new email matches the current one")
+	}
+	if _, err := uc.identityRepo.FindByEmail(ctx, newEmail); err == nil {
+		return errors.New("email already exists")
+	}
+
+	return uc.saveCode(ctx, identity.UserUUID, newEmail, entity.PurposeChangeEmail, code)
+}
+
+// ChangeEmail меняет email пользователя на адрес, подтвержденный кодом.
+func (uc *IdentityUseCase) ChangeEmail(ctx context.Context, userUUID, code string) error {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	verified, err := uc.useThrottledCode(ctx, identity, entity.PurposeChangeEmail, code)
+	if err != nil {
+		return err
+	}
+	if _, err := uc.identityRepo.FindByEmail(ctx, verified.Email); err == nil {
+		return errors.New("email already exists")
+	}
+
+	if err := identity.UpdateEmail(verified.Email); err != nil {
+		return err
+	}
+	identity.ConfirmEmail()
+
+	if err := uc.identityRepo.Update(ctx, identity); err != nil {
+		return err
+	}
+
+	// Сессии, открытые со старым адресом, завершаются вместе с его сменой.
+	return uc.sessions.RevokeAll(ctx, identity.UserUUID.String(), "")
+}
+
+// DiscardVerificationCode удаляет код, который не удалось отправить, чтобы
+// повторный запрос не ждал окончания паузы. Код, уже замененный новым, остается.
+func (uc *IdentityUseCase) DiscardVerificationCode(ctx context.Context, email string, purpose entity.VerificationPurpose, code string) error {
+	identity, err := uc.identityRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	return uc.discardCode(ctx, identity.UserUUID, purpose, code)
+}
+
+// DiscardEmailChangeCode удаляет неотправленный код смены email.
+func (uc *IdentityUseCase) DiscardEmailChangeCode(ctx context.Context, userUUID, code string) error {
+	identity, err := uc.identityRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	return uc.discardCode(ctx, identity.UserUUID, entity.PurposeChangeEmail, code)
+}
+
+func (uc *IdentityUseCase) discardCode(ctx context.Context, userUUID uuid.UUID, purpose entity.VerificationPurpose, code string) error {
+	verificationCode, err := uc.codeRepo.Find(ctx, userUUID, purpose)
+	if err != nil {
+		return err
+	}
+	if verificationCode == nil || !verificationCode.Matches(code) {
+		return nil
+	}
+
+	_, err = uc.codeRepo.Delete(ctx, verificationCode.ID, verificationCode.CodeHash)
+	return err
+}
+
+func (uc *IdentityUseCase) saveCode(ctx context.Context, userUUID uuid.UUID, email string, purpose entity.VerificationPurpose, code string) error {
+	previous, err := uc.codeRepo.Find(ctx, userUUID, purpose)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		if retryAfter := time.Until(previous.ResendAt(uc.verification.ResendCooldown)); retryAfter > 0 {
+			return &ResendCooldownError{RetryAfter: retryAfter}
+		}
+	}
+
+	verificationCode, err := entity.NewVerificationCode(userUUID, email, purpose, code, uc.verification.TTL, uc.verification.MaxAttempts)
+	if err != nil {
+		return err
+	}
+
+	return uc.codeRepo.Save(ctx, verificationCode)
+}
+
+// useThrottledCode проверяет код с учетом счетчиков неудач по аккаунту и IP.
+func (uc *IdentityUseCase) useThrottledCode(ctx context.Context, identity *entity.Identity, purpose entity.VerificationPurpose, code string) (*entity.VerificationCode, error) {
+	if err := uc.throttle.Check(ctx, identity.Email); err != nil {
+		return nil, err
+	}
+
+	verificationCode, err := uc.useCode(ctx, identity.UserUUID, purpose, code)
+	switch {
+	case errors.Is(err, ErrVerificationCodeInvalid), errors.Is(err, ErrVerificationCodeExhausted):
+		uc.registerFailure(ctx, identity.Email)
+		return nil, err
+	case err != nil:
+		return nil, err
+	}
+
+	if err := uc.throttle.Succeed(ctx, identity.Email); err != nil {
+		log.Printf("Failed to reset failed attempts: %v", err)
+	}
+	return verificationCode, nil
+}
+
+// useCode проверяет код и удаляет его после успешной проверки. Попытка
+// засчитывается до сравнения, поэтому перебор не превысит MaxAttempts.
+func (uc *IdentityUseCase) useCode(ctx context.Context, userUUID uuid.UUID, purpose entity.VerificationPurpose, code string) (*entity.VerificationCode, error) {
+	verificationCode, err := uc.codeRepo.Find(ctx, userUUID, purpose)
+	if err != nil {
+		return nil, err
+	}
+	if verificationCode == nil {
+		return nil, ErrVerificationCodeInvalid
+	}
+	if verificationCode.IsExpired(time.Now()) {
+		return nil, ErrVerificationCodeExpired
+	}
+	if verificationCode.IsExhausted() {
+		return nil, ErrVerificationCodeExhausted
+	}
+
+	counted, err := uc.codeRepo.RegisterAttempt(ctx, verificationCode.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !counted {
+		return nil, ErrVerificationCodeExhausted
+	}
+
+	if !verificationCode.Matches(code) {
+		return nil, ErrVerificationCodeInvalid
+	}
+
+	// Код удаляется, только если его не погасил и не заменил параллельный запрос.
+	deleted, err := uc.codeRepo.Delete(ctx, verificationCode.ID, verificationCode.CodeHash)
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, ErrVerificationCodeInvalid
+	}
+	return verificationCode, nil
+}
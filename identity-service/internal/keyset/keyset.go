@@ -0,0 +1,378 @@
+package keyset
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+)
+
+// Algorithm - алгоритм подписи всех ключей набора.
+const Algorithm = "RS256"
+
+// clockSkew добавляется к сроку проверки выведенного ключа на случай
+// расхождения часов между сервисами.
+const clockSkew = time.Minute
+
+var ErrNoSigningKey = errors.New("no active signing key")
+
+type Repository interface {
+	List(ctx context.Context) ([]entity.SigningKey, error)
+	Create(ctx context.Context, key *entity.SigningKey) (bool, error)
+	Retire(ctx context.Context, kid string, retiredAt, expiresAt time.Time) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// Sealer шифрует закрытые ключи перед сохранением в базу.
+type Sealer interface {
+	Seal(secret string) (string, error)
+	Open(stored string) (string, error)
+}
+
+type Config struct {
+	// RotationInterval - сколько ключ подписывает токены до замены.
+	RotationInterval time.Duration
+	// PublishAhead - за сколько до начала подписи новый ключ появляется в JWKS,
+	// чтобы его успели получить кэши gateway и остальные экземпляры.
+	PublishAhead time.Duration
+	// SyncInterval - как часто набор перечитывается из базы и проверяется ротация.
+	SyncInterval time.Duration
+	// Lifetimes - наибольший срок жизни токенов по назначению ключа: столько
+	// выведенный ключ еще проверяет выданные им токены.
+	Lifetimes map[string]time.Duration
+	// Bits - размер новых ключей RSA.
+	Bits int
+}
+
+var DefaultConfig = Config{
+	RotationInterval: 30 * 24 * time.Hour,
+	PublishAhead:     15 * time.Minute,
+	SyncInterval:     time.Minute,
+	Bits:             2048,
+}
+
+func (c Config) WithDefaults() Config {
+	if c.RotationInterval <= 0 {
+		c.RotationInterval = DefaultConfig.RotationInterval
+	}
+	if c.PublishAhead < 0 {
+		c.PublishAhead = DefaultConfig.PublishAhead
+	}
+	if c.SyncInterval <= 0 {
+		c.SyncInterval = DefaultConfig.SyncInterval
+	}
+	if c.Bits <= 0 {
+		c.Bits = DefaultConfig.Bits
+	}
+	return c
+}
+
+// Key - расшифрованный ключ набора.
+type Key struct {
+	ID        string
+	Use       string
+	Private   *rsa.PrivateKey
+	ActiveAt  time.Time
+	ExpiresAt *time.Time
+}
+
+func (k *Key) expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// Keyset - ключи подписи токенов с ротацией. Набор хранится в базе и общий
+// для всех экземпляров сервиса; каждый экземпляр держит его копию в памяти
+// и перечитывает раз в SyncInterval.
+//
+// Жизненный цикл ключа: публикация в JWKS за PublishAhead до начала подписи,
+// подпись в течение RotationInterval, затем проверка уже выданных токенов
+// до их истечения. Окна соседних ключей перекрываются, поэтому ротация не
+// требует одновременного перезапуска identity-service и gateway.
+type Keyset struct {
+	repo   Repository
+	sealer Sealer
+	cfg    Config
+
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+func New(repo Repository, sealer Sealer, cfg Config) *Keyset {
+	return &Keyset{
+		repo:   repo,
+		sealer: sealer,
+		cfg:    cfg.WithDefaults(),
+	}
+}
+
+// Import добавляет существующий ключ (например, из PEM-файла), если ключей
+// такого назначения в наборе еще нет. Так токены, выданные до перехода на
+// набор, остаются действительными до его первой ротации.
+func (s *Keyset) Import(ctx context.Context, use string, private *rsa.PrivateKey) error {
+	records, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if record.Use == use {
+			return nil
+		}
+	}
+
+	record, err := s.newRecord(use, private, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.Create(ctx, record)
+	return err
+}
+
+// Start синхронизирует набор с заданным в конфигурации интервалом до отмены контекста.
+func (s *Keyset) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Sync(ctx); err != nil {
+			log.Printf("Failed to sync signing keys: %v", err)
+		}
+	}
+}
+
+// Sync создает и выводит ключи по расписанию ротации и перечитывает набор.
+// Экземпляры могут выполнять его одновременно: лишний ключ просто будет
+// выведен при следующей синхронизации.
+func (s *Keyset) Sync(ctx context.Context) error {
+	records, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changed := false
+	for _, use := range []string{entity.SigningKeyAccess, entity.SigningKeyRefresh} {
+		rotated, err := s.rotate(ctx, use, records, now)
+		if err != nil {
+			return err
+		}
+		changed = changed || rotated
+	}
+
+	if err := s.repo.DeleteExpired(ctx); err != nil {
+		return err
+	}
+	if changed {
+		if records, err = s.repo.List(ctx); err != nil {
+			return err
+		}
+	}
+	return s.load(records)
+}
+
+func (s *Keyset) rotate(ctx context.Context, use string, records []entity.SigningKey, now time.Time) (bool, error) {
+	var current *entity.SigningKey
+	pending := false
+	for i := range records {
+		record := &records[i]
+		if record.Use != use {
+			continue
+		}
+		if record.ActiveAt.After(now) {
+			pending = true
+			continue
+		}
+		if current == nil || !record.ActiveAt.Before(current.ActiveAt) {
+			current = record
+		}
+	}
+
+	changed := false
+	for i := range records {
+		record := &records[i]
+		if record.Use != use || record == current || record.RetiredAt != nil || record.ActiveAt.After(now) {
+			continue
+		}
+		// Ключ перестал подписывать, когда начал подписывать current.
+		if _, err := s.repo.Retire(ctx, record.KID, current.ActiveAt, current.ActiveAt.Add(s.lifetime(use))); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	if pending {
+		return changed, nil
+	}
+
+	if current != nil && now.Before(current.ActiveAt.Add(s.cfg.RotationInterval-s.cfg.PublishAhead)) {
+		return changed, nil
+	}
+
+	private, err := rsa.GenerateKey(rand.Reader, s.cfg.Bits)
+	if err != nil {
+		return false, err
+	}
+	// Первый ключ подписывает сразу: заменять ему некого.
+	activeAt := time.Now()
+	if current != nil {
+		activeAt = activeAt.Add(s.cfg.PublishAhead)
+	}
+	record, err := s.newRecord(use, private, activeAt)
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.repo.Create(ctx, record); err != nil {
+		return false, err
+	}
+	log.Printf("Generated %s signing key %s, active from %s", use, record.KID, activeAt.Format(time.RFC3339))
+	return true, nil
+}
+
+func (s *Keyset) load(records []entity.SigningKey) error {
+	s.mu.RLock()
+	loaded := make(map[string]*rsa.PrivateKey, len(s.keys))
+	for _, key := range s.keys {
+		loaded[key.ID] = key.Private
+	}
+	s.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(records))
+	for _, record := range records {
+		private, ok := loaded[record.KID]
+		if !ok {
+			var err error
+			if private, err = s.open(record.PrivateKey); err != nil {
+				return fmt.Errorf("signing key %s: %w", record.KID, err)
+			}
+		}
+		keys = append(keys, &Key{
+			ID:        record.KID,
+			Use:       record.Use,
+			Private:   private,
+			ActiveAt:  record.ActiveAt,
+			ExpiresAt: record.ExpiresAt,
+		})
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// SigningKey возвращает ключ, которым сейчас подписываются токены назначения use.
+func (s *Keyset) SigningKey(use string) (*Key, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var current *Key
+	for _, key := range s.keys {
+		if key.Use != use || key.ActiveAt.After(now) || key.expired(now) {
+			continue
+		}
+		if current == nil || !key.ActiveAt.Before(current.ActiveAt) {
+			current = key
+		}
+	}
+	if current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return current, nil
+}
+
+// VerificationKeys возвращает ключ с идентификатором kid, а для токенов без
+// kid (выданных до перехода на набор) - все действующие ключи назначения use.
+func (s *Keyset) VerificationKeys(use, kid string) []*rsa.PublicKey {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []*rsa.PublicKey
+	for _, key := range s.keys {
+		if key.Use != use || key.expired(now) {
+			continue
+		}
+		if kid == "" || key.ID == kid {
+			keys = append(keys, &key.Private.PublicKey)
+		}
+	}
+	return keys
+}
+
+// JWKS возвращает открытые ключи назначения use, включая еще не начавшие
+// подписывать и уже выведенные, но действующие.
+func (s *Keyset) JWKS(use string) JWKS {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if key.Use == use && !key.expired(now) {
+			set.Keys = append(set.Keys, NewJWK(key.ID, &key.Private.PublicKey))
+		}
+	}
+	return set
+}
+
+func (s *Keyset) lifetime(use string) time.Duration {
+	return s.cfg.Lifetimes[use] + clockSkew
+}
+
+func (s *Keyset) newRecord(use string, private *rsa.PrivateKey, activeAt time.Time) (*entity.SigningKey, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.sealer.Seal(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.SigningKey{
+		KID:        Thumbprint(&private.PublicKey),
+		Use:        use,
+		Algorithm:  Algorithm,
+		PrivateKey: sealed,
+		CreatedAt:  time.Now(),
+		ActiveAt:   activeAt,
+	}, nil
+}
+
+func (s *Keyset) open(stored string) (*rsa.PrivateKey, error) {
+	data, err := s.sealer.Open(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block with private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not RSA private key")
+	}
+	return private, nil
+}
@@ -0,0 +1,114 @@
+package keyset_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/crypto/secretbox"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/keyset"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupKeysetTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.SigningKey{}))
+	return db
+}
+
+func newTestKeyset(t *testing.T, repo *postgres.SigningKeyRepository, cfg keyset.Config) *keyset.Keyset {
+	sealer, err := secretbox.New([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+
+	cfg.Lifetimes = map[string]time.Duration{entity.SigningKeyAccess: time.Hour, entity.SigningKeyRefresh: time.Hour}
+	return keyset.New(repo, sealer, cfg)
+}
+
+func TestSync_Bootstrap(t *testing.T) {
+	ctx := context.TODO()
+	db := setupKeysetTestDB(t)
+	repo := postgres.NewSigningKeyRepository(db)
+	keys := newTestKeyset(t, repo, keyset.Config{})
+
+	require.NoError(t, keys.Sync(ctx))
+
+	access, err := keys.SigningKey(entity.SigningKeyAccess)
+	require.NoError(t, err)
+	refresh, err := keys.SigningKey(entity.SigningKeyRefresh)
+	require.NoError(t, err)
+	require.NotEqual(t, access.ID, refresh.ID)
+
+	jwks := keys.JWKS(entity.SigningKeyAccess)
+	require.Len(t, jwks.Keys, 1, "ключи refresh токенов не публикуются")
+	require.Equal(t, access.ID, jwks.Keys[0].Kid)
+	require.Equal(t, "RS256", jwks.Keys[0].Alg)
+
+	var stored entity.SigningKey
+	require.NoError(t, db.Where("kid = ?", access.ID).First(&stored).Error)
+	require.True(t, strings.HasPrefix(stored.PrivateKey, "v1:"), "закрытый ключ должен храниться зашифрованным")
+
+	// Другой экземпляр получает тот же набор, а не создает свой.
+	replica := newTestKeyset(t, repo, keyset.Config{})
+	require.NoError(t, replica.Sync(ctx))
+	replicaAccess, err := replica.SigningKey(entity.SigningKeyAccess)
+	require.NoError(t, err)
+	require.Equal(t, access.ID, replicaAccess.ID)
+}
+
+func TestSync_RotationOverlap(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewSigningKeyRepository(setupKeysetTestDB(t))
+	keys := newTestKeyset(t, repo, keyset.Config{RotationInterval: time.Second, PublishAhead: time.Second})
+
+	require.NoError(t, keys.Sync(ctx))
+	first, err := keys.SigningKey(entity.SigningKeyAccess)
+	require.NoError(t, err)
+
+	// Срок ротации наступил: новый ключ публикуется, но еще не подписывает.
+	require.NoError(t, keys.Sync(ctx))
+	current, err := keys.SigningKey(entity.SigningKeyAccess)
+	require.NoError(t, err)
+	require.Equal(t, first.ID, current.ID)
+	require.Len(t, keys.JWKS(entity.SigningKeyAccess).Keys, 2)
+
+	time.Sleep(time.Second)
+	require.NoError(t, keys.Sync(ctx))
+
+	second, err := keys.SigningKey(entity.SigningKeyAccess)
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID, "опубликованный заранее ключ должен начать подписывать")
+	require.Len(t, keys.VerificationKeys(entity.SigningKeyAccess, first.ID), 1,
+		"выведенный ключ должен проверять выданные им токены")
+	require.Empty(t, keys.VerificationKeys(entity.SigningKeyRefresh, first.ID))
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewSigningKeyRepository(setupKeysetTestDB(t))
+	keys := newTestKeyset(t, repo, keyset.Config{})
+
+	legacy, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	require.NoError(t, keys.Import(ctx, entity.SigningKeyAccess, legacy))
+	require.NoError(t, keys.Sync(ctx))
+
+	access, err := keys.SigningKey(entity.SigningKeyAccess)
+	require.NoError(t, err)
+	require.Equal(t, keyset.Thumbprint(&legacy.PublicKey), access.ID)
+	require.Len(t, keys.VerificationKeys(entity.SigningKeyAccess, ""), 1, "токены без kid проверяются импортированным ключом")
+
+	// В непустой набор ключ больше не импортируется.
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	require.NoError(t, keys.Import(ctx, entity.SigningKeyAccess, other))
+	require.NoError(t, keys.Sync(ctx))
+	require.Len(t, keys.JWKS(entity.SigningKeyAccess).Keys, 1)
+}
@@ -2,8 +2,11 @@ package mocks
 
 import (
 	"context"
+	"time"
+
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/usecase"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -42,19 +45,34 @@ func (m *IdentityUseCaseMock) Logout(ctx context.Context, token string) error {
 	return args.Error(0)
 }
 
-func (m *IdentityUseCaseMock) ResetPassword(ctx context.Context, email, newPassword string) error {
-	args := m.Called(ctx, email, newPassword)
+func (m *IdentityUseCaseMock) ResetPassword(ctx context.Context, email, code, newPassword string) error {
+	args := m.Called(ctx, email, code, newPassword)
 	return args.Error(0)
 }
 
-func (m *IdentityUseCaseMock) AddVerificationCode(ctx context.Context, email, code string) error {
-	args := m.Called(ctx, email, code)
+func (m *IdentityUseCaseMock) AddVerificationCode(ctx context.Context, email string, purpose entity.VerificationPurpose, code string) error {
+	args := m.Called(ctx, email, purpose, code)
 	return args.Error(0)
 }
 
-func (m *IdentityUseCaseMock) VerifyCode(ctx context.Context, email, code string) (bool, error) {
-	args := m.Called(ctx, email, code)
-	return args.Bool(0), args.Error(1)
+func (m *IdentityUseCaseMock) RequestEmailChange(ctx context.Context, userUUID, newEmail, code string) error {
+	args := m.Called(ctx, userUUID, newEmail, code)
+	return args.Error(0)
+}
+
+func (m *IdentityUseCaseMock) DiscardVerificationCode(ctx context.Context, email string, purpose entity.VerificationPurpose, code string) error {
+	args := m.Called(ctx, email, purpose, code)
+	return args.Error(0)
+}
+
+func (m *IdentityUseCaseMock) DiscardEmailChangeCode(ctx context.Context, userUUID, code string) error {
+	args := m.Called(ctx, userUUID, code)
+	return args.Error(0)
+}
+
+func (m *IdentityUseCaseMock) ChangeEmail(ctx context.Context, userUUID, code string) error {
+	args := m.Called(ctx, userUUID, code)
+	return args.Error(0)
 }
 
 func (m *IdentityUseCaseMock) ValidateToken(ctx context.Context, token string, isRefreshToken bool) (string, error) {
@@ -67,6 +85,16 @@ func (m *IdentityUseCaseMock) ConfirmEmail(ctx context.Context, email, code stri
 	return args.Error(0)
 }
 
+func (m *IdentityUseCaseMock) RevokedTokensSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]entity.BlacklistedToken), args.Error(1)
+}
+
+func (m *IdentityUseCaseMock) DisabledIdentities(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
 // VerificationServiceMock мокирует интерфейс VerificationService
 type VerificationServiceMock struct {
 	mock.Mock
@@ -77,8 +105,8 @@ func (m *VerificationServiceMock) GenerateVerificationCode() string {
 	return args.String(0)
 }
 
-func (m *VerificationServiceMock) SendVerificationCode(email, code string) error {
-	args := m.Called(email, code)
+func (m *VerificationServiceMock) SendVerificationCode(email, code string, purpose entity.VerificationPurpose, locale string) error {
+	args := m.Called(email, code, purpose, locale)
 	return args.Error(0)
 }
 
@@ -97,7 +125,217 @@ func (m *TokenRepositoryMock) IsBlacklisted(ctx context.Context, token string) (
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *TokenRepositoryMock) ListRevokedSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).([]entity.BlacklistedToken), args.Error(1)
+}
+
 func (m *TokenRepositoryMock) CleanupExpired(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
 }
+
+// OIDCUseCaseMock мокирует интерфейс OIDCUseCase
+type OIDCUseCaseMock struct {
+	mock.Mock
+}
+
+func (m *OIDCUseCaseMock) Providers() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
+
+func (m *OIDCUseCaseMock) Start(ctx context.Context, providerName, linkUserUUID string) (string, error) {
+	args := m.Called(ctx, providerName, linkUserUUID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *OIDCUseCaseMock) Callback(ctx context.Context, providerName, code, state, userUUID string) (*usecase.OIDCResult, error) {
+	args := m.Called(ctx, providerName, code, state, userUUID)
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*usecase.OIDCResult), args.Error(1)
+}
+
+func (m *OIDCUseCaseMock) Unlink(ctx context.Context, userUUID, providerName string) error {
+	args := m.Called(ctx, userUUID, providerName)
+	return args.Error(0)
+}
+
+func (m *OIDCUseCaseMock) Accounts(ctx context.Context, userUUID string) ([]entity.ExternalAccount, error) {
+	args := m.Called(ctx, userUUID)
+	return args.Get(0).([]entity.ExternalAccount), args.Error(1)
+}
+
+// MFAUseCaseMock мокирует интерфейс MFAUseCase
+type MFAUseCaseMock struct {
+	mock.Mock
+}
+
+func (m *MFAUseCaseMock) Verify(ctx context.Context, token, code string) (*usecase.Tokens, error) {
+	args := m.Called(ctx, token, code)
+	tokens := args.Get(0)
+	if tokens == nil {
+		return nil, args.Error(1)
+	}
+	return tokens.(*usecase.Tokens), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) Status(ctx context.Context, userUUID string) (*usecase.MFAStatus, error) {
+	args := m.Called(ctx, userUUID)
+	status := args.Get(0)
+	if status == nil {
+		return nil, args.Error(1)
+	}
+	return status.(*usecase.MFAStatus), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) Enroll(ctx context.Context, userUUID string) (*usecase.TOTPEnrollment, error) {
+	args := m.Called(ctx, userUUID)
+	enrollment := args.Get(0)
+	if enrollment == nil {
+		return nil, args.Error(1)
+	}
+	return enrollment.(*usecase.TOTPEnrollment), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) ConfirmEnrollment(ctx context.Context, userUUID, code string) ([]string, error) {
+	args := m.Called(ctx, userUUID, code)
+	codes := args.Get(0)
+	if codes == nil {
+		return nil, args.Error(1)
+	}
+	return codes.([]string), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) Disable(ctx context.Context, userUUID, code string) error {
+	args := m.Called(ctx, userUUID, code)
+	return args.Error(0)
+}
+
+func (m *MFAUseCaseMock) RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) ([]string, error) {
+	args := m.Called(ctx, userUUID, code)
+	codes := args.Get(0)
+	if codes == nil {
+		return nil, args.Error(1)
+	}
+	return codes.([]string), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) EnrollmentUser(ctx context.Context, token string) (string, error) {
+	args := m.Called(ctx, token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) CompleteEnrollment(ctx context.Context, token string) (*usecase.Tokens, error) {
+	args := m.Called(ctx, token)
+	tokens := args.Get(0)
+	if tokens == nil {
+		return nil, args.Error(1)
+	}
+	return tokens.(*usecase.Tokens), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) Policies(ctx context.Context) ([]entity.MFARolePolicy, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]entity.MFARolePolicy), args.Error(1)
+}
+
+func (m *MFAUseCaseMock) SetPolicy(ctx context.Context, role string, required bool, updatedBy string) error {
+	args := m.Called(ctx, role, required, updatedBy)
+	return args.Error(0)
+}
+
+// SessionUseCaseMock мокирует интерфейс SessionUseCase
+type SessionUseCaseMock struct {
+	mock.Mock
+}
+
+func (m *SessionUseCaseMock) List(ctx context.Context, userUUID string) ([]entity.Session, error) {
+	args := m.Called(ctx, userUUID)
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *SessionUseCaseMock) CurrentSession(accessToken string) string {
+	args := m.Called(accessToken)
+	return args.String(0)
+}
+
+func (m *SessionUseCaseMock) Revoke(ctx context.Context, userUUID, sessionID string) error {
+	args := m.Called(ctx, userUUID, sessionID)
+	return args.Error(0)
+}
+
+func (m *SessionUseCaseMock) RevokeAll(ctx context.Context, userUUID, exceptSessionID string) error {
+	args := m.Called(ctx, userUUID, exceptSessionID)
+	return args.Error(0)
+}
+
+// LockoutUseCaseMock мокирует интерфейс LockoutUseCase
+type LockoutUseCaseMock struct {
+	mock.Mock
+}
+
+func (m *LockoutUseCaseMock) Locked(ctx context.Context, kind string) ([]entity.FailureCounter, error) {
+	args := m.Called(ctx, kind)
+	return args.Get(0).([]entity.FailureCounter), args.Error(1)
+}
+
+func (m *LockoutUseCaseMock) Unlock(ctx context.Context, actorUUID, kind, key string) error {
+	args := m.Called(ctx, actorUUID, kind, key)
+	return args.Error(0)
+}
+
+func (m *LockoutUseCaseMock) Events(ctx context.Context, kind, key string, limit int) ([]entity.LockoutEvent, error) {
+	args := m.Called(ctx, kind, key, limit)
+	return args.Get(0).([]entity.LockoutEvent), args.Error(1)
+}
+
+// AdminUseCaseMock мокирует интерфейс AdminUseCase
+type AdminUseCaseMock struct {
+	mock.Mock
+}
+
+func (m *AdminUseCaseMock) List(ctx context.Context, filter entity.IdentityFilter) (*usecase.IdentityPage, error) {
+	args := m.Called(ctx, filter)
+	page := args.Get(0)
+	if page == nil {
+		return nil, args.Error(1)
+	}
+	return page.(*usecase.IdentityPage), args.Error(1)
+}
+
+func (m *AdminUseCaseMock) Get(ctx context.Context, userUUID string) (*entity.Identity, error) {
+	args := m.Called(ctx, userUUID)
+	return identityResult(args)
+}
+
+func (m *AdminUseCaseMock) SetRole(ctx context.Context, actorUUID, userUUID, role string) (*entity.Identity, error) {
+	args := m.Called(ctx, actorUUID, userUUID, role)
+	return identityResult(args)
+}
+
+func (m *AdminUseCaseMock) Disable(ctx context.Context, actorUUID, userUUID string) (*entity.Identity, error) {
+	args := m.Called(ctx, actorUUID, userUUID)
+	return identityResult(args)
+}
+
+func (m *AdminUseCaseMock) Enable(ctx context.Context, actorUUID, userUUID string) (*entity.Identity, error) {
+	args := m.Called(ctx, actorUUID, userUUID)
+	return identityResult(args)
+}
+
+func (m *AdminUseCaseMock) Logout(ctx context.Context, actorUUID, userUUID string) error {
+	args := m.Called(ctx, actorUUID, userUUID)
+	return args.Error(0)
+}
+
+func identityResult(args mock.Arguments) (*entity.Identity, error) {
+	identity := args.Get(0)
+	if identity == nil {
+		return nil, args.Error(1)
+	}
+	return identity.(*entity.Identity), args.Error(1)
+}
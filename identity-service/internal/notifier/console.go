@@ -0,0 +1,27 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ConsoleNotifier записывает текстовую версию писем в w (stdout или файл)
+// вместо отправки. Используется при разработке.
+type ConsoleNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewConsoleNotifier(w io.Writer) *ConsoleNotifier {
+	return &ConsoleNotifier{w: w}
+}
+
+func (n *ConsoleNotifier) Send(_ context.Context, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.w, "--- email %s\nTo: %s\nSubject: %s\n\n%s\n---\n", msg.Template, msg.To, msg.Subject, msg.Text)
+	return err
+}
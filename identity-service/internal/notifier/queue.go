@@ -0,0 +1,163 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/pkg/metrics"
+)
+
+var (
+	ErrQueueFull   = errors.New("notification queue is full")
+	ErrQueueClosed = errors.New("notification queue is closed")
+)
+
+// QueueConfig - размер очереди писем, число отправителей и повторы при ошибках.
+// Нулевые поля заменяются значениями по умолчанию.
+type QueueConfig struct {
+	Size        int
+	Workers     int
+	MaxAttempts int
+	// Backoff - пауза перед второй попыткой, дальше она удваивается.
+	Backoff time.Duration
+	// SendTimeout ограничивает одну попытку отправки.
+	SendTimeout time.Duration
+}
+
+var DefaultQueueConfig = QueueConfig{
+	Size:        1000,
+	Workers:     2,
+	MaxAttempts: 5,
+	Backoff:     2 * time.Second,
+	SendTimeout: 30 * time.Second,
+}
+
+func (c QueueConfig) withDefaults() QueueConfig {
+	if c.Size <= 0 {
+		c.Size = DefaultQueueConfig.Size
+	}
+	if c.Workers <= 0 {
+		c.Workers = DefaultQueueConfig.Workers
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultQueueConfig.MaxAttempts
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = DefaultQueueConfig.Backoff
+	}
+	if c.SendTimeout <= 0 {
+		c.SendTimeout = DefaultQueueConfig.SendTimeout
+	}
+	return c
+}
+
+// Queue отправляет письма в фоне через Notifier с повторами и журналом доставки,
+// чтобы обработчики запросов не ждали SMTP сервер.
+type Queue struct {
+	notifier Notifier
+	cfg      QueueConfig
+
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan Message
+	wg     sync.WaitGroup
+}
+
+func NewQueue(notifier Notifier, cfg QueueConfig) *Queue {
+	cfg = cfg.withDefaults()
+	return &Queue{
+		notifier: notifier,
+		cfg:      cfg,
+		jobs:     make(chan Message, cfg.Size),
+	}
+}
+
+// Start запускает отправителей. Отмена ctx прерывает паузы между попытками.
+func (q *Queue) Start(ctx context.Context) {
+	for i := 0; i < q.cfg.Workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for msg := range q.jobs {
+				q.deliver(ctx, msg)
+			}
+		}()
+	}
+}
+
+// Enqueue ставит письмо в очередь без ожидания. Если очередь заполнена,
+// письмо не принимается.
+func (q *Queue) Enqueue(msg Message) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	select {
+	case q.jobs <- msg:
+		return nil
+	default:
+		metrics.ObserveNotification(msg.Template, metrics.NotificationDropped)
+		log.Printf("Notification %s to %s dropped: queue is full", msg.Template, maskEmail(msg.To))
+		return ErrQueueFull
+	}
+}
+
+// Close перестает принимать письма и ждет отправки уже поставленных,
+// но не дольше, чем живет ctx.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, msg Message) {
+	backoff := q.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), q.cfg.SendTimeout)
+		err := q.notifier.Send(sendCtx, msg)
+		cancel()
+
+		if err == nil {
+			metrics.ObserveNotification(msg.Template, metrics.NotificationSent)
+			log.Printf("Notification %s delivered to %s (attempt %d)", msg.Template, maskEmail(msg.To), attempt)
+			return
+		}
+		if attempt >= q.cfg.MaxAttempts {
+			metrics.ObserveNotification(msg.Template, metrics.NotificationFailed)
+			log.Printf("Notification %s to %s failed after %d attempts: %v", msg.Template, maskEmail(msg.To), attempt, err)
+			return
+		}
+		log.Printf("Notification %s to %s failed (attempt %d/%d), retrying in %s: %v",
+			msg.Template, maskEmail(msg.To), attempt, q.cfg.MaxAttempts, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			metrics.ObserveNotification(msg.Template, metrics.NotificationFailed)
+			log.Printf("Notification %s to %s abandoned: %v", msg.Template, maskEmail(msg.To), ctx.Err())
+			return
+		}
+	}
+}
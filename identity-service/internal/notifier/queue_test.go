@@ -0,0 +1,71 @@
+package notifier_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/notifier"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyNotifier отвечает ошибкой на первые failures попыток.
+type flakyNotifier struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []notifier.Message
+}
+
+func (n *flakyNotifier) Send(_ context.Context, msg notifier.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.attempts++
+	if n.attempts <= n.failures {
+		return errors.New("temporary failure")
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestQueue_RetriesUntilDelivered(t *testing.T) {
+	n := &flakyNotifier{failures: 2}
+	q := notifier.NewQueue(n, notifier.QueueConfig{Workers: 1, MaxAttempts: 3, Backoff: time.Millisecond})
+	q.Start(context.Background())
+
+	require.NoError(t, q.Enqueue(testMessage()))
+	require.NoError(t, q.Close(context.Background()))
+
+	require.Equal(t, 3, n.attempts)
+	require.Len(t, n.sent, 1)
+}
+
+func TestQueue_GivesUpAfterMaxAttempts(t *testing.T) {
+	n := &flakyNotifier{failures: 10}
+	q := notifier.NewQueue(n, notifier.QueueConfig{Workers: 1, MaxAttempts: 3, Backoff: time.Millisecond})
+	q.Start(context.Background())
+
+	require.NoError(t, q.Enqueue(testMessage()))
+	require.NoError(t, q.Close(context.Background()))
+
+	require.Equal(t, 3, n.attempts)
+	require.Empty(t, n.sent)
+}
+
+func TestQueue_Full(t *testing.T) {
+	q := notifier.NewQueue(notifier.NewMemoryNotifier(), notifier.QueueConfig{Size: 1})
+
+	require.NoError(t, q.Enqueue(testMessage()))
+	require.ErrorIs(t, q.Enqueue(testMessage()), notifier.ErrQueueFull)
+}
+
+func TestQueue_Closed(t *testing.T) {
+	q := notifier.NewQueue(notifier.NewMemoryNotifier(), notifier.QueueConfig{})
+	q.Start(context.Background())
+	require.NoError(t, q.Close(context.Background()))
+
+	require.ErrorIs(t, q.Enqueue(testMessage()), notifier.ErrQueueClosed)
+}
@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+
+	clientSmtp "github.com/JojoWeyn/duo-proj/identity-service/pkg/client/smtp"
+)
+
+// smtpDialTimeout ограничивает подключение, если у контекста нет дедлайна.
+const smtpDialTimeout = 10 * time.Second
+
+// SMTPNotifier отправляет письма через SMTP сервер с проверкой его сертификата.
+type SMTPNotifier struct {
+	cfg *clientSmtp.SMTPConfig
+}
+
+func NewSMTPNotifier(cfg *clientSmtp.SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg}
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	body, err := buildMessage(n.cfg.Sender, msg)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: n.cfg.Server,
+		RootCAs:    n.cfg.RootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	conn, err := n.dial(ctx, tlsConfig)
+	if err != nil {
+		return fmt.Errorf("error connecting to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Server)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("error creating SMTP client: %w", err)
+	}
+	defer client.Close()
+
+	if n.cfg.Security == clientSmtp.SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+
+	if n.cfg.Password != "" {
+		auth := smtp.PlainAuth("", n.cfg.Sender, n.cfg.Password, n.cfg.Server)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.cfg.Sender); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("failed to set recipient: %w", err)
+	}
+
+	wc, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to get data writer: %w", err)
+	}
+	if _, err := wc.Write(body); err != nil {
+		wc.Close()
+		return fmt.Errorf("failed to write email content: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("failed to close data writer: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (n *SMTPNotifier) dial(ctx context.Context, tlsConfig *tls.Config) (net.Conn, error) {
+	addr := net.JoinHostPort(n.cfg.Server, n.cfg.Port)
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpDialTimeout)
+		defer cancel()
+	}
+
+	switch n.cfg.Security {
+	case clientSmtp.SecurityTLS:
+		dialer := &tls.Dialer{Config: tlsConfig}
+		return dialer.DialContext(ctx, "tcp", addr)
+	case clientSmtp.SecurityStartTLS, clientSmtp.SecurityNone:
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("unknown SMTP security mode %q", n.cfg.Security)
+	}
+}
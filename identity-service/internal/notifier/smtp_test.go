@@ -0,0 +1,269 @@
+package notifier_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/notifier"
+	clientSmtp "github.com/JojoWeyn/duo-proj/identity-service/pkg/client/smtp"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer - минимальный SMTP сервер для тестов: принимает одно
+// соединение и записывает получателей и тело письма.
+type fakeSMTPServer struct {
+	listener   net.Listener
+	tlsConfig  *tls.Config
+	rejectAuth bool
+	rejectRcpt bool
+
+	mu         sync.Mutex
+	recipients []string
+	data       string
+	startedTLS bool
+	done       chan struct{}
+}
+
+func startFakeSMTPServer(t *testing.T, configure func(s *fakeSMTPServer)) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "failed to start fake SMTP server")
+
+	s := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	if configure != nil {
+		configure(s)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		defer close(s.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) config(security string) *clientSmtp.SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return clientSmtp.NewSMTPClient(clientSmtp.SMTPConfig{
+		Server:   host,
+		Port:     port,
+		Sender:   "test@kozhura.com",
+		Password: "testpass",
+		Security: security,
+	})
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			if s.tlsConfig != nil && !s.startedTLS {
+				tp.PrintfLine("250-localhost")
+				tp.PrintfLine("250-STARTTLS")
+			} else {
+				tp.PrintfLine("250-localhost")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			s.mu.Lock()
+			s.startedTLS = true
+			s.mu.Unlock()
+		case "AUTH":
+			if s.rejectAuth {
+				tp.PrintfLine("535 authentication credentials invalid")
+			} else {
+				tp.PrintfLine("235 authentication successful")
+			}
+		case "MAIL":
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			if s.rejectRcpt {
+				tp.PrintfLine("550 mailbox unavailable")
+				continue
+			}
+			s.mu.Lock()
+			s.recipients = append(s.recipients, arg)
+			s.mu.Unlock()
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			lines, err := tp.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = strings.Join(lines, "\n")
+			s.mu.Unlock()
+			tp.PrintfLine("250 ok")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) wait(t *testing.T) {
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server did not finish")
+	}
+}
+
+func testMessage() notifier.Message {
+	return notifier.Message{
+		To:       "recipient@example.com",
+		Subject:  "Kozhura: код подтверждения",
+		Text:     "Код: 123456\n",
+		HTML:     "<p>Код: <b>123456</b></p>",
+		Template: "verification_code",
+	}
+}
+
+// --- SMTPNotifier ---
+
+func TestSMTPNotifier_Success(t *testing.T) {
+	server := startFakeSMTPServer(t, nil)
+	n := notifier.NewSMTPNotifier(server.config(clientSmtp.SecurityNone))
+
+	err := n.Send(context.Background(), testMessage())
+	require.NoError(t, err, "should send email successfully")
+	server.wait(t)
+
+	require.Equal(t, []string{"TO:<recipient@example.com>"}, server.recipients)
+	require.Contains(t, server.data, "multipart/alternative")
+	require.Contains(t, server.data, "Content-Type: text/plain; charset=UTF-8")
+	require.Contains(t, server.data, "Content-Type: text/html; charset=UTF-8")
+	require.Contains(t, server.data, "Subject: =?utf-8?q?")
+}
+
+func TestSMTPNotifier_StartTLS(t *testing.T) {
+	cert, roots := selfSignedCertificate(t)
+	server := startFakeSMTPServer(t, func(s *fakeSMTPServer) {
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	})
+	cfg := server.config(clientSmtp.SecurityStartTLS)
+	cfg.RootCAs = roots
+
+	err := notifier.NewSMTPNotifier(cfg).Send(context.Background(), testMessage())
+	require.NoError(t, err, "should send email over STARTTLS")
+	server.wait(t)
+
+	require.True(t, server.startedTLS)
+	require.Len(t, server.recipients, 1)
+}
+
+func TestSMTPNotifier_StartTLSUntrustedCertificate(t *testing.T) {
+	cert, _ := selfSignedCertificate(t)
+	server := startFakeSMTPServer(t, func(s *fakeSMTPServer) {
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	})
+
+	err := notifier.NewSMTPNotifier(server.config(clientSmtp.SecurityStartTLS)).Send(context.Background(), testMessage())
+	require.Error(t, err, "should not trust self-signed certificate")
+	require.Contains(t, err.Error(), "STARTTLS failed")
+}
+
+func TestSMTPNotifier_StartTLSNotSupported(t *testing.T) {
+	server := startFakeSMTPServer(t, nil)
+
+	err := notifier.NewSMTPNotifier(server.config(clientSmtp.SecurityStartTLS)).Send(context.Background(), testMessage())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not support STARTTLS")
+}
+
+func TestSMTPNotifier_InvalidRecipient(t *testing.T) {
+	server := startFakeSMTPServer(t, func(s *fakeSMTPServer) { s.rejectRcpt = true })
+
+	err := notifier.NewSMTPNotifier(server.config(clientSmtp.SecurityNone)).Send(context.Background(), testMessage())
+	require.Error(t, err, "should fail with invalid recipient")
+	require.Contains(t, err.Error(), "failed to set recipient", "should mention recipient error")
+}
+
+func TestSMTPNotifier_AuthFailure(t *testing.T) {
+	server := startFakeSMTPServer(t, func(s *fakeSMTPServer) { s.rejectAuth = true })
+
+	err := notifier.NewSMTPNotifier(server.config(clientSmtp.SecurityNone)).Send(context.Background(), testMessage())
+	require.Error(t, err, "should fail authentication")
+	require.Contains(t, err.Error(), "authentication failed", "should mention authentication error")
+}
+
+func TestSMTPNotifier_ConnectionFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	cfg := clientSmtp.NewSMTPClient(clientSmtp.SMTPConfig{Server: host, Port: port, Sender: "test@kozhura.com"})
+	err = notifier.NewSMTPNotifier(cfg).Send(context.Background(), testMessage())
+	require.Error(t, err, "should fail to connect")
+	require.Contains(t, err.Error(), "error connecting to SMTP server", "should mention connection error")
+}
+
+func TestSMTPNotifier_HeaderInjection(t *testing.T) {
+	msg := testMessage()
+	msg.Subject = "hello\r\nBcc: victim@example.com"
+
+	cfg := clientSmtp.NewSMTPClient(clientSmtp.SMTPConfig{Server: "127.0.0.1", Port: "1"})
+	err := notifier.NewSMTPNotifier(cfg).Send(context.Background(), msg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line breaks")
+}
+
+func selfSignedCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	parsed, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(parsed)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, roots
+}
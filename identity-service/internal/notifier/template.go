@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Templates - шаблоны писем. Для письма name на языке locale нужны файлы
+// templates/<name>.<locale>.txt с блоком "subject" и templates/<name>.<locale>.html.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func NewTemplates() (*Templates, error) {
+	t := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	files, err := fs.Glob(templateFS, "templates/*")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := path.Base(file)
+		key := strings.TrimSuffix(name, path.Ext(name))
+		switch path.Ext(name) {
+		case ".txt":
+			tmpl, err := texttemplate.ParseFS(templateFS, file)
+			if err != nil {
+				return nil, err
+			}
+			if tmpl.Lookup("subject") == nil {
+				return nil, fmt.Errorf("template %s: subject is not defined", name)
+			}
+			t.text[key] = tmpl
+		case ".html":
+			tmpl, err := htmltemplate.ParseFS(templateFS, file)
+			if err != nil {
+				return nil, err
+			}
+			t.html[key] = tmpl
+		}
+	}
+
+	for key := range t.text {
+		if t.html[key] == nil {
+			return nil, fmt.Errorf("template %s: html version is missing", key)
+		}
+	}
+	return t, nil
+}
+
+// Render собирает письмо по шаблону name. Если перевода на locale нет,
+// используется DefaultLocale.
+func (t *Templates) Render(name, locale string, data any) (Message, error) {
+	key := name + "." + locale
+	if t.text[key] == nil {
+		key = name + "." + DefaultLocale
+	}
+	text, html := t.text[key], t.html[key]
+	if text == nil {
+		return Message{}, fmt.Errorf("template %s not found", name)
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.Execute(&textBody, data); err != nil {
+		return Message{}, err
+	}
+	if err := html.Execute(&htmlBody, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject:  strings.TrimSpace(subject.String()),
+		Text:     strings.TrimSpace(textBody.String()) + "\n",
+		HTML:     htmlBody.String(),
+		Template: name,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package notifier_test
+
+import (
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/notifier"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := map[string]string{
+		"":                        notifier.LocaleRU,
+		"en":                      notifier.LocaleEN,
+		"en-US,en;q=0.9":          notifier.LocaleEN,
+		"de-DE,ru;q=0.8,en;q=0.5": notifier.LocaleRU,
+		"ru;q=0.3, EN-GB;q=0.7":   notifier.LocaleEN,
+		"fr":                      notifier.LocaleRU,
+		"en;q=invalid, ru;q=0.1":  notifier.LocaleRU,
+	}
+	for header, want := range tests {
+		require.Equal(t, want, notifier.ParseLocale(header), header)
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := notifier.NewTemplates()
+	require.NoError(t, err)
+
+	data := map[string]any{"Code": "<123456>", "Purpose": "reset_password", "ExpiresInMinutes": 15}
+
+	ru, err := templates.Render("verification_code", notifier.LocaleRU, data)
+	require.NoError(t, err)
+	require.Equal(t, "Kozhura: код для сброса пароля", ru.Subject)
+	require.Contains(t, ru.Text, "<123456>")
+	require.Contains(t, ru.Text, "15 мин.")
+	require.Contains(t, ru.HTML, "&lt;123456&gt;", "html version should be escaped")
+	require.Equal(t, "verification_code", ru.Template)
+
+	en, err := templates.Render("verification_code", notifier.LocaleEN, data)
+	require.NoError(t, err)
+	require.Equal(t, "Kozhura: password reset code", en.Subject)
+	require.Contains(t, en.HTML, `lang="en"`)
+}
+
+func TestTemplates_RenderFallsBackToDefaultLocale(t *testing.T) {
+	templates, err := notifier.NewTemplates()
+	require.NoError(t, err)
+
+	msg, err := templates.Render("verification_code", "de", map[string]any{"Code": "123456", "Purpose": "confirm_email"})
+	require.NoError(t, err)
+	require.Equal(t, "Kozhura: код подтверждения email", msg.Subject)
+
+	_, err = templates.Render("unknown", notifier.LocaleRU, nil)
+	require.Error(t, err)
+}
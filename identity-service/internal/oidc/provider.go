@@ -0,0 +1,324 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// maxResponseBody ограничивает ответы провайдера.
+const maxResponseBody = 1 << 20
+
+var (
+	ErrInvalidIDToken   = errors.New("invalid id token")
+	ErrProviderResponse = errors.New("unexpected response from identity provider")
+)
+
+// Config - настройки клиента у провайдера. Эндпоинты берутся из
+// <Issuer>/.well-known/openid-configuration, явно заданные URL заменяют их
+// (для провайдеров без discovery).
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	JWKSURL     string
+}
+
+// UserInfo - пользователь провайдера.
+type UserInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type endpoints struct {
+	Issuer      string `json:"issuer"`
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+	JWKSURL     string `json:"jwks_uri"`
+}
+
+// Provider выполняет вход через OpenID Connect провайдера по коду авторизации с PKCE.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *endpoints
+	keys      *keySet
+}
+
+func NewProvider(cfg Config, client *http.Client) (*Provider, error) {
+	if cfg.Name == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, errors.New("oidc provider requires name, client id and redirect url")
+	}
+	if cfg.Issuer == "" && (cfg.AuthURL == "" || cfg.TokenURL == "") {
+		return nil, fmt.Errorf("oidc provider %s: issuer or auth and token urls are required", cfg.Name)
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}, nil
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL - адрес страницы входа провайдера. state и nonce связывают ответ
+// с запросом, verifier - секрет PKCE, провайдер получает только его хэш.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(ep.AuthURL)
+	if err != nil {
+		return "", err
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+}
+
+// Exchange обменивает код на токены и возвращает пользователя из проверенного
+// ID токена. Если в токене нет email, он запрашивается у userinfo.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*UserInfo, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens tokenResponse
+	if err := doJSON(p.client, req, &tokens); err != nil {
+		return nil, fmt.Errorf("code exchange failed: %w", err)
+	}
+
+	var user *UserInfo
+	if tokens.IDToken != "" {
+		if user, err = p.verifyIDToken(ctx, ep, tokens.IDToken, nonce); err != nil {
+			return nil, err
+		}
+	} else if ep.UserInfoURL == "" {
+		return nil, fmt.Errorf("%w: id token is missing", ErrProviderResponse)
+	}
+
+	if (user == nil || user.Email == "") && ep.UserInfoURL != "" && tokens.AccessToken != "" {
+		info, err := p.userInfo(ctx, ep, tokens.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && info.Subject != user.Subject {
+			return nil, fmt.Errorf("%w: userinfo subject mismatch", ErrProviderResponse)
+		}
+		user = info
+	}
+	if user == nil || user.Subject == "" {
+		return nil, fmt.Errorf("%w: subject is missing", ErrProviderResponse)
+	}
+	return user, nil
+}
+
+type idTokenClaims struct {
+	Nonce         string       `json:"nonce"`
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	jwt.RegisteredClaims
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, ep *endpoints, rawToken, nonce string) (*UserInfo, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keySet(ep).key(ctx, kid, token.Method)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Issuer != ep.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidIDToken)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: exp is missing", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return &UserInfo{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}, nil
+}
+
+func (p *Provider) userInfo(ctx context.Context, ep *endpoints, accessToken string) (*UserInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var info struct {
+		Subject       string       `json:"sub"`
+		Email         string       `json:"email"`
+		EmailVerified flexibleBool `json:"email_verified"`
+	}
+	if err := doJSON(p.client, req, &info); err != nil {
+		return nil, fmt.Errorf("userinfo request failed: %w", err)
+	}
+
+	return &UserInfo{Subject: info.Subject, Email: info.Email, EmailVerified: bool(info.EmailVerified)}, nil
+}
+
+// discover загружает эндпоинты провайдера при первом обращении, чтобы
+// недоступный провайдер не мешал запуску сервиса.
+func (p *Provider) discover(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	ep := &endpoints{Issuer: p.cfg.Issuer}
+	if p.cfg.Issuer != "" && (p.cfg.AuthURL == "" || p.cfg.TokenURL == "" || p.cfg.JWKSURL == "") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := doJSON(p.client, req, ep); err != nil {
+			return nil, fmt.Errorf("oidc discovery failed: %w", err)
+		}
+		if ep.Issuer != p.cfg.Issuer {
+			return nil, fmt.Errorf("%w: discovery issuer %q does not match %q", ErrProviderResponse, ep.Issuer, p.cfg.Issuer)
+		}
+	}
+
+	for _, override := range []struct {
+		value  string
+		target *string
+	}{
+		{p.cfg.AuthURL, &ep.AuthURL},
+		{p.cfg.TokenURL, &ep.TokenURL},
+		{p.cfg.UserInfoURL, &ep.UserInfoURL},
+		{p.cfg.JWKSURL, &ep.JWKSURL},
+	} {
+		if override.value != "" {
+			*override.target = override.value
+		}
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" {
+		return nil, fmt.Errorf("%w: authorization or token endpoint is missing", ErrProviderResponse)
+	}
+
+	p.endpoints = ep
+	return ep, nil
+}
+
+func (p *Provider) keySet(ep *endpoints) *keySet {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys == nil {
+		p.keys = newKeySet(ep.JWKSURL, p.client)
+	}
+	return p.keys
+}
+
+func doJSON(client *http.Client, req *http.Request, into any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrProviderResponse, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, into); err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderResponse, err)
+	}
+	return nil
+}
+
+// flexibleBool принимает email_verified как true или "true": часть провайдеров
+// отдает его строкой.
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true", "1":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// Hash - отпечаток значения state для хранения: сам state остается только у клиента.
+func Hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return fmt.Sprintf("%x", sum)
+}
@@ -0,0 +1,238 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/oidc"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIssuer - локальный OIDC провайдер: discovery, JWKS, token и userinfo.
+type mockIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+	// published - ключ, который отдает JWKS.
+	published *rsa.PublicKey
+
+	// claims - содержимое ID токена, выдаваемого на код "good-code".
+	claims     jwt.MapClaims
+	noIDToken  bool
+	userInfo   map[string]any
+	challenges map[string]string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockIssuer{key: key, published: &key.PublicKey, challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"userinfo_endpoint":      m.URL + "/userinfo",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(m.published.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.published.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "good-code" || r.PostForm.Get("client_secret") != "secret" ||
+			oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != m.challenges["good-code"] {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		resp := map[string]string{"access_token": "access", "token_type": "Bearer"}
+		if !m.noIDToken {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims)
+			token.Header["kid"] = "test-key"
+			signed, err := token.SignedString(key)
+			require.NoError(t, err)
+			resp["id_token"] = signed
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" || m.userInfo == nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(m.userInfo)
+	})
+
+	return m
+}
+
+// authorize проходит страницу входа провайдера и запоминает PKCE challenge для кода.
+func (m *mockIssuer) authorize(t *testing.T, p *oidc.Provider, nonce, verifier string) {
+	authURL, err := p.AuthCodeURL(context.Background(), "state", nonce, verifier)
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	require.Equal(t, m.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	query := parsed.Query()
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.Equal(t, "client", query.Get("client_id"))
+	require.Equal(t, "state", query.Get("state"))
+	require.Equal(t, nonce, query.Get("nonce"))
+	m.challenges["good-code"] = query.Get("code_challenge")
+}
+
+func (m *mockIssuer) idClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            m.URL,
+		"aud":            "client",
+		"sub":            "user-1",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"nonce":          nonce,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+	}
+}
+
+func newTestProvider(t *testing.T, m *mockIssuer) *oidc.Provider {
+	p, err := oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       m.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+	}, m.Client())
+	require.NoError(t, err)
+	return p
+}
+
+func TestExchange_Success(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.claims = m.idClaims("nonce")
+
+	info, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	require.NoError(t, err)
+	require.Equal(t, &oidc.UserInfo{Subject: "user-1", Email: "user@example.com", EmailVerified: true}, info)
+}
+
+func TestExchange_WrongVerifier(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.claims = m.idClaims("nonce")
+
+	_, err := p.Exchange(context.Background(), "good-code", "other-verifier", "nonce")
+	require.ErrorIs(t, err, oidc.ErrProviderResponse)
+}
+
+func TestExchange_NonceMismatch(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.claims = m.idClaims("replayed")
+
+	_, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestExchange_WrongAudience(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.claims = m.idClaims("nonce")
+	m.claims["aud"] = "another-client"
+
+	_, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestExchange_ExpiredToken(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.claims = m.idClaims("nonce")
+	m.claims["exp"] = time.Now().Add(-time.Minute).Unix()
+
+	_, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestExchange_ForgedSignature(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.claims = m.idClaims("nonce")
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m.published = &otherKey.PublicKey
+
+	_, err = p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestExchange_UserInfoFallback(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.noIDToken = true
+	m.userInfo = map[string]any{"sub": "user-1", "email": "user@example.com", "email_verified": "true"}
+
+	info, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	require.NoError(t, err)
+	require.Equal(t, "user-1", info.Subject)
+	require.True(t, info.EmailVerified)
+}
+
+func TestExchange_UserInfoSubjectMismatch(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.authorize(t, p, "nonce", "verifier")
+	m.claims = m.idClaims("nonce")
+	delete(m.claims, "email")
+	m.userInfo = map[string]any{"sub": "user-2", "email": "other@example.com", "email_verified": true}
+
+	_, err := p.Exchange(context.Background(), "good-code", "verifier", "nonce")
+	require.ErrorIs(t, err, oidc.ErrProviderResponse)
+}
+
+func TestDiscovery_IssuerMismatch(t *testing.T) {
+	m := newMockIssuer(t)
+	p, err := oidc.NewProvider(oidc.Config{
+		Name:        "mock",
+		Issuer:      m.URL + "/",
+		ClientID:    "client",
+		RedirectURL: "http://localhost/callback",
+	}, m.Client())
+	require.NoError(t, err)
+
+	_, err = p.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	require.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExternalAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalAccountRepository(db *gorm.DB) *ExternalAccountRepository {
+	return &ExternalAccountRepository{
+		db: db,
+	}
+}
+
+func (r *ExternalAccountRepository) Create(ctx context.Context, account *entity.ExternalAccount) error {
+	return r.db.WithContext(ctx).Create(account).Error
+}
+
+// FindByProviderSubject возвращает привязанную учетную запись провайдера или nil.
+func (r *ExternalAccountRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.ExternalAccount, error) {
+	var account entity.ExternalAccount
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *ExternalAccountRepository) ListByUser(ctx context.Context, userUUID uuid.UUID) ([]entity.ExternalAccount, error) {
+	var accounts []entity.ExternalAccount
+	err := r.db.WithContext(ctx).
+		Where("user_uuid = ?", userUUID).
+		Order("created_at").
+		Find(&accounts).Error
+	return accounts, err
+}
+
+// Delete отвязывает провайдера от пользователя. Возвращает false, если
+// провайдер не был привязан.
+func (r *ExternalAccountRepository) Delete(ctx context.Context, userUUID uuid.UUID, provider string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_uuid = ? AND provider = ?", userUUID, provider).
+		Delete(&entity.ExternalAccount{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+type OIDCStateRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCStateRepository(db *gorm.DB) *OIDCStateRepository {
+	return &OIDCStateRepository{
+		db: db,
+	}
+}
+
+func (r *OIDCStateRepository) Save(ctx context.Context, state *entity.OIDCState) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+// Take возвращает и удаляет state, так что он используется не больше одного
+// раза. Если state не найден или уже использован, возвращает nil.
+func (r *OIDCStateRepository) Take(ctx context.Context, stateHash string) (*entity.OIDCState, error) {
+	var state entity.OIDCState
+	err := r.db.WithContext(ctx).Where("state_hash = ?", stateHash).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := r.db.WithContext(ctx).Delete(&entity.OIDCState{}, state.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// CleanupExpired удаляет незавершенные входы с истекшим сроком.
+func (r *OIDCStateRepository) CleanupExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entity.OIDCState{}).Error
+}
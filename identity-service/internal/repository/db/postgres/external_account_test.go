@@ -0,0 +1,108 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupExternalAccountTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.ExternalAccount{}, &entity.OIDCState{}))
+	return db
+}
+
+// ---- ExternalAccount ----
+
+func TestExternalAccount_CreateAndFind(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewExternalAccountRepository(setupExternalAccountTestDB(t))
+	userUUID := uuid.New()
+
+	require.NoError(t, repo.Create(ctx, entity.NewExternalAccount(userUUID, "google", "sub-1", "user@example.com")))
+
+	account, err := repo.FindByProviderSubject(ctx, "google", "sub-1")
+	require.NoError(t, err)
+	require.NotNil(t, account)
+	require.Equal(t, userUUID, account.UserUUID)
+
+	account, err = repo.FindByProviderSubject(ctx, "yandex", "sub-1")
+	require.NoError(t, err)
+	require.Nil(t, account)
+}
+
+func TestExternalAccount_SubjectUnique(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewExternalAccountRepository(setupExternalAccountTestDB(t))
+
+	require.NoError(t, repo.Create(ctx, entity.NewExternalAccount(uuid.New(), "google", "sub-1", "a@example.com")))
+	require.Error(t, repo.Create(ctx, entity.NewExternalAccount(uuid.New(), "google", "sub-1", "b@example.com")))
+}
+
+func TestExternalAccount_ListAndDelete(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewExternalAccountRepository(setupExternalAccountTestDB(t))
+	userUUID := uuid.New()
+
+	require.NoError(t, repo.Create(ctx, entity.NewExternalAccount(userUUID, "google", "g-1", "user@example.com")))
+	require.NoError(t, repo.Create(ctx, entity.NewExternalAccount(userUUID, "yandex", "y-1", "user@example.com")))
+	require.NoError(t, repo.Create(ctx, entity.NewExternalAccount(uuid.New(), "google", "g-2", "other@example.com")))
+
+	accounts, err := repo.ListByUser(ctx, userUUID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+
+	deleted, err := repo.Delete(ctx, userUUID, "google")
+	require.NoError(t, err)
+	require.True(t, deleted)
+
+	deleted, err = repo.Delete(ctx, userUUID, "google")
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	accounts, err = repo.ListByUser(ctx, userUUID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, "yandex", accounts[0].Provider)
+}
+
+// ---- OIDCState ----
+
+func TestOIDCState_TakeOnce(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewOIDCStateRepository(setupExternalAccountTestDB(t))
+
+	state := &entity.OIDCState{StateHash: "hash", Provider: "google", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute)}
+	require.NoError(t, repo.Save(ctx, state))
+
+	taken, err := repo.Take(ctx, "hash")
+	require.NoError(t, err)
+	require.NotNil(t, taken)
+	require.Equal(t, "verifier", taken.CodeVerifier)
+
+	taken, err = repo.Take(ctx, "hash")
+	require.NoError(t, err)
+	require.Nil(t, taken)
+}
+
+func TestOIDCState_CleanupExpired(t *testing.T) {
+	ctx := context.TODO()
+	db := setupExternalAccountTestDB(t)
+	repo := postgres.NewOIDCStateRepository(db)
+
+	require.NoError(t, repo.Save(ctx, &entity.OIDCState{StateHash: "old", ExpiresAt: time.Now().Add(-time.Minute)}))
+	require.NoError(t, repo.Save(ctx, &entity.OIDCState{StateHash: "new", ExpiresAt: time.Now().Add(time.Minute)}))
+	require.NoError(t, repo.CleanupExpired(ctx))
+
+	var count int64
+	require.NoError(t, db.Model(&entity.OIDCState{}).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+}
@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -49,6 +51,7 @@ func (r *IdentityRepository) FindByUUID(ctx context.Context, uuid string) (*enti
 	return &identity, nil
 }
 
+// Update сохраняет все поля пользователя, включая пустые (например, удаленный пароль).
 func (r *IdentityRepository) Update(ctx context.Context, identity *entity.Identity) error {
 	if identity.ID == 0 {
 		return fmt.Errorf("cannot update entity: missing ID")
@@ -57,12 +60,56 @@ func (r *IdentityRepository) Update(ctx context.Context, identity *entity.Identi
 	err := r.db.WithContext(ctx).
 		Model(&entity.Identity{}).
 		Where("id = ?", identity.ID).
+		Select("*").
+		Omit("id", "created_at").
 		Updates(identity).
 		Error
 
 	return err
 }
 
+// List возвращает пользователей по фильтру, новые сначала, и их общее число без учета Limit и Offset.
+func (r *IdentityRepository) List(ctx context.Context, filter entity.IdentityFilter) ([]entity.Identity, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.Identity{})
+	if filter.Email != "" {
+		query = query.Where("LOWER(email) LIKE ?", "%"+strings.ToLower(filter.Email)+"%")
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			query = query.Where("disabled_at IS NOT NULL")
+		} else {
+			query = query.Where("disabled_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var identities []entity.Identity
+	err := query.Order("created_at DESC, id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&identities).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return identities, total, nil
+}
+
+// ListDisabledUUIDs возвращает UUID всех отключенных пользователей.
+func (r *IdentityRepository) ListDisabledUUIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var uuids []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Model(&entity.Identity{}).
+		Where("disabled_at IS NOT NULL").
+		Order("id").
+		Pluck("user_uuid", &uuids).
+		Error
+	return uuids, err
+}
+
 func (r *IdentityRepository) Delete(ctx context.Context, id int) error {
 	return r.db.WithContext(ctx).Delete(&entity.Identity{}, id).Error
 }
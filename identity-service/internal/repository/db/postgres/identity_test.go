@@ -3,9 +3,11 @@ package postgres_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -138,3 +140,76 @@ func TestDeleteIdentity_Fail_InvalidID(t *testing.T) {
 	err := repo.Delete(ctx, -1)
 	require.NoError(t, err) // GORM не падает при удалении несуществующего ID
 }
+
+func TestUpdateIdentity_ClearsPassword(t *testing.T) {
+	ctx := context.TODO()
+	db := setupTestDB(t)
+	repo := postgres.NewIdentityRepository(db)
+
+	id, _ := entity.NewIdentity("user@example.com", "password")
+	require.NoError(t, repo.Create(ctx, id))
+
+	id.RemovePassword()
+	require.NoError(t, repo.Update(ctx, id))
+
+	updated, err := repo.FindByEmail(ctx, "user@example.com")
+	require.NoError(t, err)
+	require.False(t, updated.HasPassword())
+	require.Equal(t, id.CreatedAt.Unix(), updated.CreatedAt.Unix())
+}
+
+// --- List ---
+
+func TestListIdentities_Filters(t *testing.T) {
+	ctx := context.TODO()
+	db := setupTestDB(t)
+	repo := postgres.NewIdentityRepository(db)
+
+	for _, email := range []string{"anna@example.com", "boris@example.com", "admin@example.com"} {
+		id, err := entity.NewIdentity(email, "password")
+		require.NoError(t, err)
+		if email == "admin@example.com" {
+			require.NoError(t, id.ChangeRole(entity.RoleAdmin))
+		}
+		if email == "boris@example.com" {
+			id.Disable(time.Now())
+		}
+		require.NoError(t, repo.Create(ctx, id))
+	}
+
+	disabled := true
+	result, total, err := repo.List(ctx, entity.IdentityFilter{Disabled: &disabled, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, "boris@example.com", result[0].Email)
+
+	result, total, err = repo.List(ctx, entity.IdentityFilter{Email: "ADMIN", Role: entity.RoleAdmin, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	require.Equal(t, "admin@example.com", result[0].Email)
+
+	// Общее число не зависит от размера страницы.
+	result, total, err = repo.List(ctx, entity.IdentityFilter{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), total)
+	require.Len(t, result, 2)
+}
+
+func TestListDisabledUUIDs(t *testing.T) {
+	ctx := context.TODO()
+	db := setupTestDB(t)
+	repo := postgres.NewIdentityRepository(db)
+
+	active, err := entity.NewIdentity("active@example.com", "password")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, active))
+
+	disabled, err := entity.NewIdentity("disabled@example.com", "password")
+	require.NoError(t, err)
+	disabled.Disable(time.Now())
+	require.NoError(t, repo.Create(ctx, disabled))
+
+	uuids, err := repo.ListDisabledUUIDs(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{disabled.UserUUID}, uuids)
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LockoutRepository struct {
+	db *gorm.DB
+}
+
+func NewLockoutRepository(db *gorm.DB) *LockoutRepository {
+	return &LockoutRepository{
+		db: db,
+	}
+}
+
+// Find возвращает счетчик или nil, если неудачных попыток не было.
+func (r *LockoutRepository) Find(ctx context.Context, kind, key string) (*entity.FailureCounter, error) {
+	var counter entity.FailureCounter
+	err := r.db.WithContext(ctx).Where("kind = ? AND key = ?", kind, key).First(&counter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &counter, nil
+}
+
+// RegisterFailure атомарно увеличивает счетчик и возвращает его. Если
+// предыдущая неудача была раньше window, счет начинается заново.
+func (r *LockoutRepository) RegisterFailure(ctx context.Context, kind, key string, window time.Duration) (*entity.FailureCounter, error) {
+	now := time.Now()
+	counter := &entity.FailureCounter{Kind: kind, Key: key, Failures: 1, LastFailureAt: now}
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "kind"}, {Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"failures":        gorm.Expr("CASE WHEN failure_counters.last_failure_at < ? THEN 1 ELSE failure_counters.failures + 1 END", now.Add(-window)),
+				"last_failure_at": now,
+			}),
+		}).
+		Create(counter).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.Find(ctx, kind, key)
+}
+
+// Block задает момент, до которого попытки не принимаются.
+func (r *LockoutRepository) Block(ctx context.Context, id int, until *time.Time, locked bool) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.FailureCounter{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"blocked_until": until, "locked": locked}).Error
+}
+
+// Reset удаляет счетчик. Возвращает false, если его не было.
+func (r *LockoutRepository) Reset(ctx context.Context, kind, key string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("kind = ? AND key = ?", kind, key).Delete(&entity.FailureCounter{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListLocked возвращает действующие блокировки вида kind (пустой - всех видов).
+func (r *LockoutRepository) ListLocked(ctx context.Context, kind string) ([]entity.FailureCounter, error) {
+	query := r.db.WithContext(ctx).Where("locked = ? AND blocked_until > ?", true, time.Now())
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var counters []entity.FailureCounter
+	err := query.Order("blocked_until DESC").Find(&counters).Error
+	return counters, err
+}
+
+// CleanupStale удаляет счетчики без неудач после before и без действующей блокировки.
+func (r *LockoutRepository) CleanupStale(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("last_failure_at < ? AND (blocked_until IS NULL OR blocked_until < ?)", before, time.Now()).
+		Delete(&entity.FailureCounter{}).Error
+}
+
+func (r *LockoutRepository) SaveEvent(ctx context.Context, event *entity.LockoutEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// ListEvents возвращает журнал блокировок, последние сначала. Пустые kind
+// и key не ограничивают выборку.
+func (r *LockoutRepository) ListEvents(ctx context.Context, kind, key string, limit int) ([]entity.LockoutEvent, error) {
+	query := r.db.WithContext(ctx)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if key != "" {
+		query = query.Where("key = ?", key)
+	}
+
+	var events []entity.LockoutEvent
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,121 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupLockoutTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.FailureCounter{}, &entity.LockoutEvent{}))
+	return db
+}
+
+func TestLockoutRegisterFailure(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewLockoutRepository(setupLockoutTestDB(t))
+
+	for i := 1; i <= 3; i++ {
+		counter, err := repo.RegisterFailure(ctx, entity.LockoutAccount, "test@example.com", time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, i, counter.Failures)
+	}
+
+	// Другой аккаунт считается отдельно.
+	counter, err := repo.RegisterFailure(ctx, entity.LockoutAccount, "other@example.com", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, counter.Failures)
+}
+
+func TestLockoutRegisterFailure_WindowExpired(t *testing.T) {
+	ctx := context.TODO()
+	db := setupLockoutTestDB(t)
+	repo := postgres.NewLockoutRepository(db)
+	require.NoError(t, db.Create(&entity.FailureCounter{
+		Kind:          entity.LockoutIP,
+		Key:           "192.0.2.1",
+		Failures:      7,
+		LastFailureAt: time.Now().Add(-2 * time.Hour),
+	}).Error)
+
+	counter, err := repo.RegisterFailure(ctx, entity.LockoutIP, "192.0.2.1", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, 1, counter.Failures)
+}
+
+func TestLockoutBlockAndReset(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewLockoutRepository(setupLockoutTestDB(t))
+	counter, err := repo.RegisterFailure(ctx, entity.LockoutAccount, "test@example.com", time.Hour)
+	require.NoError(t, err)
+
+	until := time.Now().Add(time.Minute)
+	require.NoError(t, repo.Block(ctx, counter.ID, &until, true))
+
+	locked, err := repo.ListLocked(ctx, entity.LockoutAccount)
+	require.NoError(t, err)
+	require.Len(t, locked, 1)
+	require.True(t, locked[0].IsBlocked(time.Now()))
+
+	locked, err = repo.ListLocked(ctx, entity.LockoutIP)
+	require.NoError(t, err)
+	require.Empty(t, locked)
+
+	reset, err := repo.Reset(ctx, entity.LockoutAccount, "test@example.com")
+	require.NoError(t, err)
+	require.True(t, reset)
+
+	found, err := repo.Find(ctx, entity.LockoutAccount, "test@example.com")
+	require.NoError(t, err)
+	require.Nil(t, found)
+
+	reset, err = repo.Reset(ctx, entity.LockoutAccount, "test@example.com")
+	require.NoError(t, err)
+	require.False(t, reset)
+}
+
+func TestLockoutCleanupStale_KeepsActiveLocks(t *testing.T) {
+	ctx := context.TODO()
+	db := setupLockoutTestDB(t)
+	repo := postgres.NewLockoutRepository(db)
+	old := time.Now().Add(-2 * time.Hour)
+	until := time.Now().Add(time.Minute)
+	require.NoError(t, db.Create(&entity.FailureCounter{Kind: entity.LockoutIP, Key: "stale", Failures: 2, LastFailureAt: old}).Error)
+	require.NoError(t, db.Create(&entity.FailureCounter{Kind: entity.LockoutIP, Key: "locked", Failures: 50, LastFailureAt: old, BlockedUntil: &until, Locked: true}).Error)
+
+	require.NoError(t, repo.CleanupStale(ctx, time.Now().Add(-time.Hour)))
+
+	found, err := repo.Find(ctx, entity.LockoutIP, "stale")
+	require.NoError(t, err)
+	require.Nil(t, found)
+
+	found, err = repo.Find(ctx, entity.LockoutIP, "locked")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+}
+
+func TestLockoutListEvents(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewLockoutRepository(setupLockoutTestDB(t))
+	now := time.Now()
+	require.NoError(t, repo.SaveEvent(ctx, &entity.LockoutEvent{Kind: entity.LockoutAccount, Key: "test@example.com", Action: entity.LockoutActionLocked, CreatedAt: now.Add(-time.Minute)}))
+	require.NoError(t, repo.SaveEvent(ctx, &entity.LockoutEvent{Kind: entity.LockoutAccount, Key: "test@example.com", Action: entity.LockoutActionUnlocked, ActorUUID: "admin123", CreatedAt: now}))
+	require.NoError(t, repo.SaveEvent(ctx, &entity.LockoutEvent{Kind: entity.LockoutIP, Key: "192.0.2.1", Action: entity.LockoutActionLocked, CreatedAt: now}))
+
+	events, err := repo.ListEvents(ctx, entity.LockoutAccount, "test@example.com", 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, entity.LockoutActionUnlocked, events[0].Action)
+
+	events, err = repo.ListEvents(ctx, "", "", 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	return &MFARepository{
+		db: db,
+	}
+}
+
+// FindTOTP возвращает секрет пользователя или nil, если второй фактор не настраивался.
+func (r *MFARepository) FindTOTP(ctx context.Context, userUUID uuid.UUID) (*entity.TOTPCredential, error) {
+	var credential entity.TOTPCredential
+	err := r.db.WithContext(ctx).Where("user_uuid = ?", userUUID).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// SaveTOTP сохраняет секрет, заменяя предыдущий секрет пользователя.
+func (r *MFARepository) SaveTOTP(ctx context.Context, credential *entity.TOTPCredential) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_uuid"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed", "last_used_step", "created_at", "confirmed_at"}),
+		}).
+		Create(credential).Error
+}
+
+// UseTOTPStep отмечает шаг времени принятого кода. Возвращает false, если
+// код этого или более позднего шага уже использован.
+func (r *MFARepository) UseTOTPStep(ctx context.Context, id int, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.TOTPCredential{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteFactors удаляет секрет и коды восстановления пользователя.
+func (r *MFARepository) DeleteFactors(ctx context.Context, userUUID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_uuid = ?", userUUID).Delete(&entity.TOTPCredential{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_uuid = ?", userUUID).Delete(&entity.RecoveryCode{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя новыми.
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userUUID uuid.UUID, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_uuid = ?", userUUID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		codes := make([]entity.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, entity.RecoveryCode{UserUUID: userUUID, CodeHash: hash, CreatedAt: now})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode погашает код восстановления. Возвращает false, если кода
+// нет или он уже использован.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userUUID uuid.UUID, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.RecoveryCode{}).
+		Where("user_uuid = ? AND code_hash = ? AND used_at IS NULL", userUUID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountRecoveryCodes возвращает число неиспользованных кодов восстановления.
+func (r *MFARepository) CountRecoveryCodes(ctx context.Context, userUUID uuid.UUID) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.RecoveryCode{}).
+		Where("user_uuid = ? AND used_at IS NULL", userUUID).
+		Count(&count).Error
+	return int(count), err
+}
+
+type MFAChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewMFAChallengeRepository(db *gorm.DB) *MFAChallengeRepository {
+	return &MFAChallengeRepository{
+		db: db,
+	}
+}
+
+func (r *MFAChallengeRepository) Save(ctx context.Context, challenge *entity.MFAChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+// Find возвращает вход, ожидающий второго фактора, или nil.
+func (r *MFAChallengeRepository) Find(ctx context.Context, tokenHash string) (*entity.MFAChallenge, error) {
+	var challenge entity.MFAChallenge
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&challenge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// RegisterAttempt засчитывает попытку ввода кода, см. VerificationCodeRepository.RegisterAttempt.
+func (r *MFAChallengeRepository) RegisterAttempt(ctx context.Context, id int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.MFAChallenge{}).
+		Where("id = ? AND attempts < max_attempts", id).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete завершает вход. Возвращает false, если он уже завершен другим запросом.
+func (r *MFAChallengeRepository) Delete(ctx context.Context, id int) (bool, error) {
+	result := r.db.WithContext(ctx).Delete(&entity.MFAChallenge{}, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *MFAChallengeRepository) CleanupExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entity.MFAChallenge{}).Error
+}
+
+type MFAPolicyRepository struct {
+	db *gorm.DB
+}
+
+func NewMFAPolicyRepository(db *gorm.DB) *MFAPolicyRepository {
+	return &MFAPolicyRepository{
+		db: db,
+	}
+}
+
+func (r *MFAPolicyRepository) List(ctx context.Context) ([]entity.MFARolePolicy, error) {
+	var policies []entity.MFARolePolicy
+	err := r.db.WithContext(ctx).Order("role").Find(&policies).Error
+	return policies, err
+}
+
+// Find возвращает требование для роли или nil, если оно не задавалось.
+func (r *MFAPolicyRepository) Find(ctx context.Context, role string) (*entity.MFARolePolicy, error) {
+	var policy entity.MFARolePolicy
+	err := r.db.WithContext(ctx).Where("role = ?", role).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *MFAPolicyRepository) Save(ctx context.Context, policy *entity.MFARolePolicy) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "role"}},
+			DoUpdates: clause.AssignmentColumns([]string{"required", "updated_by", "updated_at"}),
+		}).
+		Create(policy).Error
+}
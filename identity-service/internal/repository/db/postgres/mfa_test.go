@@ -0,0 +1,156 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupMFATestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.TOTPCredential{}, &entity.RecoveryCode{}, &entity.MFAChallenge{}, &entity.MFARolePolicy{}))
+	return db
+}
+
+// ---- TOTP ----
+
+func TestSaveTOTP_ReplacesUnconfirmed(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewMFARepository(setupMFATestDB(t))
+	userUUID := uuid.New()
+
+	require.NoError(t, repo.SaveTOTP(ctx, entity.NewTOTPCredential(userUUID, "FIRST")))
+	require.NoError(t, repo.SaveTOTP(ctx, entity.NewTOTPCredential(userUUID, "SECOND")))
+
+	credential, err := repo.FindTOTP(ctx, userUUID)
+	require.NoError(t, err)
+	require.Equal(t, "SECOND", credential.Secret)
+	require.False(t, credential.Confirmed)
+
+	credential.Confirm(100)
+	require.NoError(t, repo.SaveTOTP(ctx, credential))
+
+	credential, err = repo.FindTOTP(ctx, userUUID)
+	require.NoError(t, err)
+	require.True(t, credential.Confirmed)
+	require.Equal(t, int64(100), credential.LastUsedStep)
+}
+
+func TestUseTOTPStep_OnlyForward(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewMFARepository(setupMFATestDB(t))
+	credential := entity.NewTOTPCredential(uuid.New(), "SECRET")
+	require.NoError(t, repo.SaveTOTP(ctx, credential))
+
+	ok, err := repo.UseTOTPStep(ctx, credential.ID, 10)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.UseTOTPStep(ctx, credential.ID, 10)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+// ---- Recovery codes ----
+
+func TestRecoveryCodes_UseOnce(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewMFARepository(setupMFATestDB(t))
+	userUUID := uuid.New()
+
+	require.NoError(t, repo.ReplaceRecoveryCodes(ctx, userUUID, []string{"a", "b"}))
+
+	ok, err := repo.UseRecoveryCode(ctx, userUUID, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = repo.UseRecoveryCode(ctx, userUUID, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = repo.UseRecoveryCode(ctx, uuid.New(), "b")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	count, err := repo.CountRecoveryCodes(ctx, userUUID)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	require.NoError(t, repo.ReplaceRecoveryCodes(ctx, userUUID, []string{"c", "d", "e"}))
+	count, err = repo.CountRecoveryCodes(ctx, userUUID)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+}
+
+func TestDeleteFactors(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewMFARepository(setupMFATestDB(t))
+	userUUID := uuid.New()
+
+	require.NoError(t, repo.SaveTOTP(ctx, entity.NewTOTPCredential(userUUID, "SECRET")))
+	require.NoError(t, repo.ReplaceRecoveryCodes(ctx, userUUID, []string{"a"}))
+	require.NoError(t, repo.DeleteFactors(ctx, userUUID))
+
+	credential, err := repo.FindTOTP(ctx, userUUID)
+	require.NoError(t, err)
+	require.Nil(t, credential)
+	count, err := repo.CountRecoveryCodes(ctx, userUUID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+// ---- Challenges ----
+
+func TestMFAChallenge_Attempts(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewMFAChallengeRepository(setupMFATestDB(t))
+
+	challenge := &entity.MFAChallenge{TokenHash: "hash", UserUUID: uuid.New(), MaxAttempts: 1, ExpiresAt: time.Now().Add(time.Minute)}
+	require.NoError(t, repo.Save(ctx, challenge))
+
+	found, err := repo.Find(ctx, "hash")
+	require.NoError(t, err)
+	require.Equal(t, challenge.ID, found.ID)
+
+	ok, err := repo.RegisterAttempt(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = repo.RegisterAttempt(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	deleted, err := repo.Delete(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = repo.Delete(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.False(t, deleted)
+}
+
+// ---- Policies ----
+
+func TestMFAPolicy_SaveAndFind(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewMFAPolicyRepository(setupMFATestDB(t))
+
+	policy, err := repo.Find(ctx, "admin")
+	require.NoError(t, err)
+	require.Nil(t, policy)
+
+	require.NoError(t, repo.Save(ctx, &entity.MFARolePolicy{Role: "admin", Required: true, UpdatedAt: time.Now()}))
+	require.NoError(t, repo.Save(ctx, &entity.MFARolePolicy{Role: "admin", Required: false, UpdatedBy: "root", UpdatedAt: time.Now()}))
+
+	policies, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.False(t, policies[0].Required)
+	require.Equal(t, "root", policies[0].UpdatedBy)
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{
+		db: db,
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// Find возвращает сессию или nil, если ее нет.
+func (r *SessionRepository) Find(ctx context.Context, id uuid.UUID) (*entity.Session, error) {
+	var session entity.Session
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActive возвращает неотозванные и неистекшие сессии пользователя, последние сначала.
+func (r *SessionRepository) ListActive(ctx context.Context, userUUID uuid.UUID) ([]entity.Session, error) {
+	var sessions []entity.Session
+	err := r.db.WithContext(ctx).
+		Where("user_uuid = ? AND revoked_at IS NULL AND expires_at > ?", userUUID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Rotate сохраняет новую пару токенов сессии, только если предъявленный
+// refresh токен (previousJTI) все еще последний в семействе. Возвращает false,
+// если токен уже заменен или сессия отозвана.
+func (r *SessionRepository) Rotate(ctx context.Context, session *entity.Session, previousJTI string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.Session{}).
+		Where("id = ? AND refresh_jti = ? AND revoked_at IS NULL", session.ID, previousJTI).
+		Updates(map[string]any{
+			"refresh_jti":       session.RefreshJTI,
+			"access_jti":        session.AccessJTI,
+			"access_expires_at": session.AccessExpiresAt,
+			"expires_at":        session.ExpiresAt,
+			"last_used_at":      session.LastUsedAt,
+			"user_agent":        session.UserAgent,
+			"ip":                session.IP,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Revoke отзывает сессию. Возвращает false, если она уже была отозвана.
+func (r *SessionRepository) Revoke(ctx context.Context, id uuid.UUID, reason string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]any{"revoked_at": time.Now(), "revoke_reason": reason})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeAll отзывает все сессии пользователя, кроме except, и возвращает отозванные.
+func (r *SessionRepository) RevokeAll(ctx context.Context, userUUID uuid.UUID, except uuid.UUID, reason string) ([]entity.Session, error) {
+	var sessions []entity.Session
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_uuid = ? AND id <> ? AND revoked_at IS NULL", userUUID, except).
+			Find(&sessions).Error; err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(sessions))
+		for _, session := range sessions {
+			ids = append(ids, session.ID)
+		}
+		return tx.Model(&entity.Session{}).
+			Where("id IN ? AND revoked_at IS NULL", ids).
+			Updates(map[string]any{"revoked_at": time.Now(), "revoke_reason": reason}).Error
+	})
+	return sessions, err
+}
+
+// CleanupExpired удаляет сессии, refresh токены которых истекли.
+func (r *SessionRepository) CleanupExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now()).
+		Delete(&entity.Session{}).Error
+}
@@ -0,0 +1,111 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSessionTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.Session{}))
+	return db
+}
+
+func newTestSession(userUUID uuid.UUID, refreshJTI string) *entity.Session {
+	session := entity.NewSession(userUUID, entity.ClientInfo{Device: "phone", UserAgent: "test", IP: "192.0.2.1"})
+	session.Issue(&entity.TokenPair{
+		RefreshJTI:       refreshJTI,
+		AccessJTI:        "access-" + refreshJTI,
+		AccessExpiresAt:  time.Now().Add(time.Minute),
+		RefreshExpiresAt: time.Now().Add(time.Hour),
+	})
+	return session
+}
+
+func TestSessionRotate(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewSessionRepository(setupSessionTestDB(t))
+	session := newTestSession(uuid.New(), "first")
+	require.NoError(t, repo.Create(ctx, session))
+
+	session.Issue(&entity.TokenPair{RefreshJTI: "second", RefreshExpiresAt: time.Now().Add(time.Hour)})
+	rotated, err := repo.Rotate(ctx, session, "first")
+	require.NoError(t, err)
+	require.True(t, rotated)
+
+	// Повторное предъявление замененного токена.
+	session.Issue(&entity.TokenPair{RefreshJTI: "third", RefreshExpiresAt: time.Now().Add(time.Hour)})
+	rotated, err = repo.Rotate(ctx, session, "first")
+	require.NoError(t, err)
+	require.False(t, rotated)
+
+	found, err := repo.Find(ctx, session.ID)
+	require.NoError(t, err)
+	require.Equal(t, "second", found.RefreshJTI)
+	require.Equal(t, "phone", found.Device)
+}
+
+func TestSessionRotate_Revoked(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewSessionRepository(setupSessionTestDB(t))
+	session := newTestSession(uuid.New(), "first")
+	require.NoError(t, repo.Create(ctx, session))
+
+	revoked, err := repo.Revoke(ctx, session.ID, entity.SessionRevokedByUser)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = repo.Revoke(ctx, session.ID, entity.SessionRevokedByUser)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	session.Issue(&entity.TokenPair{RefreshJTI: "second", RefreshExpiresAt: time.Now().Add(time.Hour)})
+	rotated, err := repo.Rotate(ctx, session, "first")
+	require.NoError(t, err)
+	require.False(t, rotated)
+}
+
+func TestSessionRevokeAll(t *testing.T) {
+	ctx := context.TODO()
+	repo := postgres.NewSessionRepository(setupSessionTestDB(t))
+	userUUID := uuid.New()
+
+	current := newTestSession(userUUID, "current")
+	other := newTestSession(userUUID, "other")
+	foreign := newTestSession(uuid.New(), "foreign")
+	for _, session := range []*entity.Session{current, other, foreign} {
+		require.NoError(t, repo.Create(ctx, session))
+	}
+
+	revoked, err := repo.RevokeAll(ctx, userUUID, current.ID, entity.SessionRevokedLogout)
+	require.NoError(t, err)
+	require.Len(t, revoked, 1)
+	require.Equal(t, other.ID, revoked[0].ID)
+	require.Equal(t, "access-other", revoked[0].AccessJTI)
+
+	active, err := repo.ListActive(ctx, userUUID)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	require.Equal(t, current.ID, active[0].ID)
+
+	active, err = repo.ListActive(ctx, foreign.UserUUID)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+}
+
+func TestSessionFind_NotFound(t *testing.T) {
+	repo := postgres.NewSessionRepository(setupSessionTestDB(t))
+
+	session, err := repo.Find(context.TODO(), uuid.New())
+	require.NoError(t, err)
+	require.Nil(t, session)
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{
+		db: db,
+	}
+}
+
+// List возвращает неистекшие ключи, более ранние сначала.
+func (r *SigningKeyRepository) List(ctx context.Context) ([]entity.SigningKey, error) {
+	var keys []entity.SigningKey
+	err := r.db.WithContext(ctx).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("active_at, created_at").
+		Find(&keys).Error
+	return keys, err
+}
+
+// Create сохраняет ключ. Возвращает false, если ключ с таким kid уже есть.
+func (r *SigningKeyRepository) Create(ctx context.Context, key *entity.SigningKey) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Retire выводит ключ из подписи. Возвращает false, если его уже вывели.
+func (r *SigningKeyRepository) Retire(ctx context.Context, kid string, retiredAt, expiresAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.SigningKey{}).
+		Where("kid = ? AND retired_at IS NULL", kid).
+		Updates(map[string]any{"retired_at": retiredAt, "expires_at": expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteExpired удаляет ключи, токены которых уже истекли.
+func (r *SigningKeyRepository) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now()).
+		Delete(&entity.SigningKey{}).Error
+}
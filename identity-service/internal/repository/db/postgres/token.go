@@ -33,6 +33,16 @@ func (r *TokenRepository) IsBlacklisted(ctx context.Context, token string) (bool
 	return count > 0, err
 }
 
+// ListRevokedSince возвращает еще не истекшие отозванные токены, добавленные после since.
+func (r *TokenRepository) ListRevokedSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error) {
+	var tokens []entity.BlacklistedToken
+	err := r.db.WithContext(ctx).
+		Where("created_at > ? AND expires_at > ? AND jti <> ''", since, time.Now()).
+		Order("created_at").
+		Find(&tokens).Error
+	return tokens, err
+}
+
 func (r *TokenRepository) CleanupExpired(ctx context.Context) error {
 	return r.db.WithContext(ctx).
 		Where("expires_at < ?", time.Now()).
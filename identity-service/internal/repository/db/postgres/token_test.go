@@ -90,6 +90,45 @@ func TestIsBlacklisted_Fail_DBClosed(t *testing.T) {
 	require.False(t, isBlacklisted)
 }
 
+// ---- ListRevokedSince ----
+
+func TestListRevokedSince_Success(t *testing.T) {
+	ctx := context.TODO()
+	db := setupTokenTestDB(t)
+	repo := postgres.NewTokenRepository(db)
+
+	old := &entity.BlacklistedToken{
+		Token:     "old-token",
+		JTI:       "old-jti",
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+		CreatedAt: time.Now().Add(-10 * time.Minute),
+	}
+	fresh := &entity.BlacklistedToken{
+		Token:     "fresh-token",
+		JTI:       "fresh-jti",
+		ExpiresAt: time.Now().Add(1 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+	expired := &entity.BlacklistedToken{
+		Token:     "expired-token",
+		JTI:       "expired-jti",
+		ExpiresAt: time.Now().Add(-1 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+	_ = repo.BlacklistToken(ctx, old)
+	_ = repo.BlacklistToken(ctx, fresh)
+	_ = repo.BlacklistToken(ctx, expired)
+
+	tokens, err := repo.ListRevokedSince(ctx, time.Now().Add(-5*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, "fresh-jti", tokens[0].JTI)
+
+	tokens, err = repo.ListRevokedSince(ctx, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+}
+
 // ---- CleanupExpired ----
 
 func TestCleanupExpired_Success(t *testing.T) {
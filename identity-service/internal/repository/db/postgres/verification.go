@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VerificationCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewVerificationCodeRepository(db *gorm.DB) *VerificationCodeRepository {
+	return &VerificationCodeRepository{
+		db: db,
+	}
+}
+
+// Save сохраняет код, заменяя предыдущий код пользователя с тем же назначением.
+func (r *VerificationCodeRepository) Save(ctx context.Context, code *entity.VerificationCode) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_uuid"}, {Name: "purpose"}},
+			DoUpdates: clause.AssignmentColumns([]string{"email", "code_hash", "attempts", "max_attempts", "expires_at", "created_at"}),
+		}).
+		Create(code).Error
+}
+
+// Find возвращает код пользователя для назначения или nil, если кода нет.
+func (r *VerificationCodeRepository) Find(ctx context.Context, userUUID uuid.UUID, purpose entity.VerificationPurpose) (*entity.VerificationCode, error) {
+	var code entity.VerificationCode
+	err := r.db.WithContext(ctx).
+		Where("user_uuid = ? AND purpose = ?", userUUID, purpose).
+		First(&code).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// RegisterAttempt засчитывает попытку ввода кода. Возвращает false, если
+// попытки уже исчерпаны: проверка и увеличение счетчика выполняются одним
+// запросом, поэтому параллельные запросы не превысят лимит.
+func (r *VerificationCodeRepository) RegisterAttempt(ctx context.Context, id int) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.VerificationCode{}).
+		Where("id = ? AND attempts < max_attempts", id).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete погашает код. Возвращает false, если код уже погашен другим запросом
+// или заменен новым: удаление по id и хэшу выполняется одним запросом.
+func (r *VerificationCodeRepository) Delete(ctx context.Context, id int, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND code_hash = ?", id, codeHash).
+		Delete(&entity.VerificationCode{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,140 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/repository/db/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupVerificationTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.VerificationCode{}))
+	return db
+}
+
+// ---- Save / Find ----
+
+func TestSaveVerificationCode_ReplacesPrevious(t *testing.T) {
+	ctx := context.TODO()
+	db := setupVerificationTestDB(t)
+	repo := postgres.NewVerificationCodeRepository(db)
+	userUUID := uuid.New()
+
+	first, err := entity.NewVerificationCode(userUUID, "user@example.com", entity.PurposeConfirmEmail, "111111", time.Minute, 3)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, first))
+	ok, err := repo.RegisterAttempt(ctx, first.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	second, err := entity.NewVerificationCode(userUUID, "user@example.com", entity.PurposeConfirmEmail, "222222", time.Minute, 3)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, second))
+
+	result, err := repo.Find(ctx, userUUID, entity.PurposeConfirmEmail)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.True(t, result.Matches("222222"))
+	require.False(t, result.Matches("111111"))
+	require.Zero(t, result.Attempts)
+
+	var count int64
+	require.NoError(t, db.Model(&entity.VerificationCode{}).Count(&count).Error)
+	require.Equal(t, int64(1), count)
+}
+
+func TestFindVerificationCode_PurposeIsolated(t *testing.T) {
+	ctx := context.TODO()
+	db := setupVerificationTestDB(t)
+	repo := postgres.NewVerificationCodeRepository(db)
+	userUUID := uuid.New()
+
+	code, err := entity.NewVerificationCode(userUUID, "user@example.com", entity.PurposeResetPassword, "123456", time.Minute, 3)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, code))
+
+	result, err := repo.Find(ctx, userUUID, entity.PurposeConfirmEmail)
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
+
+// ---- RegisterAttempt ----
+
+func TestRegisterAttempt_StopsAtLimit(t *testing.T) {
+	ctx := context.TODO()
+	db := setupVerificationTestDB(t)
+	repo := postgres.NewVerificationCodeRepository(db)
+
+	code, err := entity.NewVerificationCode(uuid.New(), "user@example.com", entity.PurposeConfirmEmail, "123456", time.Minute, 2)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, code))
+
+	for i := 0; i < 2; i++ {
+		ok, err := repo.RegisterAttempt(ctx, code.ID)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	ok, err := repo.RegisterAttempt(ctx, code.ID)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+// ---- Delete ----
+
+func TestDeleteVerificationCode_Success(t *testing.T) {
+	ctx := context.TODO()
+	db := setupVerificationTestDB(t)
+	repo := postgres.NewVerificationCodeRepository(db)
+	userUUID := uuid.New()
+
+	code, err := entity.NewVerificationCode(userUUID, "user@example.com", entity.PurposeConfirmEmail, "123456", time.Minute, 3)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, code))
+
+	deleted, err := repo.Delete(ctx, code.ID, code.CodeHash)
+	require.NoError(t, err)
+	require.True(t, deleted)
+
+	result, err := repo.Find(ctx, userUUID, entity.PurposeConfirmEmail)
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	deleted, err = repo.Delete(ctx, code.ID, code.CodeHash)
+	require.NoError(t, err)
+	require.False(t, deleted, "code is used only once")
+}
+
+func TestDeleteVerificationCode_Replaced(t *testing.T) {
+	ctx := context.TODO()
+	db := setupVerificationTestDB(t)
+	repo := postgres.NewVerificationCodeRepository(db)
+	userUUID := uuid.New()
+
+	first, err := entity.NewVerificationCode(userUUID, "user@example.com", entity.PurposeConfirmEmail, "111111", time.Minute, 3)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, first))
+	previous, err := repo.Find(ctx, userUUID, entity.PurposeConfirmEmail)
+	require.NoError(t, err)
+
+	second, err := entity.NewVerificationCode(userUUID, "user@example.com", entity.PurposeConfirmEmail, "222222", time.Minute, 3)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, second))
+
+	// Код проверен до замены, но погасить его после замены нельзя.
+	deleted, err := repo.Delete(ctx, previous.ID, previous.CodeHash)
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	result, err := repo.Find(ctx, userUUID, entity.PurposeConfirmEmail)
+	require.NoError(t, err)
+	require.True(t, result.Matches("222222"))
+}
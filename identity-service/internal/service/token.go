@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/keyset"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 )
@@ -14,46 +15,47 @@ import (
 type TokenRepository interface {
 	BlacklistToken(ctx context.Context, token *entity.BlacklistedToken) error
 	IsBlacklisted(ctx context.Context, token string) (bool, error)
+	ListRevokedSince(ctx context.Context, since time.Time) ([]entity.BlacklistedToken, error)
 	CleanupExpired(ctx context.Context) error
 }
 
+// Keys - ключи подписи токенов, см. keyset.Keyset.
+type Keys interface {
+	SigningKey(use string) (*keyset.Key, error)
+	VerificationKeys(use, kid string) []*rsa.PublicKey
+}
+
 type Claims struct {
 	Sub  string `json:"sub"`
 	Role string `json:"role"`
+	// SessionID - сессия, которой выдан токен, см. entity.Session.
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type TokenService struct {
-	accessPrivateKey  *rsa.PrivateKey
-	refreshPrivateKey *rsa.PrivateKey
-	accessPublicKey   *rsa.PublicKey
-	refreshPublicKey  *rsa.PublicKey
-	accessTimeout     time.Duration
-	refreshTimeout    time.Duration
-	tokenRepo         TokenRepository
-}
-
-func NewTokenService(accessPrivateKey *rsa.PrivateKey, accessPublicKey *rsa.PublicKey,
-	refreshPrivateKey *rsa.PrivateKey, refreshPublicKey *rsa.PublicKey,
-	accessTimeout time.Duration, refreshTimeout time.Duration, tokenRepo TokenRepository) *TokenService {
+	keys           Keys
+	accessTimeout  time.Duration
+	refreshTimeout time.Duration
+	tokenRepo      TokenRepository
+}
+
+func NewTokenService(keys Keys, accessTimeout time.Duration, refreshTimeout time.Duration, tokenRepo TokenRepository) *TokenService {
 	return &TokenService{
-		accessPrivateKey:  accessPrivateKey,
-		accessPublicKey:   accessPublicKey,
-		refreshPrivateKey: refreshPrivateKey,
-		refreshPublicKey:  refreshPublicKey,
-		accessTimeout:     accessTimeout,
-		refreshTimeout:    refreshTimeout,
-		tokenRepo:         tokenRepo,
+		keys:           keys,
+		accessTimeout:  accessTimeout,
+		refreshTimeout: refreshTimeout,
+		tokenRepo:      tokenRepo,
 	}
 }
 
 func (s *TokenService) GenerateTokenPair(userID, userRole string) (string, string, error) {
-	accessToken, err := s.GenerateToken(userID, userRole, s.accessPrivateKey, s.accessTimeout)
+	accessToken, err := s.GenerateToken(userID, userRole, entity.SigningKeyAccess, s.accessTimeout)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err := s.GenerateToken(userID, userRole, s.refreshPrivateKey, s.refreshTimeout)
+	refreshToken, err := s.GenerateToken(userID, userRole, entity.SigningKeyRefresh, s.refreshTimeout)
 	if err != nil {
 		return "", "", err
 	}
@@ -61,13 +63,54 @@ func (s *TokenService) GenerateTokenPair(userID, userRole string) (string, strin
 	return accessToken, refreshToken, nil
 }
 
-func (s *TokenService) ValidateToken(token string, isRefreshToken bool) (string, string, error) {
-	publicKey := s.accessPublicKey
-	if isRefreshToken {
-		publicKey = s.refreshPublicKey
+// GenerateSessionTokens выпускает пару токенов сессии sessionID.
+func (s *TokenService) GenerateSessionTokens(userID, userRole, sessionID string) (*entity.TokenPair, error) {
+	access, err := s.newClaims(userID, userRole, sessionID, s.accessTimeout)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.newClaims(userID, userRole, sessionID, s.refreshTimeout)
+	if err != nil {
+		return nil, err
 	}
 
-	claims, err := s.ParseToken(token, publicKey)
+	accessToken, err := s.sign(access, entity.SigningKeyAccess)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := s.sign(refresh, entity.SigningKeyRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		AccessJTI:        access.ID,
+		RefreshJTI:       refresh.ID,
+		AccessExpiresAt:  access.ExpiresAt.Time,
+		RefreshExpiresAt: refresh.ExpiresAt.Time,
+	}, nil
+}
+
+// ParseSessionToken проверяет токен и возвращает его claims.
+func (s *TokenService) ParseSessionToken(token string, isRefreshToken bool) (*entity.TokenClaims, error) {
+	claims, err := s.ParseToken(token, tokenUse(isRefreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TokenClaims{
+		UserUUID:  claims.Sub,
+		Role:      claims.Role,
+		SessionID: claims.SessionID,
+		JTI:       claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (s *TokenService) ValidateToken(token string, isRefreshToken bool) (string, string, error) {
+	claims, err := s.ParseToken(token, tokenUse(isRefreshToken))
 	if err != nil {
 		return "", "", err
 	}
@@ -75,56 +118,99 @@ func (s *TokenService) ValidateToken(token string, isRefreshToken bool) (string,
 	return claims.Sub, claims.Role, nil
 }
 
-func (s *TokenService) ParseToken(tokenString string, publicKey *rsa.PublicKey) (*Claims, error) {
-	claims := &Claims{}
-
-	parsedToken, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, errors.ErrUnexpectedSigningMethod
+// ParseToken проверяет токен ключом назначения use, выбранным по kid из
+// заголовка. Токены без kid, выданные до ротации ключей, проверяются всеми
+// действующими ключами этого назначения.
+func (s *TokenService) ParseToken(tokenString string, use string) (*Claims, error) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+	kid, _ := unverified.Header["kid"].(string)
+
+	for _, publicKey := range s.keys.VerificationKeys(use, kid) {
+		claims := &Claims{}
+		parsedToken, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, errors.ErrUnexpectedSigningMethod
+			}
+			return publicKey, nil
+		})
+		if err == nil && parsedToken.Valid {
+			return claims, nil
 		}
-		return publicKey, nil
-	})
+	}
 
-	if err != nil || !parsedToken.Valid {
-		return nil, errors.ErrInvalidToken
+	return nil, errors.ErrInvalidToken
+}
+
+func (s *TokenService) GenerateToken(userID, userRole, use string, expiration time.Duration) (string, error) {
+	claims, err := s.newClaims(userID, userRole, "", expiration)
+	if err != nil {
+		return "", err
+	}
+
+	return s.sign(claims, use)
+}
+
+// sign подписывает claims текущим ключом назначения use и указывает его kid.
+func (s *TokenService) sign(claims *Claims, use string) (string, error) {
+	key, err := s.keys.SigningKey(use)
+	if err != nil {
+		return "", err
 	}
 
-	return claims, nil
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
-func (s *TokenService) GenerateToken(userID, userRole string, privateKey *rsa.PrivateKey, expiration time.Duration) (string, error) {
+func tokenUse(isRefreshToken bool) string {
+	if isRefreshToken {
+		return entity.SigningKeyRefresh
+	}
+	return entity.SigningKeyAccess
+}
+
+func (s *TokenService) newClaims(userID, userRole, sessionID string, expiration time.Duration) (*Claims, error) {
 	if userID == "" {
-		return "", errors.ErrEmptyUserID
+		return nil, errors.ErrEmptyUserID
 	}
 	if userRole == "" {
 		userRole = "user"
 	}
 
-	claims := Claims{
-		Sub:  userID,
-		Role: userRole,
+	return &Claims{
+		Sub:       userID,
+		Role:      userRole,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "identity-service",
 			ID:        uuid.New().String(),
 		},
-	}
+	}, nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	return token.SignedString(privateKey)
+// RevokeJTI отзывает токен по jti до момента его истечения.
+func (s *TokenService) RevokeJTI(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" || !expiresAt.After(time.Now()) {
+		return nil
+	}
+	return s.tokenRepo.BlacklistToken(ctx, entity.NewRevokedJTI(jti, expiresAt))
 }
 
 func (s *TokenService) BlacklistToken(ctx context.Context, token string) error {
-	claims, err := s.ParseToken(token, s.accessPublicKey)
+	claims, err := s.ParseToken(token, entity.SigningKeyAccess)
 	if err != nil {
-		claims, err = s.ParseToken(token, s.refreshPublicKey)
+		claims, err = s.ParseToken(token, entity.SigningKeyRefresh)
 		if err != nil {
 			return err
 		}
 	}
 
-	blacklistedToken := entity.NewBlacklistedToken(token, time.Unix(claims.ExpiresAt.Unix(), 0))
+	blacklistedToken := entity.NewBlacklistedToken(token, claims.ID, time.Unix(claims.ExpiresAt.Unix(), 0))
 	return s.tokenRepo.BlacklistToken(ctx, blacklistedToken)
 }
 
@@ -3,7 +3,9 @@ package service_test
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/errors"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/keyset"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/mocks"
 	"github.com/JojoWeyn/duo-proj/identity-service/internal/service"
 	"github.com/stretchr/testify/require"
@@ -21,11 +23,46 @@ func generateTestKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
 	return accessPrivateKey, refreshPrivateKey
 }
 
+// testKeys - набор ключей без ротации: подписывает последний добавленный ключ назначения.
+type testKeys struct {
+	keys []*keyset.Key
+}
+
+func newTestKeys(accessPrivateKey, refreshPrivateKey *rsa.PrivateKey) *testKeys {
+	keys := &testKeys{}
+	keys.add(entity.SigningKeyAccess, accessPrivateKey)
+	keys.add(entity.SigningKeyRefresh, refreshPrivateKey)
+	return keys
+}
+
+func (k *testKeys) add(use string, private *rsa.PrivateKey) {
+	k.keys = append(k.keys, &keyset.Key{ID: keyset.Thumbprint(&private.PublicKey), Use: use, Private: private})
+}
+
+func (k *testKeys) SigningKey(use string) (*keyset.Key, error) {
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		if k.keys[i].Use == use {
+			return k.keys[i], nil
+		}
+	}
+	return nil, keyset.ErrNoSigningKey
+}
+
+func (k *testKeys) VerificationKeys(use, kid string) []*rsa.PublicKey {
+	var keys []*rsa.PublicKey
+	for _, key := range k.keys {
+		if key.Use == use && (kid == "" || key.ID == kid) {
+			keys = append(keys, &key.Private.PublicKey)
+		}
+	}
+	return keys
+}
+
 func TestGenerateTokenPair_Success(t *testing.T) {
 	accessPrivateKey, refreshPrivateKey := generateTestKeys(t)
 	tokenRepo := new(mocks.TokenRepositoryMock)
-	service := service.NewTokenService(accessPrivateKey, &accessPrivateKey.PublicKey,
-		refreshPrivateKey, &refreshPrivateKey.PublicKey, 15*time.Minute, 7*24*time.Hour, tokenRepo)
+	service := service.NewTokenService(newTestKeys(accessPrivateKey, refreshPrivateKey),
+		15*time.Minute, 7*24*time.Hour, tokenRepo)
 
 	accessToken, refreshToken, err := service.GenerateTokenPair("user123", "admin")
 	require.NoError(t, err, "должна быть успешная генерация токенов")
@@ -36,8 +73,8 @@ func TestGenerateTokenPair_Success(t *testing.T) {
 func TestGenerateTokenPair_EmptyUserID(t *testing.T) {
 	accessPrivateKey, refreshPrivateKey := generateTestKeys(t)
 	tokenRepo := new(mocks.TokenRepositoryMock)
-	service := service.NewTokenService(accessPrivateKey, &accessPrivateKey.PublicKey,
-		refreshPrivateKey, &refreshPrivateKey.PublicKey, 15*time.Minute, 7*24*time.Hour, tokenRepo)
+	service := service.NewTokenService(newTestKeys(accessPrivateKey, refreshPrivateKey),
+		15*time.Minute, 7*24*time.Hour, tokenRepo)
 
 	_, _, err := service.GenerateTokenPair("", "admin")
 	require.Error(t, err, "должна вернуться ошибка для пустого userID")
@@ -47,13 +84,13 @@ func TestGenerateTokenPair_EmptyUserID(t *testing.T) {
 func TestGenerateTokenPair_EmptyUserRole(t *testing.T) {
 	accessPrivateKey, refreshPrivateKey := generateTestKeys(t)
 	tokenRepo := new(mocks.TokenRepositoryMock)
-	service := service.NewTokenService(accessPrivateKey, &accessPrivateKey.PublicKey,
-		refreshPrivateKey, &refreshPrivateKey.PublicKey, 15*time.Minute, 7*24*time.Hour, tokenRepo)
+	service := service.NewTokenService(newTestKeys(accessPrivateKey, refreshPrivateKey),
+		15*time.Minute, 7*24*time.Hour, tokenRepo)
 
 	accessToken, _, err := service.GenerateTokenPair("user123", "")
 	require.NoError(t, err, "должна быть успешная генерация токенов с ролью по умолчанию")
 
-	claims, err := service.ParseToken(accessToken, &accessPrivateKey.PublicKey)
+	claims, err := service.ParseToken(accessToken, entity.SigningKeyAccess)
 	require.NoError(t, err, "токен должен успешно парситься")
 	require.Equal(t, "user", claims.Role, "роль должна быть по умолчанию 'user'")
 }
@@ -61,12 +98,12 @@ func TestGenerateTokenPair_EmptyUserRole(t *testing.T) {
 func TestValidateToken_AccessToken(t *testing.T) {
 	accessPrivateKey, refreshPrivateKey := generateTestKeys(t)
 	tokenRepo := new(mocks.TokenRepositoryMock)
-	service := service.NewTokenService(accessPrivateKey, &accessPrivateKey.PublicKey,
-		refreshPrivateKey, &refreshPrivateKey.PublicKey, 15*time.Minute, 7*24*time.Hour, tokenRepo)
+	service := service.NewTokenService(newTestKeys(accessPrivateKey, refreshPrivateKey),
+		15*time.Minute, 7*24*time.Hour, tokenRepo)
 
 	userID := "user123"
 	userRole := "admin"
-	accessToken, err := service.GenerateToken(userID, userRole, accessPrivateKey, 15*time.Minute)
+	accessToken, err := service.GenerateToken(userID, userRole, entity.SigningKeyAccess, 15*time.Minute)
 	require.NoError(t, err, "должен успешно сгенерироваться access-токен")
 
 	id, role, err := service.ValidateToken(accessToken, false)
@@ -74,3 +111,50 @@ func TestValidateToken_AccessToken(t *testing.T) {
 	require.Equal(t, userID, id, "userID должен совпадать")
 	require.Equal(t, userRole, role, "роль должна совпадать")
 }
+
+func TestGenerateSessionTokens(t *testing.T) {
+	accessPrivateKey, refreshPrivateKey := generateTestKeys(t)
+	tokenRepo := new(mocks.TokenRepositoryMock)
+	service := service.NewTokenService(newTestKeys(accessPrivateKey, refreshPrivateKey),
+		15*time.Minute, 7*24*time.Hour, tokenRepo)
+
+	pair, err := service.GenerateSessionTokens("user123", "admin", "session-1")
+	require.NoError(t, err, "должна быть успешная генерация токенов сессии")
+	require.NotEqual(t, pair.AccessJTI, pair.RefreshJTI, "jti токенов должны различаться")
+
+	claims, err := service.ParseSessionToken(pair.RefreshToken, true)
+	require.NoError(t, err, "refresh-токен должен успешно парситься")
+	require.Equal(t, "session-1", claims.SessionID, "сессия должна совпадать")
+	require.Equal(t, pair.RefreshJTI, claims.JTI, "jti должен совпадать")
+	require.WithinDuration(t, pair.RefreshExpiresAt, claims.ExpiresAt, time.Second)
+
+	_, err = service.ParseSessionToken(pair.RefreshToken, false)
+	require.Error(t, err, "refresh-токен не должен приниматься как access-токен")
+}
+
+func TestParseToken_SelectsKeyByKid(t *testing.T) {
+	accessPrivateKey, refreshPrivateKey := generateTestKeys(t)
+	keys := newTestKeys(accessPrivateKey, refreshPrivateKey)
+	service := service.NewTokenService(keys, 15*time.Minute, 7*24*time.Hour, new(mocks.TokenRepositoryMock))
+
+	oldToken, err := service.GenerateToken("user123", "user", entity.SigningKeyAccess, 15*time.Minute)
+	require.NoError(t, err)
+
+	// Ротация: новый ключ подписывает, старый еще проверяет выданные им токены.
+	rotatedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys.add(entity.SigningKeyAccess, rotatedKey)
+
+	newToken, err := service.GenerateToken("user123", "user", entity.SigningKeyAccess, 15*time.Minute)
+	require.NoError(t, err)
+
+	for _, token := range []string{oldToken, newToken} {
+		claims, err := service.ParseToken(token, entity.SigningKeyAccess)
+		require.NoError(t, err, "токен должен проверяться ключом из своего kid")
+		require.Equal(t, "user123", claims.Sub)
+	}
+
+	keys.keys = keys.keys[1:]
+	_, err = service.ParseToken(oldToken, entity.SigningKeyAccess)
+	require.ErrorIs(t, err, errors.ErrInvalidToken, "токен истекшего ключа не должен приниматься")
+}
@@ -1,35 +1,32 @@
 package service_test
 
 import (
+	"context"
 	"fmt"
-	"github.com/JojoWeyn/duo-proj/identity-service/internal/service"
-	clientSmtp "github.com/JojoWeyn/duo-proj/identity-service/pkg/client/smtp"
-	"github.com/stretchr/testify/require"
-	"sync"
-
-	"net"
-	"net/smtp"
-
 	"testing"
 	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/notifier"
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/service"
+	"github.com/stretchr/testify/require"
 )
 
-func setupMockSMTPServer(t *testing.T) (string, string, net.Listener, func()) {
-	listener, err := net.Listen("tcp", "localhost:0")
-	require.NoError(t, err, "failed to start mock SMTP server")
+func setupVerificationService(t *testing.T) (*service.VerificationService, *notifier.MemoryNotifier, *notifier.Queue) {
+	templates, err := notifier.NewTemplates()
+	require.NoError(t, err)
 
-	addr := listener.Addr().String()
-	host, port, err := net.SplitHostPort(addr)
-	require.NoError(t, err, "failed to split host and port")
+	sink := notifier.NewMemoryNotifier()
+	queue := notifier.NewQueue(sink, notifier.QueueConfig{Workers: 1})
+	queue.Start(context.Background())
 
-	return host, port, listener, func() { listener.Close() }
+	return service.NewVerificationService(templates, queue, 15*time.Minute), sink, queue
 }
 
 // --- GenerateVerificationCode ---
 
 func TestGenerateVerificationCode_Success(t *testing.T) {
-	cfg := &clientSmtp.SMTPConfig{}
-	vs := service.NewVerificationService(cfg)
+	vs, _, _ := setupVerificationService(t)
 
 	code := vs.GenerateVerificationCode()
 	require.Len(t, code, 6, "code should be 6 digits")
@@ -45,175 +42,38 @@ func TestGenerateVerificationCode_Success(t *testing.T) {
 // --- SendVerificationCode ---
 
 func TestSendVerificationCode_Success(t *testing.T) {
-	host, port, listener, cleanup := setupMockSMTPServer(t)
-	defer cleanup()
-
-	cfg := &clientSmtp.SMTPConfig{
-		Server:   host,
-		Port:     port,
-		Sender:   "test@kozhura.com",
-		Password: "testpass",
-	}
-	vs := service.NewVerificationService(cfg)
-
-	// Используем WaitGroup для синхронизации
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	// Запускаем мок-сервер
-	go func() {
-		defer wg.Done()
-		conn, err := listener.Accept()
-		if err != nil {
-			return
-		}
-		defer conn.Close()
-
-		client, err := smtp.NewClient(conn, cfg.Server)
-		if err != nil {
-			return
-		}
-		defer client.Close()
-
-		err = client.Auth(smtp.PlainAuth("", cfg.Sender, cfg.Password, cfg.Server))
-		if err != nil {
-			return
-		}
-		err = client.Mail(cfg.Sender)
-		if err != nil {
-			return
-		}
-		err = client.Rcpt("recipient@example.com")
-		if err != nil {
-			return
-		}
-		wc, err := client.Data()
-		if err != nil {
-			return
-		}
-		wc.Close()
-	}()
-
-	// Даем серверу время запуститься
-	time.Sleep(100 * time.Millisecond)
-
-	err := vs.SendVerificationCode("recipient@example.com", "123456")
-	require.NoError(t, err, "should send email successfully")
-
-	// Ждем завершения работы мока
-	wg.Wait()
+	vs, sink, queue := setupVerificationService(t)
+
+	err := vs.SendVerificationCode("recipient@example.com", "123456", entity.PurposeConfirmEmail, notifier.LocaleRU)
+	require.NoError(t, err, "should queue email successfully")
+	require.NoError(t, queue.Close(context.Background()))
+
+	messages := sink.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, "recipient@example.com", messages[0].To)
+	require.Equal(t, "Kozhura: код подтверждения email", messages[0].Subject)
+	require.Contains(t, messages[0].Text, "123456")
+	require.Contains(t, messages[0].Text, "15 мин.")
+	require.Contains(t, messages[0].HTML, "123456")
 }
 
-func TestSendVerificationCode_InvalidEmail(t *testing.T) {
-	host, port, listener, cleanup := setupMockSMTPServer(t)
-	defer cleanup()
-
-	cfg := &clientSmtp.SMTPConfig{
-		Server:   host,
-		Port:     port,
-		Sender:   "test@kozhura.com",
-		Password: "testpass",
-	}
-	vs := service.NewVerificationService(cfg)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go func() {
-		defer wg.Done()
-		conn, err := listener.Accept()
-		if err != nil {
-			return
-		}
-		defer conn.Close()
-
-		client, err := smtp.NewClient(conn, cfg.Server)
-		if err != nil {
-			return
-		}
-		defer client.Close()
-
-		err = client.Auth(smtp.PlainAuth("", cfg.Sender, cfg.Password, cfg.Server))
-		if err != nil {
-			return
-		}
-		err = client.Mail(cfg.Sender)
-		if err != nil {
-			return
-		}
-		// Симулируем ошибку для невалидного email
-		err = client.Rcpt("invalid-email")
-		if err == nil {
-			client.Quit()
-			return
-		}
-	}()
-
-	time.Sleep(100 * time.Millisecond)
-
-	err := vs.SendVerificationCode("invalid-email", "123456")
-	require.Error(t, err, "should fail with invalid email")
-	require.Contains(t, err.Error(), "failed to set recipient", "should mention recipient error")
-
-	wg.Wait()
-}
+func TestSendVerificationCode_EnglishLocale(t *testing.T) {
+	vs, sink, queue := setupVerificationService(t)
 
-func TestSendVerificationCode_ConnectionFailure(t *testing.T) {
-	cfg := &clientSmtp.SMTPConfig{
-		Server:   "invalid-server",
-		Port:     "9999",
-		Sender:   "test@kozhura.com",
-		Password: "testpass",
-	}
-	vs := service.NewVerificationService(cfg)
-
-	err := vs.SendVerificationCode("recipient@example.com", "123456")
-	require.Error(t, err, "should fail to connect")
-	require.Contains(t, err.Error(), "error connecting to SMTP server", "should mention connection error")
+	err := vs.SendVerificationCode("recipient@example.com", "123456", entity.PurposeChangeEmail, notifier.LocaleEN)
+	require.NoError(t, err)
+	require.NoError(t, queue.Close(context.Background()))
+
+	messages := sink.Messages()
+	require.Len(t, messages, 1)
+	require.Equal(t, "Kozhura: email change code", messages[0].Subject)
+	require.Contains(t, messages[0].Text, "new email address")
 }
 
-func TestSendVerificationCode_AuthFailure(t *testing.T) {
-	host, port, listener, cleanup := setupMockSMTPServer(t)
-	defer cleanup()
-
-	cfg := &clientSmtp.SMTPConfig{
-		Server:   host,
-		Port:     port,
-		Sender:   "test@kozhura.com",
-		Password: "wrongpass",
-	}
-	vs := service.NewVerificationService(cfg)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go func() {
-		defer wg.Done()
-		conn, err := listener.Accept()
-		if err != nil {
-			return
-		}
-		defer conn.Close()
-
-		client, err := smtp.NewClient(conn, cfg.Server)
-		if err != nil {
-			return
-		}
-		defer client.Close()
-
-		// Симулируем ошибку авторизации
-		err = client.Auth(smtp.PlainAuth("", cfg.Sender, "invalid-password", cfg.Server))
-		if err == nil {
-			client.Quit()
-			return
-		}
-	}()
-
-	time.Sleep(100 * time.Millisecond)
-
-	err := vs.SendVerificationCode("recipient@example.com", "123456")
-	require.Error(t, err, "should fail authentication")
-	require.Contains(t, err.Error(), "authentication failed", "should mention authentication error")
-
-	wg.Wait()
+func TestSendVerificationCode_QueueClosed(t *testing.T) {
+	vs, _, queue := setupVerificationService(t)
+	require.NoError(t, queue.Close(context.Background()))
+
+	err := vs.SendVerificationCode("recipient@example.com", "123456", entity.PurposeConfirmEmail, notifier.LocaleRU)
+	require.ErrorIs(t, err, notifier.ErrQueueClosed)
 }
@@ -0,0 +1,68 @@
+package totp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/identity-service/internal/totp"
+	"github.com/stretchr/testify/require"
+)
+
+// Секрет "12345678901234567890" из RFC 6238, приложение B.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode_RFCVectors(t *testing.T) {
+	for unix, want := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestValidate_Skew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	previous, err := totp.Code(rfcSecret, totp.Step(now)-1)
+	require.NoError(t, err)
+	old, err := totp.Code(rfcSecret, totp.Step(now)-2)
+	require.NoError(t, err)
+
+	step, ok := totp.Validate(rfcSecret, previous, now, 0)
+	require.True(t, ok)
+	require.Equal(t, totp.Step(now)-1, step)
+
+	_, ok = totp.Validate(rfcSecret, old, now, 0)
+	require.False(t, ok)
+}
+
+func TestValidate_RejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := totp.Code(rfcSecret, totp.Step(now))
+	require.NoError(t, err)
+
+	step, ok := totp.Validate(rfcSecret, code, now, 0)
+	require.True(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, code, now, step)
+	require.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	_, err = totp.Code(secret, 1)
+	require.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("duo-proj", "user@example.com", "SECRET")
+	require.Equal(t, "otpauth://totp/duo-proj:user@example.com?algorithm=SHA1&digits=6&issuer=duo-proj&period=30&secret=SECRET", uri)
+}
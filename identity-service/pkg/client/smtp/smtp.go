@@ -1,17 +1,41 @@
 package smtp
 
+import "crypto/x509"
+
+// Режимы защиты соединения с SMTP сервером.
+const (
+	// SecurityTLS - TLS с момента подключения (обычно порт 465).
+	SecurityTLS = "tls"
+	// SecurityStartTLS - переход на TLS командой STARTTLS (обычно порт 587).
+	SecurityStartTLS = "starttls"
+	// SecurityNone - без шифрования, только для локальных серверов разработки.
+	SecurityNone = "none"
+)
+
 type SMTPConfig struct {
 	Server   string
 	Port     string
 	Sender   string
 	Password string
+
+	// Security - режим защиты соединения, по умолчанию SecurityTLS.
+	Security string
+	// RootCAs - корневые сертификаты для проверки сервера, nil - системные.
+	RootCAs *x509.CertPool
 }
 
 func NewSMTPClient(cfg SMTPConfig) *SMTPConfig {
+	security := cfg.Security
+	if security == "" {
+		security = SecurityTLS
+	}
+
 	return &SMTPConfig{
 		Server:   cfg.Server,
 		Port:     cfg.Port,
 		Sender:   cfg.Sender,
 		Password: cfg.Password,
+		Security: security,
+		RootCAs:  cfg.RootCAs,
 	}
 }
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Исходы доставки письма для метки status.
+const (
+	NotificationSent    = "ok"
+	NotificationFailed  = "error"
+	NotificationDropped = "dropped"
+)
+
+// NotificationsSent - количество писем по шаблонам: status - ok, error
+// (попытки исчерпаны) или dropped (очередь переполнена).
+var NotificationsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "notifications_sent_total",
+	Help: "Количество писем пользователям по исходу доставки.",
+}, []string{"template", "status"})
+
+// ObserveNotification учитывает исход доставки письма.
+func ObserveNotification(template, result string) {
+	NotificationsSent.WithLabelValues(template, result).Inc()
+}
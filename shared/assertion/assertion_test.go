@@ -0,0 +1,62 @@
+package assertion_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "test-secret"
+
+func TestVerify(t *testing.T) {
+	signer := assertion.NewSigner(secret, time.Minute)
+	token, err := signer.Sign("user-1", "admin", "user", "DELETE", "/v1/admin/users/42")
+	require.NoError(t, err)
+
+	expired, err := assertion.NewSigner(secret, -time.Minute).Sign("user-1", "admin", "user", "DELETE", "/v1/admin/users/42")
+	require.NoError(t, err)
+
+	foreign, err := assertion.NewSigner("other-secret", time.Minute).Sign("user-1", "admin", "user", "DELETE", "/v1/admin/users/42")
+	require.NoError(t, err)
+
+	noSubject, err := signer.Sign("", "admin", "user", "DELETE", "/v1/admin/users/42")
+	require.NoError(t, err)
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, assertion.Claims{Role: "admin"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		audience string
+		method   string
+		path     string
+		wantErr  bool
+	}{
+		{name: "valid", token: token, audience: "user", method: "DELETE", path: "/v1/admin/users/42"},
+		{name: "other service", token: token, audience: "course", method: "DELETE", path: "/v1/admin/users/42", wantErr: true},
+		{name: "other method", token: token, audience: "user", method: "GET", path: "/v1/admin/users/42", wantErr: true},
+		{name: "other path", token: token, audience: "user", method: "DELETE", path: "/v1/admin/users/43", wantErr: true},
+		{name: "expired", token: expired, audience: "user", method: "DELETE", path: "/v1/admin/users/42", wantErr: true},
+		{name: "foreign secret", token: foreign, audience: "user", method: "DELETE", path: "/v1/admin/users/42", wantErr: true},
+		{name: "no subject", token: noSubject, audience: "user", method: "DELETE", path: "/v1/admin/users/42", wantErr: true},
+		{name: "unsigned", token: none, audience: "user", method: "DELETE", path: "/v1/admin/users/42", wantErr: true},
+		{name: "garbage", token: "garbage", audience: "user", method: "DELETE", path: "/v1/admin/users/42", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := assertion.Verify([]byte(secret), tt.token, tt.audience, tt.method, tt.path)
+			if tt.wantErr {
+				require.ErrorIs(t, err, assertion.ErrInvalid)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "user-1", claims.Subject)
+			require.Equal(t, "admin", claims.Role)
+		})
+	}
+}
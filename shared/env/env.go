@@ -0,0 +1,29 @@
+package env
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Float читает число из переменной окружения; пустое или неверное значение
+// заменяется defaultValue.
+func Float(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// List читает список через запятую, пропуская пустые элементы.
+func List(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,46 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/shared/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloat(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  float64
+	}{
+		{name: "unset", value: "", want: 1},
+		{name: "valid", value: "0.25", want: 0.25},
+		{name: "invalid", value: "half", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_FLOAT", tt.value)
+			require.Equal(t, tt.want, env.Float("TEST_FLOAT", 1))
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "unset", value: "", want: nil},
+		{name: "single", value: "10.0.0.0/8", want: []string{"10.0.0.0/8"}},
+		{name: "trims and skips empty", value: " a, ,b ,", want: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_LIST", tt.value)
+			require.Equal(t, tt.want, env.List("TEST_LIST"))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pinger - хранилище, доступность которого определяет здоровье сервиса
+// (например, *sql.DB).
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// NewRouter регистрирует /health, по которому gateway проверяет экземпляр
+// перед тем, как отправлять на него запросы.
+func NewRouter(handler *gin.Engine, db Pinger) {
+	handler.GET("/health", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		if err := db.PingContext(ctx); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "error": "database unreachable"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+}
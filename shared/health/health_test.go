@@ -0,0 +1,46 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/shared/health"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type pinger struct {
+	err error
+}
+
+func (p pinger) PingContext(context.Context) error {
+	return p.err
+}
+
+func TestNewRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "database reachable", wantCode: http.StatusOK},
+		{name: "database unreachable", err: errors.New("connection refused"), wantCode: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			health.NewRouter(router, pinger{err: tt.err})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			require.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
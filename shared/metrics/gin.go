@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute подставляется вместо шаблона для запросов мимо маршрутов,
+// чтобы произвольные пути не раздували число рядов.
+const unmatchedRoute = "unmatched"
+
+// Middleware считает запросы и время их обработки в разрезе шаблона маршрута.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		httpInFlight.Inc()
+		start := time.Now()
+
+		c.Next()
+
+		httpInFlight.Dec()
+		httpDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	kafkaProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_produced_total",
+		Help: "Количество сообщений, отправленных в Kafka. status - ok или error.",
+	}, []string{"topic", "status"})
+
+	kafkaConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_consumed_total",
+		Help: "Количество обработанных сообщений из Kafka. status - ok или error.",
+	}, []string{"topic", "status"})
+
+	kafkaLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Число сообщений в партиции после последнего обработанного.",
+	}, []string{"topic", "partition"})
+)
+
+// ObserveProduce учитывает отправку сообщения в топик.
+func ObserveProduce(topic string, err error) {
+	kafkaProduced.WithLabelValues(topic, status(err)).Inc()
+}
+
+// ObserveConsume учитывает обработку сообщения и обновляет отставание группы
+// по партиции: highWaterMark - offset следующего сообщения, которое запишет брокер.
+func ObserveConsume(message *sarama.ConsumerMessage, highWaterMark int64, err error) {
+	kafkaConsumed.WithLabelValues(message.Topic, status(err)).Inc()
+
+	lag := highWaterMark - message.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	kafkaLag.WithLabelValues(message.Topic, strconv.Itoa(int(message.Partition))).Set(float64(lag))
+}
+
+func status(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path - путь, по которому Prometheus забирает метрики.
+const Path = "/metrics"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Количество обработанных HTTP-запросов.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Время обработки HTTP-запроса.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	httpInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Количество HTTP-запросов в обработке.",
+	})
+)
+
+// Handler отдает метрики из реестра по умолчанию в формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve отдает метрики на отдельном внутреннем адресе, а не на публичном порту
+// сервиса: Prometheus ходит туда из внутренней сети.
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(Path, Handler())
+	return http.ListenAndServe(addr, mux)
+}
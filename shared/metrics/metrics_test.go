@@ -0,0 +1,118 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// series возвращает значения рядов метрики по ее имени; ключ - метки ряда
+// в порядке объявления, значение - счетчик или gauge.
+func series(t *testing.T, name string) map[string]float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	result := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			key := ""
+			for _, label := range m.GetLabel() {
+				key += label.GetName() + "=" + label.GetValue() + ","
+			}
+			switch {
+			case m.GetCounter() != nil:
+				result[key] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				result[key] = m.GetGauge().GetValue()
+			case m.GetHistogram() != nil:
+				result[key] = float64(m.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return result
+}
+
+func TestMiddleware_Labels(t *testing.T) {
+	engine := gin.New()
+	engine.Use(metrics.Middleware())
+	engine.GET("/metrics-test/items/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/metrics-test/items/1", "/metrics-test/items/2", "/metrics-test/items/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	requests := series(t, "http_requests_total")
+	require.Equal(t, float64(2), requests["method=GET,route=/metrics-test/items/:id,status=200,"])
+	require.Equal(t, float64(1), requests["method=GET,route=/metrics-test/items/:id,status=404,"])
+
+	durations := series(t, "http_request_duration_seconds")
+	require.Equal(t, float64(3), durations["method=GET,route=/metrics-test/items/:id,"])
+
+	require.Equal(t, float64(0), series(t, "http_requests_in_flight")[""])
+}
+
+func TestMiddleware_UnmatchedRouteHasNoPathLabel(t *testing.T) {
+	engine := gin.New()
+	engine.Use(metrics.Middleware())
+
+	for _, path := range []string{"/metrics-test/random-1", "/metrics-test/random-2", "/wp-login.php"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	requests := series(t, "http_requests_total")
+	require.Equal(t, float64(3), requests["method=POST,route=unmatched,status=404,"])
+	for key := range requests {
+		require.NotContains(t, key, "random")
+		require.NotContains(t, key, "wp-login")
+	}
+}
+
+func TestKafkaObservers(t *testing.T) {
+	metrics.ObserveProduce("metrics_test_topic", nil)
+	metrics.ObserveProduce("metrics_test_topic", errors.New("broker down"))
+	metrics.ObserveProduce("metrics_test_topic", nil)
+
+	produced := series(t, "kafka_messages_produced_total")
+	require.Equal(t, float64(2), produced["status=ok,topic=metrics_test_topic,"])
+	require.Equal(t, float64(1), produced["status=error,topic=metrics_test_topic,"])
+
+	message := &sarama.ConsumerMessage{Topic: "metrics_test_topic", Partition: 3, Offset: 10}
+	metrics.ObserveConsume(message, 15, nil)
+
+	consumed := series(t, "kafka_messages_consumed_total")
+	require.Equal(t, float64(1), consumed["status=ok,topic=metrics_test_topic,"])
+	require.Equal(t, float64(4), series(t, "kafka_consumer_lag")["partition=3,topic=metrics_test_topic,"])
+
+	// Отставание не бывает отрицательным, даже если high water mark устарел.
+	metrics.ObserveConsume(message, 5, errors.New("invalid payload"))
+	require.Equal(t, float64(0), series(t, "kafka_consumer_lag")["partition=3,topic=metrics_test_topic,"])
+	require.Equal(t, float64(1), series(t, "kafka_messages_consumed_total")["status=error,topic=metrics_test_topic,"])
+}
+
+func TestHandlerExposesMetrics(t *testing.T) {
+	metrics.ObserveProduce("metrics_test_handler", nil)
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, metrics.Path, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `kafka_messages_produced_total{status="ok",topic="metrics_test_handler"} 1`)
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
+	"github.com/gin-gonic/gin"
+)
+
+// IdentityMiddleware проверяет подписанное gateway утверждение об идентичности
+// и только после этого выставляет X-User-UUID и X-User-Role. Присланные напрямую
+// заголовки X-User-* отбрасываются, поэтому обработчики могут им доверять.
+func IdentityMiddleware(secret, audience string) gin.HandlerFunc {
+	key := []byte(secret)
+
+	return func(c *gin.Context) {
+		for header := range c.Request.Header {
+			if strings.HasPrefix(header, "X-User-") {
+				c.Request.Header.Del(header)
+			}
+		}
+
+		token := c.GetHeader(assertion.Header)
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := assertion.Verify(key, token, audience, c.Request.Method, c.Request.URL.Path)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid identity assertion"})
+			c.Abort()
+			return
+		}
+
+		c.Request.Header.Set("X-User-UUID", claims.Subject)
+		c.Request.Header.Set("X-User-Role", claims.Role)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/shared/assertion"
+	"github.com/JojoWeyn/duo-proj/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func identityRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.IdentityMiddleware("test-secret", "user"))
+	router.Any("/v1/*path", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetHeader("X-User-UUID")+"|"+c.GetHeader("X-User-Role"))
+	})
+	return router
+}
+
+func TestIdentityMiddleware(t *testing.T) {
+	signer := assertion.NewSigner("test-secret", time.Minute)
+	token, err := signer.Sign("user-1", "admin", "user", http.MethodDelete, "/v1/admin/users/42")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		assertion string
+		spoofed   bool
+		wantCode  int
+		wantBody  string
+	}{
+		{name: "valid", method: http.MethodDelete, path: "/v1/admin/users/42", assertion: token, wantCode: http.StatusOK, wantBody: "user-1|admin"},
+		{name: "no assertion drops spoofed headers", method: http.MethodGet, path: "/v1/users/me", spoofed: true, wantCode: http.StatusOK, wantBody: "|"},
+		{name: "replayed on another path", method: http.MethodDelete, path: "/v1/admin/users/43", assertion: token, wantCode: http.StatusUnauthorized},
+		{name: "replayed with another method", method: http.MethodGet, path: "/v1/admin/users/42", assertion: token, wantCode: http.StatusUnauthorized},
+		{name: "invalid", method: http.MethodGet, path: "/v1/users/me", assertion: "garbage", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.assertion != "" {
+				req.Header.Set(assertion.Header, tt.assertion)
+			}
+			if tt.spoofed {
+				req.Header.Set("X-User-UUID", "attacker")
+				req.Header.Set("X-User-Role", "admin")
+			}
+
+			w := httptest.NewRecorder()
+			identityRouter().ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantBody != "" {
+				require.Equal(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
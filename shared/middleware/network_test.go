@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JojoWeyn/duo-proj/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworks(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "cidr", values: []string{"10.0.0.0/8"}, want: []string{"10.0.0.0/8"}},
+		{name: "single ipv4", values: []string{"172.18.0.5"}, want: []string{"172.18.0.5/32"}},
+		{name: "single ipv6", values: []string{"::1"}, want: []string{"::1/128"}},
+		{name: "invalid address", values: []string{"gateway"}, wantErr: true},
+		{name: "invalid cidr", values: []string{"10.0.0.0/33"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networks, err := middleware.ParseNetworks(tt.values)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make([]string, 0, len(networks))
+			for _, network := range networks {
+				got = append(got, network.String())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInternalNetwork(t *testing.T) {
+	tests := []struct {
+		name       string
+		networks   []string
+		remoteAddr string
+		wantCode   int
+	}{
+		{name: "no restriction", remoteAddr: "203.0.113.7:4000", wantCode: http.StatusOK},
+		{name: "trusted", networks: []string{"172.18.0.0/16"}, remoteAddr: "172.18.0.5:4000", wantCode: http.StatusOK},
+		{name: "untrusted", networks: []string{"172.18.0.0/16"}, remoteAddr: "203.0.113.7:4000", wantCode: http.StatusForbidden},
+		{name: "unparsable address", networks: []string{"172.18.0.0/16"}, remoteAddr: "unknown", wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networks, err := middleware.ParseNetworks(tt.networks)
+			require.NoError(t, err)
+
+			router := gin.New()
+			router.Use(middleware.InternalNetwork(networks))
+			router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "172.18.0.5")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package tracing
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDKafkaHeader = "x-request-id"
+
+// producerCarrier и consumerCarrier дают пропагатору доступ к заголовкам сообщения Kafka.
+type producerCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func (c producerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+type consumerCarrier struct {
+	msg *sarama.ConsumerMessage
+}
+
+func (c consumerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerCarrier) Set(string, string) {}
+
+func (c consumerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
+
+// StartProduce открывает span отправки и кладет trace context и request id в
+// заголовки сообщения. Span нужно завершить после отправки через End.
+func StartProduce(ctx context.Context, msg *sarama.ProducerMessage) trace.Span {
+	ctx, span := Tracer().Start(ctx, "publish "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(msg.Topic),
+		),
+	)
+
+	carrier := producerCarrier{msg: msg}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		carrier.Set(requestIDKafkaHeader, requestID)
+	}
+
+	return span
+}
+
+// StartConsume извлекает trace context и request id из заголовков сообщения и
+// открывает span его обработки.
+func StartConsume(ctx context.Context, msg *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	carrier := consumerCarrier{msg: msg}
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKafka,
+		semconv.MessagingDestinationName(msg.Topic),
+		semconv.MessagingDestinationPartitionID(strconv.Itoa(int(msg.Partition))),
+		semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+	}
+	if requestID := carrier.Get(requestIDKafkaHeader); requestID != "" {
+		ctx = WithRequestID(ctx, requestID)
+		attrs = append(attrs, attribute.String("request.id", requestID))
+	}
+
+	return Tracer().Start(ctx, "process "+msg.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
@@ -0,0 +1,159 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	traceparent = "00-" + traceID + "-00f067aa0ba902b7-01"
+)
+
+// setupTracing включает W3C-пропагатор и записывает завершенные span в память.
+func setupTracing(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	_, err := tracing.Init(context.Background(), tracing.Config{Exporter: tracing.ExporterNone})
+	require.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+type handled struct {
+	traceID   string
+	requestID string
+}
+
+func newEngine(got *handled) *gin.Engine {
+	engine := gin.New()
+	engine.Use(tracing.Middleware())
+	engine.GET("/items/:id", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		got.traceID = trace.SpanContextFromContext(ctx).TraceID().String()
+		got.requestID = tracing.RequestIDFromContext(ctx)
+		c.Status(http.StatusOK)
+	})
+	return engine
+}
+
+func TestMiddleware_ExtractsTraceContext(t *testing.T) {
+	recorder := setupTracing(t)
+
+	var got handled
+	req := httptest.NewRequest(http.MethodGet, "/items/42", nil)
+	req.Header.Set("traceparent", traceparent)
+	req.Header.Set(tracing.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	newEngine(&got).ServeHTTP(w, req)
+
+	require.Equal(t, traceID, got.traceID)
+	require.Equal(t, "req-123", got.requestID)
+	require.Equal(t, "req-123", w.Header().Get(tracing.RequestIDHeader))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /items/:id", spans[0].Name())
+	require.Equal(t, traceID, spans[0].Parent().TraceID().String())
+	require.True(t, spans[0].Parent().IsRemote())
+}
+
+func TestMiddleware_RequestID(t *testing.T) {
+	setupTracing(t)
+
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "missing", requestID: ""},
+		{name: "valid", requestID: "abc-DEF_1.2:3", keep: true},
+		{name: "unsafe characters", requestID: "bad id\n"},
+		{name: "too long", requestID: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got handled
+			req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			if tt.requestID != "" {
+				req.Header.Set(tracing.RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			newEngine(&got).ServeHTTP(w, req)
+
+			returned := w.Header().Get(tracing.RequestIDHeader)
+			require.NotEmpty(t, returned)
+			require.Equal(t, returned, got.requestID)
+			if tt.keep {
+				require.Equal(t, tt.requestID, returned)
+			} else {
+				require.NotEqual(t, tt.requestID, returned)
+				require.Len(t, returned, 32)
+			}
+		})
+	}
+}
+
+func TestKafka_PropagatesTraceContextAndRequestID(t *testing.T) {
+	recorder := setupTracing(t)
+
+	ctx, parent := tracing.Start(tracing.WithRequestID(context.Background(), "req-123"), "handler")
+	msg := &sarama.ProducerMessage{
+		Topic:   "user_progress",
+		Headers: []sarama.RecordHeader{{Key: []byte("x-request-id"), Value: []byte("stale")}},
+	}
+	produce := tracing.StartProduce(ctx, msg)
+	tracing.End(produce, nil)
+	parent.End()
+
+	headers := make(map[string]string)
+	consumed := &sarama.ConsumerMessage{Topic: msg.Topic, Partition: 1, Offset: 7}
+	for _, h := range msg.Headers {
+		headers[string(h.Key)] = string(h.Value)
+		consumed.Headers = append(consumed.Headers, &sarama.RecordHeader{Key: h.Key, Value: h.Value})
+	}
+	require.Len(t, msg.Headers, 2, "existing header is overwritten, not duplicated")
+	require.Equal(t, "req-123", headers["x-request-id"])
+	require.Contains(t, headers["traceparent"], parent.SpanContext().TraceID().String())
+
+	consumeCtx, consume := tracing.StartConsume(context.Background(), consumed)
+	tracing.End(consume, nil)
+
+	require.Equal(t, "req-123", tracing.RequestIDFromContext(consumeCtx))
+	require.Equal(t, parent.SpanContext().TraceID(), consume.SpanContext().TraceID())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spans {
+		byName[span.Name()] = span
+	}
+	require.Equal(t, byName["publish user_progress"].SpanContext().SpanID(), byName["process user_progress"].Parent().SpanID())
+}
+
+func TestStartConsume_WithoutHeaders(t *testing.T) {
+	setupTracing(t)
+
+	ctx, span := tracing.StartConsume(context.Background(), &sarama.ConsumerMessage{Topic: "user_create"})
+	tracing.End(span, nil)
+
+	require.Empty(t, tracing.RequestIDFromContext(ctx))
+	require.True(t, span.SpanContext().IsValid())
+}
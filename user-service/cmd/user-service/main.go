@@ -8,6 +8,9 @@ import (
 
 	"github.com/JojoWeyn/duo-proj/user-service/internal/controller/kafka"
 
+	"github.com/JojoWeyn/duo-proj/shared/env"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	_ "github.com/JojoWeyn/duo-proj/user-service/docs"
 	"github.com/JojoWeyn/duo-proj/user-service/internal/composite"
 	"github.com/JojoWeyn/duo-proj/user-service/pkg/client/postgresql"
@@ -28,6 +31,16 @@ func main() {
 		log.Printf("Warning: loading .env file")
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "user-service"),
+		Exporter:    getEnv("OTEL_TRACES_EXPORTER", tracing.ExporterNone),
+		SampleRatio: env.Float("OTEL_TRACES_SAMPLER_ARG", 1),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %s", err.Error())
+	}
+	defer shutdownTracing(context.Background())
+
 	db, err := postgresql.NewPostgresDB(postgresql.Config{
 		Host:     getEnv("DB_HOST", "localhost"),
 		User:     getEnv("DB_USER", "postgres"),
@@ -40,10 +53,16 @@ func main() {
 		log.Fatalf("Failed to initialize db: %s", err.Error())
 	}
 
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		log.Fatalf("Failed to register tracing plugin: %s", err.Error())
+	}
+	if err := db.Use(metrics.GormPlugin()); err != nil {
+		log.Fatalf("Failed to register metrics plugin: %s", err.Error())
+	}
+
 	cfg := composite.Config{
 		KafkaBrokers: getEnv("KAFKA_BROKERS", "kafka:29092"),
 		KafkaTopic:   getEnv("KAFKA_TOPIC", "user_create"),
-		GatewayURL:   getEnv("GATEWAY_URL", "176.109.108.209:3211"),
 		Secret:       getEnv("JWT_SIGNING_KEY", "your-signing-key"),
 		S3Endpoint:   getEnv("S3_ENDPOINT", "minio:9000"),
 		S3AccessKey:  getEnv("S3_ACCESS_KEY", "minio"),
@@ -51,6 +70,10 @@ func main() {
 		S3Bucket:     getEnv("S3_BUCKET", "user-avatar"),
 		RedisURL:     getEnv("REDIS_URL", "redis:6379"),
 		RedisDB:      getEnvAsInt("REDIS_DB", 0),
+
+		AssertionSecret: getEnv("IDENTITY_ASSERTION_SECRET", ""),
+
+		TrustedNetworks: env.List("TRUSTED_NETWORKS"),
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -68,6 +91,9 @@ func main() {
 	progressConsumer := kafka.NewProgressConsumer([]string{cfg.KafkaBrokers}, "user_progress", "user-service-group", app.ProgressUseCase)
 	go progressConsumer.Start(ctx)
 
+	identityConsumer := kafka.NewIdentityConsumer([]string{cfg.KafkaBrokers}, getEnv("KAFKA_IDENTITY_TOPIC", "identity_changed"), "user-service-identity", app.UserUseCase)
+	go identityConsumer.Start(ctx)
+
 	port := getEnv("USER_PORT", "8082")
 	if err := app.Handler().Run(":" + port); err != nil {
 		log.Fatal("Failed to start server:", err)
@@ -2,12 +2,18 @@ package composite
 
 import (
 	"context"
+	"errors"
 	"log"
 
+	"github.com/JojoWeyn/duo-proj/shared/health"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/middleware"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	v1 "github.com/JojoWeyn/duo-proj/user-service/internal/controller/http/v1"
 	"github.com/JojoWeyn/duo-proj/user-service/internal/controller/http/v1/admin"
 	"github.com/JojoWeyn/duo-proj/user-service/internal/controller/kafka"
 	"github.com/JojoWeyn/duo-proj/user-service/internal/repository/cache"
+	"github.com/JojoWeyn/duo-proj/user-service/internal/repository/events"
 	"github.com/JojoWeyn/duo-proj/user-service/pkg/client/redis"
 	"github.com/JojoWeyn/duo-proj/user-service/pkg/client/s3"
 
@@ -22,7 +28,6 @@ import (
 type Config struct {
 	KafkaBrokers string
 	KafkaTopic   string
-	GatewayURL   string
 	Secret       string
 	S3Endpoint   string
 	S3AccessKey  string
@@ -30,6 +35,11 @@ type Config struct {
 	S3Bucket     string
 	RedisURL     string
 	RedisDB      int
+
+	AssertionSecret string
+
+	// TrustedNetworks - сети (CIDR или адреса), из которых принимаются запросы; пустой список не ограничивает.
+	TrustedNetworks []string
 }
 
 type UserComposite struct {
@@ -40,6 +50,10 @@ type UserComposite struct {
 }
 
 func NewUserComposite(ctx context.Context, db *gorm.DB, cfg Config) (*UserComposite, error) {
+	if cfg.AssertionSecret == "" {
+		return nil, errors.New("identity assertion secret is required")
+	}
+
 	if err := db.AutoMigrate(&entity.User{}, &entity.Rank{}, &entity.Progress{}); err != nil {
 		return nil, err
 	}
@@ -96,11 +110,26 @@ func NewUserComposite(ctx context.Context, db *gorm.DB, cfg Config) (*UserCompos
 	}
 
 	UserUseCase := usecase.NewUserUseCase(userRepo, cacher, userS3Repo, producer)
-	AchievementUseCase := usecase.NewAchievementUseCase(achievementRepo)
+	broker := events.NewRedisBroker(redisClient)
+	AchievementUseCase := usecase.NewAchievementUseCase(achievementRepo, broker)
 	progressUseCase := usecase.NewProgressUseCase(progressRepo)
 
+	networks, err := middleware.ParseNetworks(cfg.TrustedNetworks)
+	if err != nil {
+		return nil, err
+	}
+
 	handler := gin.Default()
-	v1.NewRouter(handler, UserUseCase, AchievementUseCase, progressUseCase, cfg.GatewayURL)
+	handler.Use(middleware.InternalNetwork(networks))
+	handler.Use(tracing.Middleware(), metrics.Middleware())
+	handler.Use(middleware.IdentityMiddleware(cfg.AssertionSecret, "user"))
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	health.NewRouter(handler, sqlDB)
+	handler.GET(metrics.Path, gin.WrapH(metrics.Handler()))
+	v1.NewRouter(handler, UserUseCase, AchievementUseCase, progressUseCase, broker)
 	admin.NewAdminRouter(handler, UserUseCase, AchievementUseCase)
 
 	return &UserComposite{
@@ -8,7 +8,7 @@ import (
 type CreateAchievementDTO struct {
 	Title       string          `json:"title" binding:"required"`
 	Description string          `json:"description" binding:"required"`
-	Condition   json.RawMessage `json:"condition" binding:"required"`
+	Condition   json.RawMessage `json:"condition" binding:"required" swaggertype:"object"`
 	Secret      bool            `json:"secret"`
 }
 
@@ -27,7 +27,7 @@ type AchievementsDTO struct {
 	ID          int             `json:"id"`
 	Title       string          `json:"title"`
 	Description string          `json:"description"`
-	Condition   json.RawMessage `json:"condition"`
+	Condition   json.RawMessage `json:"condition" swaggertype:"object"`
 	Secret      bool            `json:"secret"`
 	CreatedAt   time.Time       `json:"created_at"`
 }
@@ -43,7 +43,7 @@ func newAchievementRoutes(handler *gin.RouterGroup, uc AchievementUseCase) {
 // @Produce json
 // @Success 200 {object} map[string]interface{} "Список достижений"
 // @Failure 500 {object} map[string]string "Ошибка при получении или парсинге достижений"
-// @Router /achievements [get]
+// @Router /achievements/list [get]
 func (r *achievementRoutes) getAllAchievements(c *gin.Context) {
 	achievements, err := r.uc.GetAllAchievements(c.Request.Context())
 	if err != nil {
@@ -48,6 +48,18 @@ func newAdminRoutes(handler *gin.RouterGroup, uc UserUseCase, ac AchievementUseC
 	}
 }
 
+// @Summary Получить всех пользователей
+// @Description Возвращает список пользователей с пагинацией
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Лимит (по умолчанию 50)"
+// @Param offset query int false "Смещение (по умолчанию 0)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users [get]
 func (r *adminRoutes) getAllUsers(c *gin.Context) {
 	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	if err != nil || limit <= 0 {
@@ -79,6 +91,16 @@ func (r *adminRoutes) getAllUsers(c *gin.Context) {
 	})
 }
 
+// @Summary Удалить пользователя
+// @Description Удаляет пользователя по UUID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "UUID пользователя"
+// @Success 200
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id} [delete]
 func (r *adminRoutes) deleteUser(c *gin.Context) {
 	id := c.Param("id")
 	err := r.userUseCase.DeleteUser(c.Request.Context(), uuid.MustParse(id))
@@ -90,6 +112,17 @@ func (r *adminRoutes) deleteUser(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// @Summary Создать достижение
+// @Description Создает достижение с условием получения
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body dto.CreateAchievementDTO true "Данные достижения"
+// @Success 201 {object} map[string]dto.AchievementsDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/achievements/create [post]
 func (r *adminRoutes) createAchievement(c *gin.Context) {
 	var createDTO dto.CreateAchievementDTO
 
@@ -135,6 +168,18 @@ func (r *adminRoutes) createAchievement(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"achievement": response})
 }
 
+// @Summary Получить достижение
+// @Description Возвращает достижение по ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID достижения"
+// @Success 200 {object} map[string]dto.AchievementsDTO
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/achievements/{id} [get]
 func (r *adminRoutes) getAchievementByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -167,6 +212,18 @@ func (r *adminRoutes) getAchievementByID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"achievement": response})
 }
 
+// @Summary Обновить достижение
+// @Description Обновляет достижение по ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID достижения"
+// @Param data body dto.CreateAchievementDTO true "Данные достижения"
+// @Success 200 {object} map[string]dto.AchievementsDTO
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/achievements/{id} [patch]
 func (r *adminRoutes) updateAchievement(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -218,6 +275,17 @@ func (r *adminRoutes) updateAchievement(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"achievement": response})
 }
 
+// @Summary Удалить достижение
+// @Description Удаляет достижение по ID
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID достижения"
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/achievements/{id} [delete]
 func (r *adminRoutes) deleteAchievement(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -235,6 +303,15 @@ func (r *adminRoutes) deleteAchievement(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// @Summary Получить все достижения
+// @Description Возвращает все достижения, включая секретные
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string][]dto.AchievementsDTO
+// @Failure 500 {object} map[string]string
+// @Router /admin/achievements/list [get]
 func (r *adminRoutes) getAllAchievements(c *gin.Context) {
 	achievements, err := r.achievementUseCase.GetAllAchievements(c.Request.Context())
 	if err != nil {
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// eventsHeartbeat - интервал комментариев SSE, которые держат соединение
+// открытым через gateway и прокси, пока событий нет.
+const eventsHeartbeat = 30 * time.Second
+
+type EventSubscriber interface {
+	Subscribe(ctx context.Context, userID uuid.UUID) (<-chan string, func(), error)
+}
+
+type eventRoutes struct {
+	subscriber EventSubscriber
+}
+
+func newEventRoutes(handler *gin.RouterGroup, subscriber EventSubscriber) {
+	r := &eventRoutes{
+		subscriber: subscriber,
+	}
+
+	handler.GET("/users/me/events", r.stream)
+}
+
+// @Summary Поток событий пользователя
+// @Description Server-Sent Events: событие achievement приходит при получении достижения. Соединение держится открытым, пока клиент его не закроет.
+// @Tags Users
+// @Produce text/event-stream
+// @Success 200 {string} string "Поток событий"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/events [get]
+func (r *eventRoutes) stream(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.GetHeader("X-User-UUID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid UUID format"})
+		return
+	}
+
+	events, cancel, err := r.subscriber.Subscribe(c.Request.Context(), userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to subscribe to events"})
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			_, err := io.WriteString(w, "data: "+event+"\n\n")
+			return err == nil
+		}
+	})
+}
@@ -59,7 +59,7 @@ func newUserRoutes(handler *gin.RouterGroup, uc UserUseCase, puc ProgressUseCase
 // @Success 200 {object} dto.StreakResponseDTO "Серия активности пользователя (streak)"
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
-// @Router /users/streak [get]
+// @Router /users/me/streak [get]
 func (r *userRoutes) getStreak(c *gin.Context) {
 	userUUID, err := uuid.Parse(c.GetHeader("X-User-UUID"))
 	if err != nil {
@@ -124,7 +124,7 @@ func (r *userRoutes) getLeaderboard(c *gin.Context) {
 // @Produce json
 // @Success 200 {object} dto.ProgressResponseDTO "Прогресс пользователя"
 // @Failure 404 {object} map[string]string
-// @Router /users/progress [get]
+// @Router /users/me/progress [get]
 func (r *userRoutes) getProgress(c *gin.Context) {
 	sub := c.GetHeader("X-User-UUID")
 
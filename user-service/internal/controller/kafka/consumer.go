@@ -10,6 +10,8 @@ import (
 	"syscall"
 
 	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	"github.com/google/uuid"
 )
 
@@ -98,39 +100,48 @@ func (c ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 	}
 
 	for message := range claim.Messages() {
-		log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
+		ctx, span := tracing.StartConsume(c.ctx, message)
+		err := c.handleMessage(ctx, message)
+		tracing.End(span, err)
+		metrics.ObserveConsume(message, claim.HighWaterMarkOffset(), err)
 
-		var msg struct {
-			UUID   string `json:"uuid"`
-			Login  string `json:"login"`
-			Action string `json:"action"`
-		}
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
 
-		if err := json.Unmarshal(message.Value, &msg); err != nil {
-			log.Printf("Failed to parse message JSON: %v", err)
-			continue
-		}
+func (c ConsumerGroupHandler) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
 
-		receivedUUID, err := uuid.Parse(msg.UUID)
-		if err != nil {
-			log.Printf("Invalid UUID format: %v", err)
-			continue
-		}
+	var msg struct {
+		UUID   string `json:"uuid"`
+		Login  string `json:"login"`
+		Action string `json:"action"`
+	}
 
-		if msg.Action == "" {
-			if err := c.userUseCase.CreateUser(c.ctx, receivedUUID, msg.Login); err != nil {
-				log.Printf("Error creating user: %v", err)
-			} else {
-				log.Printf("User with UUID %s successfully created", receivedUUID)
-			}
-		} else {
-			err := c.achievementUsecase.CheckAchievements(c.ctx, receivedUUID, msg.Action)
-			if err != nil {
-				log.Printf("Error checking achievements: %v", err)
-			}
+	if err := json.Unmarshal(message.Value, &msg); err != nil {
+		log.Printf("Failed to parse message JSON: %v", err)
+		return err
+	}
+
+	receivedUUID, err := uuid.Parse(msg.UUID)
+	if err != nil {
+		log.Printf("Invalid UUID format: %v", err)
+		return err
+	}
+
+	if msg.Action == "" {
+		if err := c.userUseCase.CreateUser(ctx, receivedUUID, msg.Login); err != nil {
+			log.Printf("Error creating user: %v", err)
+			return err
 		}
+		log.Printf("User with UUID %s successfully created", receivedUUID)
+		return nil
+	}
 
-		session.MarkMessage(message, "")
+	if err := c.achievementUsecase.CheckAchievements(ctx, receivedUUID, msg.Action); err != nil {
+		log.Printf("Error checking achievements: %v", err)
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
+	"github.com/google/uuid"
+)
+
+type IdentityUseCase interface {
+	SetDisabled(ctx context.Context, uuid uuid.UUID, disabled bool) error
+}
+
+// IdentityConsumer читает события identity-service об изменении пользователей
+// администратором и скрывает отключенных пользователей из рейтинга.
+type IdentityConsumer struct {
+	brokers         []string
+	topic           string
+	groupID         string
+	identityUseCase IdentityUseCase
+}
+
+func NewIdentityConsumer(brokers []string, topic, groupID string, identityUseCase IdentityUseCase) *IdentityConsumer {
+	return &IdentityConsumer{
+		brokers:         brokers,
+		topic:           topic,
+		groupID:         groupID,
+		identityUseCase: identityUseCase,
+	}
+}
+
+func (c *IdentityConsumer) Start(ctx context.Context) {
+	config := sarama.NewConfig()
+	config.Version = sarama.MaxVersion
+	config.Consumer.Return.Errors = true
+	// Повторное применение disabled/enabled по порядку дает то же состояние,
+	// поэтому новая группа читает топик с начала и не теряет ранние события.
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	consumerGroup, err := sarama.NewConsumerGroup(c.brokers, c.groupID, config)
+	if err != nil {
+		log.Fatalf("Error creating consumer group client: %v", err)
+	}
+	defer consumerGroup.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	consumer := NewIdentityConsumerGroupHandler(ctx, c.identityUseCase)
+
+	go func() {
+		for {
+			if err := consumerGroup.Consume(ctx, []string{c.topic}, &consumer); err != nil {
+				if ctx.Err() != nil {
+					log.Println("Consumer loop exiting due to context cancellation")
+					return
+				}
+				log.Printf("Error from consumer: %v", err)
+			}
+		}
+	}()
+
+	sigterm := make(chan os.Signal, 1)
+	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-ctx.Done():
+		log.Println("terminating: context cancelled")
+	case <-sigterm:
+		log.Println("terminating: via signal")
+	}
+}
+
+type IdentityConsumerGroupHandler struct {
+	identityUseCase IdentityUseCase
+	ctx             context.Context
+}
+
+// NewIdentityConsumerGroupHandler создает обработчик событий, который применяет
+// их в контексте ctx.
+func NewIdentityConsumerGroupHandler(ctx context.Context, identityUseCase IdentityUseCase) IdentityConsumerGroupHandler {
+	return IdentityConsumerGroupHandler{identityUseCase: identityUseCase, ctx: ctx}
+}
+
+func (IdentityConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error   { return nil }
+func (IdentityConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error { return nil }
+func (c IdentityConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for message := range claim.Messages() {
+		ctx, span := tracing.StartConsume(c.ctx, message)
+		err := c.handleMessage(ctx, message)
+		tracing.End(span, err)
+		metrics.ObserveConsume(message, claim.HighWaterMarkOffset(), err)
+
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
+
+func (c IdentityConsumerGroupHandler) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
+
+	var msg struct {
+		UUID     string `json:"uuid"`
+		Action   string `json:"action"`
+		Disabled bool   `json:"disabled"`
+	}
+
+	if err := json.Unmarshal(message.Value, &msg); err != nil {
+		log.Printf("Failed to parse message JSON: %v", err)
+		return err
+	}
+
+	// Смена роли и принудительный выход не меняют данные user-service.
+	if msg.Action != "disabled" && msg.Action != "enabled" {
+		return nil
+	}
+
+	userUUID, err := uuid.Parse(msg.UUID)
+	if err != nil {
+		log.Printf("Invalid user UUID: %v", err)
+		return err
+	}
+
+	if err := c.identityUseCase.SetDisabled(ctx, userUUID, msg.Disabled); err != nil {
+		log.Printf("Error updating user status: %v", err)
+		return err
+	}
+	return nil
+}
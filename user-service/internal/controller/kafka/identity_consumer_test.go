@@ -0,0 +1,118 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/user-service/internal/controller/kafka"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type disabledCall struct {
+	uuid     uuid.UUID
+	disabled bool
+}
+
+type identityUseCaseStub struct {
+	calls []disabledCall
+	err   error
+}
+
+func (s *identityUseCaseStub) SetDisabled(_ context.Context, uuid uuid.UUID, disabled bool) error {
+	s.calls = append(s.calls, disabledCall{uuid: uuid, disabled: disabled})
+	return s.err
+}
+
+// sessionStub запоминает отмеченные сообщения; остальные методы сессии
+// обработчику не нужны.
+type sessionStub struct {
+	sarama.ConsumerGroupSession
+	marked []int64
+}
+
+func (s *sessionStub) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type claimStub struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *claimStub) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+func (c *claimStub) HighWaterMarkOffset() int64               { return int64(cap(c.messages)) }
+
+func consume(t *testing.T, useCase kafka.IdentityUseCase, values ...string) *sessionStub {
+	t.Helper()
+
+	claim := &claimStub{messages: make(chan *sarama.ConsumerMessage, len(values))}
+	for i, value := range values {
+		claim.messages <- &sarama.ConsumerMessage{Topic: "identity_changed", Offset: int64(i), Value: []byte(value)}
+	}
+	close(claim.messages)
+
+	session := &sessionStub{}
+	handler := kafka.NewIdentityConsumerGroupHandler(context.Background(), useCase)
+	require.NoError(t, handler.ConsumeClaim(session, claim))
+	return session
+}
+
+func TestIdentityConsumer_HandleMessage(t *testing.T) {
+	userUUID := uuid.New()
+
+	tests := []struct {
+		name  string
+		value string
+		want  []disabledCall
+	}{
+		{
+			name:  "disabled",
+			value: `{"uuid":"` + userUUID.String() + `","action":"disabled","disabled":true}`,
+			want:  []disabledCall{{uuid: userUUID, disabled: true}},
+		},
+		{
+			name:  "enabled",
+			value: `{"uuid":"` + userUUID.String() + `","action":"enabled","disabled":false}`,
+			want:  []disabledCall{{uuid: userUUID, disabled: false}},
+		},
+		{
+			name:  "role change is ignored",
+			value: `{"uuid":"` + userUUID.String() + `","action":"role_changed","disabled":false}`,
+		},
+		{
+			name:  "malformed payload",
+			value: `{"uuid":`,
+		},
+		{
+			name:  "invalid uuid",
+			value: `{"uuid":"not-a-uuid","action":"disabled","disabled":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase := &identityUseCaseStub{}
+			session := consume(t, useCase, tt.value)
+
+			require.Equal(t, tt.want, useCase.calls)
+			require.Equal(t, []int64{0}, session.marked, "message is committed even if it cannot be applied")
+		})
+	}
+}
+
+func TestIdentityConsumer_ContinuesAfterFailure(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	useCase := &identityUseCaseStub{err: errors.New("database is down")}
+
+	session := consume(t, useCase,
+		`{"uuid":"`+first.String()+`","action":"disabled","disabled":true}`,
+		`not json`,
+		`{"uuid":"`+second.String()+`","action":"enabled","disabled":false}`,
+	)
+
+	require.Equal(t, []disabledCall{{uuid: first, disabled: true}, {uuid: second, disabled: false}}, useCase.calls)
+	require.Equal(t, []int64{0, 1, 2}, session.marked)
+}
@@ -1,9 +1,12 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 )
 
 type Producer struct {
@@ -33,7 +36,7 @@ func NewProducer(brokers, topic string) (*Producer, error) {
 	}, nil
 }
 
-func (p *Producer) SendUserEvent(uuid, login, action string) error {
+func (p *Producer) SendUserEvent(ctx context.Context, uuid, login, action string) error {
 	event := UserEvent{
 		UUID:   uuid,
 		Login:  login,
@@ -50,7 +53,14 @@ func (p *Producer) SendUserEvent(uuid, login, action string) error {
 		Value: sarama.ByteEncoder(value),
 	}
 
-	_, _, err = p.producer.SendMessage(msg)
+	return p.send(ctx, msg)
+}
+
+func (p *Producer) send(ctx context.Context, msg *sarama.ProducerMessage) error {
+	span := tracing.StartProduce(ctx, msg)
+	_, _, err := p.producer.SendMessage(msg)
+	tracing.End(span, err)
+	metrics.ObserveProduce(msg.Topic, err)
 	return err
 }
 
@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/JojoWeyn/duo-proj/shared/metrics"
+	"github.com/JojoWeyn/duo-proj/shared/tracing"
 	"github.com/google/uuid"
 )
 
@@ -84,34 +86,43 @@ func (ProgressConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error
 func (c ProgressConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 
 	for message := range claim.Messages() {
-		log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
-
-		var msg struct {
-			UserUUID   string    `json:"user_uuid"`
-			EntityType string    `json:"entity_type"`
-			EntityUUID string    `json:"entity_uuid"`
-			Points     int       `json:"points"`
-			IsCorrect  bool      `json:"is_correct"`
-			CreatedAt  time.Time `json:"created_at"`
-		}
+		ctx, span := tracing.StartConsume(c.ctx, message)
+		err := c.handleMessage(ctx, message)
+		tracing.End(span, err)
+		metrics.ObserveConsume(message, claim.HighWaterMarkOffset(), err)
 
-		if err := json.Unmarshal(message.Value, &msg); err != nil {
-			log.Printf("Failed to parse message JSON: %v", err)
-			continue
-		}
+		session.MarkMessage(message, "")
+	}
+	return nil
+}
+
+func (c ProgressConsumerGroupHandler) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
 
-		entityUUID := uuid.MustParse(msg.EntityUUID)
-		userUUID := uuid.MustParse(msg.UserUUID)
+	var msg struct {
+		UserUUID   string    `json:"user_uuid"`
+		EntityType string    `json:"entity_type"`
+		EntityUUID string    `json:"entity_uuid"`
+		Points     int       `json:"points"`
+		IsCorrect  bool      `json:"is_correct"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
 
-		isCompleted := c.progressUseCase.CheckProgress(c.ctx, userUUID, entityUUID)
+	if err := json.Unmarshal(message.Value, &msg); err != nil {
+		log.Printf("Failed to parse message JSON: %v", err)
+		return err
+	}
 
-		if !isCompleted {
-			if err := c.progressUseCase.AddProgress(c.ctx, userUUID, msg.EntityType, entityUUID, msg.Points, msg.CreatedAt); err != nil {
-				log.Printf("Error adding progress: %v", err)
-			}
-		}
+	entityUUID := uuid.MustParse(msg.EntityUUID)
+	userUUID := uuid.MustParse(msg.UserUUID)
 
-		session.MarkMessage(message, "")
+	isCompleted := c.progressUseCase.CheckProgress(ctx, userUUID, entityUUID)
+
+	if !isCompleted {
+		if err := c.progressUseCase.AddProgress(ctx, userUUID, msg.EntityType, entityUUID, msg.Points, msg.CreatedAt); err != nil {
+			log.Printf("Error adding progress: %v", err)
+			return err
+		}
 	}
 	return nil
 }
@@ -21,6 +21,7 @@ type User struct {
 	Achievements    []Achievement `json:"achievements" gorm:"many2many:user_achievements;"`
 	TotalPoints     int           `json:"total_points" gorm:"default:0"`
 	FinishedCourses int64         `json:"finished_courses" gorm:"default:0"`
+	Disabled        bool          `json:"disabled" gorm:"default:false"`
 }
 
 type Leaderboard struct {
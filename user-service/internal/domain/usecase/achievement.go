@@ -8,6 +8,8 @@ import (
 
 	"github.com/JojoWeyn/duo-proj/user-service/internal/controller/http/dto"
 	"github.com/JojoWeyn/duo-proj/user-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/user-service/internal/repository/events"
+	"github.com/JojoWeyn/duo-proj/user-service/pkg/metrics"
 	"github.com/google/uuid"
 )
 
@@ -15,20 +17,27 @@ type AchievementRepository interface {
 	GetAllAchievements(ctx context.Context) ([]entity.Achievement, error)
 	GetAllUserAchievements(ctx context.Context, userID uuid.UUID) ([]dto.UserAchievementsDTO, error)
 	GetUserAchievementProgress(ctx context.Context, userID uuid.UUID, achievementID int) (*entity.UserAchievementProgress, error)
-	UpdateUserAchievementProgress(ctx context.Context, userID uuid.UUID, achievement entity.Achievement, countIncrement int) error
+	UpdateUserAchievementProgress(ctx context.Context, userID uuid.UUID, achievement entity.Achievement, countIncrement int) (bool, error)
 	CreateAchievement(ctx context.Context, achievement entity.Achievement) (entity.Achievement, error)
 	GetAchievementByID(ctx context.Context, id int) (entity.Achievement, error)
 	UpdateAchievement(ctx context.Context, id int, achievement entity.Achievement) (entity.Achievement, error)
 	DeleteAchievement(ctx context.Context, id int) error
 }
 
+// EventPublisher доставляет событие в открытые потоки пользователя.
+type EventPublisher interface {
+	Publish(ctx context.Context, userID uuid.UUID, event events.Event) error
+}
+
 type AchievementUseCase struct {
 	achievementRepo AchievementRepository
+	events          EventPublisher
 }
 
-func NewAchievementUseCase(achievementRepo AchievementRepository) *AchievementUseCase {
+func NewAchievementUseCase(achievementRepo AchievementRepository, events EventPublisher) *AchievementUseCase {
 	return &AchievementUseCase{
 		achievementRepo: achievementRepo,
+		events:          events,
 	}
 }
 
@@ -108,15 +117,27 @@ func (uc *AchievementUseCase) CheckAchievements(ctx context.Context, userID uuid
 func (uc *AchievementUseCase) checkSimpleCounter(ctx context.Context, userID uuid.UUID, ach entity.Achievement, cond entity.Condition) {
 	countIncrement := 1
 
-	err := uc.achievementRepo.UpdateUserAchievementProgress(ctx, userID, ach, countIncrement)
+	unlocked, err := uc.achievementRepo.UpdateUserAchievementProgress(ctx, userID, ach, countIncrement)
 	if err != nil {
 		fmt.Println("error updating user achievement progress:", err)
 		return
 	}
 
-	progress, _ := uc.achievementRepo.GetUserAchievementProgress(ctx, userID, ach.ID)
-	if progress != nil && progress.Achieved {
+	if unlocked {
+		metrics.AchievementsUnlocked.Inc()
 		fmt.Printf("user %s achieved: %s\n", userID, ach.Title)
+
+		event := events.Event{Type: "achievement", Data: dto.AchievementsDTO{
+			ID:          ach.ID,
+			Title:       ach.Title,
+			Description: ach.Description,
+			Condition:   json.RawMessage(ach.Condition),
+			Secret:      ach.Secret,
+			CreatedAt:   ach.CreatedAt,
+		}}
+		if err := uc.events.Publish(ctx, userID, event); err != nil {
+			log.Printf("failed to publish achievement event: %v", err)
+		}
 	}
 }
 
@@ -18,6 +18,7 @@ type UserRepository interface {
 	FindByUUID(ctx context.Context, uuid uuid.UUID) (*entity.User, error)
 	Update(ctx context.Context, user *entity.User) error
 	Delete(ctx context.Context, uuid uuid.UUID) error
+	SetDisabled(ctx context.Context, uuid uuid.UUID, disabled bool) error
 	GetAll(ctx context.Context, limit, offset int) ([]*entity.User, error)
 }
 
@@ -104,13 +105,19 @@ func (uc *UserUseCase) UpdateUser(ctx context.Context, uuid uuid.UUID, updateDat
 		return err
 	}
 
-	err = uc.producer.SendUserEvent(user.UUID.String(), user.Login, "update")
+	err = uc.producer.SendUserEvent(ctx, user.UUID.String(), user.Login, "update")
 	if err != nil {
 		return fmt.Errorf("failed to produce message: %w", err)
 	}
 
 	return uc.userRepo.Update(ctx, user)
 }
+
+// SetDisabled отмечает пользователя, отключенного или снова включенного в identity-service.
+func (uc *UserUseCase) SetDisabled(ctx context.Context, uuid uuid.UUID, disabled bool) error {
+	return uc.userRepo.SetDisabled(ctx, uuid, disabled)
+}
+
 func (uc *UserUseCase) DeleteUser(ctx context.Context, uuid uuid.UUID) error {
 	return uc.userRepo.Delete(ctx, uuid)
 }
@@ -39,7 +39,9 @@ func (r *AchievementRepository) GetUserAchievementProgress(ctx context.Context,
 	return &progress, nil
 }
 
-func (r *AchievementRepository) UpdateUserAchievementProgress(ctx context.Context, userID uuid.UUID, achievement entity.Achievement, countIncrement int) error {
+// UpdateUserAchievementProgress увеличивает счетчик достижения и возвращает true,
+// если достижение получено именно этим вызовом.
+func (r *AchievementRepository) UpdateUserAchievementProgress(ctx context.Context, userID uuid.UUID, achievement entity.Achievement, countIncrement int) (bool, error) {
 	now := time.Now()
 	var progress entity.UserAchievementProgress
 
@@ -56,13 +58,13 @@ func (r *AchievementRepository) UpdateUserAchievementProgress(ctx context.Contex
 				Achieved:      false,
 				UpdatedAt:     now,
 			}
-			return r.db.WithContext(ctx).Create(&progress).Error
+			return false, r.db.WithContext(ctx).Create(&progress).Error
 		}
-		return err
+		return false, err
 	}
 
 	if progress.Achieved {
-		return nil
+		return false, nil
 	}
 
 	progress.CurrentCount += countIncrement
@@ -71,7 +73,7 @@ func (r *AchievementRepository) UpdateUserAchievementProgress(ctx context.Contex
 	var cond entity.Condition
 	err = json.Unmarshal([]byte(achievement.Condition), &cond)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if progress.CurrentCount >= cond.Count && !progress.Achieved {
@@ -79,7 +81,10 @@ func (r *AchievementRepository) UpdateUserAchievementProgress(ctx context.Contex
 		progress.AchievedAt = &now
 	}
 
-	return r.db.WithContext(ctx).Save(&progress).Error
+	if err := r.db.WithContext(ctx).Save(&progress).Error; err != nil {
+		return false, err
+	}
+	return progress.Achieved, nil
 }
 
 func (r *AchievementRepository) GetAllUserAchievements(ctx context.Context, userID uuid.UUID) ([]dto.UserAchievementsDTO, error) {
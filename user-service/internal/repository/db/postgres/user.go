@@ -84,6 +84,8 @@ func (r *UserRepository) GetLeaderboard(ctx context.Context, limit, offset int)
 				users u
 			LEFT JOIN
 				progresses p ON u.uuid = p.user_uuid AND p.entity_type = 'lesson'
+			WHERE
+				NOT u.disabled
 			GROUP BY
 				u.uuid, u.login, u.name, u.second_name, u.last_name, u.avatar
 		)
@@ -116,6 +118,13 @@ func (r *UserRepository) Update(ctx context.Context, user *entity.User) error {
 		Updates(user).Error
 }
 
+func (r *UserRepository) SetDisabled(ctx context.Context, uuid uuid.UUID, disabled bool) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.User{}).
+		Where("uuid = ?", uuid).
+		Update("disabled", disabled).Error
+}
+
 func (r *UserRepository) Delete(ctx context.Context, uuid uuid.UUID) error {
 	return r.db.WithContext(ctx).Where("uuid = ?", uuid).Delete(&entity.User{}).Error
 }
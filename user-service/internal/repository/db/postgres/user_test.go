@@ -0,0 +1,62 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JojoWeyn/duo-proj/user-service/internal/domain/entity"
+	"github.com/JojoWeyn/duo-proj/user-service/internal/repository/db/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&entity.Rank{}, &entity.User{}, &entity.Progress{}))
+	return db
+}
+
+func createUserWithPoints(t *testing.T, db *gorm.DB, login string, points int) *entity.User {
+	user := entity.NewUser(uuid.New(), login)
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Create(&entity.Progress{
+		UUID:        uuid.New(),
+		UserUUID:    user.UUID,
+		EntityType:  "lesson",
+		EntityUUID:  uuid.New(),
+		Points:      points,
+		CompletedAt: time.Now(),
+	}).Error)
+	return user
+}
+
+func TestGetLeaderboard_SkipsDisabledUsers(t *testing.T) {
+	ctx := context.TODO()
+	db := setupTestDB(t)
+	repo := postgres.NewUserRepository(db)
+
+	leader := createUserWithPoints(t, db, "leader", 300)
+	second := createUserWithPoints(t, db, "second", 200)
+	third := createUserWithPoints(t, db, "third", 100)
+
+	require.NoError(t, repo.SetDisabled(ctx, leader.UUID, true))
+
+	leaderboard, err := repo.GetLeaderboard(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, leaderboard, 2)
+	require.Equal(t, second.UUID, leaderboard[0].UserUUID)
+	require.Equal(t, 1, leaderboard[0].Rank, "ranks are renumbered without the disabled user")
+	require.Equal(t, third.UUID, leaderboard[1].UserUUID)
+
+	// После включения пользователь возвращается на свое место.
+	require.NoError(t, repo.SetDisabled(ctx, leader.UUID, false))
+
+	leaderboard, err = repo.GetLeaderboard(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, leaderboard, 3)
+	require.Equal(t, leader.UUID, leaderboard[0].UserUUID)
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+const channelPrefix = "user:events:"
+
+// Event - событие для пользователя, которое доставляется в его открытые потоки.
+type Event struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// RedisBroker рассылает события пользователя через Redis Pub/Sub, поэтому
+// событие доходит до потока независимо от того, какая реплика его открыла.
+type RedisBroker struct {
+	redisClient *redis.Client
+}
+
+func NewRedisBroker(redisClient *redis.Client) *RedisBroker {
+	return &RedisBroker{
+		redisClient: redisClient,
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, userID uuid.UUID, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.redisClient.Publish(ctx, channelPrefix+userID.String(), data).Err()
+}
+
+// Subscribe возвращает события пользователя в виде JSON до вызова cancel.
+func (b *RedisBroker) Subscribe(ctx context.Context, userID uuid.UUID) (<-chan string, func(), error) {
+	sub := b.redisClient.Subscribe(ctx, channelPrefix+userID.String())
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for msg := range sub.Channel() {
+			select {
+			case out <- msg.Payload:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, func() { sub.Close() }, nil
+}
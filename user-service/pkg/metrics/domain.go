@@ -0,0 +1,12 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// AchievementsUnlocked - количество выданных пользователям достижений.
+var AchievementsUnlocked = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "achievements_unlocked_total",
+	Help: "Количество достижений, полученных пользователями.",
+})